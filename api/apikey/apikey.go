@@ -3,6 +3,7 @@ package apikey
 import (
 	"crypto/rand"
 	"io"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 )
@@ -11,12 +12,15 @@ import (
 type APIKeyService interface {
 	HashRaw(rawKey string) []byte
 	GenerateApiKey(user portainer.User, description string) (string, *portainer.APIKey, error)
+	GenerateScopedApiKey(user portainer.User, description string, expiresAt int64, scope portainer.APIKeyScope) (string, *portainer.APIKey, error)
 	GetAPIKey(apiKeyID portainer.APIKeyID) (*portainer.APIKey, error)
 	GetAPIKeys(userID portainer.UserID) ([]portainer.APIKey, error)
 	GetDigestUserAndKey(digest []byte) (portainer.User, portainer.APIKey, error)
 	UpdateAPIKey(apiKey *portainer.APIKey) error
 	DeleteAPIKey(apiKeyID portainer.APIKeyID) error
 	InvalidateUserKeyCache(userId portainer.UserID) bool
+	GetExpiringAPIKeys(before time.Time) ([]portainer.APIKey, error)
+	PurgeExpiredAPIKeys() error
 }
 
 // generateRandomKey generates a random key of specified length
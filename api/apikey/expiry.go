@@ -0,0 +1,76 @@
+package apikey
+
+import (
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/notification/events"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	expiryJobInterval = time.Hour
+	// expiryWarningPeriod is the period before their expiration during which the API keys are reported as expiring
+	expiryWarningPeriod = 7 * 24 * time.Hour
+)
+
+// expiryMonitor purges the expired API keys and reports the keys nearing their expiration.
+// Each key is only reported once per run of the application.
+type expiryMonitor struct {
+	apiKeyService APIKeyService
+	publisher     portainer.NotificationEventPublisher
+	mu            sync.Mutex
+	reported      map[portainer.APIKeyID]bool
+}
+
+// StartExpiryJob schedules the periodic purge of the expired API keys and the report of the keys nearing their expiration.
+func StartExpiryJob(scheduler *scheduler.Scheduler, apiKeyService APIKeyService, publisher portainer.NotificationEventPublisher) {
+	monitor := newExpiryMonitor(apiKeyService, publisher)
+
+	scheduler.StartJobEvery(expiryJobInterval, func() error {
+		err := monitor.run(time.Now())
+		if err != nil {
+			logrus.WithError(err).Error("unable to process the expired API keys")
+		}
+
+		// never stop the job, the next run may succeed
+		return nil
+	})
+}
+
+func newExpiryMonitor(apiKeyService APIKeyService, publisher portainer.NotificationEventPublisher) *expiryMonitor {
+	return &expiryMonitor{
+		apiKeyService: apiKeyService,
+		publisher:     publisher,
+		reported:      map[portainer.APIKeyID]bool{},
+	}
+}
+
+func (monitor *expiryMonitor) run(now time.Time) error {
+	err := monitor.apiKeyService.PurgeExpiredAPIKeys()
+	if err != nil {
+		return err
+	}
+
+	expiring, err := monitor.apiKeyService.GetExpiringAPIKeys(now.Add(expiryWarningPeriod))
+	if err != nil {
+		return err
+	}
+
+	monitor.mu.Lock()
+	defer monitor.mu.Unlock()
+
+	for i := range expiring {
+		apiKey := &expiring[i]
+		if monitor.reported[apiKey.ID] {
+			continue
+		}
+
+		monitor.publisher.Publish(events.APIKeyExpiring(apiKey))
+		monitor.reported[apiKey.ID] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package apikey
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+type publisherMock struct {
+	events []portainer.NotificationEvent
+}
+
+func (p *publisherMock) Publish(event portainer.NotificationEvent) {
+	p.events = append(p.events, event)
+}
+
+func Test_expiryMonitor(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	service := NewAPIKeyService(store.APIKeyRepository(), store.User())
+	publisher := &publisherMock{}
+	monitor := newExpiryMonitor(service, publisher)
+
+	user := portainer.User{ID: 1}
+	now := time.Now()
+
+	_, expired, err := service.GenerateScopedApiKey(user, "expired", now.Add(-time.Hour).Unix(), portainer.APIKeyScope{})
+	is.NoError(err)
+	_, expiring, err := service.GenerateScopedApiKey(user, "expiring", now.Add(24*time.Hour).Unix(), portainer.APIKeyScope{})
+	is.NoError(err)
+	_, _, err = service.GenerateScopedApiKey(user, "later", now.Add(30*24*time.Hour).Unix(), portainer.APIKeyScope{})
+	is.NoError(err)
+	_, _, err = service.GenerateApiKey(user, "never")
+	is.NoError(err)
+
+	is.NoError(monitor.run(now))
+
+	_, err = store.APIKeyRepository().GetAPIKey(expired.ID)
+	is.True(store.IsErrObjectNotFound(err))
+
+	keys, err := store.APIKeyRepository().GetAPIKeys()
+	is.NoError(err)
+	is.Len(keys, 3)
+
+	is.Len(publisher.events, 1)
+	is.Equal(portainer.NotificationEventAPIKeyExpiring, publisher.events[0].Type)
+	is.Equal(int(expiring.ID), publisher.events[0].ResourceID)
+
+	// keys are only reported once
+	is.NoError(monitor.run(now))
+	is.Len(publisher.events, 1)
+}
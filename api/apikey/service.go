@@ -16,6 +16,8 @@ const portainerAPIKeyPrefix = "ptr_"
 
 var ErrInvalidAPIKey = errors.New("Invalid API key")
 
+var ErrExpiredAPIKey = errors.New("Expired API key")
+
 type apiKeyService struct {
 	apiKeyRepository dataservices.APIKeyRepository
 	userRepository   dataservices.UserService
@@ -39,6 +41,13 @@ func (a *apiKeyService) HashRaw(rawKey string) []byte {
 // GenerateApiKey generates a raw API key for a user (for one-time display).
 // The generated API key is stored in the cache and database.
 func (a *apiKeyService) GenerateApiKey(user portainer.User, description string) (string, *portainer.APIKey, error) {
+	return a.GenerateScopedApiKey(user, description, 0, portainer.APIKeyScope{})
+}
+
+// GenerateScopedApiKey generates a raw API key for a user (for one-time display), restricted to a scope
+// and expiring at the specified Unix timestamp (0 for a key that never expires).
+// The generated API key is stored in the cache and database.
+func (a *apiKeyService) GenerateScopedApiKey(user portainer.User, description string, expiresAt int64, scope portainer.APIKeyScope) (string, *portainer.APIKey, error) {
 	randKey := generateRandomKey(32)
 	encodedRawAPIKey := base64.StdEncoding.EncodeToString(randKey)
 	prefixedAPIKey := portainerAPIKeyPrefix + encodedRawAPIKey
@@ -51,6 +60,8 @@ func (a *apiKeyService) GenerateApiKey(user portainer.User, description string)
 		Prefix:      prefixedAPIKey[:7],
 		DateCreated: time.Now().Unix(),
 		Digest:      hashDigest,
+		ExpiresAt:   expiresAt,
+		Scope:       scope,
 	}
 
 	err := a.apiKeyRepository.CreateAPIKey(apiKey)
@@ -76,10 +87,15 @@ func (a *apiKeyService) GetAPIKeys(userID portainer.UserID) ([]portainer.APIKey,
 
 // GetDigestUserAndKey returns the user and api-key associated to a specified hash digest.
 // A cache lookup is performed first; if the user/api-key is not found in the cache, respective database lookups are performed.
+// Expired API keys are evicted from the cache and rejected with ErrExpiredAPIKey.
 func (a *apiKeyService) GetDigestUserAndKey(digest []byte) (portainer.User, portainer.APIKey, error) {
 	// get api key from cache if possible
 	cachedUser, cachedKey, ok := a.cache.Get(digest)
 	if ok {
+		if isExpired(cachedKey, time.Now()) {
+			a.cache.Delete(digest)
+			return portainer.User{}, portainer.APIKey{}, ErrExpiredAPIKey
+		}
 		return cachedUser, cachedKey, nil
 	}
 
@@ -88,6 +104,10 @@ func (a *apiKeyService) GetDigestUserAndKey(digest []byte) (portainer.User, port
 		return portainer.User{}, portainer.APIKey{}, errors.Wrap(err, "Unable to retrieve API key")
 	}
 
+	if isExpired(*apiKey, time.Now()) {
+		return portainer.User{}, portainer.APIKey{}, ErrExpiredAPIKey
+	}
+
 	user, err := a.userRepository.User(apiKey.UserID)
 	if err != nil {
 		return portainer.User{}, portainer.APIKey{}, errors.Wrap(err, "Unable to retrieve digest user")
@@ -125,3 +145,44 @@ func (a *apiKeyService) DeleteAPIKey(apiKeyID portainer.APIKeyID) error {
 func (a *apiKeyService) InvalidateUserKeyCache(userId portainer.UserID) bool {
 	return a.cache.InvalidateUserKeyCache(userId)
 }
+
+// GetExpiringAPIKeys returns the API keys expiring before the specified time, including the expired ones.
+func (a *apiKeyService) GetExpiringAPIKeys(before time.Time) ([]portainer.APIKey, error) {
+	apiKeys, err := a.apiKeyRepository.GetAPIKeys()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to retrieve API keys")
+	}
+
+	expiring := make([]portainer.APIKey, 0)
+	for _, apiKey := range apiKeys {
+		if isExpired(apiKey, before) {
+			expiring = append(expiring, apiKey)
+		}
+	}
+
+	return expiring, nil
+}
+
+// PurgeExpiredAPIKeys deletes the expired API keys from the database and the cache.
+func (a *apiKeyService) PurgeExpiredAPIKeys() error {
+	expired, err := a.GetExpiringAPIKeys(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, apiKey := range expired {
+		a.cache.Delete(apiKey.Digest)
+
+		err := a.apiKeyRepository.DeleteAPIKey(apiKey.ID)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Unable to delete API key: %d", apiKey.ID))
+		}
+	}
+
+	return nil
+}
+
+// isExpired returns true when the API key has an expiration date which is not after the specified time.
+func isExpired(apiKey portainer.APIKey, at time.Time) bool {
+	return apiKey.ExpiresAt != 0 && apiKey.ExpiresAt <= at.Unix()
+}
@@ -307,3 +307,61 @@ func Test_InvalidateUserKeyCache(t *testing.T) {
 		is.True(ok)
 	})
 }
+
+func Test_ExpiredAPIKeys(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	service := NewAPIKeyService(store.APIKeyRepository(), store.User())
+
+	user := portainer.User{ID: 1, Username: "user"}
+	is.NoError(store.User().Create(&user))
+
+	expiredAt := time.Now().Add(-time.Minute).Unix()
+
+	t.Run("Expired key is rejected and evicted from cache", func(t *testing.T) {
+		_, apiKey, err := service.GenerateScopedApiKey(user, "test-expired", expiredAt, portainer.APIKeyScope{})
+		is.NoError(err)
+
+		_, _, err = service.GetDigestUserAndKey(apiKey.Digest)
+		is.ErrorIs(err, ErrExpiredAPIKey)
+
+		_, _, ok := service.cache.Get(apiKey.Digest)
+		is.False(ok)
+
+		// the database lookup rejects the key as well
+		_, _, err = service.GetDigestUserAndKey(apiKey.Digest)
+		is.ErrorIs(err, ErrExpiredAPIKey)
+	})
+
+	t.Run("Key is valid until its expiration date", func(t *testing.T) {
+		scope := portainer.APIKeyScope{ReadOnly: true, EndpointIDs: []portainer.EndpointID{1}}
+		_, apiKey, err := service.GenerateScopedApiKey(user, "test-valid", time.Now().Add(time.Hour).Unix(), scope)
+		is.NoError(err)
+
+		_, key, err := service.GetDigestUserAndKey(apiKey.Digest)
+		is.NoError(err)
+		is.Equal(scope, key.Scope)
+	})
+
+	t.Run("Purge deletes expired keys only", func(t *testing.T) {
+		_, expired, err := service.GenerateScopedApiKey(user, "test-purged", expiredAt, portainer.APIKeyScope{})
+		is.NoError(err)
+
+		_, valid, err := service.GenerateApiKey(user, "test-kept")
+		is.NoError(err)
+
+		is.NoError(service.PurgeExpiredAPIKeys())
+
+		_, err = service.GetAPIKey(expired.ID)
+		is.True(store.IsErrObjectNotFound(err))
+
+		_, _, ok := service.cache.Get(expired.Digest)
+		is.False(ok)
+
+		_, err = service.GetAPIKey(valid.ID)
+		is.NoError(err)
+	})
+}
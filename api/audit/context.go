@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+type contextKey int
+
+const recordKey contextKey = iota
+
+// record holds the audit details collected while a request travels through
+// the middleware chain. It is stored as a pointer inside the request context
+// so that inner handlers and proxies can enrich it.
+type record struct {
+	userID       portainer.UserID
+	username     string
+	apiKeyID     portainer.APIKeyID
+	resourceType string
+	resourceID   string
+}
+
+func withRecord(ctx context.Context, rec *record) context.Context {
+	return context.WithValue(ctx, recordKey, rec)
+}
+
+func recordFromContext(ctx context.Context) *record {
+	rec, ok := ctx.Value(recordKey).(*record)
+	if !ok {
+		return nil
+	}
+	return rec
+}
+
+// SetUser attaches the authenticated user to the audit record of the request, if any.
+func SetUser(ctx context.Context, userID portainer.UserID, username string) {
+	if rec := recordFromContext(ctx); rec != nil {
+		rec.userID = userID
+		rec.username = username
+	}
+}
+
+// SetAPIKey attaches the API key used to authenticate the request to its audit record, if any.
+func SetAPIKey(ctx context.Context, apiKeyID portainer.APIKeyID) {
+	if rec := recordFromContext(ctx); rec != nil {
+		rec.apiKeyID = apiKeyID
+	}
+}
+
+// SetResource overrides the resource type and identifier that would otherwise be inferred from the request path.
+func SetResource(ctx context.Context, resourceType, resourceID string) {
+	if rec := recordFromContext(ctx); rec != nil {
+		rec.resourceType = resourceType
+		rec.resourceID = resourceID
+	}
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// Middleware records an audit log entry for every state-changing request (POST, PUT, PATCH, DELETE),
+// including the ones proxied to Docker and Kubernetes environments.
+func (service *Service) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAuditedMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &record{}
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(withRecord(r.Context(), rec)))
+
+		service.Record(newEntry(r, rec, recorder.statusCode))
+	})
+}
+
+func isAuditedMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func newEntry(r *http.Request, rec *record, statusCode int) *portainer.AuditLog {
+	endpointID, resourceType, resourceID := parseResource(r.URL.Path)
+	if rec.resourceType != "" {
+		resourceType = rec.resourceType
+		resourceID = rec.resourceID
+	}
+
+	outcome := portainer.AuditLogOutcomeSuccess
+	if statusCode >= http.StatusBadRequest {
+		outcome = portainer.AuditLogOutcomeFailure
+	}
+
+	return &portainer.AuditLog{
+		UserID:       rec.userID,
+		Username:     rec.username,
+		APIKeyID:     rec.apiKeyID,
+		EndpointID:   endpointID,
+		Method:       r.Method,
+		Path:         r.URL.Path,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		StatusCode:   statusCode,
+		Outcome:      outcome,
+	}
+}
+
+// parseResource infers the environment, resource type and resource identifier from an API path.
+// e.g. /api/stacks/3 => stacks, 3
+// e.g. /api/endpoints/1/docker/containers/abc/start => 1, containers, abc
+// e.g. /api/endpoints/1/kubernetes/api/v1/namespaces/default/pods/web => 1, pods, default/web
+func parseResource(path string) (portainer.EndpointID, string, string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return 0, "", ""
+	}
+
+	if segments[0] != "endpoints" || len(segments) < 2 {
+		return 0, segments[0], segmentAt(segments, 1)
+	}
+
+	id, err := strconv.Atoi(segments[1])
+	if err != nil {
+		return 0, segments[0], segments[1]
+	}
+	endpointID := portainer.EndpointID(id)
+
+	if len(segments) < 4 {
+		return endpointID, "endpoints", segments[1]
+	}
+
+	switch segments[2] {
+	case "kubernetes":
+		resourceType, resourceID := parseKubernetesResource(segments[3:])
+		return endpointID, resourceType, resourceID
+	case "docker", "agent", "azure":
+		return endpointID, segments[3], segmentAt(segments, 4)
+	}
+
+	return endpointID, "endpoints", segments[1]
+}
+
+// parseKubernetesResource extracts the resource kind and name from a Kubernetes API path,
+// namespaced resources are identified as namespace/name.
+func parseKubernetesResource(segments []string) (string, string) {
+	switch segmentAt(segments, 0) {
+	case "api":
+		// api/<version>/...
+		segments = trimSegments(segments, 2)
+	case "apis":
+		// apis/<group>/<version>/...
+		segments = trimSegments(segments, 3)
+	}
+
+	if len(segments) == 0 {
+		return "", ""
+	}
+
+	if segments[0] == "namespaces" && len(segments) >= 3 {
+		namespace := segments[1]
+		name := segmentAt(segments, 3)
+		if name != "" {
+			name = namespace + "/" + name
+		}
+		return segments[2], name
+	}
+
+	return segments[0], segmentAt(segments, 1)
+}
+
+func trimSegments(segments []string, count int) []string {
+	if count > len(segments) {
+		return nil
+	}
+	return segments[count:]
+}
+
+func segmentAt(segments []string, index int) string {
+	if index < len(segments) {
+		return segments[index]
+	}
+	return ""
+}
+
+// statusRecorder captures the status code written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (recorder *statusRecorder) WriteHeader(statusCode int) {
+	recorder.statusCode = statusCode
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush allows streamed responses (e.g. image pulls) to go through the recorder.
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack allows upgraded connections (e.g. container attach) to go through the recorder.
+func (recorder *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	recorder.statusCode = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseResource(t *testing.T) {
+	tests := []struct {
+		path                 string
+		expectedEndpointID   portainer.EndpointID
+		expectedResourceType string
+		expectedResourceID   string
+	}{
+		{"/api/stacks", 0, "stacks", ""},
+		{"/api/stacks/3/start", 0, "stacks", "3"},
+		{"/api/endpoints/4", 4, "endpoints", "4"},
+		{"/api/endpoints/4/docker/containers/abc/start", 4, "containers", "abc"},
+		{"/api/endpoints/4/kubernetes/api/v1/namespaces/default/pods/web", 4, "pods", "default/web"},
+		{"/api/endpoints/4/kubernetes/apis/apps/v1/namespaces/default/deployments", 4, "deployments", ""},
+		{"/api/endpoints/4/kubernetes/api/v1/namespaces/default", 4, "namespaces", "default"},
+		{"/api/endpoints/4/kubernetes/api/v1/nodes/node-1", 4, "nodes", "node-1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			endpointID, resourceType, resourceID := parseResource(test.path)
+			assert.Equal(t, test.expectedEndpointID, endpointID)
+			assert.Equal(t, test.expectedResourceType, resourceType)
+			assert.Equal(t, test.expectedResourceID, resourceID)
+		})
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	service := NewService(store)
+
+	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetUser(r.Context(), 2, "bob")
+		SetAPIKey(r.Context(), 7)
+
+		if r.URL.Path == "/api/stacks/1" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	t.Run("read requests are not recorded", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/stacks", nil))
+
+		logs, err := store.AuditLog().AuditLogs()
+		is.NoError(err)
+		is.Len(logs, 0)
+	})
+
+	t.Run("mutating requests are recorded with their outcome", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/endpoints/3/docker/containers/abc/stop", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/stacks/1", nil))
+
+		logs, err := store.AuditLog().AuditLogs()
+		is.NoError(err)
+		is.Len(logs, 2)
+
+		is.Equal(portainer.UserID(2), logs[0].UserID)
+		is.Equal("bob", logs[0].Username)
+		is.Equal(portainer.APIKeyID(7), logs[0].APIKeyID)
+		is.Equal(portainer.EndpointID(3), logs[0].EndpointID)
+		is.Equal("containers", logs[0].ResourceType)
+		is.Equal("abc", logs[0].ResourceID)
+		is.Equal(portainer.AuditLogOutcomeSuccess, logs[0].Outcome)
+
+		is.Equal("stacks", logs[1].ResourceType)
+		is.Equal(http.StatusForbidden, logs[1].StatusCode)
+		is.Equal(portainer.AuditLogOutcomeFailure, logs[1].Outcome)
+	})
+}
+
+func Test_PurgeExpiredLogs(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	service := NewService(store)
+
+	now := time.Now().UTC()
+	service.Record(&portainer.AuditLog{Method: http.MethodPost, Timestamp: now.Add(-48 * time.Hour).Unix()})
+	service.Record(&portainer.AuditLog{Method: http.MethodPut, Timestamp: now.Unix()})
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuditLogRetention = "24h"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	is.NoError(service.PurgeExpiredLogs())
+
+	logs, err := store.AuditLog().AuditLogs()
+	is.NoError(err)
+	is.Len(logs, 1)
+	is.Equal(http.MethodPut, logs[0].Method)
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+const retentionJobInterval = time.Hour
+
+// Service records audit log entries and enforces their retention.
+type Service struct {
+	dataStore dataservices.DataStore
+}
+
+// NewService creates a new instance of the audit service.
+func NewService(dataStore dataservices.DataStore) *Service {
+	return &Service{
+		dataStore: dataStore,
+	}
+}
+
+// Record stores an audit log entry. Failures are logged and never surfaced to the caller
+// so that auditing can't break the audited operation.
+func (service *Service) Record(entry *portainer.AuditLog) {
+	if entry.Timestamp == 0 {
+		entry.Timestamp = time.Now().UTC().Unix()
+	}
+
+	err := service.dataStore.AuditLog().Create(entry)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"method": entry.Method,
+			"path":   entry.Path,
+		}).Error("unable to persist audit log entry")
+	}
+}
+
+// PurgeExpiredLogs removes the audit log entries older than the retention configured in the settings.
+func (service *Service) PurgeExpiredLogs() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	retention, err := parseRetention(settings.AuditLogRetention)
+	if err != nil {
+		return err
+	}
+
+	if retention == 0 {
+		return nil
+	}
+
+	return service.dataStore.AuditLog().DeleteAuditLogsBefore(time.Now().UTC().Add(-retention).Unix())
+}
+
+// StartRetentionJob schedules the periodic purge of expired audit log entries.
+func (service *Service) StartRetentionJob(scheduler *scheduler.Scheduler) {
+	scheduler.StartJobEvery(retentionJobInterval, func() error {
+		err := service.PurgeExpiredLogs()
+		if err != nil {
+			logrus.WithError(err).Error("unable to purge expired audit logs")
+		}
+
+		// never stop the job, the next run may succeed
+		return nil
+	})
+}
+
+func parseRetention(retention string) (time.Duration, error) {
+	if retention == "" {
+		retention = portainer.DefaultAuditLogRetention
+	}
+
+	return time.ParseDuration(retention)
+}
@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// scheduledBackupPrefix is prepended to the name of the archives created by the scheduled backups,
+// it is used to tell them apart from the other files of a destination.
+const scheduledBackupPrefix = "portainer-backup_"
+
+// Destination is a storage where the backup archives can be written to and read from.
+type Destination interface {
+	// Upload writes the content of the reader into the archive named name.
+	Upload(name string, reader io.ReadSeeker) error
+	// List returns the backup archives available in the destination.
+	List() ([]portainer.BackupInfo, error)
+	// Open returns a reader of the archive named name. The caller is responsible of closing it.
+	Open(name string) (io.ReadCloser, error)
+	// Delete removes the archive named name.
+	Delete(name string) error
+}
+
+// NewDestination creates the destination described by the scheduled backup settings.
+func NewDestination(settings portainer.ScheduledBackupSettings) (Destination, error) {
+	switch settings.Destination {
+	case portainer.BackupDestinationLocal:
+		return NewLocalDestination(settings.LocalDirectory)
+	case portainer.BackupDestinationS3:
+		return NewS3Destination(settings.S3Settings)
+	}
+
+	return nil, errors.Errorf("unsupported backup destination type: %d", settings.Destination)
+}
+
+func validateArchiveName(name string) error {
+	if name == "" || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) || !isBackupArchive(name) {
+		return errors.Errorf("invalid backup archive name: %q", name)
+	}
+
+	return nil
+}
+
+func isBackupArchive(name string) bool {
+	return strings.HasPrefix(name, scheduledBackupPrefix) &&
+		(strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tar.gz.encrypted"))
+}
+
+func newBackupInfo(name string, size int64, createdAt int64) portainer.BackupInfo {
+	return portainer.BackupInfo{
+		Name:      name,
+		Size:      size,
+		CreatedAt: createdAt,
+		Encrypted: strings.HasSuffix(name, ".encrypted"),
+	}
+}
+
+// sortBackupsByDate orders the backups from the newest to the oldest.
+func sortBackupsByDate(backups []portainer.BackupInfo) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].CreatedAt == backups[j].CreatedAt {
+			return backups[i].Name > backups[j].Name
+		}
+		return backups[i].CreatedAt > backups[j].CreatedAt
+	})
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// LocalDestination stores the backup archives inside a directory of the local filesystem.
+type LocalDestination struct {
+	directory string
+}
+
+// NewLocalDestination creates a destination writing the backup archives inside directory.
+func NewLocalDestination(directory string) (*LocalDestination, error) {
+	if directory == "" {
+		return nil, errors.New("the backup directory is required")
+	}
+
+	return &LocalDestination{directory: directory}, nil
+}
+
+func (destination *LocalDestination) Upload(name string, reader io.ReadSeeker) error {
+	if err := validateArchiveName(name); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destination.directory, 0700); err != nil {
+		return errors.Wrap(err, "failed to create the backup directory")
+	}
+
+	// write to a temporary file first so that a partial archive never shows up in the list
+	tmpPath := filepath.Join(destination.directory, "."+name+".tmp")
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the backup file")
+	}
+
+	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "failed to write the backup file")
+	}
+
+	return os.Rename(tmpPath, filepath.Join(destination.directory, name))
+}
+
+func (destination *LocalDestination) List() ([]portainer.BackupInfo, error) {
+	entries, err := os.ReadDir(destination.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []portainer.BackupInfo{}, nil
+		}
+		return nil, errors.Wrap(err, "failed to read the backup directory")
+	}
+
+	backups := make([]portainer.BackupInfo, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !isBackupArchive(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the backup file %s", entry.Name())
+		}
+
+		backups = append(backups, newBackupInfo(entry.Name(), info.Size(), info.ModTime().Unix()))
+	}
+
+	sortBackupsByDate(backups)
+
+	return backups, nil
+}
+
+func (destination *LocalDestination) Open(name string) (io.ReadCloser, error) {
+	if err := validateArchiveName(name); err != nil {
+		return nil, err
+	}
+
+	return os.Open(filepath.Join(destination.directory, name))
+}
+
+func (destination *LocalDestination) Delete(name string) error {
+	if err := validateArchiveName(name); err != nil {
+		return err
+	}
+
+	return os.Remove(filepath.Join(destination.directory, name))
+}
@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// S3Destination stores the backup archives inside an S3 compatible bucket (AWS S3, MinIO...).
+type S3Destination struct {
+	client *s3.Client
+	bucket string
+	folder string
+}
+
+// NewS3Destination creates a destination writing the backup archives inside the bucket described by settings.
+// When an endpoint is specified, path-style addressing is used as most S3 compatible services expect it.
+func NewS3Destination(settings portainer.BackupS3Settings) (*S3Destination, error) {
+	if settings.BucketName == "" {
+		return nil, errors.New("the bucket name is required")
+	}
+
+	region := settings.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	options := s3.Options{
+		Region:      region,
+		Credentials: aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(settings.AccessKeyID, settings.SecretAccessKey, "")),
+	}
+
+	if settings.Endpoint != "" {
+		options.EndpointResolver = s3.EndpointResolverFromURL(settings.Endpoint)
+		options.UsePathStyle = true
+	}
+
+	return &S3Destination{
+		client: s3.New(options),
+		bucket: settings.BucketName,
+		folder: strings.Trim(settings.Folder, "/"),
+	}, nil
+}
+
+func (destination *S3Destination) key(name string) string {
+	if destination.folder == "" {
+		return name
+	}
+	return path.Join(destination.folder, name)
+}
+
+func (destination *S3Destination) Upload(name string, reader io.ReadSeeker) error {
+	if err := validateArchiveName(name); err != nil {
+		return err
+	}
+
+	_, err := destination.client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket: aws.String(destination.bucket),
+		Key:    aws.String(destination.key(name)),
+		Body:   reader,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to upload the backup to the bucket")
+	}
+
+	return nil
+}
+
+func (destination *S3Destination) List() ([]portainer.BackupInfo, error) {
+	prefix := destination.key(scheduledBackupPrefix)
+
+	backups := make([]portainer.BackupInfo, 0)
+	paginator := s3.NewListObjectsV2Paginator(destination.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(destination.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list the backups of the bucket")
+		}
+
+		for _, object := range page.Contents {
+			name := path.Base(aws.ToString(object.Key))
+			if !isBackupArchive(name) || destination.key(name) != aws.ToString(object.Key) {
+				continue
+			}
+
+			var createdAt int64
+			if object.LastModified != nil {
+				createdAt = object.LastModified.Unix()
+			}
+
+			backups = append(backups, newBackupInfo(name, object.Size, createdAt))
+		}
+	}
+
+	sortBackupsByDate(backups)
+
+	return backups, nil
+}
+
+func (destination *S3Destination) Open(name string) (io.ReadCloser, error) {
+	if err := validateArchiveName(name); err != nil {
+		return nil, err
+	}
+
+	output, err := destination.client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(destination.bucket),
+		Key:    aws.String(destination.key(name)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download the backup from the bucket")
+	}
+
+	return output.Body, nil
+}
+
+func (destination *S3Destination) Delete(name string) error {
+	if err := validateArchiveName(name); err != nil {
+		return err
+	}
+
+	_, err := destination.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(destination.bucket),
+		Key:    aws.String(destination.key(name)),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the backup from the bucket")
+	}
+
+	return nil
+}
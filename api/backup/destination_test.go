@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3Server is a minimal in-memory stand-in for an S3 compatible service (e.g. MinIO)
+// that supports the path-style requests used by the S3 destination.
+type fakeS3Server struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+type fakeS3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	Size         int64  `xml:"Size"`
+}
+
+type fakeS3ListResult struct {
+	XMLName     xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name        string         `xml:"Name"`
+	Prefix      string         `xml:"Prefix"`
+	KeyCount    int            `xml:"KeyCount"`
+	IsTruncated bool           `xml:"IsTruncated"`
+	Contents    []fakeS3Object `xml:"Contents"`
+}
+
+func newFakeS3Server() *fakeS3Server {
+	return &fakeS3Server{objects: make(map[string][]byte)}
+}
+
+func (s *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// path-style: /<bucket>/<key>
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket := parts[0]
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+
+	switch {
+	case r.Method == http.MethodPut && key != "":
+		body, _ := io.ReadAll(r.Body)
+		s.objects[bucket+"/"+key] = body
+	case r.Method == http.MethodGet && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		result := fakeS3ListResult{Name: bucket, Prefix: prefix}
+		for objectKey, content := range s.objects {
+			name := strings.TrimPrefix(objectKey, bucket+"/")
+			if strings.HasPrefix(name, prefix) {
+				result.Contents = append(result.Contents, fakeS3Object{
+					Key:          name,
+					LastModified: time.Now().UTC().Format(time.RFC3339),
+					Size:         int64(len(content)),
+				})
+			}
+		}
+		sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+		result.KeyCount = len(result.Contents)
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodGet:
+		content, ok := s.objects[bucket+"/"+key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(content)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, bucket+"/"+key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func testDestination(t *testing.T, destination Destination) {
+	is := assert.New(t)
+
+	names := []string{
+		"portainer-backup_2022-08-01_02-00-00.tar.gz",
+		"portainer-backup_2022-08-02_02-00-00.tar.gz.encrypted",
+	}
+	for _, name := range names {
+		err := destination.Upload(name, bytes.NewReader([]byte(name)))
+		is.NoError(err, "upload should succeed")
+	}
+
+	backups, err := destination.List()
+	is.NoError(err, "list should succeed")
+	is.Len(backups, 2)
+	is.Equal(names[1], backups[0].Name, "backups should be ordered from the newest to the oldest")
+	is.True(backups[0].Encrypted)
+	is.False(backups[1].Encrypted)
+
+	reader, err := destination.Open(names[0])
+	is.NoError(err, "open should succeed")
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	is.Equal(names[0], string(content))
+
+	err = destination.Delete(names[0])
+	is.NoError(err, "delete should succeed")
+
+	backups, err = destination.List()
+	is.NoError(err)
+	is.Len(backups, 1)
+
+	err = destination.Upload("../portainer-backup_evil.tar.gz", bytes.NewReader(nil))
+	is.Error(err, "names containing a path should be rejected")
+
+	_, err = destination.Open("portainer.db")
+	is.Error(err, "files which aren't backups should be rejected")
+}
+
+func Test_LocalDestination(t *testing.T) {
+	dir := t.TempDir()
+
+	destination, err := NewDestination(portainer.ScheduledBackupSettings{
+		Destination:    portainer.BackupDestinationLocal,
+		LocalDirectory: filepath.Join(dir, "backups"),
+	})
+	assert.NoError(t, err)
+
+	testDestination(t, destination)
+
+	_, err = os.Stat(filepath.Join(dir, "portainer-backup_evil.tar.gz"))
+	assert.True(t, os.IsNotExist(err), "no file should be written outside of the directory")
+}
+
+func Test_S3Destination(t *testing.T) {
+	fakeS3 := newFakeS3Server()
+	server := httptest.NewServer(fakeS3)
+	defer server.Close()
+
+	destination, err := NewDestination(portainer.ScheduledBackupSettings{
+		Destination: portainer.BackupDestinationS3,
+		S3Settings: portainer.BackupS3Settings{
+			Endpoint:        server.URL,
+			BucketName:      "portainer",
+			Folder:          "/production/",
+			AccessKeyID:     "access",
+			SecretAccessKey: "secret",
+		},
+	})
+	assert.NoError(t, err)
+
+	testDestination(t, destination)
+
+	_, ok := fakeS3.objects["portainer/production/portainer-backup_2022-08-02_02-00-00.tar.gz.encrypted"]
+	assert.True(t, ok, "the backups should be written inside the folder")
+}
+
+func Test_NewDestination_shouldFailWithUnknownType(t *testing.T) {
+	_, err := NewDestination(portainer.ScheduledBackupSettings{})
+	assert.Error(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/portainer/portainer/api/archive"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/database/boltdb"
+	"github.com/portainer/portainer/api/database/sqlite"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/http/offlinegate"
@@ -18,6 +19,8 @@ import (
 
 var filesToRestore = append(filesToBackup, "portainer.db")
 
+const sqliteHeader = "SQLite format 3\x00"
+
 // Restores system state from backup archive, will trigger system shutdown, when finished.
 func RestoreArchive(archive io.Reader, password string, filestorePath string, gate *offlinegate.OfflineGate, datastore dataservices.DataStore, shutdownTrigger context.CancelFunc) error {
 	var err error
@@ -72,6 +75,8 @@ func restoreFiles(srcDir string, destinationDir string) error {
 	// Prevent the possibility of having both databases.  Remove any default new instance
 	os.Remove(filepath.Join(destinationDir, boltdb.DatabaseFileName))
 	os.Remove(filepath.Join(destinationDir, boltdb.EncryptedDatabaseFileName))
+	os.Remove(filepath.Join(destinationDir, sqlite.DatabaseFileName))
+	os.Remove(filepath.Join(destinationDir, sqlite.EncryptedDatabaseFileName))
 
 	// Now copy the database.  It'll be either portainer.db or portainer.edb
 
@@ -81,5 +86,32 @@ func restoreFiles(srcDir string, destinationDir string) error {
 		return err
 	}
 
-	return filesystem.CopyPath(filepath.Join(srcDir, boltdb.DatabaseFileName), destinationDir)
+	err = filesystem.CopyPath(filepath.Join(srcDir, boltdb.DatabaseFileName), destinationDir)
+	if err != nil {
+		return err
+	}
+
+	// The database is always archived as portainer.db, give back its name to a SQLite database
+	restoredPath := filepath.Join(destinationDir, boltdb.DatabaseFileName)
+	if isSQLiteDatabase(restoredPath) {
+		return os.Rename(restoredPath, filepath.Join(destinationDir, sqlite.DatabaseFileName))
+	}
+
+	return nil
+}
+
+// isSQLiteDatabase checks the header of the file against the SQLite format signature
+func isSQLiteDatabase(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+
+	return string(header) == sqliteHeader
 }
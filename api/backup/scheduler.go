@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/offlinegate"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+// Scheduler creates backup archives on the schedule defined in the settings,
+// writes them to the configured destination and enforces their retention.
+type Scheduler struct {
+	mu            sync.Mutex
+	scheduler     *scheduler.Scheduler
+	dataStore     dataservices.DataStore
+	gate          *offlinegate.OfflineGate
+	filestorePath string
+	jobID         string
+}
+
+// NewScheduler creates a new instance of the backup scheduler.
+func NewScheduler(scheduler *scheduler.Scheduler, dataStore dataservices.DataStore, gate *offlinegate.OfflineGate, filestorePath string) *Scheduler {
+	return &Scheduler{
+		scheduler:     scheduler,
+		dataStore:     dataStore,
+		gate:          gate,
+		filestorePath: filestorePath,
+	}
+}
+
+// Start schedules the backups using the settings stored in the database.
+func (s *Scheduler) Start() error {
+	settings, err := s.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return s.Reschedule(settings.ScheduledBackupSettings)
+}
+
+// Reschedule replaces the current backup job with one matching the given settings.
+// No job is scheduled when the scheduled backups are disabled.
+func (s *Scheduler) Reschedule(settings portainer.ScheduledBackupSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jobID != "" {
+		s.scheduler.StopJob(s.jobID)
+		s.jobID = ""
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	jobID, err := s.scheduler.StartJobWithCronRule(settings.CronRule, func() error {
+		_, err := s.RunBackup()
+		if err != nil {
+			logrus.WithError(err).Error("scheduled backup failed")
+		}
+
+		// never stop the job, the next run may succeed
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.jobID = jobID
+
+	return nil
+}
+
+// RunBackup creates a backup archive, writes it to the configured destination
+// and removes the backups exceeding the retention count. Returns the name of the created backup.
+func (s *Scheduler) RunBackup() (string, error) {
+	settings, err := s.dataStore.Settings().Settings()
+	if err != nil {
+		return "", err
+	}
+	backupSettings := settings.ScheduledBackupSettings
+
+	destination, err := NewDestination(backupSettings)
+	if err != nil {
+		return "", err
+	}
+
+	archivePath, err := CreateBackupArchive(backupSettings.Password, s.gate, s.dataStore, s.filestorePath)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(filepath.Dir(archivePath))
+
+	name := scheduledBackupPrefix + filepath.Base(archivePath)
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open the backup archive")
+	}
+	defer archive.Close()
+
+	err = destination.Upload(name, archive)
+	if err != nil {
+		return "", err
+	}
+
+	logrus.WithField("name", name).Info("scheduled backup created")
+
+	err = applyRetention(destination, backupSettings.RetentionCount)
+	if err != nil {
+		return name, errors.Wrap(err, "failed to remove the expired backups")
+	}
+
+	return name, nil
+}
+
+// ListBackups returns the backups available in the configured destination, from the newest to the oldest.
+func (s *Scheduler) ListBackups() ([]portainer.BackupInfo, error) {
+	destination, err := s.destination()
+	if err != nil {
+		return nil, err
+	}
+
+	return destination.List()
+}
+
+// OpenBackup returns a reader of the backup archive named name from the configured destination.
+func (s *Scheduler) OpenBackup(name string) (io.ReadCloser, error) {
+	destination, err := s.destination()
+	if err != nil {
+		return nil, err
+	}
+
+	return destination.Open(name)
+}
+
+func (s *Scheduler) destination() (Destination, error) {
+	settings, err := s.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDestination(settings.ScheduledBackupSettings)
+}
+
+func applyRetention(destination Destination, retentionCount int) error {
+	if retentionCount <= 0 {
+		return nil
+	}
+
+	backups, err := destination.List()
+	if err != nil {
+		return err
+	}
+
+	if len(backups) <= retentionCount {
+		return nil
+	}
+
+	for _, backup := range backups[retentionCount:] {
+		err := destination.Delete(backup.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/offlinegate"
+	i "github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applyRetention_shouldKeepTheNewestBackups(t *testing.T) {
+	is := assert.New(t)
+
+	destination, _ := NewLocalDestination(t.TempDir())
+	for day := 1; day <= 5; day++ {
+		name := fmt.Sprintf("portainer-backup_2022-08-0%d_02-00-00.tar.gz", day)
+		destination.Upload(name, bytes.NewReader(nil))
+	}
+
+	err := applyRetention(destination, 2)
+	is.NoError(err)
+
+	backups, _ := destination.List()
+	is.Len(backups, 2)
+	is.Equal("portainer-backup_2022-08-05_02-00-00.tar.gz", backups[0].Name)
+	is.Equal("portainer-backup_2022-08-04_02-00-00.tar.gz", backups[1].Name)
+
+	err = applyRetention(destination, 0)
+	is.NoError(err)
+
+	backups, _ = destination.List()
+	is.Len(backups, 2, "a retention count of 0 should keep all the backups")
+}
+
+func Test_RunBackup_shouldUploadTheArchiveToTheDestination(t *testing.T) {
+	is := assert.New(t)
+
+	filestorePath := t.TempDir()
+	settings := &portainer.Settings{
+		ScheduledBackupSettings: portainer.ScheduledBackupSettings{
+			Enabled:        true,
+			CronRule:       "0 2 * * *",
+			Password:       "secret",
+			RetentionCount: 1,
+			Destination:    portainer.BackupDestinationLocal,
+			LocalDirectory: filepath.Join(filestorePath, "scheduled_backups"),
+		},
+	}
+	datastore := i.NewDatastore(i.WithSettingsService(settings))
+
+	sched := scheduler.NewScheduler(context.Background())
+	defer sched.Shutdown()
+
+	s := NewScheduler(sched, datastore, offlinegate.NewOfflineGate(), filestorePath)
+
+	name, err := s.RunBackup()
+	is.NoError(err)
+
+	backups, err := s.ListBackups()
+	is.NoError(err)
+	is.Len(backups, 1)
+	is.Equal(name, backups[0].Name)
+	is.True(backups[0].Encrypted, "the backup should be encrypted with the settings password")
+
+	reader, err := s.OpenBackup(name)
+	is.NoError(err)
+	reader.Close()
+}
+
+func Test_Reschedule(t *testing.T) {
+	is := assert.New(t)
+
+	datastore := i.NewDatastore(i.WithSettingsService(&portainer.Settings{}))
+
+	sched := scheduler.NewScheduler(context.Background())
+	defer sched.Shutdown()
+	s := NewScheduler(sched, datastore, offlinegate.NewOfflineGate(), t.TempDir())
+
+	err := s.Reschedule(portainer.ScheduledBackupSettings{Enabled: true, CronRule: "invalid"})
+	is.Error(err, "an invalid cron rule should be rejected")
+	is.Empty(s.jobID)
+
+	err = s.Reschedule(portainer.ScheduledBackupSettings{Enabled: true, CronRule: "0 2 * * *"})
+	is.NoError(err)
+	is.NotEmpty(s.jobID)
+
+	err = s.Reschedule(portainer.ScheduledBackupSettings{Enabled: false})
+	is.NoError(err)
+	is.Empty(s.jobID, "disabling the backups should stop the job")
+}
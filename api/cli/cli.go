@@ -22,6 +22,7 @@ var (
 	errSocketOrNamedPipeNotFound     = errors.New("Unable to locate Unix socket or named pipe")
 	errInvalidSnapshotInterval       = errors.New("Invalid snapshot interval")
 	errAdminPassExcludeAdminPassFile = errors.New("Cannot use --admin-password with --admin-password-file")
+	errMigrateFromBoltDBType         = errors.New("Cannot use --migrate-from-boltdb without --db-type=sqlite")
 )
 
 // ParseFlags parse the CLI flags and return a portainer.Flags struct
@@ -35,6 +36,8 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		TunnelPort:                kingpin.Flag("tunnel-port", "Port to serve the tunnel server").Default(defaultTunnelServerPort).String(),
 		Assets:                    kingpin.Flag("assets", "Path to the assets").Default(defaultAssetsDirectory).Short('a').String(),
 		Data:                      kingpin.Flag("data", "Path to the folder where the data is stored").Default(defaultDataDirectory).Short('d').String(),
+		DBType:                    kingpin.Flag("db-type", "Type of the database used to store the data. Valid values are: boltdb, sqlite").Default(defaultDBType).Enum("boltdb", "sqlite"),
+		MigrateFromBoltDB:         kingpin.Flag("migrate-from-boltdb", "Copy the existing BoltDB database into the database selected by --db-type and exit").Bool(),
 		DemoEnvironment:           kingpin.Flag("demo", "Demo environment").Bool(),
 		EndpointURL:               kingpin.Flag("host", "Environment URL").Short('H').String(),
 		FeatureFlags:              BoolPairs(kingpin.Flag("feat", "List of feature flags").Hidden()),
@@ -62,6 +65,7 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		MaxBatchSize:              kingpin.Flag("max-batch-size", "Maximum size of a batch").Int(),
 		MaxBatchDelay:             kingpin.Flag("max-batch-delay", "Maximum delay before a batch starts").Duration(),
 		SecretKeyName:             kingpin.Flag("secret-key-name", "Secret key name for encryption and will be used as /run/secrets/<secret-key-name>.").Default(defaultSecretKeyName).String(),
+		TrustedProxies:            kingpin.Flag("trusted-proxy", "Address or CIDR range of a reverse proxy allowed to set the client address with the X-Forwarded-For header, can be repeated").Strings(),
 	}
 
 	kingpin.Parse()
@@ -96,6 +100,10 @@ func (*Service) ValidateFlags(flags *portainer.CLIFlags) error {
 		return errAdminPassExcludeAdminPassFile
 	}
 
+	if *flags.MigrateFromBoltDB && *flags.DBType == "boltdb" {
+		return errMigrateFromBoltDBType
+	}
+
 	return nil
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/portainer/libhelm"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/audit"
 	"github.com/portainer/portainer/api/build"
 	"github.com/portainer/portainer/api/chisel"
 	"github.com/portainer/portainer/api/cli"
@@ -42,7 +43,9 @@ import (
 	"github.com/portainer/portainer/api/kubernetes"
 	kubecli "github.com/portainer/portainer/api/kubernetes/cli"
 	"github.com/portainer/portainer/api/ldap"
+	"github.com/portainer/portainer/api/notification"
 	"github.com/portainer/portainer/api/oauth"
+	"github.com/portainer/portainer/api/saml"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks"
 )
@@ -70,7 +73,18 @@ func initFileService(dataStorePath string) portainer.FileService {
 }
 
 func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService portainer.FileService, shutdownCtx context.Context) dataservices.DataStore {
-	connection, err := database.NewDatabase("boltdb", *flags.Data, secretKey)
+	if *flags.MigrateFromBoltDB {
+		err := database.MigrateBoltDBToSQLite(*flags.Data, secretKey)
+		if err != nil {
+			logrus.Fatalf("Failed migrating the BoltDB database: %v", err)
+		}
+
+		logrus.Println("Exiting database migration")
+		os.Exit(0)
+		return nil
+	}
+
+	connection, err := database.NewDatabase(*flags.DBType, *flags.Data, secretKey)
 	if err != nil {
 		logrus.Fatalf("failed creating database connection: %s", err)
 	}
@@ -79,8 +93,6 @@ func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService port
 		bconn.MaxBatchSize = *flags.MaxBatchSize
 		bconn.MaxBatchDelay = *flags.MaxBatchDelay
 		bconn.InitialMmapSize = *flags.InitialMmapSize
-	} else {
-		logrus.Fatalf("failed creating database connection: expecting a boltdb database type but a different one was received")
 	}
 
 	store := datastore.NewStore(*flags.Data, fileService, connection)
@@ -178,6 +190,10 @@ func initHelmPackageManager(assetsPath string) (libhelm.HelmPackageManager, erro
 	return libhelm.NewHelmPackageManager(libhelm.HelmConfig{BinaryPath: assetsPath})
 }
 
+func initHelmReleaseManager(assetsPath string) portainer.HelmReleaseManager {
+	return exec.NewHelmReleaseManager(assetsPath)
+}
+
 func initAPIKeyService(datastore dataservices.DataStore) apikey.APIKeyService {
 	return apikey.NewAPIKeyService(datastore.APIKeyRepository(), datastore.User())
 }
@@ -206,8 +222,12 @@ func initOAuthService() portainer.OAuthService {
 	return oauth.NewService()
 }
 
-func initGitService() portainer.GitService {
-	return git.NewService()
+func initSAMLService() portainer.SAMLService {
+	return saml.NewService()
+}
+
+func initGitService(dataStorePath string) portainer.GitService {
+	return git.NewService(path.Join(dataStorePath, "git_cache"))
 }
 
 func initSSLService(addr, certPath, keyPath string, fileService portainer.FileService, dataStore dataservices.DataStore, shutdownTrigger context.CancelFunc) (*ssl.Service, error) {
@@ -235,11 +255,11 @@ func initKubernetesClientFactory(signatureService portainer.DigitalSignatureServ
 	return kubecli.NewClientFactory(signatureService, reverseTunnelService, instanceID, dataStore)
 }
 
-func initSnapshotService(snapshotIntervalFromFlag string, dataStore dataservices.DataStore, dockerClientFactory *docker.ClientFactory, kubernetesClientFactory *kubecli.ClientFactory, shutdownCtx context.Context) (portainer.SnapshotService, error) {
+func initSnapshotService(snapshotIntervalFromFlag string, dataStore dataservices.DataStore, dockerClientFactory *docker.ClientFactory, kubernetesClientFactory *kubecli.ClientFactory, eventPublisher portainer.NotificationEventPublisher, shutdownCtx context.Context) (portainer.SnapshotService, error) {
 	dockerSnapshotter := docker.NewSnapshotter(dockerClientFactory)
 	kubernetesSnapshotter := kubernetes.NewSnapshotter(kubernetesClientFactory)
 
-	snapshotService, err := snapshot.NewService(snapshotIntervalFromFlag, dataStore, dockerSnapshotter, kubernetesSnapshotter, shutdownCtx)
+	snapshotService, err := snapshot.NewService(snapshotIntervalFromFlag, dataStore, dockerSnapshotter, kubernetesSnapshotter, eventPublisher, shutdownCtx)
 	if err != nil {
 		return nil, err
 	}
@@ -569,7 +589,8 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 
 	ldapService := initLDAPService()
 	oauthService := initOAuthService()
-	gitService := initGitService()
+	samlService := initSAMLService()
+	gitService := initGitService(*flags.Data)
 
 	openAMTService := openamt.NewService()
 
@@ -597,7 +618,9 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	dockerClientFactory := initDockerClientFactory(digitalSignatureService, reverseTunnelService)
 	kubernetesClientFactory := initKubernetesClientFactory(digitalSignatureService, reverseTunnelService, instanceID, dataStore)
 
-	snapshotService, err := initSnapshotService(*flags.SnapshotInterval, dataStore, dockerClientFactory, kubernetesClientFactory, shutdownCtx)
+	eventBus := notification.NewEventBus()
+
+	snapshotService, err := initSnapshotService(*flags.SnapshotInterval, dataStore, dockerClientFactory, kubernetesClientFactory, eventBus, shutdownCtx)
 	if err != nil {
 		logrus.Fatalf("Failed initializing snapshot service: %v", err)
 	}
@@ -630,6 +653,8 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		logrus.Fatalf("Failed initializing helm package manager: %v", err)
 	}
 
+	helmReleaseManager := initHelmReleaseManager(*flags.Assets)
+
 	err = edge.LoadEdgeJobs(dataStore, reverseTunnelService)
 	if err != nil {
 		logrus.Fatalf("Failed loading edge jobs from database: %v", err)
@@ -697,11 +722,22 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	}
 
 	scheduler := scheduler.NewScheduler(shutdownCtx)
-	stackDeployer := stacks.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer)
+	stackDeployer := stacks.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer, eventBus)
 	stacks.StartStackSchedules(scheduler, stackDeployer, dataStore, gitService)
 
+	auditService := audit.NewService(dataStore)
+	auditService.StartRetentionJob(scheduler)
+
+	notificationService := notification.NewService(dataStore)
+	notificationService.Start(eventBus)
+	notificationService.StartRetentionJob(scheduler)
+
+	apikey.StartExpiryJob(scheduler, apiKeyService, eventBus)
+
 	return &http.Server{
 		AuthorizationService:        authorizationService,
+		AuditService:                auditService,
+		EventBus:                    eventBus,
 		ReverseTunnelService:        reverseTunnelService,
 		Status:                      applicationStatus,
 		BindAddress:                 *flags.Addr,
@@ -713,12 +749,14 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		ComposeStackManager:         composeStackManager,
 		KubernetesDeployer:          kubernetesDeployer,
 		HelmPackageManager:          helmPackageManager,
+		HelmReleaseManager:          helmReleaseManager,
 		CryptoService:               cryptoService,
 		APIKeyService:               apiKeyService,
 		JWTService:                  jwtService,
 		FileService:                 fileService,
 		LDAPService:                 ldapService,
 		OAuthService:                oauthService,
+		SAMLService:                 samlService,
 		GitService:                  gitService,
 		OpenAMTService:              openAMTService,
 		ProxyManager:                proxyManager,
@@ -734,6 +772,7 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		ShutdownTrigger:             shutdownTrigger,
 		StackDeployer:               stackDeployer,
 		DemoService:                 demoService,
+		TrustedProxies:              *flags.TrustedProxies,
 	}
 }
 
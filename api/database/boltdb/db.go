@@ -426,3 +426,19 @@ func (connection *DbConnection) RestoreMetadata(s map[string]interface{}) error
 
 	return err
 }
+
+// ForEachRawObject calls fn for every key/value pair of every bucket. Values are passed as they are stored,
+// i.e. encrypted when the store is encrypted. It is used to copy the database into another store.
+func (connection *DbConnection) ForEachRawObject(fn func(bucketName string, key, value []byte) error) error {
+	return connection.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			return bucket.ForEach(func(k, v []byte) error {
+				if v == nil {
+					return nil
+				}
+
+				return fn(string(name), k, v)
+			})
+		})
+	})
+}
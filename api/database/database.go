@@ -5,6 +5,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/database/boltdb"
+	"github.com/portainer/portainer/api/database/sqlite"
 )
 
 // NewDatabase should use config options to return a connection to the requested database
@@ -15,6 +16,11 @@ func NewDatabase(storeType, storePath string, encryptionKey []byte) (connection
 			Path:          storePath,
 			EncryptionKey: encryptionKey,
 		}, nil
+	case "sqlite":
+		return &sqlite.DbConnection{
+			Path:          storePath,
+			EncryptionKey: encryptionKey,
+		}, nil
 	}
 	return nil, fmt.Errorf("unknown storage database: %s", storeType)
 }
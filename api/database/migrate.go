@@ -0,0 +1,101 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/portainer/portainer/api/database/boltdb"
+	"github.com/portainer/portainer/api/database/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// MigrateBoltDBToSQLite copies the content of the BoltDB database found in storePath into a new SQLite database.
+// Objects are copied as they are stored, the SQLite database is therefore encrypted only if the BoltDB one is.
+// The BoltDB database is left untouched.
+func MigrateBoltDBToSQLite(storePath string, encryptionKey []byte) error {
+	encrypted, err := boltDBEncryptionState(storePath, encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	destination := &sqlite.DbConnection{Path: storePath, EncryptionKey: encryptionKey}
+	for _, filename := range []string{sqlite.DatabaseFileName, sqlite.EncryptedDatabaseFileName} {
+		if fileExists(path.Join(storePath, filename)) {
+			return fmt.Errorf("a SQLite database already exists: %s", path.Join(storePath, filename))
+		}
+	}
+
+	source := &boltdb.DbConnection{Path: storePath, EncryptionKey: encryptionKey}
+	source.SetEncrypted(encrypted)
+	destination.SetEncrypted(encrypted)
+
+	err = source.Open()
+	if err != nil {
+		return errors.Wrap(err, "failed opening the BoltDB database")
+	}
+	defer source.Close()
+
+	err = destination.Open()
+	if err != nil {
+		return errors.Wrap(err, "failed creating the SQLite database")
+	}
+	defer destination.Close()
+
+	logrus.WithFields(logrus.Fields{
+		"from": source.GetDatabaseFilePath(),
+		"to":   destination.GetDatabaseFilePath(),
+	}).Info("Migrating database")
+
+	err = destination.PutRawObjects(source.ForEachRawObject)
+	if err != nil {
+		os.Remove(destination.GetDatabaseFilePath())
+		return errors.Wrap(err, "failed copying the database objects")
+	}
+
+	metadata, err := source.BackupMetadata()
+	if err != nil {
+		os.Remove(destination.GetDatabaseFilePath())
+		return errors.Wrap(err, "failed reading the BoltDB sequences")
+	}
+
+	// RestoreMetadata expects the JSON representation of the sequences
+	sequences := make(map[string]interface{}, len(metadata))
+	for bucketName, sequence := range metadata {
+		sequences[bucketName] = float64(sequence.(int))
+	}
+
+	err = destination.RestoreMetadata(sequences)
+	if err != nil {
+		os.Remove(destination.GetDatabaseFilePath())
+		return errors.Wrap(err, "failed restoring the bucket sequences")
+	}
+
+	logrus.Info("Database successfully migrated")
+	return nil
+}
+
+// boltDBEncryptionState returns whether the BoltDB database stored in storePath is encrypted
+func boltDBEncryptionState(storePath string, encryptionKey []byte) (bool, error) {
+	haveDbFile := fileExists(path.Join(storePath, boltdb.DatabaseFileName))
+	haveEdbFile := fileExists(path.Join(storePath, boltdb.EncryptedDatabaseFileName))
+
+	switch {
+	case haveDbFile && haveEdbFile:
+		return false, boltdb.ErrHaveEncryptedAndUnencrypted
+	case haveEdbFile && encryptionKey == nil:
+		return false, boltdb.ErrHaveEncryptedWithNoKey
+	case haveEdbFile:
+		return true, nil
+	case haveDbFile:
+		return false, nil
+	}
+
+	return false, fmt.Errorf("no BoltDB database found in %s", storePath)
+}
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
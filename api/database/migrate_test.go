@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/portainer/portainer/api/database/boltdb"
+	"github.com/portainer/portainer/api/database/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+type testObject struct {
+	ID   int    `json:"Id"`
+	Name string `json:"Name"`
+}
+
+func Test_MigrateBoltDBToSQLite(t *testing.T) {
+	is := assert.New(t)
+
+	storePath := t.TempDir()
+	encryptionKey := []byte("apassphrasewhichneedstobe32bytes")
+
+	source := &boltdb.DbConnection{Path: storePath, EncryptionKey: encryptionKey}
+	source.SetEncrypted(true)
+	is.NoError(source.Open())
+	is.NoError(source.SetServiceName("objects"))
+	is.NoError(source.SetServiceName("version"))
+	for _, name := range []string{"first", "second"} {
+		err := source.CreateObject("objects", func(id uint64) (int, interface{}) {
+			return int(id), &testObject{ID: int(id), Name: name}
+		})
+		is.NoError(err)
+	}
+	is.NoError(source.CreateObjectWithStringId("version", []byte("DB_VERSION"), "70"))
+	is.NoError(source.Close())
+
+	is.NoError(MigrateBoltDBToSQLite(storePath, encryptionKey))
+
+	destination := &sqlite.DbConnection{Path: storePath, EncryptionKey: encryptionKey}
+	migrationRequired, err := destination.NeedsEncryptionMigration()
+	is.NoError(err)
+	is.False(migrationRequired)
+	is.NoError(destination.Open())
+	defer destination.Close()
+
+	var object testObject
+	is.NoError(destination.GetObject("objects", destination.ConvertToKey(2), &object))
+	is.Equal("second", object.Name)
+
+	var version string
+	is.NoError(destination.GetObject("version", []byte("DB_VERSION"), &version))
+	is.Equal("70", version)
+
+	is.Equal(3, destination.GetNextIdentifier("objects"))
+
+	is.Error(MigrateBoltDBToSQLite(storePath, encryptionKey), "an existing SQLite database must not be overwritten")
+}
@@ -0,0 +1,507 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/portainer/portainer/api/database/codec"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+	"github.com/sirupsen/logrus"
+
+	// registers the pure Go "sqlite" database/sql driver
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DatabaseFileName          = "portainer.sqlite"
+	EncryptedDatabaseFileName = "portainer.esqlite"
+)
+
+var (
+	ErrHaveEncryptedAndUnencrypted = errors.New("Portainer has detected both an encrypted and un-encrypted database and cannot start.  Only one database should exist")
+	ErrHaveEncryptedWithNoKey      = errors.New("The portainer database is encrypted, but no secret was loaded")
+)
+
+// buckets mirrors the BoltDB layout: every bucket has its own sequence and
+// stores its objects as opaque (possibly encrypted) JSON blobs.
+// Keys are compared as blobs, which keeps the BoltDB big endian ordering.
+const schema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	name     TEXT    NOT NULL PRIMARY KEY,
+	sequence INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS objects (
+	bucket TEXT NOT NULL,
+	key    BLOB NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+`
+
+// busyTimeout lets concurrent writers (e.g. other Portainer replicas) wait for the lock instead of failing
+const busyTimeout = 5000
+
+type DbConnection struct {
+	Path          string
+	EncryptionKey []byte
+	isEncrypted   bool
+
+	*sql.DB
+}
+
+// GetDatabaseFileName get the database filename
+func (connection *DbConnection) GetDatabaseFileName() string {
+	if connection.IsEncryptedStore() {
+		return EncryptedDatabaseFileName
+	}
+
+	return DatabaseFileName
+}
+
+// GetDatabaseFilePath get the path + filename for the database file
+func (connection *DbConnection) GetDatabaseFilePath() string {
+	return path.Join(connection.Path, connection.GetDatabaseFileName())
+}
+
+// GetStorePath get the filename and path for the database file
+func (connection *DbConnection) GetStorePath() string {
+	return connection.Path
+}
+
+func (connection *DbConnection) SetEncrypted(flag bool) {
+	connection.isEncrypted = flag
+}
+
+// Return true if the database is encrypted
+func (connection *DbConnection) IsEncryptedStore() bool {
+	return connection.getEncryptionKey() != nil
+}
+
+// NeedsEncryptionMigration returns true if database encryption is enabled and
+// we have an un-encrypted DB that requires migration to an encrypted DB.
+// It follows the same rules as the BoltDB store, using portainer.sqlite and portainer.esqlite.
+func (connection *DbConnection) NeedsEncryptionMigration() (bool, error) {
+	if connection.EncryptionKey != nil {
+		connection.SetEncrypted(true)
+	}
+
+	_, err := os.Stat(path.Join(connection.Path, DatabaseFileName))
+	haveDbFile := err == nil
+
+	_, err = os.Stat(path.Join(connection.Path, EncryptedDatabaseFileName))
+	haveEdbFile := err == nil
+
+	if haveDbFile && haveEdbFile {
+		return false, ErrHaveEncryptedAndUnencrypted
+	}
+
+	if haveDbFile && connection.EncryptionKey != nil {
+		return true, nil
+	}
+
+	if haveEdbFile && connection.EncryptionKey == nil {
+		return false, ErrHaveEncryptedWithNoKey
+	}
+
+	return false, nil
+}
+
+// Open opens and initializes the SQLite database.
+func (connection *DbConnection) Open() error {
+	logrus.Infof("Loading PortainerDB: %s", connection.GetDatabaseFileName())
+
+	db, err := sql.Open("sqlite", connection.dataSourceName(false))
+	if err != nil {
+		return err
+	}
+
+	// a single connection serializes the writes of this instance,
+	// other processes are handled by the busy timeout
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	connection.DB = db
+	return nil
+}
+
+func (connection *DbConnection) dataSourceName(readOnly bool) string {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_txlock=immediate", connection.GetDatabaseFilePath(), busyTimeout)
+	if readOnly {
+		dsn += "&mode=ro"
+	}
+
+	return dsn
+}
+
+// Close closes the SQLite database.
+// Safe to being called multiple times.
+func (connection *DbConnection) Close() error {
+	if connection.DB == nil {
+		return nil
+	}
+
+	err := connection.DB.Close()
+	connection.DB = nil
+	return err
+}
+
+// BackupTo backs up db to a provided writer.
+// The database is first copied to a temporary file with VACUUM INTO, which doesn't block other readers.
+func (connection *DbConnection) BackupTo(w io.Writer) error {
+	dir, err := ioutil.TempDir("", "portainer-sqlite-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	backupPath := path.Join(dir, DatabaseFileName)
+
+	_, err = connection.Exec("VACUUM INTO ?", backupPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(backupPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func (connection *DbConnection) ExportRaw(filename string) error {
+	databasePath := connection.GetDatabaseFilePath()
+	if _, err := os.Stat(databasePath); err != nil {
+		return fmt.Errorf("stat on %s failed: %s", databasePath, err)
+	}
+
+	b, err := connection.ExportJson(true)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0600)
+}
+
+// ConvertToKey returns an 8-byte big endian representation of v.
+// Using the same encoding as BoltDB keeps the objects ordered by identifier.
+func (connection *DbConnection) ConvertToKey(v int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+// SetServiceName creates the bucket used by a service if it doesn't exist yet.
+func (connection *DbConnection) SetServiceName(bucketName string) error {
+	_, err := connection.Exec("INSERT OR IGNORE INTO buckets (name) VALUES (?)", bucketName)
+	return err
+}
+
+// GetObject is a generic function used to retrieve an unmarshalled object from a database.
+func (connection *DbConnection) GetObject(bucketName string, key []byte, object interface{}) error {
+	var data []byte
+
+	err := connection.QueryRow("SELECT value FROM objects WHERE bucket = ? AND key = ?", bucketName, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return dserrors.ErrObjectNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return connection.UnmarshalObjectWithJsoniter(data, object)
+}
+
+func (connection *DbConnection) getEncryptionKey() []byte {
+	if !connection.isEncrypted {
+		return nil
+	}
+
+	return connection.EncryptionKey
+}
+
+// UpdateObject is a generic function used to update an object inside a database.
+func (connection *DbConnection) UpdateObject(bucketName string, key []byte, object interface{}) error {
+	data, err := connection.MarshalObject(object)
+	if err != nil {
+		return err
+	}
+
+	return putObject(connection.DB, bucketName, key, data)
+}
+
+// DeleteObject is a generic function used to delete an object inside a database.
+func (connection *DbConnection) DeleteObject(bucketName string, key []byte) error {
+	_, err := connection.Exec("DELETE FROM objects WHERE bucket = ? AND key = ?", bucketName, key)
+	return err
+}
+
+// DeleteAllObjects delete all objects where matching() returns (id, ok).
+func (connection *DbConnection) DeleteAllObjects(bucketName string, matching func(o interface{}) (id int, ok bool)) error {
+	return connection.update(func(tx *sql.Tx) error {
+		values, err := readValues(tx, bucketName)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range values {
+			var obj interface{}
+			err := connection.UnmarshalObject(v, &obj)
+			if err != nil {
+				return err
+			}
+
+			if id, ok := matching(obj); ok {
+				_, err := tx.Exec("DELETE FROM objects WHERE bucket = ? AND key = ?", bucketName, connection.ConvertToKey(id))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetNextIdentifier is a generic function that returns the specified bucket identifier incremented by 1.
+func (connection *DbConnection) GetNextIdentifier(bucketName string) int {
+	var identifier int
+
+	connection.update(func(tx *sql.Tx) error {
+		id, err := nextSequence(tx, bucketName)
+		if err != nil {
+			return err
+		}
+		identifier = int(id)
+		return nil
+	})
+
+	return identifier
+}
+
+// CreateObject creates a new object in the bucket, using the next bucket sequence id
+func (connection *DbConnection) CreateObject(bucketName string, fn func(uint64) (int, interface{})) error {
+	return connection.update(func(tx *sql.Tx) error {
+		seqId, err := nextSequence(tx, bucketName)
+		if err != nil {
+			return err
+		}
+
+		id, obj := fn(seqId)
+
+		data, err := connection.MarshalObject(obj)
+		if err != nil {
+			return err
+		}
+
+		return putObject(tx, bucketName, connection.ConvertToKey(id), data)
+	})
+}
+
+// CreateObjectWithId creates a new object in the bucket, using the specified id
+func (connection *DbConnection) CreateObjectWithId(bucketName string, id int, obj interface{}) error {
+	return connection.UpdateObject(bucketName, connection.ConvertToKey(id), obj)
+}
+
+// CreateObjectWithStringId creates a new object in the bucket, using the specified id
+func (connection *DbConnection) CreateObjectWithStringId(bucketName string, id []byte, obj interface{}) error {
+	return connection.UpdateObject(bucketName, id, obj)
+}
+
+// CreateObjectWithSetSequence creates a new object in the bucket, using the specified id, and sets the bucket sequence
+func (connection *DbConnection) CreateObjectWithSetSequence(bucketName string, id int, obj interface{}) error {
+	data, err := connection.MarshalObject(obj)
+	if err != nil {
+		return err
+	}
+
+	return connection.update(func(tx *sql.Tx) error {
+		err := setSequence(tx, bucketName, uint64(id))
+		if err != nil {
+			return err
+		}
+
+		return putObject(tx, bucketName, connection.ConvertToKey(id), data)
+	})
+}
+
+func (connection *DbConnection) GetAll(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return connection.getAll(bucketName, obj, append, connection.UnmarshalObject)
+}
+
+func (connection *DbConnection) GetAllWithJsoniter(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error)) error {
+	return connection.getAll(bucketName, obj, append, connection.UnmarshalObjectWithJsoniter)
+}
+
+func (connection *DbConnection) getAll(bucketName string, obj interface{}, append func(o interface{}) (interface{}, error), unmarshal func(data []byte, object interface{}) error) error {
+	// the rows are read first so that append can safely use the connection
+	values, err := readValues(connection.DB, bucketName)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		err := unmarshal(v, obj)
+		if err != nil {
+			return err
+		}
+
+		obj, err = append(obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (connection *DbConnection) BackupMetadata() (map[string]interface{}, error) {
+	buckets := map[string]interface{}{}
+
+	rows, err := connection.Query("SELECT name, sequence FROM buckets")
+	if err != nil {
+		return buckets, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var sequence int
+		err := rows.Scan(&name, &sequence)
+		if err != nil {
+			return buckets, err
+		}
+		buckets[name] = sequence
+	}
+
+	return buckets, rows.Err()
+}
+
+func (connection *DbConnection) RestoreMetadata(s map[string]interface{}) error {
+	var err error
+
+	for bucketName, v := range s {
+		id, ok := v.(float64) // JSON ints are unmarshalled to interface as float64. See: https://pkg.go.dev/encoding/json#Decoder.Decode
+		if !ok {
+			logrus.Errorf("Failed to restore metadata to bucket %s, skipped", bucketName)
+			continue
+		}
+
+		err = connection.update(func(tx *sql.Tx) error {
+			return setSequence(tx, bucketName, uint64(id))
+		})
+	}
+
+	return err
+}
+
+// PutRawObjects stores the values provided by the iterate function as they are, without any encoding or encryption.
+// It is used to copy the content of another store in a single transaction.
+func (connection *DbConnection) PutRawObjects(iterate func(put func(bucketName string, key, value []byte) error) error) error {
+	return connection.update(func(tx *sql.Tx) error {
+		return iterate(func(bucketName string, key, value []byte) error {
+			_, err := tx.Exec("INSERT OR IGNORE INTO buckets (name) VALUES (?)", bucketName)
+			if err != nil {
+				return err
+			}
+
+			return putObject(tx, bucketName, key, value)
+		})
+	})
+}
+
+// MarshalObject encodes an object to binary format
+func (connection *DbConnection) MarshalObject(object interface{}) ([]byte, error) {
+	return codec.MarshalObject(object, connection.getEncryptionKey())
+}
+
+// UnmarshalObject decodes an object from binary data
+func (connection *DbConnection) UnmarshalObject(data []byte, object interface{}) error {
+	return codec.UnmarshalObject(data, object, connection.getEncryptionKey())
+}
+
+// UnmarshalObjectWithJsoniter decodes an object from binary data using the jsoniter library
+func (connection *DbConnection) UnmarshalObjectWithJsoniter(data []byte, object interface{}) error {
+	return codec.UnmarshalObjectWithJsoniter(data, object, connection.getEncryptionKey())
+}
+
+// update runs fn inside a write transaction
+func (connection *DbConnection) update(fn func(tx *sql.Tx) error) error {
+	tx, err := connection.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func putObject(db execer, bucketName string, key, data []byte) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO objects (bucket, key, value) VALUES (?, ?, ?)", bucketName, key, data)
+	return err
+}
+
+func readValues(db querier, bucketName string) ([][]byte, error) {
+	rows, err := db.Query("SELECT value FROM objects WHERE bucket = ? ORDER BY key", bucketName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([][]byte, 0)
+	for rows.Next() {
+		var value []byte
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+func nextSequence(tx *sql.Tx, bucketName string) (uint64, error) {
+	_, err := tx.Exec("UPDATE buckets SET sequence = sequence + 1 WHERE name = ?", bucketName)
+	if err != nil {
+		return 0, err
+	}
+
+	var sequence uint64
+	err = tx.QueryRow("SELECT sequence FROM buckets WHERE name = ?", bucketName).Scan(&sequence)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("bucket %s does not exist", bucketName)
+	}
+
+	return sequence, err
+}
+
+func setSequence(tx *sql.Tx, bucketName string, sequence uint64) error {
+	_, err := tx.Exec("INSERT INTO buckets (name, sequence) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET sequence = excluded.sequence", bucketName, sequence)
+	return err
+}
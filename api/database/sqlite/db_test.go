@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path"
+	"testing"
+
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testObject struct {
+	ID   int    `json:"Id"`
+	Name string `json:"Name"`
+}
+
+func newTestConnection(t *testing.T, encrypted bool) *DbConnection {
+	connection := &DbConnection{Path: t.TempDir()}
+	if encrypted {
+		key := sha256.Sum256([]byte("my secret key"))
+		connection.EncryptionKey = key[:]
+		connection.SetEncrypted(true)
+	}
+
+	err := connection.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+
+	err = connection.SetServiceName("objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return connection
+}
+
+func Test_ObjectLifecycle(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		is := assert.New(t)
+		connection := newTestConnection(t, encrypted)
+
+		for _, name := range []string{"first", "second", "third"} {
+			err := connection.CreateObject("objects", func(id uint64) (int, interface{}) {
+				return int(id), &testObject{ID: int(id), Name: name}
+			})
+			is.NoError(err)
+		}
+
+		var object testObject
+		err := connection.GetObject("objects", connection.ConvertToKey(2), &object)
+		is.NoError(err)
+		is.Equal("second", object.Name)
+
+		object.Name = "updated"
+		is.NoError(connection.UpdateObject("objects", connection.ConvertToKey(2), &object))
+
+		is.NoError(connection.DeleteObject("objects", connection.ConvertToKey(1)))
+		err = connection.GetObject("objects", connection.ConvertToKey(1), &object)
+		is.Equal(dserrors.ErrObjectNotFound, err)
+
+		names := []string{}
+		err = connection.GetAll("objects", &testObject{}, func(o interface{}) (interface{}, error) {
+			names = append(names, o.(*testObject).Name)
+			return &testObject{}, nil
+		})
+		is.NoError(err)
+		is.Equal([]string{"updated", "third"}, names)
+
+		is.Equal(4, connection.GetNextIdentifier("objects"))
+	}
+}
+
+func Test_DeleteAllObjects(t *testing.T) {
+	is := assert.New(t)
+	connection := newTestConnection(t, true)
+
+	for i := 1; i <= 4; i++ {
+		is.NoError(connection.CreateObjectWithId("objects", i, &testObject{ID: i}))
+	}
+
+	err := connection.DeleteAllObjects("objects", func(o interface{}) (int, bool) {
+		id := int(o.(map[string]interface{})["Id"].(float64))
+		return id, id%2 == 0
+	})
+	is.NoError(err)
+
+	ids := []int{}
+	err = connection.GetAllWithJsoniter("objects", &testObject{}, func(o interface{}) (interface{}, error) {
+		ids = append(ids, o.(*testObject).ID)
+		return &testObject{}, nil
+	})
+	is.NoError(err)
+	is.Equal([]int{1, 3}, ids)
+}
+
+func Test_Metadata(t *testing.T) {
+	is := assert.New(t)
+	connection := newTestConnection(t, false)
+
+	is.NoError(connection.CreateObjectWithSetSequence("objects", 10, &testObject{ID: 10}))
+
+	metadata, err := connection.BackupMetadata()
+	is.NoError(err)
+	is.Equal(10, metadata["objects"])
+
+	is.NoError(connection.RestoreMetadata(map[string]interface{}{"objects": float64(20)}))
+	is.Equal(21, connection.GetNextIdentifier("objects"))
+}
+
+func Test_BackupTo(t *testing.T) {
+	is := assert.New(t)
+	connection := newTestConnection(t, false)
+
+	is.NoError(connection.CreateObjectWithId("objects", 1, &testObject{ID: 1, Name: "backup"}))
+
+	var buf bytes.Buffer
+	is.NoError(connection.BackupTo(&buf))
+
+	restored := &DbConnection{Path: t.TempDir()}
+	is.NoError(os.WriteFile(path.Join(restored.Path, DatabaseFileName), buf.Bytes(), 0600))
+	is.NoError(restored.Open())
+	defer restored.Close()
+
+	var object testObject
+	is.NoError(restored.GetObject("objects", restored.ConvertToKey(1), &object))
+	is.Equal("backup", object.Name)
+}
+
+func Test_NeedsEncryptionMigration(t *testing.T) {
+	is := assert.New(t)
+
+	cases := []struct {
+		name         string
+		dbnames      []string
+		key          bool
+		expectError  error
+		expectResult bool
+	}{
+		{name: "portainer.esqlite + key", dbnames: []string{EncryptedDatabaseFileName}, key: true},
+		{name: "portainer.sqlite + key", dbnames: []string{DatabaseFileName}, key: true, expectResult: true},
+		{name: "portainer.sqlite + no key", dbnames: []string{DatabaseFileName}},
+		{name: "new store + key", key: true},
+		{name: "portainer.esqlite + no key", dbnames: []string{EncryptedDatabaseFileName}, expectError: ErrHaveEncryptedWithNoKey},
+		{name: "both databases", dbnames: []string{DatabaseFileName, EncryptedDatabaseFileName}, key: true, expectError: ErrHaveEncryptedAndUnencrypted},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			connection := &DbConnection{Path: t.TempDir()}
+			for _, dbname := range tc.dbnames {
+				is.NoError(os.WriteFile(path.Join(connection.Path, dbname), []byte{}, 0600))
+			}
+
+			if tc.key {
+				connection.EncryptionKey = []byte("secret")
+			}
+
+			result, err := connection.NeedsEncryptionMigration()
+			is.Equal(tc.expectError, err)
+			is.Equal(tc.expectResult, result)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExportJson creates a JSON representation of the database, using the same layout as the BoltDB export.
+// You can include the database's metadata or ignore it.
+// The database file is opened read-only, so the store doesn't have to be closed.
+func (c *DbConnection) ExportJson(metadata bool) ([]byte, error) {
+	logrus.WithField("databasePath", c.GetDatabaseFilePath()).Infof("exportJson")
+
+	connection := &DbConnection{
+		Path:          c.Path,
+		EncryptionKey: c.EncryptionKey,
+		isEncrypted:   c.isEncrypted,
+	}
+
+	db, err := sql.Open("sqlite", connection.dataSourceName(true))
+	if err != nil {
+		return []byte("{}"), err
+	}
+	defer db.Close()
+	connection.DB = db
+
+	backup := make(map[string]interface{})
+	if metadata {
+		meta, err := connection.BackupMetadata()
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed exporting metadata: %v", err)
+		}
+		backup["__metadata"] = meta
+	}
+
+	rows, err := db.Query("SELECT bucket, key, value FROM objects ORDER BY bucket, key")
+	if err != nil {
+		return []byte("{}"), err
+	}
+	defer rows.Close()
+
+	lists := make(map[string][]interface{})
+	version := make(map[string]string)
+
+	for rows.Next() {
+		var bucketName string
+		var k, v []byte
+		err := rows.Scan(&bucketName, &k, &v)
+		if err != nil {
+			return []byte("{}"), err
+		}
+
+		if bucketName == "version" {
+			version[string(k)] = string(v)
+			continue
+		}
+
+		var obj interface{}
+		err = connection.UnmarshalObject(v, &obj)
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to unmarshal (bucket %s): %v", bucketName, string(v))
+			obj = v
+		}
+		lists[bucketName] = append(lists[bucketName], obj)
+	}
+	if err := rows.Err(); err != nil {
+		return []byte("{}"), err
+	}
+
+	if len(version) > 0 {
+		backup["version"] = version
+	}
+
+	for bucketName, list := range lists {
+		if bucketName == "ssl" ||
+			bucketName == "settings" ||
+			bucketName == "tunnel_server" {
+			backup[bucketName] = list[0]
+			continue
+		}
+		backup[bucketName] = list
+	}
+
+	return json.MarshalIndent(backup, "", "  ")
+}
@@ -0,0 +1,91 @@
+package auditlog
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "audit_logs"
+)
+
+// Service represents a service for managing audit log data.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// AuditLogs returns an array containing all the audit log entries.
+func (service *Service) AuditLogs() ([]portainer.AuditLog, error) {
+	var logs = make([]portainer.AuditLog, 0)
+
+	err := service.connection.GetAllWithJsoniter(
+		BucketName,
+		&portainer.AuditLog{},
+		func(obj interface{}) (interface{}, error) {
+			entry, ok := obj.(*portainer.AuditLog)
+			if !ok {
+				logrus.WithField("obj", obj).Errorf("Failed to convert to AuditLog object")
+				return nil, fmt.Errorf("failed to convert to AuditLog object: %s", obj)
+			}
+			logs = append(logs, *entry)
+			return &portainer.AuditLog{}, nil
+		})
+
+	return logs, err
+}
+
+// Create assigns an ID to a new audit log entry and saves it.
+func (service *Service) Create(entry *portainer.AuditLog) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			entry.ID = portainer.AuditLogID(id)
+			return int(entry.ID), entry
+		},
+	)
+}
+
+// DeleteAuditLogsBefore deletes all the audit log entries older than the given unix timestamp.
+func (service *Service) DeleteAuditLogsBefore(timestamp int64) error {
+	return service.connection.DeleteAllObjects(
+		BucketName,
+		func(obj interface{}) (id int, ok bool) {
+			entry, ok := obj.(map[string]interface{})
+			if !ok {
+				logrus.WithField("obj", obj).Errorf("Failed to convert to AuditLog object")
+				return -1, false
+			}
+
+			entryTimestamp, ok := entry["Timestamp"].(float64)
+			if !ok || int64(entryTimestamp) >= timestamp {
+				return -1, false
+			}
+
+			entryID, ok := entry["Id"].(float64)
+			if !ok {
+				return -1, false
+			}
+
+			return int(entryID), true
+		},
+	)
+}
@@ -0,0 +1,90 @@
+package gitcredential
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "git_credentials"
+)
+
+// Service represents a service for managing Git credential data.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// GitCredentials returns an array containing all the Git credentials.
+func (service *Service) GitCredentials() ([]portainer.GitCredential, error) {
+	var credentials = make([]portainer.GitCredential, 0)
+
+	err := service.connection.GetAll(
+		BucketName,
+		&portainer.GitCredential{},
+		func(obj interface{}) (interface{}, error) {
+			credential, ok := obj.(*portainer.GitCredential)
+			if !ok {
+				logrus.WithField("obj", obj).Errorf("Failed to convert to GitCredential object")
+				return nil, fmt.Errorf("failed to convert to GitCredential object: %s", obj)
+			}
+			credentials = append(credentials, *credential)
+			return &portainer.GitCredential{}, nil
+		})
+
+	return credentials, err
+}
+
+// GitCredential returns a Git credential by ID.
+func (service *Service) GitCredential(ID portainer.GitCredentialID) (*portainer.GitCredential, error) {
+	var credential portainer.GitCredential
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// Create assigns an ID to a new Git credential and saves it.
+func (service *Service) Create(credential *portainer.GitCredential) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			credential.ID = portainer.GitCredentialID(id)
+			return int(credential.ID), credential
+		},
+	)
+}
+
+// UpdateGitCredential updates a Git credential.
+func (service *Service) UpdateGitCredential(ID portainer.GitCredentialID, credential *portainer.GitCredential) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.UpdateObject(BucketName, identifier, credential)
+}
+
+// DeleteGitCredential deletes a Git credential.
+func (service *Service) DeleteGitCredential(ID portainer.GitCredentialID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
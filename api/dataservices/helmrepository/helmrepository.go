@@ -0,0 +1,90 @@
+package helmrepository
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "helm_repositories"
+)
+
+// Service represents a service for managing the Helm repositories shared by the administrators.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// HelmRepositories returns an array containing all the Helm repositories.
+func (service *Service) HelmRepositories() ([]portainer.HelmRepository, error) {
+	var repositories = make([]portainer.HelmRepository, 0)
+
+	err := service.connection.GetAll(
+		BucketName,
+		&portainer.HelmRepository{},
+		func(obj interface{}) (interface{}, error) {
+			repository, ok := obj.(*portainer.HelmRepository)
+			if !ok {
+				logrus.WithField("obj", obj).Errorf("Failed to convert to HelmRepository object")
+				return nil, fmt.Errorf("failed to convert to HelmRepository object: %s", obj)
+			}
+			repositories = append(repositories, *repository)
+			return &portainer.HelmRepository{}, nil
+		})
+
+	return repositories, err
+}
+
+// HelmRepository returns a Helm repository by ID.
+func (service *Service) HelmRepository(ID portainer.HelmRepositoryID) (*portainer.HelmRepository, error) {
+	var repository portainer.HelmRepository
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &repository)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository, nil
+}
+
+// Create assigns an ID to a new Helm repository and saves it.
+func (service *Service) Create(repository *portainer.HelmRepository) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			repository.ID = portainer.HelmRepositoryID(id)
+			return int(repository.ID), repository
+		},
+	)
+}
+
+// UpdateHelmRepository updates a Helm repository.
+func (service *Service) UpdateHelmRepository(ID portainer.HelmRepositoryID, repository *portainer.HelmRepository) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.UpdateObject(BucketName, identifier, repository)
+}
+
+// DeleteHelmRepository deletes a Helm repository.
+func (service *Service) DeleteHelmRepository(ID portainer.HelmRepositoryID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
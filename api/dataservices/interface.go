@@ -24,6 +24,7 @@ type (
 		Export(filename string) (err error)
 		IsErrObjectNotFound(err error) bool
 
+		AuditLog() AuditLogService
 		CustomTemplate() CustomTemplateService
 		EdgeGroup() EdgeGroupService
 		EdgeJob() EdgeJobService
@@ -32,7 +33,11 @@ type (
 		EndpointGroup() EndpointGroupService
 		EndpointRelation() EndpointRelationService
 		FDOProfile() FDOProfileService
+		GitCredential() GitCredentialService
+		HelmRepository() HelmRepositoryService
 		HelmUserRepository() HelmUserRepositoryService
+		NotificationChannel() NotificationChannelService
+		NotificationDelivery() NotificationDeliveryService
 		Registry() RegistryService
 		ResourceControl() ResourceControlService
 		Role() RoleService
@@ -40,6 +45,7 @@ type (
 		Settings() SettingsService
 		SSLSettings() SSLSettingsService
 		Stack() StackService
+		StackVersion() StackVersionService
 		Tag() TagService
 		TeamMembership() TeamMembershipService
 		Team() TeamService
@@ -49,6 +55,14 @@ type (
 		Webhook() WebhookService
 	}
 
+	// AuditLogService represents a service to manage audit logs
+	AuditLogService interface {
+		AuditLogs() ([]portainer.AuditLog, error)
+		Create(entry *portainer.AuditLog) error
+		DeleteAuditLogsBefore(timestamp int64) error
+		BucketName() string
+	}
+
 	// CustomTemplateService represents a service to manage custom templates
 	CustomTemplateService interface {
 		GetNextIdentifier() int
@@ -134,6 +148,26 @@ type (
 		BucketName() string
 	}
 
+	// GitCredentialService represents a service to manage Git credentials
+	GitCredentialService interface {
+		GitCredentials() ([]portainer.GitCredential, error)
+		GitCredential(ID portainer.GitCredentialID) (*portainer.GitCredential, error)
+		Create(credential *portainer.GitCredential) error
+		UpdateGitCredential(ID portainer.GitCredentialID, credential *portainer.GitCredential) error
+		DeleteGitCredential(ID portainer.GitCredentialID) error
+		BucketName() string
+	}
+
+	// HelmRepositoryService represents a service to manage the Helm repositories shared by the administrators
+	HelmRepositoryService interface {
+		HelmRepositories() ([]portainer.HelmRepository, error)
+		HelmRepository(ID portainer.HelmRepositoryID) (*portainer.HelmRepository, error)
+		Create(repository *portainer.HelmRepository) error
+		UpdateHelmRepository(ID portainer.HelmRepositoryID, repository *portainer.HelmRepository) error
+		DeleteHelmRepository(ID portainer.HelmRepositoryID) error
+		BucketName() string
+	}
+
 	// HelmUserRepositoryService represents a service to manage HelmUserRepositories
 	HelmUserRepositoryService interface {
 		HelmUserRepositories() ([]portainer.HelmUserRepository, error)
@@ -151,6 +185,30 @@ type (
 		GenerateTokenForKubeconfig(data *portainer.TokenData) (string, error)
 		ParseAndVerifyToken(token string) (*portainer.TokenData, error)
 		SetUserSessionDuration(userSessionDuration time.Duration)
+		CreateSession(userID portainer.UserID, ipAddress, userAgent string) (*portainer.Session, error)
+		UserSessions(userID portainer.UserID) []portainer.Session
+		RevokeSession(userID portainer.UserID, sessionID string) bool
+		RevokeUserSessions(userID portainer.UserID) error
+	}
+
+	// NotificationChannelService represents a service to manage notification channels
+	NotificationChannelService interface {
+		NotificationChannels() ([]portainer.NotificationChannel, error)
+		NotificationChannel(ID portainer.NotificationChannelID) (*portainer.NotificationChannel, error)
+		Create(channel *portainer.NotificationChannel) error
+		UpdateNotificationChannel(ID portainer.NotificationChannelID, channel *portainer.NotificationChannel) error
+		DeleteNotificationChannel(ID portainer.NotificationChannelID) error
+		BucketName() string
+	}
+
+	// NotificationDeliveryService represents a service to manage the delivery log of the notifications
+	NotificationDeliveryService interface {
+		NotificationDeliveries() ([]portainer.NotificationDelivery, error)
+		NotificationDelivery(ID portainer.NotificationDeliveryID) (*portainer.NotificationDelivery, error)
+		Create(delivery *portainer.NotificationDelivery) error
+		UpdateNotificationDelivery(ID portainer.NotificationDeliveryID, delivery *portainer.NotificationDelivery) error
+		DeleteNotificationDeliveriesBefore(timestamp int64) error
+		BucketName() string
 	}
 
 	// RegistryService represents a service for managing registry data
@@ -180,6 +238,7 @@ type (
 		Roles() ([]portainer.Role, error)
 		Create(role *portainer.Role) error
 		UpdateRole(ID portainer.RoleID, role *portainer.Role) error
+		DeleteRole(ID portainer.RoleID) error
 		BucketName() string
 	}
 
@@ -189,6 +248,7 @@ type (
 		GetAPIKey(keyID portainer.APIKeyID) (*portainer.APIKey, error)
 		UpdateAPIKey(key *portainer.APIKey) error
 		DeleteAPIKey(ID portainer.APIKeyID) error
+		GetAPIKeys() ([]portainer.APIKey, error)
 		GetAPIKeysByUserID(userID portainer.UserID) ([]portainer.APIKey, error)
 		GetAPIKeyByDigest(digest []byte) (*portainer.APIKey, error)
 	}
@@ -223,6 +283,15 @@ type (
 		BucketName() string
 	}
 
+	// StackVersionService represents a service for managing stack version data
+	StackVersionService interface {
+		StackVersionsByStackID(stackID portainer.StackID) ([]portainer.StackVersion, error)
+		StackVersion(ID portainer.StackVersionID) (*portainer.StackVersion, error)
+		Create(version *portainer.StackVersion) error
+		DeleteStackVersion(ID portainer.StackVersionID) error
+		BucketName() string
+	}
+
 	// TagService represents a service for managing tag data
 	TagService interface {
 		Tags() ([]portainer.Tag, error)
@@ -0,0 +1,110 @@
+package notificationdelivery
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "notification_deliveries"
+)
+
+// Service represents a service for managing notification delivery data.
+type Service struct {
+	connection portainer.Connection
+}
+
+func (service *Service) BucketName() string {
+	return BucketName
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// NotificationDeliveries returns an array containing all the notification deliveries.
+func (service *Service) NotificationDeliveries() ([]portainer.NotificationDelivery, error) {
+	var deliveries = make([]portainer.NotificationDelivery, 0)
+
+	err := service.connection.GetAllWithJsoniter(
+		BucketName,
+		&portainer.NotificationDelivery{},
+		func(obj interface{}) (interface{}, error) {
+			delivery, ok := obj.(*portainer.NotificationDelivery)
+			if !ok {
+				logrus.WithField("obj", obj).Errorf("Failed to convert to NotificationDelivery object")
+				return nil, fmt.Errorf("failed to convert to NotificationDelivery object: %s", obj)
+			}
+			deliveries = append(deliveries, *delivery)
+			return &portainer.NotificationDelivery{}, nil
+		})
+
+	return deliveries, err
+}
+
+// NotificationDelivery returns a notification delivery by ID.
+func (service *Service) NotificationDelivery(ID portainer.NotificationDeliveryID) (*portainer.NotificationDelivery, error) {
+	var delivery portainer.NotificationDelivery
+	identifier := service.connection.ConvertToKey(int(ID))
+
+	err := service.connection.GetObject(BucketName, identifier, &delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// Create assigns an ID to a new notification delivery and saves it.
+func (service *Service) Create(delivery *portainer.NotificationDelivery) error {
+	return service.connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			delivery.ID = portainer.NotificationDeliveryID(id)
+			return int(delivery.ID), delivery
+		},
+	)
+}
+
+// UpdateNotificationDelivery updates a notification delivery.
+func (service *Service) UpdateNotificationDelivery(ID portainer.NotificationDeliveryID, delivery *portainer.NotificationDelivery) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.UpdateObject(BucketName, identifier, delivery)
+}
+
+// DeleteNotificationDeliveriesBefore deletes all the notification deliveries created before the given unix timestamp.
+func (service *Service) DeleteNotificationDeliveriesBefore(timestamp int64) error {
+	return service.connection.DeleteAllObjects(
+		BucketName,
+		func(obj interface{}) (id int, ok bool) {
+			delivery, ok := obj.(map[string]interface{})
+			if !ok {
+				logrus.WithField("obj", obj).Errorf("Failed to convert to NotificationDelivery object")
+				return -1, false
+			}
+
+			createdAt, ok := delivery["CreatedAt"].(float64)
+			if !ok || int64(createdAt) >= timestamp {
+				return -1, false
+			}
+
+			deliveryID, ok := delivery["Id"].(float64)
+			if !ok {
+				return -1, false
+			}
+
+			return int(deliveryID), true
+		},
+	)
+}
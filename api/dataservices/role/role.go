@@ -82,3 +82,9 @@ func (service *Service) UpdateRole(ID portainer.RoleID, role *portainer.Role) er
 	identifier := service.connection.ConvertToKey(int(ID))
 	return service.connection.UpdateObject(BucketName, identifier, role)
 }
+
+// DeleteRole deletes a role.
+func (service *Service) DeleteRole(ID portainer.RoleID) error {
+	identifier := service.connection.ConvertToKey(int(ID))
+	return service.connection.DeleteObject(BucketName, identifier)
+}
@@ -87,13 +87,13 @@ func (service *Service) StacksByName(name string) ([]portainer.Stack, error) {
 		BucketName,
 		&portainer.Stack{},
 		func(obj interface{}) (interface{}, error) {
-			stack, ok := obj.(portainer.Stack)
+			stack, ok := obj.(*portainer.Stack)
 			if !ok {
 				logrus.WithField("obj", obj).Errorf("Failed to convert to Stack object")
 				return nil, fmt.Errorf("Failed to convert to Stack object: %s", obj)
 			}
 			if stack.Name == name {
-				stacks = append(stacks, stack)
+				stacks = append(stacks, *stack)
 			}
 			return &portainer.Stack{}, nil
 		})
@@ -71,6 +71,7 @@ func (store *Store) checkOrCreateDefaultSettings() error {
 			UserSessionTimeout:       portainer.DefaultUserSessionTimeout,
 			KubeconfigExpiry:         portainer.DefaultKubeconfigExpiry,
 			KubectlShellImage:        portainer.DefaultKubectlShellImage,
+			AuditLogRetention:        portainer.DefaultAuditLogRetention,
 		}
 
 		return store.SettingsService.UpdateSettings(defaultSettings)
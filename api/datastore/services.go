@@ -8,6 +8,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/dataservices/apikeyrepository"
+	"github.com/portainer/portainer/api/dataservices/auditlog"
 	"github.com/portainer/portainer/api/dataservices/customtemplate"
 	"github.com/portainer/portainer/api/dataservices/dockerhub"
 	"github.com/portainer/portainer/api/dataservices/edgegroup"
@@ -18,7 +19,11 @@ import (
 	"github.com/portainer/portainer/api/dataservices/endpointrelation"
 	"github.com/portainer/portainer/api/dataservices/extension"
 	"github.com/portainer/portainer/api/dataservices/fdoprofile"
+	"github.com/portainer/portainer/api/dataservices/gitcredential"
+	"github.com/portainer/portainer/api/dataservices/helmrepository"
 	"github.com/portainer/portainer/api/dataservices/helmuserrepository"
+	"github.com/portainer/portainer/api/dataservices/notificationchannel"
+	"github.com/portainer/portainer/api/dataservices/notificationdelivery"
 	"github.com/portainer/portainer/api/dataservices/registry"
 	"github.com/portainer/portainer/api/dataservices/resourcecontrol"
 	"github.com/portainer/portainer/api/dataservices/role"
@@ -26,6 +31,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices/settings"
 	"github.com/portainer/portainer/api/dataservices/ssl"
 	"github.com/portainer/portainer/api/dataservices/stack"
+	"github.com/portainer/portainer/api/dataservices/stackversion"
 	"github.com/portainer/portainer/api/dataservices/tag"
 	"github.com/portainer/portainer/api/dataservices/team"
 	"github.com/portainer/portainer/api/dataservices/teammembership"
@@ -41,33 +47,39 @@ import (
 type Store struct {
 	connection portainer.Connection
 
-	fileService               portainer.FileService
-	CustomTemplateService     *customtemplate.Service
-	DockerHubService          *dockerhub.Service
-	EdgeGroupService          *edgegroup.Service
-	EdgeJobService            *edgejob.Service
-	EdgeStackService          *edgestack.Service
-	EndpointGroupService      *endpointgroup.Service
-	EndpointService           *endpoint.Service
-	EndpointRelationService   *endpointrelation.Service
-	ExtensionService          *extension.Service
-	FDOProfilesService        *fdoprofile.Service
-	HelmUserRepositoryService *helmuserrepository.Service
-	RegistryService           *registry.Service
-	ResourceControlService    *resourcecontrol.Service
-	RoleService               *role.Service
-	APIKeyRepositoryService   *apikeyrepository.Service
-	ScheduleService           *schedule.Service
-	SettingsService           *settings.Service
-	SSLSettingsService        *ssl.Service
-	StackService              *stack.Service
-	TagService                *tag.Service
-	TeamMembershipService     *teammembership.Service
-	TeamService               *team.Service
-	TunnelServerService       *tunnelserver.Service
-	UserService               *user.Service
-	VersionService            *version.Service
-	WebhookService            *webhook.Service
+	fileService                 portainer.FileService
+	AuditLogService             *auditlog.Service
+	CustomTemplateService       *customtemplate.Service
+	DockerHubService            *dockerhub.Service
+	EdgeGroupService            *edgegroup.Service
+	EdgeJobService              *edgejob.Service
+	EdgeStackService            *edgestack.Service
+	EndpointGroupService        *endpointgroup.Service
+	EndpointService             *endpoint.Service
+	EndpointRelationService     *endpointrelation.Service
+	ExtensionService            *extension.Service
+	FDOProfilesService          *fdoprofile.Service
+	GitCredentialService        *gitcredential.Service
+	HelmRepositoryService       *helmrepository.Service
+	HelmUserRepositoryService   *helmuserrepository.Service
+	NotificationChannelService  *notificationchannel.Service
+	NotificationDeliveryService *notificationdelivery.Service
+	RegistryService             *registry.Service
+	ResourceControlService      *resourcecontrol.Service
+	RoleService                 *role.Service
+	APIKeyRepositoryService     *apikeyrepository.Service
+	ScheduleService             *schedule.Service
+	SettingsService             *settings.Service
+	SSLSettingsService          *ssl.Service
+	StackService                *stack.Service
+	StackVersionService         *stackversion.Service
+	TagService                  *tag.Service
+	TeamMembershipService       *teammembership.Service
+	TeamService                 *team.Service
+	TunnelServerService         *tunnelserver.Service
+	UserService                 *user.Service
+	VersionService              *version.Service
+	WebhookService              *webhook.Service
 }
 
 func (store *Store) initServices() error {
@@ -77,6 +89,12 @@ func (store *Store) initServices() error {
 	}
 	store.RoleService = authorizationsetService
 
+	auditLogService, err := auditlog.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.AuditLogService = auditLogService
+
 	customTemplateService, err := customtemplate.NewService(store.connection)
 	if err != nil {
 		return err
@@ -137,12 +155,36 @@ func (store *Store) initServices() error {
 	}
 	store.FDOProfilesService = fdoProfilesService
 
+	gitCredentialService, err := gitcredential.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.GitCredentialService = gitCredentialService
+
+	helmRepositoryService, err := helmrepository.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.HelmRepositoryService = helmRepositoryService
+
 	helmUserRepositoryService, err := helmuserrepository.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.HelmUserRepositoryService = helmUserRepositoryService
 
+	notificationChannelService, err := notificationchannel.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.NotificationChannelService = notificationChannelService
+
+	notificationDeliveryService, err := notificationdelivery.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.NotificationDeliveryService = notificationDeliveryService
+
 	registryService, err := registry.NewService(store.connection)
 	if err != nil {
 		return err
@@ -173,6 +215,12 @@ func (store *Store) initServices() error {
 	}
 	store.StackService = stackService
 
+	stackVersionService, err := stackversion.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.StackVersionService = stackVersionService
+
 	tagService, err := tag.NewService(store.connection)
 	if err != nil {
 		return err
@@ -230,6 +278,11 @@ func (store *Store) initServices() error {
 	return nil
 }
 
+// AuditLog gives access to the AuditLog data management layer
+func (store *Store) AuditLog() dataservices.AuditLogService {
+	return store.AuditLogService
+}
+
 // CustomTemplate gives access to the CustomTemplate data management layer
 func (store *Store) CustomTemplate() dataservices.CustomTemplateService {
 	return store.CustomTemplateService
@@ -270,11 +323,31 @@ func (store *Store) FDOProfile() dataservices.FDOProfileService {
 	return store.FDOProfilesService
 }
 
+// GitCredential gives access to the GitCredential data management layer
+func (store *Store) GitCredential() dataservices.GitCredentialService {
+	return store.GitCredentialService
+}
+
+// HelmRepository gives access to the HelmRepository data management layer
+func (store *Store) HelmRepository() dataservices.HelmRepositoryService {
+	return store.HelmRepositoryService
+}
+
 // HelmUserRepository access the helm user repository settings
 func (store *Store) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return store.HelmUserRepositoryService
 }
 
+// NotificationChannel gives access to the NotificationChannel data management layer
+func (store *Store) NotificationChannel() dataservices.NotificationChannelService {
+	return store.NotificationChannelService
+}
+
+// NotificationDelivery gives access to the NotificationDelivery data management layer
+func (store *Store) NotificationDelivery() dataservices.NotificationDeliveryService {
+	return store.NotificationDeliveryService
+}
+
 // Registry gives access to the Registry data management layer
 func (store *Store) Registry() dataservices.RegistryService {
 	return store.RegistryService
@@ -310,6 +383,11 @@ func (store *Store) Stack() dataservices.StackService {
 	return store.StackService
 }
 
+// StackVersion gives access to the StackVersion data management layer
+func (store *Store) StackVersion() dataservices.StackVersionService {
+	return store.StackVersionService
+}
+
 // Tag gives access to the Tag data management layer
 func (store *Store) Tag() dataservices.TagService {
 	return store.TagService
@@ -354,6 +432,7 @@ type storeExport struct {
 	EndpointGroup      []portainer.EndpointGroup      `json:"endpoint_groups,omitempty"`
 	EndpointRelation   []portainer.EndpointRelation   `json:"endpoint_relations,omitempty"`
 	Extensions         []portainer.Extension          `json:"extension,omitempty"`
+	HelmRepository     []portainer.HelmRepository     `json:"helm_repositories,omitempty"`
 	HelmUserRepository []portainer.HelmUserRepository `json:"helm_user_repository,omitempty"`
 	Registry           []portainer.Registry           `json:"registries,omitempty"`
 	ResourceControl    []portainer.ResourceControl    `json:"resource_control,omitempty"`
@@ -440,6 +519,14 @@ func (store *Store) Export(filename string) (err error) {
 		backup.Extensions = r
 	}
 
+	if r, err := store.HelmRepository().HelmRepositories(); err != nil {
+		if !store.IsErrObjectNotFound(err) {
+			logrus.WithError(err).Errorf("Exporting Helm Repositories")
+		}
+	} else {
+		backup.HelmRepository = r
+	}
+
 	if r, err := store.HelmUserRepository().HelmUserRepositories(); err != nil {
 		if !store.IsErrObjectNotFound(err) {
 			logrus.WithError(err).Errorf("Exporting Helm User Repositories")
@@ -629,6 +716,10 @@ func (store *Store) Import(filename string) (err error) {
 		store.EndpointRelation().UpdateEndpointRelation(v.EndpointID, &v)
 	}
 
+	for _, v := range backup.HelmRepository {
+		store.HelmRepository().UpdateHelmRepository(v.ID, &v)
+	}
+
 	for _, v := range backup.HelmUserRepository {
 		store.HelmUserRepository().UpdateHelmUserRepository(v.ID, &v)
 	}
@@ -0,0 +1,73 @@
+package exectest
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/portainer/libhelm/release"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+type helmMockReleaseManager struct {
+	revisions map[string][]portainer.HelmReleaseRevision
+}
+
+// NewHelmReleaseManager returns a release manager keeping the revisions of the releases in memory.
+// The releases are created by upgrading them.
+func NewHelmReleaseManager() portainer.HelmReleaseManager {
+	return &helmMockReleaseManager{
+		revisions: make(map[string][]portainer.HelmReleaseRevision),
+	}
+}
+
+func (manager *helmMockReleaseManager) addRevision(name, namespace, chart, description string) *release.Release {
+	key := namespace + "/" + name
+	for i := range manager.revisions[key] {
+		manager.revisions[key][i].Status = "superseded"
+	}
+
+	revision := portainer.HelmReleaseRevision{
+		Revision:    len(manager.revisions[key]) + 1,
+		Status:      "deployed",
+		Chart:       chart,
+		Description: description,
+	}
+	manager.revisions[key] = append(manager.revisions[key], revision)
+
+	return &release.Release{Name: name, Namespace: namespace, Version: revision.Revision}
+}
+
+func (manager *helmMockReleaseManager) Upgrade(upgradeOpts portainer.HelmUpgradeOptions) (*release.Release, error) {
+	_, exists := manager.revisions[upgradeOpts.Namespace+"/"+upgradeOpts.Name]
+	if upgradeOpts.Install && exists {
+		return nil, errors.New("cannot re-use a name that is still in use")
+	}
+
+	return manager.addRevision(upgradeOpts.Name, upgradeOpts.Namespace, upgradeOpts.Chart, "Upgrade complete"), nil
+}
+
+func (manager *helmMockReleaseManager) History(historyOpts portainer.HelmHistoryOptions) ([]portainer.HelmReleaseRevision, error) {
+	revisions, ok := manager.revisions[historyOpts.Namespace+"/"+historyOpts.Name]
+	if !ok {
+		return nil, errors.New("release: not found")
+	}
+	return revisions, nil
+}
+
+func (manager *helmMockReleaseManager) Rollback(rollbackOpts portainer.HelmRollbackOptions) (*release.Release, error) {
+	revisions, ok := manager.revisions[rollbackOpts.Namespace+"/"+rollbackOpts.Name]
+	if !ok || len(revisions) < 2 {
+		return nil, errors.New("release has no previous revision")
+	}
+
+	revision := rollbackOpts.Revision
+	if revision == 0 {
+		revision = len(revisions) - 1
+	}
+	if revision > len(revisions) {
+		return nil, errors.Errorf("release has no %d version", revision)
+	}
+
+	return manager.addRevision(rollbackOpts.Name, rollbackOpts.Namespace, revisions[revision-1].Chart, fmt.Sprintf("Rollback to %d", revision)), nil
+}
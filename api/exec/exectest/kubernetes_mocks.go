@@ -18,6 +18,18 @@ func (deployer *kubernetesMockDeployer) Remove(userID portainer.UserID, endpoint
 	return "", nil
 }
 
+func (deployer *kubernetesMockDeployer) DryRun(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	return "", nil
+}
+
 func (deployer *kubernetesMockDeployer) ConvertCompose(data []byte) ([]byte, error) {
 	return nil, nil
 }
+
+func (deployer *kubernetesMockDeployer) RenderKustomize(directory string) ([]byte, error) {
+	return nil, nil
+}
+
+func (deployer *kubernetesMockDeployer) RenderHelmChart(releaseName, namespace, chartDirectory string, valuesFiles []string) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,241 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/portainer/libhelm/options"
+	"github.com/portainer/libhelm/release"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// helmRepositoryName is the name of the Helm repository added in the temporary repository configurations
+const helmRepositoryName = "portainer-repository"
+
+// HelmReleaseManager represents a service to upgrade Helm releases, list their revisions and roll them back
+// with the helm binary, it complements the release operations of libhelm.
+type HelmReleaseManager struct {
+	binaryPath string
+}
+
+// NewHelmReleaseManager initializes a new HelmReleaseManager service.
+func NewHelmReleaseManager(binaryPath string) *HelmReleaseManager {
+	return &HelmReleaseManager{
+		binaryPath: binaryPath,
+	}
+}
+
+// Upgrade runs `helm upgrade`, or `helm install` to install a new release, and returns the upgraded release
+func (manager *HelmReleaseManager) Upgrade(upgradeOpts portainer.HelmUpgradeOptions) (*release.Release, error) {
+	verb := "upgrade"
+	if upgradeOpts.Install {
+		verb = "install"
+	}
+
+	chart := upgradeOpts.Chart
+	var args []string
+	if upgradeOpts.Username != "" && upgradeOpts.Repo != "" && !IsOCIChart(chart) {
+		repositoryDir, err := manager.repositoryLogin(upgradeOpts.Repo, upgradeOpts.Username, upgradeOpts.Password)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(repositoryDir)
+
+		chart = helmRepositoryName + "/" + chart
+		args = append(args, repositoryArgs(repositoryDir)...)
+	} else if upgradeOpts.Repo != "" {
+		args = append(args, "--repo", upgradeOpts.Repo)
+	}
+
+	args = append([]string{verb, upgradeOpts.Name, chart, "--output", "json"}, args...)
+	if upgradeOpts.Version != "" {
+		args = append(args, "--version", upgradeOpts.Version)
+	}
+	if upgradeOpts.Namespace != "" {
+		args = append(args, "--namespace", upgradeOpts.Namespace)
+	}
+	if upgradeOpts.ValuesFile != "" {
+		args = append(args, "--values", upgradeOpts.ValuesFile)
+	}
+	if upgradeOpts.ReuseValues && !upgradeOpts.Install {
+		args = append(args, "--reuse-values")
+	}
+
+	if upgradeOpts.Username != "" && IsOCIChart(chart) {
+		registryConfig, err := manager.registryLogin(chart, upgradeOpts.Username, upgradeOpts.Password)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(filepath.Dir(registryConfig))
+
+		args = append(args, "--registry-config", registryConfig)
+	}
+
+	output, err := manager.run(args, upgradeOpts.KubernetesClusterAccess)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to run helm %s", verb)
+	}
+
+	upgraded := &release.Release{}
+	err = json.Unmarshal(output, upgraded)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal helm %s response to Release struct", verb)
+	}
+
+	return upgraded, nil
+}
+
+// History runs `helm history` and returns the revisions of a release, from the oldest to the most recent one
+func (manager *HelmReleaseManager) History(historyOpts portainer.HelmHistoryOptions) ([]portainer.HelmReleaseRevision, error) {
+	args := []string{"history", historyOpts.Name, "--output", "json"}
+	if historyOpts.Namespace != "" {
+		args = append(args, "--namespace", historyOpts.Namespace)
+	}
+
+	output, err := manager.run(args, historyOpts.KubernetesClusterAccess)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to run helm history")
+	}
+
+	revisions := []portainer.HelmReleaseRevision{}
+	err = json.Unmarshal(output, &revisions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal helm history response")
+	}
+
+	return revisions, nil
+}
+
+// Rollback runs `helm rollback` and returns the release with the manifest of the restored revision
+func (manager *HelmReleaseManager) Rollback(rollbackOpts portainer.HelmRollbackOptions) (*release.Release, error) {
+	args := []string{"rollback", rollbackOpts.Name}
+	if rollbackOpts.Revision > 0 {
+		args = append(args, strconv.Itoa(rollbackOpts.Revision))
+	}
+	if rollbackOpts.Namespace != "" {
+		args = append(args, "--namespace", rollbackOpts.Namespace)
+	}
+
+	_, err := manager.run(args, rollbackOpts.KubernetesClusterAccess)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to run helm rollback")
+	}
+
+	args = []string{"get", "manifest", rollbackOpts.Name}
+	if rollbackOpts.Namespace != "" {
+		args = append(args, "--namespace", rollbackOpts.Namespace)
+	}
+
+	manifest, err := manager.run(args, rollbackOpts.KubernetesClusterAccess)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to retrieve the manifest of the release")
+	}
+
+	return &release.Release{
+		Name:      rollbackOpts.Name,
+		Namespace: rollbackOpts.Namespace,
+		Manifest:  string(manifest),
+	}, nil
+}
+
+// IsOCIChart returns true when a chart is referenced by an oci:// URL
+func IsOCIChart(chart string) bool {
+	return strings.HasPrefix(chart, "oci://")
+}
+
+// registryLogin logs in the registry hosting an oci:// chart and returns the path of the registry configuration
+// holding the credentials. The configuration is written in its own temporary directory, the credentials are
+// never stored in the registry configuration of the helm binary.
+func (manager *HelmReleaseManager) registryLogin(chart, username, password string) (string, error) {
+	chartURL, err := url.Parse(chart)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid oci:// chart reference")
+	}
+
+	dir, err := ioutil.TempDir("", "helm-registry")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the registry configuration directory")
+	}
+	registryConfig := filepath.Join(dir, "config.json")
+
+	args := []string{"registry", "login", chartURL.Host, "--username", username, "--password-stdin", "--registry-config", registryConfig}
+	_, err = manager.runWithInput(args, strings.NewReader(password))
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", errors.WithMessage(err, "failed to log in the registry of the chart")
+	}
+
+	return registryConfig, nil
+}
+
+// repositoryLogin adds a Helm repository requiring credentials in a repository configuration written in its own
+// temporary directory, along with the cache of the repository index. It returns the directory, the credentials
+// are passed through the standard input and never appear in the arguments of the helm binary.
+func (manager *HelmReleaseManager) repositoryLogin(repo, username, password string) (string, error) {
+	dir, err := ioutil.TempDir("", "helm-repository")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the repository configuration directory")
+	}
+
+	args := append([]string{"repo", "add", helmRepositoryName, repo, "--username", username, "--password-stdin"}, repositoryArgs(dir)...)
+	_, err = manager.runWithInput(args, strings.NewReader(password))
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", errors.WithMessage(err, "failed to add the Helm repository")
+	}
+
+	return dir, nil
+}
+
+// repositoryArgs returns the arguments making the helm binary use the repository configuration and cache of a directory
+func repositoryArgs(dir string) []string {
+	return []string{
+		"--repository-config", filepath.Join(dir, "repositories.yaml"),
+		"--repository-cache", filepath.Join(dir, "cache"),
+	}
+}
+
+// run executes the helm binary against the Kubernetes cluster with the access of the current user
+func (manager *HelmReleaseManager) run(args []string, clusterAccess *options.KubernetesClusterAccess) ([]byte, error) {
+	if clusterAccess != nil {
+		args = append(args,
+			"--kube-apiserver", clusterAccess.ClusterServerURL,
+			"--kube-token", clusterAccess.AuthToken,
+			"--kube-ca-file", clusterAccess.CertificateAuthorityFile,
+		)
+	}
+
+	return manager.runWithInput(args, nil)
+}
+
+// runWithInput executes the helm binary with a standard input
+func (manager *HelmReleaseManager) runWithInput(args []string, stdin io.Reader) ([]byte, error) {
+	command := path.Join(manager.binaryPath, "helm")
+	if runtime.GOOS == "windows" {
+		command = path.Join(manager.binaryPath, "helm.exe")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = stdin
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, stderr.String())
+	}
+
+	return output, nil
+}
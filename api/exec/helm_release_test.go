@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeHelm writes a helm binary recording its arguments, one invocation per line
+func fakeHelm(t *testing.T) (string, func() []string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake helm binary is a shell script")
+	}
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" >> "$(dirname "$0")/args.log"
+cat > /dev/null
+echo '{"name":"app","namespace":"default","version":1}'
+`
+	err := os.WriteFile(filepath.Join(dir, "helm"), []byte(script), 0755)
+	assert.NoError(t, err)
+
+	return dir, func() []string {
+		content, err := os.ReadFile(filepath.Join(dir, "args.log"))
+		assert.NoError(t, err)
+		return strings.Split(strings.TrimSpace(string(content)), "\n")
+	}
+}
+
+func Test_HelmReleaseManager_Upgrade(t *testing.T) {
+	t.Run("new releases are installed, not upgraded", func(t *testing.T) {
+		dir, invocations := fakeHelm(t)
+		manager := NewHelmReleaseManager(dir)
+
+		_, err := manager.Upgrade(portainer.HelmUpgradeOptions{Name: "app", Chart: "nginx", Repo: "https://charts.local", Install: true, ReuseValues: true})
+		assert.NoError(t, err)
+
+		calls := invocations()
+		assert.Len(t, calls, 1)
+		assert.True(t, strings.HasPrefix(calls[0], "install app nginx "), calls[0])
+		assert.NotContains(t, calls[0], "--reuse-values")
+	})
+
+	t.Run("repository credentials are not passed as arguments", func(t *testing.T) {
+		dir, invocations := fakeHelm(t)
+		manager := NewHelmReleaseManager(dir)
+
+		_, err := manager.Upgrade(portainer.HelmUpgradeOptions{Name: "app", Chart: "nginx", Repo: "https://charts.local", Username: "user", Password: "s3cr3t"})
+		assert.NoError(t, err)
+
+		calls := invocations()
+		assert.Len(t, calls, 2)
+		assert.True(t, strings.HasPrefix(calls[0], "repo add "+helmRepositoryName+" https://charts.local --username user --password-stdin"), calls[0])
+		assert.True(t, strings.HasPrefix(calls[1], "upgrade app "+helmRepositoryName+"/nginx "), calls[1])
+		assert.NotContains(t, calls[1], "--repo ")
+		for _, call := range calls {
+			assert.NotContains(t, call, "s3cr3t")
+		}
+	})
+}
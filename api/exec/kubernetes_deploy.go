@@ -86,6 +86,11 @@ func (deployer *KubernetesDeployer) Remove(userID portainer.UserID, endpoint *po
 	return deployer.command("delete", userID, endpoint, manifestFiles, namespace)
 }
 
+// DryRun submits the Kubernetes resources defined in manifest(s) to the server without persisting them
+func (deployer *KubernetesDeployer) DryRun(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	return deployer.command("dry-run", userID, endpoint, manifestFiles, namespace)
+}
+
 func (deployer *KubernetesDeployer) command(operation string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
 	token, err := deployer.getToken(userID, endpoint, endpoint.Type == portainer.KubernetesLocalEnvironment)
 	if err != nil {
@@ -117,7 +122,11 @@ func (deployer *KubernetesDeployer) command(operation string, userID portainer.U
 		args = append(args, "--ignore-not-found=true")
 	}
 
-	args = append(args, operation)
+	if operation == "dry-run" {
+		args = append(args, "apply", "--dry-run=server")
+	} else {
+		args = append(args, operation)
+	}
 	for _, path := range manifestFiles {
 		args = append(args, "-f", strings.TrimSpace(path))
 	}
@@ -159,6 +168,48 @@ func (deployer *KubernetesDeployer) ConvertCompose(data []byte) ([]byte, error)
 	return output, nil
 }
 
+// RenderKustomize leverages the kubectl binary to build the manifest of a Kustomize directory.
+func (deployer *KubernetesDeployer) RenderKustomize(directory string) ([]byte, error) {
+	command := path.Join(deployer.binaryPath, "kubectl")
+	if runtime.GOOS == "windows" {
+		command = path.Join(deployer.binaryPath, "kubectl.exe")
+	}
+
+	return render(command, "kustomize", directory)
+}
+
+// RenderHelmChart leverages the helm binary to render the manifest of a local chart directory with values files,
+// the dependencies of the chart are downloaded when they are missing.
+func (deployer *KubernetesDeployer) RenderHelmChart(releaseName, namespace, chartDirectory string, valuesFiles []string) ([]byte, error) {
+	command := path.Join(deployer.binaryPath, "helm")
+	if runtime.GOOS == "windows" {
+		command = path.Join(deployer.binaryPath, "helm.exe")
+	}
+
+	args := []string{"template", releaseName, chartDirectory, "--dependency-update"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	for _, valuesFile := range valuesFiles {
+		args = append(args, "--values", valuesFile)
+	}
+
+	return render(command, args...)
+}
+
+func render(command string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render the manifest: %q", stderr.String())
+	}
+
+	return output, nil
+}
+
 func (deployer *KubernetesDeployer) getAgentURL(endpoint *portainer.Endpoint) (string, *factory.ProxyServer, error) {
 	proxy, err := deployer.proxyManager.CreateAgentProxyServer(endpoint)
 	if err != nil {
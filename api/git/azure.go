@@ -151,6 +151,59 @@ func (a *azureDownloader) latestCommitID(ctx context.Context, options fetchOptio
 	return items.Value[0].CommitId, nil
 }
 
+func (a *azureDownloader) listRefs(ctx context.Context, options fetchOptions) ([]string, error) {
+	config, err := parseUrl(options.repositoryUrl)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse url")
+	}
+
+	refsUrl := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/refs?api-version=6.0",
+		a.baseUrl,
+		url.PathEscape(config.organisation),
+		url.PathEscape(config.project),
+		url.PathEscape(config.repository))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", refsUrl, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create a new HTTP request")
+	}
+
+	if options.username != "" || options.password != "" {
+		req.SetBasicAuth(options.username, options.password)
+	} else if config.username != "" || config.password != "" {
+		req.SetBasicAuth(config.username, config.password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to make an HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get repository refs with a status \"%v\"", resp.Status)
+	}
+
+	var refs struct {
+		Value []struct {
+			Name string `json:"name"`
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&refs); err != nil {
+		return nil, errors.Wrap(err, "could not parse Azure refs response")
+	}
+
+	names := make([]string, 0, len(refs.Value))
+	for _, ref := range refs.Value {
+		if strings.HasPrefix(ref.Name, branchPrefix) || strings.HasPrefix(ref.Name, tagPrefix) {
+			names = append(names, ref.Name)
+		}
+	}
+
+	return names, nil
+}
+
 func parseUrl(rawUrl string) (*azureOptions, error) {
 	if strings.HasPrefix(rawUrl, "https://") || strings.HasPrefix(rawUrl, "http://") {
 		return parseHttpUrl(rawUrl)
@@ -0,0 +1,218 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	cacheCommitFile = "commit"
+	// cacheMaxAge is the duration after which an unused cached clone is removed
+	cacheMaxAge = 24 * time.Hour
+	// cacheMaxEntries is the number of cached clones kept, the least recently used ones are removed first
+	cacheMaxEntries = 50
+	// cacheEvictionInterval is the minimal duration between two evictions
+	cacheEvictionInterval = time.Minute
+)
+
+// cacheEntry serializes the uses of a cached clone, the clones of different references or repositories
+// are not serialized
+type cacheEntry struct {
+	mu    sync.Mutex
+	users int
+}
+
+// cachedCloneRepository copies the repository from the cache when the cached clone of the reference
+// is at its latest commit, otherwise the repository is cloned into the cache first.
+// The latest commit is always fetched with the credentials of the caller so that a cached clone
+// is only served to the callers allowed to read the repository.
+func (service *Service) cachedCloneRepository(destination string, options cloneOptions) error {
+	commitID, err := service.latestCommitID(fetchOptions{
+		repositoryUrl: options.repositoryUrl,
+		username:      options.username,
+		password:      options.password,
+		ssh:           options.ssh,
+		referenceName: options.referenceName,
+	})
+	if err != nil {
+		return err
+	}
+
+	key := cacheKey(options)
+	service.lockCacheEntry(key)
+	defer service.unlockCacheEntry(key)
+	defer service.evictCacheEntries()
+
+	entryDir := filepath.Join(service.cacheDir, key)
+	treeDir := filepath.Join(entryDir, "tree")
+
+	cachedCommitID, err := os.ReadFile(filepath.Join(entryDir, cacheCommitFile))
+	if err != nil || !strings.EqualFold(string(cachedCommitID), commitID) {
+		if err := service.refreshCacheEntry(entryDir, commitID, options); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return errors.Wrap(err, "failed to create the destination directory")
+	}
+
+	if err := filesystem.CopyDir(treeDir, destination, false); err != nil {
+		return errors.Wrap(err, "failed to copy the repository from the cache")
+	}
+
+	// the modification time of the commit file tracks the last use of the cached clone
+	now := time.Now()
+	os.Chtimes(filepath.Join(entryDir, cacheCommitFile), now, now)
+
+	return nil
+}
+
+// lockCacheEntry waits until the cached clone of the key is not used anymore and marks it as used
+func (service *Service) lockCacheEntry(key string) {
+	service.cacheMu.Lock()
+	if service.cacheEntries == nil {
+		service.cacheEntries = make(map[string]*cacheEntry)
+	}
+
+	entry, ok := service.cacheEntries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		service.cacheEntries[key] = entry
+	}
+	entry.users++
+	service.cacheMu.Unlock()
+
+	entry.mu.Lock()
+}
+
+// unlockCacheEntry releases the cached clone of the key
+func (service *Service) unlockCacheEntry(key string) {
+	service.cacheMu.Lock()
+	defer service.cacheMu.Unlock()
+
+	entry := service.cacheEntries[key]
+	entry.mu.Unlock()
+
+	entry.users--
+	if entry.users == 0 {
+		delete(service.cacheEntries, key)
+	}
+}
+
+// evictCacheEntries removes the cached clones unused for cacheMaxAge and the least recently used ones beyond
+// cacheMaxEntries, at most once per cacheEvictionInterval. The cached clones in use are never removed.
+func (service *Service) evictCacheEntries() {
+	service.cacheMu.Lock()
+
+	now := time.Now()
+	if now.Sub(service.lastEviction) < cacheEvictionInterval {
+		service.cacheMu.Unlock()
+		return
+	}
+	service.lastEviction = now
+
+	type cachedClone struct {
+		key      string
+		lastUsed time.Time
+	}
+
+	dirEntries, err := os.ReadDir(service.cacheDir)
+	if err != nil {
+		service.cacheMu.Unlock()
+		logrus.WithError(err).Warn("unable to list the cached Git repositories")
+		return
+	}
+
+	clones := make([]cachedClone, 0, len(dirEntries))
+	evicted := make([]string, 0)
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+
+		// left over by a previous eviction
+		if strings.HasSuffix(dirEntry.Name(), ".evicted") {
+			evicted = append(evicted, filepath.Join(service.cacheDir, dirEntry.Name()))
+			continue
+		}
+
+		lastUsed := time.Time{}
+		if info, err := os.Stat(filepath.Join(service.cacheDir, dirEntry.Name(), cacheCommitFile)); err == nil {
+			lastUsed = info.ModTime()
+		}
+		clones = append(clones, cachedClone{key: dirEntry.Name(), lastUsed: lastUsed})
+	}
+
+	sort.Slice(clones, func(i, j int) bool {
+		return clones[i].lastUsed.After(clones[j].lastUsed)
+	})
+
+	// the evicted clones are renamed while the lock is held, so that they are removed outside of it
+	for i, clone := range clones {
+		if _, inUse := service.cacheEntries[clone.key]; inUse {
+			continue
+		}
+		if i < cacheMaxEntries && now.Sub(clone.lastUsed) <= cacheMaxAge {
+			continue
+		}
+
+		evictedDir := filepath.Join(service.cacheDir, clone.key+".evicted")
+		if err := os.Rename(filepath.Join(service.cacheDir, clone.key), evictedDir); err == nil {
+			evicted = append(evicted, evictedDir)
+		}
+	}
+
+	service.cacheMu.Unlock()
+
+	for _, dir := range evicted {
+		if err := os.RemoveAll(dir); err != nil {
+			logrus.WithError(err).WithField("directory", dir).Warn("unable to remove an evicted Git repository from the cache")
+		}
+	}
+}
+
+// refreshCacheEntry replaces the cached clone of a reference by a fresh clone
+func (service *Service) refreshCacheEntry(entryDir, commitID string, options cloneOptions) error {
+	if err := os.RemoveAll(entryDir); err != nil {
+		return errors.Wrap(err, "failed to remove the outdated cached repository")
+	}
+
+	if err := os.MkdirAll(entryDir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create the cache directory")
+	}
+
+	if err := service.cloneRepository(filepath.Join(entryDir, "tree"), options); err != nil {
+		os.RemoveAll(entryDir)
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(entryDir, cacheCommitFile), []byte(commitID), 0600); err != nil {
+		os.RemoveAll(entryDir)
+		return errors.Wrap(err, "failed to write the commit of the cached repository")
+	}
+
+	return nil
+}
+
+// cacheKey identifies the clone of a reference of a repository with the options changing its content
+func cacheKey(options cloneOptions) string {
+	// the order of the sparse paths doesn't change the content of the clone
+	sparsePaths := append([]string(nil), options.sparsePaths...)
+	sort.Strings(sparsePaths)
+
+	key := fmt.Sprintf("%s\n%s\n%t\n%t\n%d\n%s", options.repositoryUrl, options.referenceName, options.recurseSubmodules, options.lfs, options.depth, strings.Join(sparsePaths, ","))
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,33 @@
+package git
+
+import (
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	gittypes "github.com/portainer/portainer/api/git/types"
+)
+
+// ResolveAuthentication returns the credentials to use to access a repository.
+// When the authentication references a stored Git credential, the credential is loaded
+// from the database so that a rotated credential is used without updating its consumers.
+func ResolveAuthentication(dataStore dataservices.DataStore, auth *gittypes.GitAuthentication) (*gittypes.GitAuthentication, error) {
+	if auth == nil || auth.GitCredentialID == 0 {
+		return auth, nil
+	}
+
+	credential, err := dataStore.GitCredential().GitCredential(portainer.GitCredentialID(auth.GitCredentialID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to retrieve the Git credential %d", auth.GitCredentialID)
+	}
+
+	return &gittypes.GitAuthentication{
+		Username:        credential.Username,
+		Password:        credential.Password,
+		SSHPrivateKey:   credential.SSHPrivateKey,
+		SSHPassphrase:   credential.SSHPassphrase,
+		SSHKnownHosts:   credential.SSHKnownHosts,
+		GitCredentialID: auth.GitCredentialID,
+
+		InsecureSkipHostKeyVerification: credential.InsecureSkipHostKeyVerification,
+	}, nil
+}
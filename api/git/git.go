@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -14,29 +16,37 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/client"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage/memory"
+	gittypes "github.com/portainer/portainer/api/git/types"
 )
 
 type fetchOptions struct {
 	repositoryUrl string
 	username      string
 	password      string
+	ssh           sshOptions
 	referenceName string
 }
 
 type cloneOptions struct {
-	repositoryUrl string
-	username      string
-	password      string
-	referenceName string
-	depth         int
+	repositoryUrl     string
+	username          string
+	password          string
+	ssh               sshOptions
+	referenceName     string
+	depth             int
+	recurseSubmodules bool
+	lfs               bool
+	sparsePaths       []string
 }
 
 type downloader interface {
 	download(ctx context.Context, dst string, opt cloneOptions) error
 	latestCommitID(ctx context.Context, opt fetchOptions) (string, error)
+	listRefs(ctx context.Context, opt fetchOptions) ([]string, error)
 }
 
 type gitClient struct {
@@ -44,22 +54,41 @@ type gitClient struct {
 }
 
 func (c gitClient) download(ctx context.Context, dst string, opt cloneOptions) error {
+	auth, err := getAuth(opt.repositoryUrl, opt.username, opt.password, opt.ssh)
+	if err != nil {
+		return err
+	}
+
 	gitOptions := git.CloneOptions{
 		URL:   opt.repositoryUrl,
 		Depth: opt.depth,
-		Auth:  getAuth(opt.username, opt.password),
+		Auth:  auth,
 	}
 
 	if opt.referenceName != "" {
 		gitOptions.ReferenceName = plumbing.ReferenceName(opt.referenceName)
 	}
 
-	_, err := git.PlainCloneContext(ctx, dst, false, &gitOptions)
+	if opt.recurseSubmodules {
+		gitOptions.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
+	}
+
+	// the submodules are only checked out along with the whole tree
+	sparse := len(opt.sparsePaths) > 0 && !opt.recurseSubmodules
+	gitOptions.NoCheckout = sparse
+
+	repo, err := git.PlainCloneContext(ctx, dst, false, &gitOptions)
 
 	if err != nil {
 		return errors.Wrap(err, "failed to clone git repository")
 	}
 
+	if sparse {
+		if err := checkoutSparsePaths(repo, dst, opt.sparsePaths); err != nil {
+			return errors.Wrap(err, "failed to checkout the sparse checkout paths")
+		}
+	}
+
 	if !c.preserveGitDirectory {
 		os.RemoveAll(filepath.Join(dst, ".git"))
 	}
@@ -73,8 +102,13 @@ func (c gitClient) latestCommitID(ctx context.Context, opt fetchOptions) (string
 		URLs: []string{opt.repositoryUrl},
 	})
 
+	auth, err := getAuth(opt.repositoryUrl, opt.username, opt.password, opt.ssh)
+	if err != nil {
+		return "", err
+	}
+
 	listOptions := &git.ListOptions{
-		Auth: getAuth(opt.username, opt.password),
+		Auth: auth,
 	}
 
 	refs, err := remote.List(listOptions)
@@ -100,7 +134,45 @@ func (c gitClient) latestCommitID(ctx context.Context, opt fetchOptions) (string
 	return "", errors.Errorf("could not find ref %q in the repository", opt.referenceName)
 }
 
-func getAuth(username, password string) *githttp.BasicAuth {
+func (c gitClient) listRefs(ctx context.Context, opt fetchOptions) ([]string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{opt.repositoryUrl},
+	})
+
+	auth, err := getAuth(opt.repositoryUrl, opt.username, opt.password, opt.ssh)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list repository refs")
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Name().IsBranch() || ref.Name().IsTag() {
+			names = append(names, ref.Name().String())
+		}
+	}
+
+	return names, nil
+}
+
+func getAuth(repositoryURL, username, password string, ssh sshOptions) (transport.AuthMethod, error) {
+	if isSSHUrl(repositoryURL) {
+		return getSSHAuth(repositoryURL, username, ssh)
+	}
+
+	if basicAuth := getBasicAuth(username, password); basicAuth != nil {
+		return basicAuth, nil
+	}
+
+	return nil, nil
+}
+
+func getBasicAuth(username, password string) *githttp.BasicAuth {
 	if password != "" {
 		if username == "" {
 			username = "token"
@@ -119,10 +191,17 @@ type Service struct {
 	httpsCli *http.Client
 	azure    downloader
 	git      downloader
+	// directory where the cloned repositories are cached, the cache is disabled when empty
+	cacheDir string
+	// guards the entries in use and the eviction of the cache
+	cacheMu      sync.Mutex
+	cacheEntries map[string]*cacheEntry
+	lastEviction time.Time
 }
 
 // NewService initializes a new service.
-func NewService() *Service {
+// The cloned repositories are cached in cacheDir, the cache is disabled when cacheDir is empty.
+func NewService(cacheDir string) *Service {
 	httpsCli := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -137,43 +216,114 @@ func NewService() *Service {
 		httpsCli: httpsCli,
 		azure:    NewAzureDownloader(httpsCli),
 		git:      gitClient{},
+		cacheDir: cacheDir,
 	}
 }
 
 // CloneRepository clones a git repository using the specified URL in the specified
-// destination folder.
-func (service *Service) CloneRepository(destination, repositoryURL, referenceName, username, password string) error {
+// destination folder. The clone options are optional.
+func (service *Service) CloneRepository(destination, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOpts *gittypes.CloneOptions) error {
 	options := cloneOptions{
 		repositoryUrl: repositoryURL,
-		username:      username,
-		password:      password,
 		referenceName: referenceName,
 		depth:         1,
 	}
+	options.username, options.password, options.ssh = authOptions(auth)
+
+	if cloneOpts != nil {
+		options.recurseSubmodules = cloneOpts.RecurseSubmodules
+		options.lfs = cloneOpts.LFS
+		options.sparsePaths = cloneOpts.SparsePaths
+		if cloneOpts.Depth > 0 {
+			options.depth = cloneOpts.Depth
+		}
+	}
+
+	if service.cacheDir != "" {
+		return service.cachedCloneRepository(destination, options)
+	}
 
 	return service.cloneRepository(destination, options)
 }
 
 func (service *Service) cloneRepository(destination string, options cloneOptions) error {
+	var err error
 	if isAzureUrl(options.repositoryUrl) {
-		return service.azure.download(context.TODO(), destination, options)
+		err = service.azure.download(context.TODO(), destination, options)
+	} else {
+		err = service.git.download(context.TODO(), destination, options)
+	}
+	if err != nil {
+		return err
 	}
 
-	return service.git.download(context.TODO(), destination, options)
+	if len(options.sparsePaths) > 0 {
+		if err := sparseCheckout(destination, options.sparsePaths); err != nil {
+			return errors.Wrap(err, "failed to remove the files outside of the sparse checkout paths")
+		}
+	}
+
+	if options.lfs {
+		if err := service.fetchLFSObjects(context.TODO(), destination, options); err != nil {
+			return errors.Wrap(err, "failed to fetch the Git LFS objects")
+		}
+	}
+
+	return nil
 }
 
 // LatestCommitID returns SHA1 of the latest commit of the specified reference
-func (service *Service) LatestCommitID(repositoryURL, referenceName, username, password string) (string, error) {
+func (service *Service) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication) (string, error) {
 	options := fetchOptions{
 		repositoryUrl: repositoryURL,
-		username:      username,
-		password:      password,
 		referenceName: referenceName,
 	}
+	options.username, options.password, options.ssh = authOptions(auth)
+
+	return service.latestCommitID(options)
+}
+
+// ListRefs returns the branches and the tags of a repository, e.g. refs/heads/main and refs/tags/v1.0.0
+func (service *Service) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication) ([]string, error) {
+	options := fetchOptions{
+		repositoryUrl: repositoryURL,
+	}
+	options.username, options.password, options.ssh = authOptions(auth)
+
+	var refs []string
+	var err error
+	if isAzureUrl(options.repositoryUrl) {
+		refs, err = service.azure.listRefs(context.TODO(), options)
+	} else {
+		refs, err = service.git.listRefs(context.TODO(), options)
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	sort.Strings(refs)
+
+	return refs, nil
+}
+
+func (service *Service) latestCommitID(options fetchOptions) (string, error) {
 	if isAzureUrl(options.repositoryUrl) {
 		return service.azure.latestCommitID(context.TODO(), options)
 	}
 
 	return service.git.latestCommitID(context.TODO(), options)
 }
+
+func authOptions(auth *gittypes.GitAuthentication) (string, string, sshOptions) {
+	if auth == nil {
+		return "", "", sshOptions{}
+	}
+
+	return auth.Username, auth.Password, sshOptions{
+		privateKey: auth.SSHPrivateKey,
+		passphrase: auth.SSHPassphrase,
+		knownHosts: auth.SSHKnownHosts,
+
+		insecureSkipHostKeyVerification: auth.InsecureSkipHostKeyVerification,
+	}
+}
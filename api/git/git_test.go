@@ -2,11 +2,13 @@ package git
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -60,7 +62,7 @@ func Test_ClonePublicRepository_Shallow(t *testing.T) {
 	}
 	defer os.RemoveAll(dir)
 	t.Logf("Cloning into %s", dir)
-	err = service.CloneRepository(dir, repositoryURL, referenceName, "", "")
+	err = service.CloneRepository(dir, repositoryURL, referenceName, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, getCommitHistoryLength(t, err, dir), "cloned repo has incorrect depth")
 }
@@ -79,7 +81,7 @@ func Test_ClonePublicRepository_NoGitDirectory(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	t.Logf("Cloning into %s", dir)
-	err = service.CloneRepository(dir, repositoryURL, referenceName, "", "")
+	err = service.CloneRepository(dir, repositoryURL, referenceName, nil, nil)
 	assert.NoError(t, err)
 	assert.NoDirExists(t, filepath.Join(dir, ".git"))
 }
@@ -114,12 +116,72 @@ func Test_latestCommitID(t *testing.T) {
 	repositoryURL := bareRepoDir
 	referenceName := "refs/heads/main"
 
-	id, err := service.LatestCommitID(repositoryURL, referenceName, "", "")
+	id, err := service.LatestCommitID(repositoryURL, referenceName, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "68dcaa7bd452494043c64252ab90db0f98ecf8d2", id)
 }
 
+func Test_CloneRepository_Cache(t *testing.T) {
+	is := assert.New(t)
+
+	service := Service{git: gitClient{}, cacheDir: t.TempDir()} // no need for http client since the test access the repo via file system.
+	options := cloneOptions{repositoryUrl: bareRepoDir, referenceName: "refs/heads/main", depth: 1}
+
+	err := service.CloneRepository(t.TempDir(), bareRepoDir, "refs/heads/main", nil, nil)
+	is.NoError(err)
+
+	entryDir := filepath.Join(service.cacheDir, cacheKey(options))
+	commitID, err := os.ReadFile(filepath.Join(entryDir, cacheCommitFile))
+	is.NoError(err)
+	is.Equal("68dcaa7bd452494043c64252ab90db0f98ecf8d2", string(commitID))
+
+	// a file only present in the cache proves that the second clone is served from the cache
+	is.NoError(os.WriteFile(filepath.Join(entryDir, "tree", "cached"), []byte("cached"), 0644))
+
+	dir := t.TempDir()
+	err = service.CloneRepository(dir, bareRepoDir, "refs/heads/main", nil, nil)
+	is.NoError(err)
+	is.FileExists(filepath.Join(dir, "cached"))
+
+	is.NotEqual(cacheKey(options), cacheKey(cloneOptions{repositoryUrl: bareRepoDir, referenceName: "refs/heads/main", depth: 1, lfs: true}), "the options changing the content should be part of the key")
+
+	sparsePaths := []string{"stacks/web", "stacks/db"}
+	is.Equal(cacheKey(cloneOptions{repositoryUrl: bareRepoDir, referenceName: "refs/heads/main", sparsePaths: []string{"stacks/db", "stacks/web"}}), cacheKey(cloneOptions{repositoryUrl: bareRepoDir, referenceName: "refs/heads/main", sparsePaths: sparsePaths}), "the order of the sparse paths should not change the key")
+	is.Equal([]string{"stacks/web", "stacks/db"}, sparsePaths, "the sparse paths of the caller should not be sorted")
+}
+
+func Test_evictCacheEntries(t *testing.T) {
+	is := assert.New(t)
+
+	service := Service{cacheDir: t.TempDir()}
+
+	addEntry := func(key string, lastUsed time.Time) {
+		entryDir := filepath.Join(service.cacheDir, key)
+		is.NoError(os.MkdirAll(filepath.Join(entryDir, "tree"), 0700))
+		is.NoError(os.WriteFile(filepath.Join(entryDir, cacheCommitFile), []byte("commit"), 0600))
+		is.NoError(os.Chtimes(filepath.Join(entryDir, cacheCommitFile), lastUsed, lastUsed))
+	}
+
+	now := time.Now()
+	addEntry("expired", now.Add(-cacheMaxAge-time.Hour))
+	addEntry("expired-in-use", now.Add(-cacheMaxAge-time.Hour))
+	for i := 0; i <= cacheMaxEntries; i++ {
+		addEntry(fmt.Sprintf("recent-%d", i), now.Add(-time.Duration(i)*time.Minute))
+	}
+
+	service.lockCacheEntry("expired-in-use")
+	service.evictCacheEntries()
+	service.unlockCacheEntry("expired-in-use")
+
+	is.NoDirExists(filepath.Join(service.cacheDir, "expired"))
+	is.DirExists(filepath.Join(service.cacheDir, "expired-in-use"), "a cached clone in use should not be removed")
+	is.DirExists(filepath.Join(service.cacheDir, "recent-0"))
+	is.DirExists(filepath.Join(service.cacheDir, fmt.Sprintf("recent-%d", cacheMaxEntries-1)))
+	is.NoDirExists(filepath.Join(service.cacheDir, fmt.Sprintf("recent-%d", cacheMaxEntries)), "the least recently used clones beyond the limit should be removed")
+	is.Empty(service.cacheEntries)
+}
+
 func getCommitHistoryLength(t *testing.T, err error, dir string) int {
 	repo, err := git.PlainOpen(dir)
 	if err != nil {
@@ -153,6 +215,10 @@ func (t *testDownloader) latestCommitID(_ context.Context, _ fetchOptions) (stri
 	return "", nil
 }
 
+func (t *testDownloader) listRefs(_ context.Context, _ fetchOptions) ([]string, error) {
+	return nil, nil
+}
+
 func Test_cloneRepository_azure(t *testing.T) {
 	tests := []struct {
 		name   string
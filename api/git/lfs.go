@@ -0,0 +1,271 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// pointer files are small text files, larger files are never parsed
+	lfsPointerMaxSize = 1024
+	lfsMediaType      = "application/vnd.git-lfs+json"
+)
+
+type lfsPointer struct {
+	path string
+	oid  string
+	size int64
+}
+
+type lfsObject struct {
+	Oid     string `json:"oid"`
+	Size    int64  `json:"size"`
+	Actions struct {
+		Download *struct {
+			Href   string            `json:"href"`
+			Header map[string]string `json:"header"`
+		} `json:"download"`
+	} `json:"actions,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// fetchLFSObjects replaces the Git LFS pointers of a cloned repository by the content of the files.
+// The objects are downloaded with the basic transfer of the Git LFS batch API of the repository.
+func (service *Service) fetchLFSObjects(ctx context.Context, dir string, options cloneOptions) error {
+	pointers, err := findLFSPointers(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(pointers) == 0 {
+		return nil
+	}
+
+	if isSSHUrl(options.repositoryUrl) {
+		return errors.New("Git LFS is only supported for HTTP(S) repositories")
+	}
+
+	objects, err := service.lfsBatch(ctx, options, pointers)
+	if err != nil {
+		return err
+	}
+
+	for _, pointer := range pointers {
+		object, ok := objects[pointer.oid]
+		if !ok {
+			return errors.Errorf("the Git LFS object %s of %s is missing", pointer.oid, pointer.path)
+		}
+
+		if err := service.downloadLFSObject(ctx, object, pointer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) httpClient() *http.Client {
+	if service.httpsCli != nil {
+		return service.httpsCli
+	}
+	return http.DefaultClient
+}
+
+// lfsBatch requests the download actions of the pointed objects, indexed by oid
+func (service *Service) lfsBatch(ctx context.Context, options cloneOptions, pointers []lfsPointer) (map[string]lfsObject, error) {
+	type batchObject struct {
+		Oid  string `json:"oid"`
+		Size int64  `json:"size"`
+	}
+
+	request := struct {
+		Operation string        `json:"operation"`
+		Transfers []string      `json:"transfers"`
+		Objects   []batchObject `json:"objects"`
+	}{Operation: "download", Transfers: []string{"basic"}}
+
+	for _, pointer := range pointers {
+		request.Objects = append(request.Objects, batchObject{Oid: pointer.oid, Size: pointer.size})
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lfsURL(options.repositoryUrl)+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	if basicAuth := getBasicAuth(options.username, options.password); basicAuth != nil {
+		req.SetBasicAuth(basicAuth.Username, basicAuth.Password)
+	}
+
+	resp, err := service.httpClient().Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request the Git LFS batch API")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("the Git LFS batch API responded with the status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Objects []lfsObject `json:"objects"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the Git LFS batch API response")
+	}
+
+	objects := make(map[string]lfsObject, len(response.Objects))
+	for _, object := range response.Objects {
+		objects[object.Oid] = object
+	}
+
+	return objects, nil
+}
+
+func (service *Service) downloadLFSObject(ctx context.Context, object lfsObject, pointer lfsPointer) error {
+	if object.Error != nil {
+		return errors.Errorf("unable to download the Git LFS object of %s: %s", pointer.path, object.Error.Message)
+	}
+	if object.Actions.Download == nil {
+		return errors.Errorf("no download action for the Git LFS object of %s", pointer.path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, object.Actions.Download.Href, nil)
+	if err != nil {
+		return err
+	}
+	for name, value := range object.Actions.Download.Header {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := service.httpClient().Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download the Git LFS object of %s", pointer.path)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download the Git LFS object of %s, status %d", pointer.path, resp.StatusCode)
+	}
+
+	tempPath := pointer.path + ".lfs"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	file.Close()
+	if err != nil {
+		os.Remove(tempPath)
+		return errors.Wrapf(err, "failed to write the Git LFS object of %s", pointer.path)
+	}
+
+	if hex.EncodeToString(hash.Sum(nil)) != pointer.oid {
+		os.Remove(tempPath)
+		return errors.Errorf("the Git LFS object of %s doesn't match its oid", pointer.path)
+	}
+
+	return os.Rename(tempPath, pointer.path)
+}
+
+// lfsURL returns the Git LFS server URL of a repository, see https://github.com/git-lfs/git-lfs/blob/main/docs/api/server-discovery.md
+func lfsURL(repositoryURL string) string {
+	url := strings.TrimSuffix(repositoryURL, "/")
+	if !strings.HasSuffix(url, ".git") {
+		url += ".git"
+	}
+	return url + "/info/lfs"
+}
+
+// findLFSPointers returns the Git LFS pointer files of a directory, the .git directory is skipped
+func findLFSPointers(dir string) ([]lfsPointer, error) {
+	pointers := make([]lfsPointer, 0)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.Mode().IsRegular() || info.Size() > lfsPointerMaxSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if pointer, ok := parseLFSPointer(content); ok {
+			pointer.path = path
+			pointers = append(pointers, pointer)
+		}
+
+		return nil
+	})
+
+	return pointers, err
+}
+
+// parseLFSPointer parses the content of a Git LFS pointer file, see https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+func parseLFSPointer(content []byte) (lfsPointer, bool) {
+	if !bytes.HasPrefix(content, []byte(lfsPointerVersion+"\n")) {
+		return lfsPointer{}, false
+	}
+
+	pointer := lfsPointer{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), " ")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "oid":
+			pointer.oid = strings.TrimPrefix(value, "sha256:")
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return lfsPointer{}, false
+			}
+			pointer.size = size
+		}
+	}
+
+	if pointer.oid == "" {
+		return lfsPointer{}, false
+	}
+
+	return pointer, true
+}
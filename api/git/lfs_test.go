@@ -0,0 +1,82 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseLFSPointer(t *testing.T) {
+	pointer, ok := parseLFSPointer([]byte("version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"))
+	assert.True(t, ok)
+	assert.Equal(t, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", pointer.oid)
+	assert.Equal(t, int64(12345), pointer.size)
+
+	_, ok = parseLFSPointer([]byte("services:\n  web:\n    image: nginx\n"))
+	assert.False(t, ok)
+}
+
+func Test_lfsURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/org/repo.git/info/lfs", lfsURL("https://github.com/org/repo"))
+	assert.Equal(t, "https://github.com/org/repo.git/info/lfs", lfsURL("https://github.com/org/repo.git/"))
+}
+
+func Test_fetchLFSObjects(t *testing.T) {
+	is := assert.New(t)
+
+	content := []byte("large file content")
+	hash := sha256.Sum256(content)
+	oid := hex.EncodeToString(hash[:])
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/org/repo.git/info/lfs/objects/batch":
+			username, password, _ := r.BasicAuth()
+			is.Equal("user", username)
+			is.Equal("token", password)
+
+			w.Header().Set("Content-Type", lfsMediaType)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"objects": []map[string]interface{}{{
+					"oid":  oid,
+					"size": len(content),
+					"actions": map[string]interface{}{
+						"download": map[string]interface{}{
+							"href":   server.URL + "/objects/" + oid,
+							"header": map[string]string{"Authorization": "Bearer object-token"},
+						},
+					},
+				}},
+			})
+		case "/objects/" + oid:
+			is.Equal("Bearer object-token", r.Header.Get("Authorization"))
+			w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	pointerPath := filepath.Join(dir, "data.bin")
+	is.NoError(os.WriteFile(pointerPath, []byte(fmt.Sprintf("%s\noid sha256:%s\nsize %d\n", lfsPointerVersion, oid, len(content))), 0644))
+	is.NoError(os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services: {}\n"), 0644))
+
+	service := Service{}
+	err := service.fetchLFSObjects(context.Background(), dir, cloneOptions{repositoryUrl: server.URL + "/org/repo", username: "user", password: "token"})
+	is.NoError(err)
+
+	downloaded, err := os.ReadFile(pointerPath)
+	is.NoError(err)
+	is.Equal(content, downloaded)
+}
@@ -0,0 +1,244 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Git providers able to send push webhooks
+const (
+	WebhookProviderGitHub    = "github"
+	WebhookProviderGitLab    = "gitlab"
+	WebhookProviderGitea     = "gitea"
+	WebhookProviderBitbucket = "bitbucket"
+)
+
+var (
+	// ErrInvalidWebhookSignature is returned when the signature or the token of a webhook doesn't match the secret
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrNotPushEvent is returned when a webhook is sent for another event than a push, e.g. the ping of a new GitHub webhook
+	ErrNotPushEvent = errors.New("webhook event is not a push")
+)
+
+// pushEventMaxCommits is the number of commits after which GitHub and GitLab truncate the commits of a push payload
+const pushEventMaxCommits = 20
+
+// PushEvent represents a push sent by a Git provider webhook
+type PushEvent struct {
+	// Pushed references, e.g. refs/heads/main
+	Refs []string
+	// Files added, modified or removed by the pushed commits.
+	// Nil when the provider doesn't send the changed files or when the pushed commits may not all be listed.
+	ChangedFiles []string
+}
+
+// IsValidWebhookProvider returns true when webhooks sent by the provider can be parsed
+func IsValidWebhookProvider(provider string) bool {
+	switch provider {
+	case WebhookProviderGitHub, WebhookProviderGitLab, WebhookProviderGitea, WebhookProviderBitbucket:
+		return true
+	}
+	return false
+}
+
+// ParsePushEvent verifies the signature or the token of a webhook sent by a Git provider
+// and returns the pushed references and files.
+func ParsePushEvent(provider string, header http.Header, body []byte, secret string) (*PushEvent, error) {
+	switch provider {
+	case WebhookProviderGitHub:
+		if err := verifySignature(strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256="), body, secret); err != nil {
+			return nil, err
+		}
+		if header.Get("X-GitHub-Event") != "push" {
+			return nil, ErrNotPushEvent
+		}
+		return parseCommitsPushEvent(body)
+
+	case WebhookProviderGitea:
+		if err := verifySignature(header.Get("X-Gitea-Signature"), body, secret); err != nil {
+			return nil, err
+		}
+		if header.Get("X-Gitea-Event") != "push" {
+			return nil, ErrNotPushEvent
+		}
+		return parseCommitsPushEvent(body)
+
+	case WebhookProviderGitLab:
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return nil, ErrInvalidWebhookSignature
+		}
+		if header.Get("X-Gitlab-Event") != "Push Hook" && header.Get("X-Gitlab-Event") != "Tag Push Hook" {
+			return nil, ErrNotPushEvent
+		}
+		return parseCommitsPushEvent(body)
+
+	case WebhookProviderBitbucket:
+		if err := verifySignature(strings.TrimPrefix(header.Get("X-Hub-Signature"), "sha256="), body, secret); err != nil {
+			return nil, err
+		}
+		switch header.Get("X-Event-Key") {
+		case "repo:push", "repo:refs_changed":
+			return parseBitbucketPushEvent(body)
+		}
+		return nil, ErrNotPushEvent
+	}
+
+	return nil, errors.Errorf("unsupported webhook provider: %s", provider)
+}
+
+// verifySignature checks the hex encoded HMAC-SHA256 signature of a webhook body
+func verifySignature(signature string, body []byte, secret string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || signature == "" {
+		return ErrInvalidWebhookSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return ErrInvalidWebhookSignature
+	}
+
+	return nil
+}
+
+// parseCommitsPushEvent parses the push payload shared by GitHub, GitLab and Gitea. The changed files are unknown
+// when the push lists no commits, e.g. a new branch or a forced push, or when the list of commits may be truncated.
+func parseCommitsPushEvent(body []byte) (*PushEvent, error) {
+	var payload struct {
+		Ref string `json:"ref"`
+		// total number of pushed commits, sent by GitLab and Gitea
+		TotalCommitsCount *int `json:"total_commits_count"`
+		TotalCommits      *int `json:"total_commits"`
+		Commits           []struct {
+			Added    []string `json:"added"`
+			Modified []string `json:"modified"`
+			Removed  []string `json:"removed"`
+		} `json:"commits"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the webhook payload")
+	}
+
+	event := &PushEvent{Refs: []string{payload.Ref}}
+
+	total := payload.TotalCommitsCount
+	if total == nil {
+		total = payload.TotalCommits
+	}
+
+	switch {
+	case len(payload.Commits) == 0:
+		return event, nil
+	case total != nil && *total > len(payload.Commits):
+		return event, nil
+	case total == nil && len(payload.Commits) >= pushEventMaxCommits:
+		return event, nil
+	}
+
+	event.ChangedFiles = make([]string, 0)
+	for _, commit := range payload.Commits {
+		event.ChangedFiles = append(event.ChangedFiles, commit.Added...)
+		event.ChangedFiles = append(event.ChangedFiles, commit.Modified...)
+		event.ChangedFiles = append(event.ChangedFiles, commit.Removed...)
+	}
+
+	return event, nil
+}
+
+// parseBitbucketPushEvent parses the push payloads of Bitbucket Cloud and Bitbucket Server,
+// they don't contain the changed files
+func parseBitbucketPushEvent(body []byte) (*PushEvent, error) {
+	var payload struct {
+		// Bitbucket Cloud
+		Push struct {
+			Changes []struct {
+				New *struct {
+					Type string `json:"type"`
+					Name string `json:"name"`
+				} `json:"new"`
+			} `json:"changes"`
+		} `json:"push"`
+		// Bitbucket Server
+		Changes []struct {
+			RefID string `json:"refId"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the webhook payload")
+	}
+
+	event := &PushEvent{Refs: make([]string, 0)}
+	for _, change := range payload.Push.Changes {
+		if change.New == nil {
+			// the branch or the tag was deleted
+			continue
+		}
+
+		if change.New.Type == "tag" {
+			event.Refs = append(event.Refs, "refs/tags/"+change.New.Name)
+		} else {
+			event.Refs = append(event.Refs, "refs/heads/"+change.New.Name)
+		}
+	}
+	for _, change := range payload.Changes {
+		event.Refs = append(event.Refs, change.RefID)
+	}
+
+	return event, nil
+}
+
+// MatchesReference returns true when one of the pushed references is the reference name of a repository configuration.
+// An empty reference name matches any push as the default branch of the repository is unknown.
+func (event *PushEvent) MatchesReference(referenceName string) bool {
+	if referenceName == "" {
+		return true
+	}
+
+	for _, ref := range event.Refs {
+		if ref == referenceName || ref == "refs/heads/"+referenceName || ref == "refs/tags/"+referenceName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesTagConstraint returns true when one of the pushed references is a tag satisfying a semver constraint
+func (event *PushEvent) MatchesTagConstraint(constraint string) bool {
+	for _, ref := range event.Refs {
+		if MatchesTagConstraint(ref, constraint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ChangesAnyFile returns true when the push changes one of the files, or a file inside one of the directories,
+// given relatively to the root of the repository. It also returns true when the changed files are unknown.
+func (event *PushEvent) ChangesAnyFile(files []string) bool {
+	if event.ChangedFiles == nil {
+		return true
+	}
+
+	for _, changedFile := range event.ChangedFiles {
+		changedFile = path.Clean(changedFile)
+		for _, file := range files {
+			file = path.Clean(strings.TrimPrefix(file, "/"))
+			if changedFile == file || strings.HasPrefix(changedFile, file+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const pushPayload = `{"ref":"refs/heads/main","commits":[{"added":["docker-compose.yml"],"modified":["README.md"],"removed":[]}]}`
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func Test_ParsePushEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		header   http.Header
+		body     string
+		wantErr  error
+		wantRefs []string
+	}{
+		{
+			name:     "github push",
+			provider: WebhookProviderGitHub,
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign(pushPayload, "secret")}, "X-Github-Event": {"push"}},
+			body:     pushPayload,
+			wantRefs: []string{"refs/heads/main"},
+		},
+		{
+			name:     "github invalid signature",
+			provider: WebhookProviderGitHub,
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign(pushPayload, "other")}, "X-Github-Event": {"push"}},
+			body:     pushPayload,
+			wantErr:  ErrInvalidWebhookSignature,
+		},
+		{
+			name:     "github missing signature",
+			provider: WebhookProviderGitHub,
+			header:   http.Header{"X-Github-Event": {"push"}},
+			body:     pushPayload,
+			wantErr:  ErrInvalidWebhookSignature,
+		},
+		{
+			name:     "github ping",
+			provider: WebhookProviderGitHub,
+			header:   http.Header{"X-Hub-Signature-256": {"sha256=" + sign("{}", "secret")}, "X-Github-Event": {"ping"}},
+			body:     "{}",
+			wantErr:  ErrNotPushEvent,
+		},
+		{
+			name:     "gitea push",
+			provider: WebhookProviderGitea,
+			header:   http.Header{"X-Gitea-Signature": {sign(pushPayload, "secret")}, "X-Gitea-Event": {"push"}},
+			body:     pushPayload,
+			wantRefs: []string{"refs/heads/main"},
+		},
+		{
+			name:     "gitlab push",
+			provider: WebhookProviderGitLab,
+			header:   http.Header{"X-Gitlab-Token": {"secret"}, "X-Gitlab-Event": {"Push Hook"}},
+			body:     pushPayload,
+			wantRefs: []string{"refs/heads/main"},
+		},
+		{
+			name:     "gitlab invalid token",
+			provider: WebhookProviderGitLab,
+			header:   http.Header{"X-Gitlab-Token": {"other"}, "X-Gitlab-Event": {"Push Hook"}},
+			body:     pushPayload,
+			wantErr:  ErrInvalidWebhookSignature,
+		},
+		{
+			name:     "bitbucket cloud push",
+			provider: WebhookProviderBitbucket,
+			header:   http.Header{"X-Hub-Signature": {"sha256=" + sign(`{"push":{"changes":[{"new":{"type":"tag","name":"v1"}},{"new":null}]}}`, "secret")}, "X-Event-Key": {"repo:push"}},
+			body:     `{"push":{"changes":[{"new":{"type":"tag","name":"v1"}},{"new":null}]}}`,
+			wantRefs: []string{"refs/tags/v1"},
+		},
+		{
+			name:     "bitbucket server push",
+			provider: WebhookProviderBitbucket,
+			header:   http.Header{"X-Hub-Signature": {"sha256=" + sign(`{"changes":[{"refId":"refs/heads/main"}]}`, "secret")}, "X-Event-Key": {"repo:refs_changed"}},
+			body:     `{"changes":[{"refId":"refs/heads/main"}]}`,
+			wantRefs: []string{"refs/heads/main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParsePushEvent(tt.provider, tt.header, []byte(tt.body), "secret")
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRefs, event.Refs)
+		})
+	}
+}
+
+func Test_PushEvent_MatchesReference(t *testing.T) {
+	event := &PushEvent{Refs: []string{"refs/heads/main"}}
+
+	assert.True(t, event.MatchesReference("refs/heads/main"))
+	assert.True(t, event.MatchesReference("main"))
+	assert.True(t, event.MatchesReference(""), "an empty reference should match the default branch")
+	assert.False(t, event.MatchesReference("refs/heads/develop"))
+	assert.False(t, event.MatchesReference("refs/tags/main"))
+}
+
+func Test_PushEvent_ChangesAnyFile(t *testing.T) {
+	event := &PushEvent{ChangedFiles: []string{"docker-compose.yml", "config/app.env"}}
+
+	assert.True(t, event.ChangesAnyFile([]string{"docker-compose.yml"}))
+	assert.True(t, event.ChangesAnyFile([]string{"stack.yml", "./config/app.env"}))
+	assert.False(t, event.ChangesAnyFile([]string{"stack.yml"}))
+	assert.True(t, event.ChangesAnyFile([]string{"config"}), "a change inside a directory should match the directory")
+	assert.False(t, event.ChangesAnyFile([]string{"conf"}))
+
+	assert.True(t, (&PushEvent{}).ChangesAnyFile([]string{"stack.yml"}), "unknown changed files should always match")
+}
+
+func Test_parseCommitsPushEvent_changedFiles(t *testing.T) {
+	commits := func(count int) string {
+		list := make([]string, count)
+		for i := range list {
+			list[i] = `{"modified":["README.md"]}`
+		}
+		return "[" + strings.Join(list, ",") + "]"
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantUnknown bool
+	}{
+		{name: "all the commits are listed", body: pushPayload},
+		{name: "no commits listed", body: `{"ref":"refs/heads/main","commits":[]}`, wantUnknown: true},
+		{name: "github commits may be truncated", body: `{"ref":"refs/heads/main","commits":` + commits(pushEventMaxCommits) + `}`, wantUnknown: true},
+		{name: "gitlab commits are truncated", body: `{"ref":"refs/heads/main","total_commits_count":25,"commits":` + commits(pushEventMaxCommits) + `}`, wantUnknown: true},
+		{name: "gitea commits are truncated", body: `{"ref":"refs/heads/main","total_commits":3,"commits":` + commits(2) + `}`, wantUnknown: true},
+		{name: "gitlab commits are complete", body: `{"ref":"refs/heads/main","total_commits_count":20,"commits":` + commits(pushEventMaxCommits) + `}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := parseCommitsPushEvent([]byte(tt.body))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUnknown, event.ChangedFiles == nil)
+			assert.Equal(t, tt.wantUnknown, event.ChangesAnyFile([]string{"stack.yml"}), "the stack should be redeployed when the changed files are unknown")
+		})
+	}
+}
+
+func Test_PushEvent_MatchesTagConstraint(t *testing.T) {
+	assert.True(t, (&PushEvent{Refs: []string{"refs/tags/v1.4.3"}}).MatchesTagConstraint("~1.4"))
+	assert.False(t, (&PushEvent{Refs: []string{"refs/tags/v1.5.0"}}).MatchesTagConstraint("~1.4"))
+	assert.False(t, (&PushEvent{Refs: []string{"refs/heads/v1.4.3"}}).MatchesTagConstraint("~1.4"), "a branch should not match a tag constraint")
+}
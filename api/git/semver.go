@@ -0,0 +1,253 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/pkg/errors"
+)
+
+// ErrNoMatchingTag is returned when no tag of a repository satisfies a version constraint
+var ErrNoMatchingTag = errors.New("no tag matches the version constraint")
+
+var constraintOperatorSpaces = regexp.MustCompile(`(>=|<=|!=|>|<|=|~|\^)\s+`)
+
+type versionComparator struct {
+	operator string
+	version  semver.Version
+}
+
+// versionConstraint is a set of comparators groups, a version satisfies the constraint
+// when it satisfies all the comparators of one of the groups
+type versionConstraint struct {
+	groups [][]versionComparator
+	// pre-release versions only satisfy a constraint mentioning a pre-release version
+	allowPreRelease bool
+}
+
+// ValidateTagConstraint returns an error when a semver constraint cannot be parsed
+func ValidateTagConstraint(constraint string) error {
+	_, err := parseVersionConstraint(constraint)
+	return err
+}
+
+// ResolveTagConstraint returns the tag reference of the highest version satisfying a semver constraint,
+// e.g. refs/tags/v1.4.2 for the constraint ~1.4.
+// The constraint supports the comparisons =, !=, >, >=, <, <=, the tilde (~1.4) and caret (^1.4) ranges
+// and the wildcards (1.4.x). The comparisons separated by spaces or commas must all be satisfied,
+// the groups of comparisons separated by || are alternatives.
+// The tags that are not semantic versions, with or without a v prefix, are ignored.
+func ResolveTagConstraint(refs []string, constraint string) (string, error) {
+	c, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	var resolvedRef string
+	var resolvedVersion *semver.Version
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref, tagPrefix) {
+			continue
+		}
+
+		version, err := semver.NewVersion(strings.TrimPrefix(strings.TrimPrefix(ref, tagPrefix), "v"))
+		if err != nil {
+			continue
+		}
+
+		if !c.matches(*version) {
+			continue
+		}
+
+		if resolvedVersion == nil || resolvedVersion.LessThan(*version) {
+			resolvedRef = ref
+			resolvedVersion = version
+		}
+	}
+
+	if resolvedVersion == nil {
+		return "", ErrNoMatchingTag
+	}
+
+	return resolvedRef, nil
+}
+
+// MatchesTagConstraint returns true when the reference is a tag whose version satisfies the constraint
+func MatchesTagConstraint(ref, constraint string) bool {
+	resolved, err := ResolveTagConstraint([]string{ref}, constraint)
+	return err == nil && resolved == ref
+}
+
+func parseVersionConstraint(constraint string) (*versionConstraint, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return nil, errors.New("empty version constraint")
+	}
+
+	c := &versionConstraint{}
+	for _, group := range strings.Split(constraint, "||") {
+		terms := strings.FieldsFunc(constraintOperatorSpaces.ReplaceAllString(group, "$1"), func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(terms) == 0 {
+			return nil, errors.Errorf("invalid version constraint %q", constraint)
+		}
+
+		comparators := make([]versionComparator, 0, len(terms))
+		for _, term := range terms {
+			termComparators, preRelease, err := parseConstraintTerm(term)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "invalid version constraint %q", constraint)
+			}
+			comparators = append(comparators, termComparators...)
+			c.allowPreRelease = c.allowPreRelease || preRelease
+		}
+
+		c.groups = append(c.groups, comparators)
+	}
+
+	return c, nil
+}
+
+// parseConstraintTerm converts a term of a constraint to comparators, it also returns
+// true when the version of the term is a pre-release
+func parseConstraintTerm(term string) ([]versionComparator, bool, error) {
+	operator := ""
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, op) {
+			operator = op
+			break
+		}
+	}
+
+	version, parts, err := parsePartialVersion(strings.TrimPrefix(term, operator))
+	if err != nil {
+		return nil, false, err
+	}
+	preRelease := version.PreRelease != ""
+
+	if parts == 0 {
+		// wildcard: any version
+		if operator == "" || operator == "=" || operator == ">=" || operator == "<=" {
+			return nil, false, nil
+		}
+		return nil, false, errors.Errorf("invalid wildcard term %q", term)
+	}
+
+	// upper bound of the versions matching the given parts, e.g. 1.5.0 for 1.4
+	next := version
+	switch parts {
+	case 1:
+		next = semver.Version{Major: version.Major + 1}
+	case 2:
+		next = semver.Version{Major: version.Major, Minor: version.Minor + 1}
+	}
+
+	switch operator {
+	case "", "=":
+		if parts == 3 {
+			return []versionComparator{{"=", version}}, preRelease, nil
+		}
+		return []versionComparator{{">=", version}, {"<", next}}, preRelease, nil
+	case "!=", ">=", "<":
+		return []versionComparator{{operator, version}}, preRelease, nil
+	case ">":
+		if parts == 3 {
+			return []versionComparator{{">", version}}, preRelease, nil
+		}
+		return []versionComparator{{">=", next}}, preRelease, nil
+	case "<=":
+		if parts == 3 {
+			return []versionComparator{{"<=", version}}, preRelease, nil
+		}
+		return []versionComparator{{"<", next}}, preRelease, nil
+	case "~":
+		upper := semver.Version{Major: version.Major, Minor: version.Minor + 1}
+		if parts == 1 {
+			upper = semver.Version{Major: version.Major + 1}
+		}
+		return []versionComparator{{">=", version}, {"<", upper}}, preRelease, nil
+	case "^":
+		upper := semver.Version{Major: version.Major + 1}
+		if version.Major == 0 && parts > 1 {
+			upper = semver.Version{Minor: version.Minor + 1}
+			if version.Minor == 0 && parts == 3 {
+				upper = semver.Version{Patch: version.Patch + 1}
+			}
+		}
+		return []versionComparator{{">=", version}, {"<", upper}}, preRelease, nil
+	}
+
+	return nil, false, errors.Errorf("invalid term %q", term)
+}
+
+// parsePartialVersion parses a version whose minor and patch numbers can be omitted or replaced by a wildcard,
+// it returns the number of parts given
+func parsePartialVersion(value string) (semver.Version, int, error) {
+	value = strings.TrimPrefix(value, "v")
+
+	parts := strings.SplitN(value, ".", 3)
+	given := 0
+	for given < len(parts) && parts[given] != "x" && parts[given] != "X" && parts[given] != "*" {
+		given++
+	}
+
+	if given == 0 {
+		return semver.Version{}, 0, nil
+	}
+
+	padded := strings.Join(parts[:given], ".")
+	for i := given; i < 3; i++ {
+		padded += ".0"
+	}
+
+	version, err := semver.NewVersion(padded)
+	if err != nil {
+		return semver.Version{}, 0, err
+	}
+
+	return *version, given, nil
+}
+
+func (c *versionConstraint) matches(version semver.Version) bool {
+	if version.PreRelease != "" && !c.allowPreRelease {
+		return false
+	}
+
+	for _, group := range c.groups {
+		if matchesAll(version, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAll(version semver.Version, comparators []versionComparator) bool {
+	for _, comparator := range comparators {
+		cmp := version.Compare(comparator.version)
+
+		var ok bool
+		switch comparator.operator {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolveTagConstraint(t *testing.T) {
+	refs := []string{
+		"refs/heads/main",
+		"refs/heads/v9.0.0",
+		"refs/tags/latest",
+		"refs/tags/v0.2.1",
+		"refs/tags/v0.2.5",
+		"refs/tags/v0.3.0",
+		"refs/tags/v1.3.9",
+		"refs/tags/v1.4.0",
+		"refs/tags/v1.4.2",
+		"refs/tags/1.4.10",
+		"refs/tags/v1.5.0-rc.1",
+		"refs/tags/v1.5.0",
+		"refs/tags/v2.0.0",
+		"refs/tags/v2.7.1",
+		"refs/tags/v3.0.0",
+	}
+
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{"~1.4", "refs/tags/1.4.10"},
+		{"~1.4.2", "refs/tags/1.4.10"},
+		{"^0.2", "refs/tags/v0.2.5"},
+		{"^1.3", "refs/tags/v1.5.0"},
+		{">=2.0.0 <3", "refs/tags/v2.7.1"},
+		{">= 2.0.0, < 3", "refs/tags/v2.7.1"},
+		{"1.4.x", "refs/tags/1.4.10"},
+		{"1.x", "refs/tags/v1.5.0"},
+		{"*", "refs/tags/v3.0.0"},
+		{"1.4.0", "refs/tags/v1.4.0"},
+		{"=v2.0.0", "refs/tags/v2.0.0"},
+		{"<1.4", "refs/tags/v1.3.9"},
+		{">1.4 <2", "refs/tags/v1.5.0"},
+		{"~1.4 || ~0.3", "refs/tags/1.4.10"},
+		{"~0.2 || ~0.3", "refs/tags/v0.3.0"},
+		{">=1.5.0-rc.0 <1.5.0", "refs/tags/v1.5.0-rc.1"},
+		{"~1.5 != 1.5.0", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			ref, err := ResolveTagConstraint(refs, test.constraint)
+			if test.expected == "" {
+				assert.ErrorIs(t, err, ErrNoMatchingTag)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ref)
+		})
+	}
+}
+
+func Test_ResolveTagConstraint_excludesPreReleases(t *testing.T) {
+	ref, err := ResolveTagConstraint([]string{"refs/tags/v1.0.0", "refs/tags/v1.1.0-beta.1"}, "^1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/tags/v1.0.0", ref)
+}
+
+func Test_ValidateTagConstraint(t *testing.T) {
+	for _, constraint := range []string{"~1.4", "^0.2.1", ">=2.0.0 <3", "1.4.x", "~1.4 || ^2", "v1.2.3"} {
+		assert.NoError(t, ValidateTagConstraint(constraint), constraint)
+	}
+
+	for _, constraint := range []string{"", "latest", "~a.b", ">=1.0 ||", ">x"} {
+		assert.Error(t, ValidateTagConstraint(constraint), constraint)
+	}
+}
+
+func Test_MatchesTagConstraint(t *testing.T) {
+	assert.True(t, MatchesTagConstraint("refs/tags/v1.4.3", "~1.4"))
+	assert.False(t, MatchesTagConstraint("refs/tags/v1.5.0", "~1.4"))
+	assert.False(t, MatchesTagConstraint("refs/heads/main", "*"))
+}
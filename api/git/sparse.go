@@ -0,0 +1,166 @@
+package git
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pkg/errors"
+)
+
+// checkoutSparsePaths writes the files of the HEAD commit of a repository cloned without checkout that are
+// inside the sparse paths, the other files of the commit are never written. Like the cone mode of
+// git sparse-checkout, the files at the root of the repository and in the parent directories of the sparse
+// paths are also written.
+func checkoutSparsePaths(repo *git.Repository, dir string, sparsePaths []string) error {
+	head, err := repo.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve the HEAD of the repository")
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the HEAD commit")
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the tree of the HEAD commit")
+	}
+
+	paths := normalizeSparsePaths(sparsePaths)
+
+	return tree.Files().ForEach(func(file *object.File) error {
+		if !sparseConeIncludes(file.Name, paths) {
+			return nil
+		}
+
+		return writeTreeFile(dir, file)
+	})
+}
+
+// writeTreeFile writes a file of a commit tree in the directory, with its executable bit or as a symbolic link
+func writeTreeFile(dir string, file *object.File) error {
+	target := filepath.Join(dir, filepath.FromSlash(file.Name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	if file.Mode == filemode.Symlink {
+		link, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	}
+
+	perm := os.FileMode(0644)
+	if file.Mode == filemode.Executable {
+		perm = 0755
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// sparseCheckout removes the files of a repository tree that are outside of the sparse paths. It is used
+// when the files cannot be checked out selectively: for the archives of Azure DevOps repositories and
+// for the repositories cloned with their submodules.
+func sparseCheckout(dir string, sparsePaths []string) error {
+	paths := normalizeSparsePaths(sparsePaths)
+
+	return filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == "." {
+			return nil
+		}
+
+		if rel == ".git" {
+			return filepath.SkipDir
+		}
+
+		if info.IsDir() {
+			if isInSparsePaths(rel, paths) {
+				return filepath.SkipDir
+			}
+			if isSparsePathsParent(rel, paths) {
+				return nil
+			}
+		} else if sparseConeIncludes(rel, paths) {
+			return nil
+		}
+
+		if err := os.RemoveAll(filePath); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// normalizeSparsePaths returns the sparse paths relative to the root of the repository, with forward slashes
+func normalizeSparsePaths(sparsePaths []string) []string {
+	paths := make([]string, 0, len(sparsePaths))
+	for _, sparsePath := range sparsePaths {
+		paths = append(paths, path.Clean(strings.Trim(filepath.ToSlash(sparsePath), "/")))
+	}
+	return paths
+}
+
+// sparseConeIncludes returns true when a file is part of the sparse checkout: it is at the root of the
+// repository, inside one of the sparse paths or directly inside one of their parent directories
+func sparseConeIncludes(rel string, paths []string) bool {
+	parent := path.Dir(rel)
+	return parent == "." || isInSparsePaths(rel, paths) || isSparsePathsParent(parent, paths)
+}
+
+// isInSparsePaths returns true when the path is one of the sparse paths or is inside one of them
+func isInSparsePaths(rel string, paths []string) bool {
+	for _, p := range paths {
+		if p == "." || rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isSparsePathsParent returns true when the directory is a parent of one of the sparse paths
+func isSparsePathsParent(rel string, paths []string) bool {
+	for _, p := range paths {
+		if strings.HasPrefix(p, rel+"/") {
+			return true
+		}
+	}
+	return false
+}
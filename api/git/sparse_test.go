@@ -0,0 +1,77 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sparseCheckout(t *testing.T) {
+	is := assert.New(t)
+
+	dir := t.TempDir()
+	for _, file := range []string{
+		"README.md",
+		".git/HEAD",
+		"stacks/common.env",
+		"stacks/web/docker-compose.yml",
+		"stacks/web/config/nginx.conf",
+		"stacks/db/docker-compose.yml",
+		"docs/index.md",
+	} {
+		is.NoError(os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755))
+		is.NoError(os.WriteFile(filepath.Join(dir, file), []byte(file), 0644))
+	}
+
+	is.NoError(sparseCheckout(dir, []string{"/stacks/web/"}))
+
+	is.FileExists(filepath.Join(dir, "README.md"), "the files at the root should be kept")
+	is.FileExists(filepath.Join(dir, ".git", "HEAD"))
+	is.FileExists(filepath.Join(dir, "stacks", "common.env"), "the files of the parent directories should be kept")
+	is.FileExists(filepath.Join(dir, "stacks", "web", "docker-compose.yml"))
+	is.FileExists(filepath.Join(dir, "stacks", "web", "config", "nginx.conf"))
+	is.NoDirExists(filepath.Join(dir, "stacks", "db"))
+	is.NoDirExists(filepath.Join(dir, "docs"))
+}
+
+func Test_download_checksOutOnlyTheSparsePaths(t *testing.T) {
+	is := assert.New(t)
+
+	repoDir := t.TempDir()
+	repo, err := git.PlainInit(repoDir, false)
+	is.NoError(err)
+
+	files := []string{"README.md", "stacks/common.env", "stacks/web/docker-compose.yml", "stacks/db/docker-compose.yml", "docs/index.md"}
+	for _, file := range files {
+		is.NoError(os.MkdirAll(filepath.Dir(filepath.Join(repoDir, file)), 0755))
+		is.NoError(os.WriteFile(filepath.Join(repoDir, file), []byte(file), 0644))
+	}
+	is.NoError(os.WriteFile(filepath.Join(repoDir, "stacks", "web", "start.sh"), []byte("#!/bin/sh"), 0755))
+
+	worktree, err := repo.Worktree()
+	is.NoError(err)
+	is.NoError(worktree.AddGlob("."))
+	_, err = worktree.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@portainer.io", When: time.Now()}})
+	is.NoError(err)
+
+	dir := filepath.Join(t.TempDir(), "clone")
+	err = gitClient{}.download(context.Background(), dir, cloneOptions{repositoryUrl: repoDir, depth: 1, sparsePaths: []string{"stacks/web"}})
+	is.NoError(err)
+
+	is.FileExists(filepath.Join(dir, "README.md"))
+	is.FileExists(filepath.Join(dir, "stacks", "common.env"))
+	is.FileExists(filepath.Join(dir, "stacks", "web", "docker-compose.yml"))
+	is.NoDirExists(filepath.Join(dir, "stacks", "db"), "the files outside of the sparse paths should not be written")
+	is.NoDirExists(filepath.Join(dir, "docs"))
+	is.NoDirExists(filepath.Join(dir, ".git"))
+
+	info, err := os.Stat(filepath.Join(dir, "stacks", "web", "start.sh"))
+	is.NoError(err)
+	is.Equal(os.FileMode(0755), info.Mode().Perm(), "the executable bit should be kept")
+}
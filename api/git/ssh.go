@@ -0,0 +1,127 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const defaultSSHUser = "git"
+
+type sshOptions struct {
+	// private key in PEM format
+	privateKey string
+	// passphrase used to decrypt the private key, if it is encrypted
+	passphrase string
+	// content of a known_hosts file used to verify the server host key, required unless
+	// insecureSkipHostKeyVerification is set
+	knownHosts string
+	// accept any server host key when knownHosts is empty
+	insecureSkipHostKeyVerification bool
+}
+
+var errKnownHostsRequired = errors.New("a known_hosts content is required to verify the SSH host key of the repository")
+
+// isSSHUrl returns true when the repository is accessed over SSH,
+// for example ssh://git@gitea.local/org/repo.git or git@gitea.local:org/repo.git
+func isSSHUrl(repositoryURL string) bool {
+	endpoint, err := transport.NewEndpoint(repositoryURL)
+	if err != nil {
+		return false
+	}
+
+	return endpoint.Protocol == "ssh"
+}
+
+func getSSHAuth(repositoryURL, username string, opt sshOptions) (transport.AuthMethod, error) {
+	if opt.privateKey == "" {
+		return nil, errors.New("a private key is required to access a repository over SSH")
+	}
+
+	if username == "" {
+		username = defaultSSHUser
+		if endpoint, err := transport.NewEndpoint(repositoryURL); err == nil && endpoint.User != "" {
+			username = endpoint.User
+		}
+	}
+
+	auth, err := gitssh.NewPublicKeys(username, []byte(opt.privateKey), opt.passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the SSH private key")
+	}
+
+	if opt.knownHosts == "" {
+		if !opt.insecureSkipHostKeyVerification {
+			return nil, errKnownHostsRequired
+		}
+
+		auth.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return auth, nil
+	}
+
+	auth.HostKeyCallback, err = knownHostsCallback(opt.knownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth, nil
+}
+
+// knownHostsCallback builds a host key callback from the content of a known_hosts file.
+func knownHostsCallback(knownHosts string) (ssh.HostKeyCallback, error) {
+	file, err := ioutil.TempFile("", "portainer-known-hosts-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary known_hosts file")
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(knownHosts)
+	file.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write the temporary known_hosts file")
+	}
+
+	// knownhosts.New reads the whole file, so it can be removed right after
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid known_hosts content")
+	}
+
+	return callback, nil
+}
+
+// IsValidRepositoryURL returns true when the URL is a valid HTTP(S) or SSH repository URL.
+func IsValidRepositoryURL(repositoryURL string) bool {
+	return govalidator.IsURL(repositoryURL) || isSSHUrl(repositoryURL)
+}
+
+// ValidateSSHCredentials checks that the private key can be decoded with the passphrase
+// and that the known_hosts content is valid. The known_hosts content can only be omitted when
+// the host key verification is explicitly skipped.
+func ValidateSSHCredentials(privateKey, passphrase, knownHosts string, insecureSkipHostKeyVerification bool) error {
+	var err error
+	if passphrase != "" {
+		_, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		_, err = ssh.ParsePrivateKey([]byte(privateKey))
+	}
+	if err != nil {
+		return errors.Wrap(err, "invalid SSH private key")
+	}
+
+	if knownHosts == "" {
+		if !insecureSkipHostKeyVerification {
+			return errKnownHostsRequired
+		}
+		return nil
+	}
+
+	_, err = knownHostsCallback(knownHosts)
+	return err
+}
@@ -0,0 +1,146 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func generatePrivateKey(t *testing.T, passphrase string) (string, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate a private key: %v", err)
+	}
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if passphrase != "" {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatalf("failed to encrypt the private key: %v", err)
+		}
+	}
+
+	return string(pem.EncodeToMemory(block)), key
+}
+
+func Test_isSSHUrl(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"ssh://git@gitea.local:2222/org/repo.git", true},
+		{"git@gitea.local:org/repo.git", true},
+		{"https://gitea.local/org/repo.git", false},
+		{"http://gitea.local/org/repo.git", false},
+		{"/tmp/repo.git", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isSSHUrl(tt.url), tt.url)
+	}
+}
+
+func Test_IsValidRepositoryURL(t *testing.T) {
+	assert.True(t, IsValidRepositoryURL("https://github.com/portainer/portainer.git"))
+	assert.True(t, IsValidRepositoryURL("git@gitea.local:org/repo.git"))
+	assert.True(t, IsValidRepositoryURL("ssh://gitea.local/org/repo"))
+	assert.False(t, IsValidRepositoryURL("not a url"))
+}
+
+func Test_getAuth_shouldUseBasicAuthForHttpUrls(t *testing.T) {
+	auth, err := getAuth("https://gitea.local/org/repo.git", "", "token", sshOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "token", Password: "token"}, auth)
+
+	auth, err = getAuth("https://gitea.local/org/repo.git", "", "", sshOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+}
+
+func Test_getAuth_shouldUsePublicKeysForSSHUrls(t *testing.T) {
+	privateKey, _ := generatePrivateKey(t, "")
+
+	auth, err := getAuth("ssh://deploy@gitea.local/org/repo.git", "", "", sshOptions{privateKey: privateKey, insecureSkipHostKeyVerification: true})
+	assert.NoError(t, err)
+
+	publicKeys, ok := auth.(*gitssh.PublicKeys)
+	assert.True(t, ok, "expected SSH public keys authentication, got %T", auth)
+	assert.Equal(t, "deploy", publicKeys.User, "the user of the URL should be used")
+
+	auth, err = getAuth("git@gitea.local:org/repo.git", "", "", sshOptions{privateKey: privateKey, insecureSkipHostKeyVerification: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "git", auth.(*gitssh.PublicKeys).User)
+
+	auth, err = getAuth("git@gitea.local:org/repo.git", "custom", "", sshOptions{privateKey: privateKey, insecureSkipHostKeyVerification: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", auth.(*gitssh.PublicKeys).User, "the username of the credentials should take precedence")
+}
+
+func Test_getAuth_shouldFailWithoutPrivateKeyForSSHUrls(t *testing.T) {
+	_, err := getAuth("git@gitea.local:org/repo.git", "", "password", sshOptions{})
+	assert.Error(t, err)
+}
+
+func Test_getAuth_shouldDecryptPrivateKeyWithPassphrase(t *testing.T) {
+	privateKey, _ := generatePrivateKey(t, "secret")
+
+	_, err := getAuth("git@gitea.local:org/repo.git", "", "", sshOptions{privateKey: privateKey, passphrase: "secret", insecureSkipHostKeyVerification: true})
+	assert.NoError(t, err)
+
+	_, err = getAuth("git@gitea.local:org/repo.git", "", "", sshOptions{privateKey: privateKey, passphrase: "wrong", insecureSkipHostKeyVerification: true})
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateSSHCredentials(privateKey, "secret", "", true))
+	assert.Error(t, ValidateSSHCredentials(privateKey, "", "", true))
+	assert.Error(t, ValidateSSHCredentials("not a key", "", "", true))
+}
+
+func Test_getAuth_shouldRequireKnownHostsUnlessSkipped(t *testing.T) {
+	privateKey, _ := generatePrivateKey(t, "")
+
+	_, err := getAuth("git@gitea.local:org/repo.git", "", "", sshOptions{privateKey: privateKey})
+	assert.ErrorIs(t, err, errKnownHostsRequired)
+	assert.ErrorIs(t, ValidateSSHCredentials(privateKey, "", "", false), errKnownHostsRequired)
+
+	auth, err := getAuth("git@gitea.local:org/repo.git", "", "", sshOptions{privateKey: privateKey, insecureSkipHostKeyVerification: true})
+	assert.NoError(t, err)
+
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	_, hostKey := generatePrivateKey(t, "")
+	hostPublicKey, err := ssh.NewPublicKey(&hostKey.PublicKey)
+	assert.NoError(t, err)
+	assert.NoError(t, auth.(*gitssh.PublicKeys).HostKeyCallback("gitea.local:22", remote, hostPublicKey))
+}
+
+func Test_getAuth_shouldVerifyHostKeyWithKnownHosts(t *testing.T) {
+	privateKey, _ := generatePrivateKey(t, "")
+	_, hostKey := generatePrivateKey(t, "")
+	_, otherHostKey := generatePrivateKey(t, "")
+
+	hostPublicKey, err := ssh.NewPublicKey(&hostKey.PublicKey)
+	assert.NoError(t, err)
+	otherHostPublicKey, err := ssh.NewPublicKey(&otherHostKey.PublicKey)
+	assert.NoError(t, err)
+
+	knownHosts := knownhosts.Line([]string{"gitea.local"}, hostPublicKey)
+	assert.NoError(t, ValidateSSHCredentials(privateKey, "", knownHosts, false))
+	assert.Error(t, ValidateSSHCredentials(privateKey, "", "gitea.local not-a-key", false))
+
+	auth, err := getAuth("git@gitea.local:org/repo.git", "", "", sshOptions{privateKey: privateKey, knownHosts: knownHosts})
+	assert.NoError(t, err)
+	callback := auth.(*gitssh.PublicKeys).HostKeyCallback
+
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	assert.NoError(t, callback("gitea.local:22", remote, hostPublicKey))
+	assert.Error(t, callback("gitea.local:22", remote, otherHostPublicKey), "an unknown host key should be rejected")
+	assert.Error(t, callback("other.local:22", remote, hostPublicKey), "an unknown host should be rejected")
+}
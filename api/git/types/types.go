@@ -12,9 +12,41 @@ type RepoConfig struct {
 	Authentication *GitAuthentication
 	// Repository hash
 	ConfigHash string `example:"bc4c183d756879ea4d173315338110b31004b8e0"`
+	// Options used to clone the repository
+	CloneOptions *CloneOptions `json:",omitempty"`
+	// Semver constraint over the tags of the repository, e.g. ~1.4 or >=2.0.0 <3.
+	// When set, ReferenceName is the tag of the highest version satisfying the constraint
+	TagConstraint string `json:",omitempty" example:"~1.4"`
+}
+
+// CloneOptions represents the options used to clone a repository
+type CloneOptions struct {
+	// Clone the submodules of the repository, recursively
+	RecurseSubmodules bool `example:"false"`
+	// Replace the Git LFS pointers by the content of the files, only for HTTP(S) repositories
+	LFS bool `example:"false"`
+	// Directories to checkout, the whole repository is checked out when empty.
+	// Like the cone mode of git sparse-checkout, the files at the root of the repository
+	// and in the parent directories of the paths are always checked out.
+	// Only the files of the tree are sparse, the objects of the fetched commits are still downloaded
+	// as partial clones are not supported. With the submodules or an Azure DevOps repository, the whole
+	// tree is checked out before the files outside of the paths are removed
+	SparsePaths []string `example:"stacks/web"`
+	// Number of commits to fetch, defaults to 1
+	Depth int `example:"1"`
 }
 
 type GitAuthentication struct {
 	Username string
 	Password string
+	// SSH private key in PEM format, used when the repository URL is an SSH URL
+	SSHPrivateKey string `json:",omitempty"`
+	// Passphrase of the SSH private key
+	SSHPassphrase string `json:",omitempty"`
+	// Content of a known_hosts file used to verify the SSH host key
+	SSHKnownHosts string `json:",omitempty"`
+	// Accept any SSH host key when no known_hosts content is specified
+	InsecureSkipHostKeyVerification bool `json:",omitempty"`
+	// Identifier of the stored Git credential to use instead of the inline credentials
+	GitCredentialID int `json:",omitempty" example:"1"`
 }
@@ -0,0 +1,185 @@
+package auditlogs
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+type auditLogListQuery struct {
+	userID       portainer.UserID
+	username     string
+	endpointID   portainer.EndpointID
+	method       string
+	resourceType string
+	outcome      portainer.AuditLogOutcome
+	from         int64
+	to           int64
+}
+
+// @id AuditLogList
+// @summary List audit logs
+// @description List the audit log entries, most recent first.
+// @description **Access policy**: administrator
+// @tags audit_logs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param start query int false "Start searching from"
+// @param limit query int false "Limit results to this value"
+// @param userId query int false "Only return entries of this user"
+// @param username query string false "Only return entries of this username"
+// @param endpointId query int false "Only return entries targeting this environment(endpoint)"
+// @param method query string false "Only return entries with this HTTP method"
+// @param resourceType query string false "Only return entries targeting this resource type"
+// @param outcome query string false "Only return entries with this outcome" Enum("success", "failure")
+// @param from query int false "Only return entries recorded at or after this unix timestamp"
+// @param to query int false "Only return entries recorded at or before this unix timestamp"
+// @success 200 {array} portainer.AuditLog "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /audit-logs [get]
+func (handler *Handler) auditLogList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	start, _ := request.RetrieveNumericQueryParameter(r, "start", true)
+	if start != 0 {
+		start--
+	}
+
+	limit, _ := request.RetrieveNumericQueryParameter(r, "limit", true)
+
+	query, err := parseQuery(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameters", err)
+	}
+
+	logs, err := handler.DataStore.AuditLog().AuditLogs()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve audit logs from the database", err)
+	}
+
+	filteredLogs := filterAuditLogs(logs, query)
+
+	sort.SliceStable(filteredLogs, func(i, j int) bool {
+		if filteredLogs[i].Timestamp == filteredLogs[j].Timestamp {
+			return filteredLogs[i].ID > filteredLogs[j].ID
+		}
+		return filteredLogs[i].Timestamp > filteredLogs[j].Timestamp
+	})
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(filteredLogs)))
+	return response.JSON(w, paginateAuditLogs(filteredLogs, start, limit))
+}
+
+func parseQuery(r *http.Request) (auditLogListQuery, error) {
+	userID, err := request.RetrieveNumericQueryParameter(r, "userId", true)
+	if err != nil {
+		return auditLogListQuery{}, err
+	}
+
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return auditLogListQuery{}, err
+	}
+
+	from, err := retrieveTimestampQueryParameter(r, "from")
+	if err != nil {
+		return auditLogListQuery{}, err
+	}
+
+	to, err := retrieveTimestampQueryParameter(r, "to")
+	if err != nil {
+		return auditLogListQuery{}, err
+	}
+
+	username, _ := request.RetrieveQueryParameter(r, "username", true)
+	method, _ := request.RetrieveQueryParameter(r, "method", true)
+	resourceType, _ := request.RetrieveQueryParameter(r, "resourceType", true)
+	outcome, _ := request.RetrieveQueryParameter(r, "outcome", true)
+
+	return auditLogListQuery{
+		userID:       portainer.UserID(userID),
+		username:     username,
+		endpointID:   portainer.EndpointID(endpointID),
+		method:       strings.ToUpper(method),
+		resourceType: resourceType,
+		outcome:      portainer.AuditLogOutcome(outcome),
+		from:         from,
+		to:           to,
+	}, nil
+}
+
+func retrieveTimestampQueryParameter(r *http.Request, name string) (int64, error) {
+	value, _ := request.RetrieveQueryParameter(r, name, true)
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func filterAuditLogs(logs []portainer.AuditLog, query auditLogListQuery) []portainer.AuditLog {
+	filteredLogs := make([]portainer.AuditLog, 0, len(logs))
+
+	for _, entry := range logs {
+		if query.userID != 0 && entry.UserID != query.userID {
+			continue
+		}
+
+		if query.username != "" && !strings.EqualFold(entry.Username, query.username) {
+			continue
+		}
+
+		if query.endpointID != 0 && entry.EndpointID != query.endpointID {
+			continue
+		}
+
+		if query.method != "" && entry.Method != query.method {
+			continue
+		}
+
+		if query.resourceType != "" && entry.ResourceType != query.resourceType {
+			continue
+		}
+
+		if query.outcome != "" && entry.Outcome != query.outcome {
+			continue
+		}
+
+		if query.from != 0 && entry.Timestamp < query.from {
+			continue
+		}
+
+		if query.to != 0 && entry.Timestamp > query.to {
+			continue
+		}
+
+		filteredLogs = append(filteredLogs, entry)
+	}
+
+	return filteredLogs
+}
+
+func paginateAuditLogs(logs []portainer.AuditLog, start, limit int) []portainer.AuditLog {
+	if limit == 0 {
+		return logs
+	}
+
+	logCount := len(logs)
+
+	if start > logCount {
+		start = logCount
+	}
+
+	end := start + limit
+	if end > logCount {
+		end = logCount
+	}
+
+	return logs[start:end]
+}
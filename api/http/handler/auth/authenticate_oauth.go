@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"regexp"
 
 	"github.com/asaskevich/govalidator"
 	httperror "github.com/portainer/libhttp/error"
@@ -15,6 +16,8 @@ import (
 type oauthPayload struct {
 	// OAuth code returned from OAuth Provided
 	Code string
+	// Nonce sent in the authorization request, required with an OpenID Connect provider
+	Nonce string
 }
 
 func (payload *oauthPayload) Validate(r *http.Request) error {
@@ -24,21 +27,21 @@ func (payload *oauthPayload) Validate(r *http.Request) error {
 	return nil
 }
 
-func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuthSettings) (string, error) {
+func (handler *Handler) authenticateOAuth(code, nonce string, settings *portainer.OAuthSettings) (*portainer.OAuthInfo, error) {
 	if code == "" {
-		return "", errors.New("Invalid OAuth authorization code")
+		return nil, errors.New("Invalid OAuth authorization code")
 	}
 
 	if settings == nil {
-		return "", errors.New("Invalid OAuth configuration")
+		return nil, errors.New("Invalid OAuth configuration")
 	}
 
-	username, err := handler.OAuthService.Authenticate(code, settings)
+	info, err := handler.OAuthService.Authenticate(code, nonce, settings)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return username, nil
+	return info, nil
 }
 
 // @id ValidateOAuth
@@ -69,24 +72,28 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "OAuth authentication is not enabled", Err: errors.New("OAuth authentication is not enabled")}
 	}
 
-	username, err := handler.authenticateOAuth(payload.Code, &settings.OAuthSettings)
+	info, err := handler.authenticateOAuth(payload.Code, payload.Nonce, &settings.OAuthSettings)
 	if err != nil {
 		log.Printf("[DEBUG] - OAuth authentication error: %s", err)
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to authenticate through OAuth", Err: httperrors.ErrUnauthorized}
 	}
 
-	user, err := handler.DataStore.User().UserByUsername(username)
+	user, err := handler.DataStore.User().UserByUsername(info.Username)
 	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve a user with the specified username from the database", Err: err}
 	}
 
+	if user != nil && user.Disabled {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "User account is disabled", Err: httperrors.ErrUnauthorized}
+	}
+
 	if user == nil && !settings.OAuthSettings.OAuthAutoCreateUsers {
 		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Account not created beforehand in Portainer and automatic user provisioning not enabled", Err: httperrors.ErrUnauthorized}
 	}
 
 	if user == nil {
 		user = &portainer.User{
-			Username: username,
+			Username: info.Username,
 			Role:     portainer.StandardUserRole,
 		}
 
@@ -95,7 +102,7 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user inside the database", Err: err}
 		}
 
-		if settings.OAuthSettings.DefaultTeamID != 0 {
+		if settings.OAuthSettings.DefaultTeamID != 0 && !settings.OAuthSettings.OAuthAutoMapTeamMemberships {
 			membership := &portainer.TeamMembership{
 				UserID: user.ID,
 				TeamID: settings.OAuthSettings.DefaultTeamID,
@@ -110,5 +117,119 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 
 	}
 
-	return handler.writeToken(w, user, false)
+	if settings.OAuthSettings.OAuthAutoMapTeamMemberships {
+		err = handler.reconcileOAuthUser(user, info.Groups, &settings.OAuthSettings)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to update the team memberships of the user", Err: err}
+		}
+	}
+
+	return handler.writeToken(w, r, user, noPasswordChange)
+}
+
+// reconcileOAuthUser updates the role and the team memberships of the user according to its groups.
+// The user is member of the teams of the matching claim mappings, or of the default team when none matches,
+// and removed from the other teams referenced by the mappings. The memberships of the teams that are not
+// referenced by the settings are left untouched. When enabled, the administrator role is granted to the
+// members of the administrator groups and revoked from the others.
+func (handler *Handler) reconcileOAuthUser(user *portainer.User, groups []string, settings *portainer.OAuthSettings) error {
+	mappings := settings.TeamMemberships
+
+	if mappings.AdminAutoPopulate && !isUserInitialAdmin(user) {
+		role := portainer.StandardUserRole
+		if matchesAnyGroup(mappings.AdminGroupClaimsRegexList, groups) {
+			role = portainer.AdministratorRole
+		}
+
+		if user.Role != role {
+			user.Role = role
+
+			err := handler.DataStore.User().UpdateUser(user.ID, user)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	managedTeams := map[portainer.TeamID]bool{}
+	expectedTeams := map[portainer.TeamID]bool{}
+	for _, mapping := range mappings.OAuthClaimMappings {
+		managedTeams[mapping.Team] = true
+
+		if matchesAnyGroup([]string{mapping.ClaimValRegex}, groups) {
+			expectedTeams[mapping.Team] = true
+		}
+	}
+
+	if settings.DefaultTeamID != 0 {
+		managedTeams[settings.DefaultTeamID] = true
+
+		if len(expectedTeams) == 0 {
+			expectedTeams[settings.DefaultTeamID] = true
+		}
+	}
+
+	memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, membership := range memberships {
+		if !managedTeams[membership.TeamID] {
+			continue
+		}
+
+		if expectedTeams[membership.TeamID] {
+			delete(expectedTeams, membership.TeamID)
+			continue
+		}
+
+		err := handler.DataStore.TeamMembership().DeleteTeamMembership(membership.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for teamID := range expectedTeams {
+		_, err := handler.DataStore.Team().Team(teamID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			log.Printf("[WARN] [http,auth,oauth] [message: the team %d of the OAuth settings does not exist]", teamID)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		membership := &portainer.TeamMembership{
+			UserID: user.ID,
+			TeamID: teamID,
+			Role:   portainer.TeamMember,
+		}
+
+		err = handler.DataStore.TeamMembership().Create(membership)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchesAnyGroup returns true when one of the groups matches one of the regular expressions.
+// The expressions are anchored and must match the whole group, "admin" does not match "not-admin".
+// Invalid expressions, which are rejected when the settings are saved, never match.
+func matchesAnyGroup(expressions []string, groups []string) bool {
+	for _, expression := range expressions {
+		re, err := regexp.Compile("^(?:" + expression + ")$")
+		if err != nil {
+			continue
+		}
+
+		for _, group := range groups {
+			if re.MatchString(group) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_reconcileOAuthUser(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	user := &portainer.User{Username: "jane", Role: portainer.StandardUserRole}
+	for _, u := range []*portainer.User{admin, user} {
+		is.NoError(store.User().Create(u))
+	}
+
+	developers := &portainer.Team{Name: "developers"}
+	operators := &portainer.Team{Name: "operators"}
+	guests := &portainer.Team{Name: "guests"}
+	manual := &portainer.Team{Name: "manual"}
+	for _, team := range []*portainer.Team{developers, operators, guests, manual} {
+		is.NoError(store.Team().Create(team))
+	}
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: user.ID, TeamID: manual.ID, Role: portainer.TeamMember}))
+
+	settings := &portainer.OAuthSettings{
+		DefaultTeamID:               guests.ID,
+		OAuthAutoMapTeamMemberships: true,
+		TeamMemberships: portainer.OAuthTeamMemberships{
+			OAuthClaimName: "groups",
+			OAuthClaimMappings: []portainer.OAuthClaimMapping{
+				{ClaimValRegex: "dev-.*", Team: developers.ID},
+				{ClaimValRegex: "^ops$", Team: operators.ID},
+			},
+			AdminAutoPopulate:         true,
+			AdminGroupClaimsRegexList: []string{"^portainer-admins$"},
+		},
+	}
+
+	handler := &Handler{DataStore: store}
+
+	userTeams := func() []portainer.TeamID {
+		memberships, err := store.TeamMembership().TeamMembershipsByUserID(user.ID)
+		is.NoError(err)
+
+		teams := []portainer.TeamID{}
+		for _, membership := range memberships {
+			teams = append(teams, membership.TeamID)
+		}
+		return teams
+	}
+
+	t.Run("the user is added to the teams of the matching mappings and granted the administrator role", func(t *testing.T) {
+		err := handler.reconcileOAuthUser(user, []string{"dev-frontend", "ops", "portainer-admins"}, settings)
+		is.NoError(err)
+
+		is.ElementsMatch([]portainer.TeamID{developers.ID, operators.ID, manual.ID}, userTeams())
+
+		stored, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.Equal(portainer.AdministratorRole, stored.Role)
+	})
+
+	t.Run("the user is removed from the teams of the groups it left and loses the administrator role", func(t *testing.T) {
+		err := handler.reconcileOAuthUser(user, []string{"dev-backend"}, settings)
+		is.NoError(err)
+
+		is.ElementsMatch([]portainer.TeamID{developers.ID, manual.ID}, userTeams())
+
+		stored, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.Equal(portainer.StandardUserRole, stored.Role)
+	})
+
+	t.Run("the user is added to the default team when no mapping matches", func(t *testing.T) {
+		err := handler.reconcileOAuthUser(user, []string{"sales"}, settings)
+		is.NoError(err)
+
+		is.ElementsMatch([]portainer.TeamID{guests.ID, manual.ID}, userTeams())
+	})
+
+	t.Run("the initial administrator keeps its role", func(t *testing.T) {
+		err := handler.reconcileOAuthUser(admin, []string{}, settings)
+		is.NoError(err)
+
+		stored, err := store.User().User(admin.ID)
+		is.NoError(err)
+		is.Equal(portainer.AdministratorRole, stored.Role)
+	})
+}
+
+func Test_matchesAnyGroup(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(matchesAnyGroup([]string{"admins"}, []string{"users", "admins"}))
+	is.True(matchesAnyGroup([]string{"dev-.*"}, []string{"dev-frontend"}))
+	is.True(matchesAnyGroup([]string{"^ops$"}, []string{"ops"}))
+	is.True(matchesAnyGroup([]string{"ops|admins"}, []string{"admins"}))
+
+	is.False(matchesAnyGroup([]string{"admins"}, []string{"not-admins", "admins-readonly"}), "the expressions must match the whole group")
+	is.False(matchesAnyGroup([]string{"ops|admins"}, []string{"devops"}), "the alternations must be anchored too")
+	is.False(matchesAnyGroup([]string{"("}, []string{"("}), "invalid expressions never match")
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+)
+
+// samlCodeTimeout is the time the frontend has to exchange the code received after a SAML authentication
+const samlCodeTimeout = time.Minute
+
+type samlPayload struct {
+	// Code received by the frontend after the SAML authentication
+	Code string
+}
+
+func (payload *samlPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Code) {
+		return errors.New("Invalid SAML authentication code")
+	}
+	return nil
+}
+
+// samlCodes holds the one-time codes handed to the frontend after a SAML authentication. The identity provider
+// posts its response to the API through the browser, the frontend then exchanges the code for a token.
+type samlCodes struct {
+	mu    sync.Mutex
+	codes map[string]samlCode
+}
+
+type samlCode struct {
+	userID    portainer.UserID
+	expiresAt time.Time
+}
+
+func newSAMLCodes() *samlCodes {
+	return &samlCodes{codes: map[string]samlCode{}}
+}
+
+func (c *samlCodes) add(userID portainer.UserID) (string, error) {
+	code, err := generateCode()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, value := range c.codes {
+		if now.After(value.expiresAt) {
+			delete(c.codes, key)
+		}
+	}
+
+	c.codes[code] = samlCode{userID: userID, expiresAt: now.Add(samlCodeTimeout)}
+
+	return code, nil
+}
+
+// consume returns the user authenticated with the code, a code can only be used once
+func (c *samlCodes) consume(code string) (portainer.UserID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.codes[code]
+	if !ok {
+		return 0, false
+	}
+	delete(c.codes, code)
+
+	return value.userID, time.Now().Before(value.expiresAt)
+}
+
+func (handler *Handler) samlSettings() (*portainer.Settings, *httperror.HandlerError) {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve settings from the database", Err: err}
+	}
+
+	if settings.AuthenticationMethod != portainer.AuthenticationSAML {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "SAML authentication is not enabled", Err: errors.New("SAML authentication is not enabled")}
+	}
+
+	return settings, nil
+}
+
+// @id SAMLLogin
+// @summary Authenticate with SAML
+// @description Redirect to the identity provider with a signed authentication request.
+// @description **Access policy**: public
+// @tags auth
+// @success 302 "Redirect to the identity provider"
+// @failure 403 "SAML authentication is not enabled"
+// @failure 500 "Server error"
+// @router /auth/saml/login [get]
+func (handler *Handler) samlLogin(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, handlerErr := handler.samlSettings()
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	requestURL, err := handler.SAMLService.AuthenticationRequestURL(&settings.SAMLSettings)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to create the SAML authentication request", Err: err}
+	}
+
+	http.Redirect(w, r, requestURL, http.StatusFound)
+	return nil
+}
+
+// @id SAMLMetadata
+// @summary Retrieve the SAML service provider metadata
+// @description Retrieve the metadata used to register Portainer in the identity provider.
+// @description **Access policy**: public
+// @tags auth
+// @produce xml
+// @success 200 "Success"
+// @failure 404 "SAML is not configured"
+// @failure 500 "Server error"
+// @router /auth/saml/metadata [get]
+func (handler *Handler) samlMetadata(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve settings from the database", Err: err}
+	}
+
+	if settings.SAMLSettings.SPCertificate == "" {
+		return &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "SAML is not configured", Err: errors.New("SAML is not configured")}
+	}
+
+	metadata, err := handler.SAMLService.Metadata(&settings.SAMLSettings)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to generate the SAML metadata", Err: err}
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.Write(metadata)
+	return nil
+}
+
+// @id SAMLAssertionConsumerService
+// @summary Receive the SAML response of the identity provider
+// @description Validate the response posted by the identity provider, create the user when enabled and add it to the teams named after its groups.
+// @description The browser is then redirected to the frontend with a one-time code to exchange for a token.
+// @description **Access policy**: public
+// @tags auth
+// @accept x-www-form-urlencoded
+// @param SAMLResponse formData string true "Base64 encoded SAML response"
+// @success 303 "Redirect to the frontend"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid SAML response"
+// @failure 403 "SAML authentication is not enabled or the user is not allowed to login"
+// @failure 500 "Server error"
+// @router /auth/saml/acs [post]
+func (handler *Handler) samlAssertionConsumerService(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	samlResponse, err := request.RetrieveMultiPartFormValue(r, "SAMLResponse", false)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid SAML response", Err: err}
+	}
+
+	settings, handlerErr := handler.samlSettings()
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	info, err := handler.SAMLService.Authenticate(samlResponse, &settings.SAMLSettings)
+	if err != nil {
+		log.Printf("[DEBUG] - SAML authentication error: %s", err)
+		return &httperror.HandlerError{StatusCode: http.StatusUnauthorized, Message: "Unable to authenticate through SAML", Err: httperrors.ErrUnauthorized}
+	}
+
+	user, err := handler.DataStore.User().UserByUsername(info.Username)
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve a user with the specified username from the database", Err: err}
+	}
+
+	if user != nil && user.Disabled {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "User account is disabled", Err: httperrors.ErrUnauthorized}
+	}
+
+	if user == nil && !settings.SAMLSettings.AutoCreateUsers {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Account not created beforehand in Portainer and automatic user provisioning not enabled", Err: httperrors.ErrUnauthorized}
+	}
+
+	if user == nil {
+		user = &portainer.User{
+			Username: info.Username,
+			Role:     portainer.StandardUserRole,
+		}
+
+		err = handler.DataStore.User().Create(user)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user inside the database", Err: err}
+		}
+
+		if settings.SAMLSettings.DefaultTeamID != 0 {
+			membership := &portainer.TeamMembership{
+				UserID: user.ID,
+				TeamID: settings.SAMLSettings.DefaultTeamID,
+				Role:   portainer.TeamMember,
+			}
+
+			err = handler.DataStore.TeamMembership().Create(membership)
+			if err != nil {
+				return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist team membership inside the database", Err: err}
+			}
+		}
+	}
+
+	err = handler.addUserIntoGroupTeams(user, info.Groups)
+	if err != nil {
+		log.Printf("Warning: unable to automatically add user into teams: %s\n", err.Error())
+	}
+
+	code, err := handler.samlCodes.add(user.ID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to generate the SAML authentication code", Err: err}
+	}
+
+	http.Redirect(w, r, strings.TrimSuffix(settings.SAMLSettings.RootURL, "/")+"/#!/auth?samlCode="+code, http.StatusSeeOther)
+	return nil
+}
+
+// @id ValidateSAML
+// @summary Authenticate with the code received after a SAML authentication
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body samlPayload true "Code received after the SAML authentication"
+// @success 200 {object} authenticateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 422 "Invalid code"
+// @failure 500 "Server error"
+// @router /auth/saml/validate [post]
+func (handler *Handler) validateSAML(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload samlPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	userID, ok := handler.samlCodes.consume(payload.Code)
+	if !ok {
+		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid SAML authentication code", Err: httperrors.ErrUnauthorized}
+	}
+
+	user, err := handler.DataStore.User().User(userID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve a user with the specified identifier inside the database", Err: err}
+	}
+
+	if user.Disabled {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "User account is disabled", Err: httperrors.ErrUnauthorized}
+	}
+
+	return handler.writeToken(w, r, user, noPasswordChange)
+}
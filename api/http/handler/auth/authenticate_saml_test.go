@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/saml"
+	"github.com/portainer/portainer/api/saml/samltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_samlAuthentication(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	developers := &portainer.Team{Name: "developers"}
+	guests := &portainer.Team{Name: "guests"}
+	for _, team := range []*portainer.Team{developers, guests} {
+		is.NoError(store.Team().Create(team))
+	}
+
+	idp, err := samltest.NewIdentityProvider("https://idp.example.org", "https://idp.example.org/sso")
+	is.NoError(err)
+
+	certificate, key, err := saml.GenerateKeyPair("portainer")
+	is.NoError(err)
+
+	rootURL := "https://portainer.example.org"
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.AuthenticationMethod = portainer.AuthenticationSAML
+	settings.SAMLSettings = portainer.SAMLSettings{
+		IdPMetadata:     idp.Metadata(),
+		RootURL:         rootURL,
+		EntityID:        rootURL + saml.MetadataPath,
+		GroupsAttribute: "groups",
+		AutoCreateUsers: true,
+		DefaultTeamID:   guests.ID,
+		SPCertificate:   certificate,
+		SPPrivateKey:    key,
+	}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, security.NewLoginThrottler(), passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+	h.SAMLService = saml.NewService()
+
+	validate := func(code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/saml/validate", strings.NewReader(`{"Code":"`+code+`"}`))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("the metadata of the service provider is served", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/saml/metadata", nil))
+
+		is.Equal(http.StatusOK, rr.Code)
+		is.Contains(rr.Body.String(), rootURL+saml.AssertionConsumerServicePath)
+	})
+
+	t.Run("the user is created and authenticated with the code received after the SAML authentication", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/saml/login", nil))
+		is.Equal(http.StatusFound, rr.Code)
+
+		requestID, err := idp.RequestID(rr.Header().Get("Location"), certificate)
+		is.NoError(err)
+
+		response, err := idp.Encode(samltest.Response{
+			InResponseTo:  requestID,
+			Destination:   rootURL + saml.AssertionConsumerServicePath,
+			Audience:      rootURL + saml.MetadataPath,
+			NameID:        "alice",
+			Attributes:    map[string][]string{"groups": {"Developers"}},
+			SignAssertion: true,
+		})
+		is.NoError(err)
+
+		form := url.Values{"SAMLResponse": {response}}
+		req := httptest.NewRequest(http.MethodPost, "/auth/saml/acs", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		is.Equal(http.StatusSeeOther, rr.Code)
+
+		location := rr.Header().Get("Location")
+		is.True(strings.HasPrefix(location, rootURL+"/#!/auth?samlCode="))
+		code := strings.TrimPrefix(location, rootURL+"/#!/auth?samlCode=")
+
+		rr = validate(code)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var token authenticateResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&token))
+		tokenData, err := jwtService.ParseAndVerifyToken(token.JWT)
+		is.NoError(err)
+		is.Equal("alice", tokenData.Username)
+
+		memberships, err := store.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+		is.NoError(err)
+		teams := []portainer.TeamID{}
+		for _, membership := range memberships {
+			teams = append(teams, membership.TeamID)
+		}
+		is.ElementsMatch([]portainer.TeamID{developers.ID, guests.ID}, teams)
+
+		is.Equal(http.StatusUnprocessableEntity, validate(code).Code)
+	})
+
+	t.Run("invalid SAML responses are rejected", func(t *testing.T) {
+		form := url.Values{"SAMLResponse": {"PHNhbWxwOlJlc3BvbnNlLz4="}}
+		req := httptest.NewRequest(http.MethodPost, "/auth/saml/acs", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_authenticateDisabledUser(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	cryptoService := &crypto.Service{}
+	password, err := cryptoService.Hash("Passw0rd!Passw0rd!")
+	is.NoError(err)
+
+	admin := &portainer.User{Username: "admin", Password: password, Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+	user := &portainer.User{Username: "alice", Password: password, Role: portainer.StandardUserRole, Disabled: true}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(100, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, security.NewLoginThrottler(), passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+	h.CryptoService = cryptoService
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(`{"Username":"`+username+`","Password":"`+password+`"}`)))
+		return rr
+	}
+
+	t.Run("a disabled account cannot be told apart from invalid credentials", func(t *testing.T) {
+		unknown := login("bob", "wrong")
+		wrongPassword := login("alice", "wrong")
+		validPassword := login("alice", "Passw0rd!Passw0rd!")
+
+		is.Equal(http.StatusUnprocessableEntity, unknown.Code)
+		is.Equal(unknown.Code, wrongPassword.Code)
+		is.Equal(unknown.Code, validPassword.Code)
+		is.Equal(unknown.Body.String(), wrongPassword.Body.String())
+		is.Equal(unknown.Body.String(), validPassword.Body.String())
+	})
+}
+
+func Test_authenticatePasswordChange(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings.MaxPasswordAge = 90
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	cryptoService := &crypto.Service{}
+	strongPassword, err := cryptoService.Hash("Passw0rd!Passw0rd!")
+	is.NoError(err)
+	weakPassword, err := cryptoService.Hash("weak")
+	is.NoError(err)
+
+	now := time.Now()
+	is.NoError(store.User().Create(&portainer.User{Username: "admin", Password: strongPassword, Role: portainer.AdministratorRole, PasswordChangedAt: now.Unix()}))
+	is.NoError(store.User().Create(&portainer.User{Username: "weak", Password: weakPassword, Role: portainer.StandardUserRole, PasswordChangedAt: now.Unix()}))
+	is.NoError(store.User().Create(&portainer.User{Username: "expired", Password: strongPassword, Role: portainer.StandardUserRole, PasswordChangedAt: now.AddDate(0, 0, -91).Unix()}))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(100, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, security.NewLoginThrottler(), passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+	h.CryptoService = cryptoService
+
+	login := func(username, password string) *portainer.TokenData {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(`{"Username":"`+username+`","Password":"`+password+`"}`)))
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp authenticateResponse
+		is.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		tokenData, err := jwtService.ParseAndVerifyToken(resp.JWT)
+		is.NoError(err)
+		return tokenData
+	}
+
+	t.Run("a valid password doesn't have to be changed", func(t *testing.T) {
+		tokenData := login("admin", "Passw0rd!Passw0rd!")
+		is.False(tokenData.ForceChangePassword)
+		is.False(tokenData.PasswordChangeRequired)
+	})
+
+	t.Run("the change of a password that doesn't match the policy can be postponed", func(t *testing.T) {
+		tokenData := login("weak", "weak")
+		is.True(tokenData.ForceChangePassword)
+		is.False(tokenData.PasswordChangeRequired)
+	})
+
+	t.Run("an expired password must be changed", func(t *testing.T) {
+		tokenData := login("expired", "Passw0rd!Passw0rd!")
+		is.True(tokenData.ForceChangePassword)
+		is.True(tokenData.PasswordChangeRequired)
+	})
+}
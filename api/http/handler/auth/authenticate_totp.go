@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/totp"
+)
+
+const (
+	// totpChallengeTimeout is the time the user has to provide the one-time password after a successful password authentication
+	totpChallengeTimeout = 5 * time.Minute
+	// totpChallengeMaxAttempts is the number of invalid one-time passwords after which the user has to authenticate again
+	totpChallengeMaxAttempts = 5
+)
+
+type totpPayload struct {
+	// Token received after the password authentication
+	Token string `example:"4e5f8a0c9d" validate:"required"`
+	// One-time password generated by the authenticator application, or one of the recovery codes
+	Code string `example:"123456" validate:"required"`
+}
+
+func (payload *totpPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Token) {
+		return errors.New("Invalid TOTP token")
+	}
+	if govalidator.IsNull(payload.Code) {
+		return errors.New("Invalid one-time password")
+	}
+	return nil
+}
+
+type totpEnrollPayload struct {
+	// Token received after the password authentication
+	Token string `example:"4e5f8a0c9d" validate:"required"`
+}
+
+func (payload *totpEnrollPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Token) {
+		return errors.New("Invalid TOTP token")
+	}
+	return nil
+}
+
+type totpEnrollResponse struct {
+	// Base32 encoded secret to register in the authenticator application
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// otpauth URI of the secret, usually displayed as a QR code
+	ProvisioningURI string `json:"provisioningUri" example:"otpauth://totp/Portainer:admin?secret=JBSWY3DPEHPK3PXP"`
+}
+
+// totpChallenges holds the pending authentications of the users who passed the password authentication
+// and still have to provide a one-time password.
+type totpChallenges struct {
+	mu         sync.Mutex
+	challenges map[string]*totpChallenge
+	// verifications serializes the one-time password verifications, from the lookup of the challenge to its removal,
+	// so that a challenge, a time step or a recovery code can't be used by two concurrent requests
+	verifications sync.Mutex
+}
+
+type totpChallenge struct {
+	userID         portainer.UserID
+	passwordChange passwordChange
+	expiresAt      time.Time
+	attempts       int
+}
+
+func newTOTPChallenges() *totpChallenges {
+	return &totpChallenges{challenges: map[string]*totpChallenge{}}
+}
+
+func (c *totpChallenges) add(userID portainer.UserID, change passwordChange) (string, error) {
+	token, err := generateCode()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, value := range c.challenges {
+		if now.After(value.expiresAt) {
+			delete(c.challenges, key)
+		}
+	}
+
+	c.challenges[token] = &totpChallenge{userID: userID, passwordChange: change, expiresAt: now.Add(totpChallengeTimeout)}
+
+	return token, nil
+}
+
+// get returns the pending challenge associated to the token
+func (c *totpChallenges) get(token string) (totpChallenge, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	challenge, ok := c.challenges[token]
+	if !ok || time.Now().After(challenge.expiresAt) {
+		return totpChallenge{}, false
+	}
+
+	return *challenge, true
+}
+
+// fail records an invalid one-time password, the challenge is dropped after too many attempts
+func (c *totpChallenges) fail(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	challenge, ok := c.challenges[token]
+	if !ok {
+		return
+	}
+
+	challenge.attempts++
+	if challenge.attempts >= totpChallengeMaxAttempts {
+		delete(c.challenges, token)
+	}
+}
+
+func (c *totpChallenges) remove(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.challenges, token)
+}
+
+func (handler *Handler) writeTOTPChallenge(w http.ResponseWriter, user *portainer.User, change passwordChange) *httperror.HandlerError {
+	token, err := handler.totpChallenges.add(user.ID, change)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to generate the TOTP token", Err: err}
+	}
+
+	return response.JSON(w, &authenticateResponse{TOTPToken: token, TOTPEnrollmentRequired: !user.TOTP.Enabled})
+}
+
+// totpChallengeUser returns the pending challenge associated to the token and its user
+func (handler *Handler) totpChallengeUser(token string) (totpChallenge, *portainer.User, *httperror.HandlerError) {
+	challenge, ok := handler.totpChallenges.get(token)
+	if !ok {
+		return challenge, nil, &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid or expired TOTP token", Err: httperrors.ErrUnauthorized}
+	}
+
+	user, err := handler.DataStore.User().User(challenge.userID)
+	if err != nil {
+		return challenge, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve a user with the specified identifier inside the database", Err: err}
+	}
+
+	if user.Disabled {
+		return challenge, nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "User account is disabled", Err: httperrors.ErrUnauthorized}
+	}
+
+	return challenge, user, nil
+}
+
+// @id AuthenticateTOTPEnroll
+// @summary Enroll in the two-factor authentication during the login
+// @description Generate the TOTP secret of a user who must enroll in the two-factor authentication before login.
+// @description The enrollment completes when a first one-time password is sent to /auth/totp.
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body totpEnrollPayload true "Token received after the password authentication"
+// @success 200 {object} totpEnrollResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 409 "The user is already enrolled"
+// @failure 422 "Invalid or expired token"
+// @failure 500 "Server error"
+// @router /auth/totp/enroll [post]
+func (handler *Handler) totpEnroll(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload totpEnrollPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	handler.totpChallenges.verifications.Lock()
+	defer handler.totpChallenges.verifications.Unlock()
+
+	_, user, handlerErr := handler.totpChallengeUser(payload.Token)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	if user.TOTP.Enabled {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Two-factor authentication is already enabled for this user", Err: errors.New("Two-factor authentication is already enabled")}
+	}
+
+	secret, err := totp.Enroll(&user.TOTP)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to generate the TOTP secret", Err: err}
+	}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user changes inside the database", Err: err}
+	}
+
+	return response.JSON(w, &totpEnrollResponse{Secret: secret, ProvisioningURI: totp.ProvisioningURI(user.Username, secret)})
+}
+
+// @id AuthenticateTOTP
+// @summary Complete an authentication with a one-time password
+// @description Validate the one-time password, or a recovery code, of a user who passed the password authentication and return the JWT.
+// @description For a user enrolling in the two-factor authentication, the one-time password enables it and the recovery codes are returned along with the JWT.
+// @description **Access policy**: public
+// @tags auth
+// @accept json
+// @produce json
+// @param body body totpPayload true "Token received after the password authentication and one-time password"
+// @success 200 {object} authenticateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "User account is disabled or temporarily locked"
+// @failure 422 "Invalid token or one-time password"
+// @failure 429 "Too many failed login attempts, retry later"
+// @failure 500 "Server error"
+// @router /auth/totp [post]
+func (handler *Handler) authenticateTOTP(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload totpPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	// the user is loaded, the one-time password verified, the used time step persisted and the challenge
+	// consumed under the lock, a concurrent request with the same token and code finds no challenge
+	handler.totpChallenges.verifications.Lock()
+	defer handler.totpChallenges.verifications.Unlock()
+
+	challenge, user, handlerErr := handler.totpChallengeUser(payload.Token)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	handlerErr = handler.checkLoginThrottle(w, user.Username)
+	if handlerErr != nil {
+		return handlerErr
+	}
+	defer handler.loginThrottler.Release(user.Username)
+
+	var ok bool
+	var recoveryCodes []string
+	if user.TOTP.Enabled {
+		ok = totp.Verify(&user.TOTP, payload.Code, time.Now())
+	} else {
+		recoveryCodes, err = totp.Activate(&user.TOTP, payload.Code, time.Now())
+		ok = err == nil
+	}
+
+	if !ok {
+		handler.totpChallenges.fail(payload.Token)
+		handler.loginThrottler.Fail(user.Username)
+		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid one-time password", Err: httperrors.ErrUnauthorized}
+	}
+
+	// persist the used time step or recovery code
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user changes inside the database", Err: err}
+	}
+
+	handler.totpChallenges.remove(payload.Token)
+	handler.loginThrottler.Succeed(user.Username)
+
+	token, handlerErr := handler.generateSessionToken(r, composeTokenData(user, challenge.passwordChange))
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	return response.JSON(w, &authenticateResponse{JWT: token, RecoveryCodes: recoveryCodes})
+}
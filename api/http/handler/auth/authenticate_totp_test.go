@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_totpAuthentication(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	cryptoService := &crypto.Service{}
+	password, err := cryptoService.Hash("Passw0rd!Passw0rd!")
+	is.NoError(err)
+
+	admin := &portainer.User{Username: "admin", Password: password, Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+	user := &portainer.User{Username: "alice", Password: password, Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(100, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, security.NewLoginThrottler(), passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+	h.CryptoService = cryptoService
+
+	post := func(path, body string) (*httptest.ResponseRecorder, authenticateResponse) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+
+		var resp authenticateResponse
+		if rr.Code == http.StatusOK {
+			is.NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+		}
+		return rr, resp
+	}
+
+	login := func(username string) authenticateResponse {
+		rr, resp := post("/auth", `{"Username":"`+username+`","Password":"Passw0rd!Passw0rd!"}`)
+		is.Equal(http.StatusOK, rr.Code)
+		return resp
+	}
+
+	t.Run("users without two-factor authentication receive a JWT", func(t *testing.T) {
+		resp := login("alice")
+		is.NotEmpty(resp.JWT)
+		is.Empty(resp.TOTPToken)
+	})
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.EnforceTOTP = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	var recoveryCodes []string
+	var secret string
+
+	t.Run("users must enroll when the two-factor authentication is enforced", func(t *testing.T) {
+		resp := login("alice")
+		is.Empty(resp.JWT)
+		is.NotEmpty(resp.TOTPToken)
+		is.True(resp.TOTPEnrollmentRequired)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth/totp/enroll", strings.NewReader(`{"Token":"`+resp.TOTPToken+`"}`)))
+		is.Equal(http.StatusOK, rr.Code)
+
+		var enrollment totpEnrollResponse
+		is.NoError(json.Unmarshal(rr.Body.Bytes(), &enrollment))
+		is.True(strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/Portainer:alice?"))
+		secret = enrollment.Secret
+
+		code, err := totp.GenerateCode(secret, time.Now())
+		is.NoError(err)
+
+		rr, completed := post("/auth/totp", `{"Token":"`+resp.TOTPToken+`","Code":"`+code+`"}`)
+		is.Equal(http.StatusOK, rr.Code)
+		is.NotEmpty(completed.JWT)
+		is.NotEmpty(completed.RecoveryCodes)
+		recoveryCodes = completed.RecoveryCodes
+
+		// the token can only be used once
+		rr, _ = post("/auth/totp", `{"Token":"`+resp.TOTPToken+`","Code":"`+code+`"}`)
+		is.Equal(http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("enrolled users are asked for a one-time password", func(t *testing.T) {
+		resp := login("alice")
+		is.Empty(resp.JWT)
+		is.False(resp.TOTPEnrollmentRequired)
+
+		rr, _ := post("/auth/totp", `{"Token":"`+resp.TOTPToken+`","Code":"000000"}`)
+		is.Equal(http.StatusUnprocessableEntity, rr.Code)
+
+		rr, completed := post("/auth/totp", `{"Token":"`+resp.TOTPToken+`","Code":"`+recoveryCodes[0]+`"}`)
+		is.Equal(http.StatusOK, rr.Code)
+		is.NotEmpty(completed.JWT)
+		is.Empty(completed.RecoveryCodes)
+
+		tokenData, err := jwtService.ParseAndVerifyToken(completed.JWT)
+		is.NoError(err)
+		is.Equal("alice", tokenData.Username)
+	})
+
+	t.Run("concurrent requests with the same token and code receive a single JWT", func(t *testing.T) {
+		resp := login("alice")
+		body := `{"Token":"` + resp.TOTPToken + `","Code":"` + recoveryCodes[1] + `"}`
+
+		var wg sync.WaitGroup
+		codes := make([]int, 10)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth/totp", strings.NewReader(body)))
+				codes[i] = rr.Code
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, code := range codes {
+			if code == http.StatusOK {
+				succeeded++
+			}
+		}
+		is.Equal(1, succeeded)
+
+		h.loginThrottler.Succeed("alice")
+	})
+
+	t.Run("the token is dropped after too many invalid one-time passwords", func(t *testing.T) {
+		resp := login("alice")
+
+		for i := 0; i < totpChallengeMaxAttempts; i++ {
+			rr, _ := post("/auth/totp", `{"Token":"`+resp.TOTPToken+`","Code":"000000"}`)
+			is.Equal(http.StatusUnprocessableEntity, rr.Code)
+
+			// skip the delays between the failed attempts of the account
+			h.loginThrottler.Succeed("alice")
+		}
+
+		code, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+		is.NoError(err)
+
+		rr, _ := post("/auth/totp", `{"Token":"`+resp.TOTPToken+`","Code":"`+code+`"}`)
+		is.Equal(http.StatusUnprocessableEntity, rr.Code)
+	})
+}
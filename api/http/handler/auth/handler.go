@@ -20,22 +20,47 @@ type Handler struct {
 	JWTService                  dataservices.JWTService
 	LDAPService                 portainer.LDAPService
 	OAuthService                portainer.OAuthService
+	SAMLService                 portainer.SAMLService
 	ProxyManager                *proxy.Manager
 	KubernetesTokenCacheManager *kubernetes.TokenCacheManager
 	passwordStrengthChecker     security.PasswordStrengthChecker
+	rateLimiter                 *security.RateLimiter
+	loginThrottler              *security.LoginThrottler
+	samlCodes                   *samlCodes
+	totpChallenges              *totpChallenges
 }
 
 // NewHandler creates a handler to manage authentication operations.
-func NewHandler(bouncer *security.RequestBouncer, rateLimiter *security.RateLimiter, passwordStrengthChecker security.PasswordStrengthChecker) *Handler {
+func NewHandler(bouncer *security.RequestBouncer, rateLimiter *security.RateLimiter, loginThrottler *security.LoginThrottler, passwordStrengthChecker security.PasswordStrengthChecker) *Handler {
 	h := &Handler{
 		Router:                  mux.NewRouter(),
 		passwordStrengthChecker: passwordStrengthChecker,
+		rateLimiter:             rateLimiter,
+		loginThrottler:          loginThrottler,
+		samlCodes:               newSAMLCodes(),
+		totpChallenges:          newTOTPChallenges(),
 	}
 
 	h.Handle("/auth/oauth/validate",
 		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.validateOAuth)))).Methods(http.MethodPost)
+	h.Handle("/auth/saml/login",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.samlLogin))).Methods(http.MethodGet)
+	h.Handle("/auth/saml/metadata",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.samlMetadata))).Methods(http.MethodGet)
+	h.Handle("/auth/saml/acs",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.samlAssertionConsumerService)))).Methods(http.MethodPost)
+	h.Handle("/auth/saml/validate",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.validateSAML)))).Methods(http.MethodPost)
+	h.Handle("/auth/totp",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.authenticateTOTP)))).Methods(http.MethodPost)
+	h.Handle("/auth/totp/enroll",
+		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.totpEnroll)))).Methods(http.MethodPost)
 	h.Handle("/auth",
 		rateLimiter.LimitAccess(bouncer.PublicAccess(httperror.LoggerHandler(h.authenticate)))).Methods(http.MethodPost)
+	h.Handle("/auth/lockouts",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.lockoutList))).Methods(http.MethodGet)
+	h.Handle("/auth/lockouts/{username}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.lockoutDelete))).Methods(http.MethodDelete)
 	h.Handle("/auth/logout",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.logout))).Methods(http.MethodPost)
 
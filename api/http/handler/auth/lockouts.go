@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+// checkLoginThrottle rejects the login attempts on locked accounts and the attempts sent before the end of the
+// delay following the previous failures. The Retry-After header tells the client when to try again.
+// The allowed attempts are reserved, the caller must release them once their outcome is recorded.
+func (handler *Handler) checkLoginThrottle(w http.ResponseWriter, username string) *httperror.HandlerError {
+	wait, err := handler.loginThrottler.Check(username)
+	if wait > 0 {
+		w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+	}
+
+	if err == security.ErrAccountLocked {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Account temporarily locked after too many failed login attempts", Err: err}
+	}
+
+	if wait > 0 {
+		return &httperror.HandlerError{StatusCode: http.StatusTooManyRequests, Message: "Too many failed login attempts, retry later", Err: fmt.Errorf("next login attempt allowed in %s", wait)}
+	}
+
+	return nil
+}
+
+// @id LockoutList
+// @summary List the locked accounts
+// @description List the accounts temporarily locked after too many failed login attempts.
+// @description **Access policy**: administrator
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} security.LockedAccount "Success"
+// @failure 403 "Permission denied"
+// @failure 500 "Server error"
+// @router /auth/lockouts [get]
+func (handler *Handler) lockoutList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return response.JSON(w, handler.loginThrottler.LockedAccounts())
+}
+
+// @id LockoutDelete
+// @summary Unlock an account
+// @description Unlock an account locked after too many failed login attempts and forget these attempts.
+// @description **Access policy**: administrator
+// @tags auth
+// @security ApiKeyAuth
+// @security jwt
+// @param username path string true "Username of the locked account"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Account not locked"
+// @failure 500 "Server error"
+// @router /auth/lockouts/{username} [delete]
+func (handler *Handler) lockoutDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	username, err := request.RetrieveRouteVariableValue(r, "username")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid username route variable", Err: err}
+	}
+
+	if !handler.loginThrottler.Unlock(username) {
+		return &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find a locked account with the specified username", Err: fmt.Errorf("account %s is not locked", username)}
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_loginLockout(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	cryptoService := &crypto.Service{}
+	password, err := cryptoService.Hash("Passw0rd!Passw0rd!")
+	is.NoError(err)
+
+	admin := &portainer.User{Username: "admin", Password: password, Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+	user := &portainer.User{Username: "alice", Password: password, Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(100, 1*time.Second, 1*time.Hour)
+	loginThrottler := security.NewLoginThrottler()
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, loginThrottler, passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+	h.CryptoService = cryptoService
+
+	adminJWT, err := jwtService.GenerateToken(&portainer.TokenData{ID: admin.ID, Username: admin.Username, Role: admin.Role})
+	is.NoError(err)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/auth", strings.NewReader(`{"Username":"alice","Password":"`+password+`"}`)))
+		return rr
+	}
+
+	asAdmin := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", adminJWT))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("failed attempts delay the next ones", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			is.Equal(http.StatusUnprocessableEntity, login("wrong").Code)
+		}
+
+		rr := login("Passw0rd!Passw0rd!")
+		is.Equal(http.StatusTooManyRequests, rr.Code)
+		is.NotEmpty(rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("locked accounts cannot login with valid credentials", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			loginThrottler.Fail("alice")
+		}
+
+		is.Equal(http.StatusForbidden, login("Passw0rd!Passw0rd!").Code)
+	})
+
+	t.Run("only administrators list and unlock the locked accounts", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/lockouts", nil))
+		is.Equal(http.StatusUnauthorized, rr.Code)
+
+		rr = asAdmin(http.MethodGet, "/auth/lockouts")
+		is.Equal(http.StatusOK, rr.Code)
+
+		var locked []security.LockedAccount
+		is.NoError(json.NewDecoder(rr.Body).Decode(&locked))
+		is.Len(locked, 1)
+		is.Equal("alice", locked[0].Username)
+
+		is.Equal(http.StatusNoContent, asAdmin(http.MethodDelete, "/auth/lockouts/alice").Code)
+		is.Equal(http.StatusNotFound, asAdmin(http.MethodDelete, "/auth/lockouts/alice").Code)
+
+		is.Equal(http.StatusOK, login("Passw0rd!Passw0rd!").Code)
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 // @id Logout
 // @summary Logout
+// @description Revoke the session of the JWT used to authenticate the request.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -23,6 +24,10 @@ func (handler *Handler) logout(w http.ResponseWriter, r *http.Request) *httperro
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to retrieve user details from authentication token", err}
 	}
 
+	if tokenData.SessionID != "" {
+		handler.JWTService.RevokeSession(tokenData.ID, tokenData.SessionID)
+	}
+
 	handler.KubernetesTokenCacheManager.RemoveUserFromCache(int(tokenData.ID))
 
 	return response.Empty(w)
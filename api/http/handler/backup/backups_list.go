@@ -0,0 +1,28 @@
+package backup
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id BackupList
+// @summary Lists the backups created by the scheduled backups
+// @description Lists the backup archives available in the destination configured in the scheduled backup settings, from the newest to the oldest.
+// @description **Access policy**: admin
+// @tags backup
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.BackupInfo "Success"
+// @failure 500 "Server error"
+// @router /backups [get]
+func (h *Handler) backupList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	backups, err := h.backupScheduler.ListBackups()
+	if err != nil {
+		return httperror.InternalServerError("Unable to list the backups", err)
+	}
+
+	return response.JSON(w, backups)
+}
@@ -0,0 +1,81 @@
+package backup
+
+import (
+	"net/http"
+	"strings"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	operations "github.com/portainer/portainer/api/backup"
+)
+
+type backupRestorePayload struct {
+	// Password used to decrypt the backup, the password of the scheduled backup settings is used when empty
+	Password string
+}
+
+func (p *backupRestorePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id BackupRestore
+// @summary Triggers a system restore using a backup created by the scheduled backups
+// @description Triggers a system restore using a backup archive stored in the destination configured in the scheduled backup settings.
+// @description The instance shuts down once the restore is complete.
+// @description **Access policy**: admin
+// @tags backup
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param name path string true "Backup archive name"
+// @param body body backupRestorePayload false "An object contains the password to decrypt the backup with"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /backups/{name}/restore [post]
+func (h *Handler) backupRestore(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	name, err := request.RetrieveRouteVariableValue(r, "name")
+	if err != nil {
+		return httperror.BadRequest("Invalid backup name route variable", err)
+	}
+
+	// the payload is optional
+	var payload backupRestorePayload
+	if r.ContentLength != 0 {
+		err = request.DecodeAndValidateJSONPayload(r, &payload)
+		if err != nil {
+			return httperror.BadRequest("Invalid request payload", err)
+		}
+	}
+
+	// archives created without a password are not encrypted
+	password := ""
+	if strings.HasSuffix(name, ".encrypted") {
+		password = payload.Password
+	}
+
+	if password == "" && strings.HasSuffix(name, ".encrypted") {
+		settings, err := h.dataStore.Settings().Settings()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+		}
+		password = settings.ScheduledBackupSettings.Password
+	}
+
+	archive, err := h.backupScheduler.OpenBackup(name)
+	if err != nil {
+		return httperror.InternalServerError("Unable to open the backup", err)
+	}
+	defer archive.Close()
+
+	h.adminMonitor.Stop()
+	defer h.adminMonitor.Start()
+
+	err = operations.RestoreArchive(archive, password, h.filestorePath, h.gate, h.dataStore, h.shutdownTrigger)
+	if err != nil {
+		return httperror.InternalServerError("Failed to restore the backup", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	httperror "github.com/portainer/libhttp/error"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/adminmonitor"
+	operations "github.com/portainer/portainer/api/backup"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/http/middlewares"
@@ -24,6 +25,7 @@ type Handler struct {
 	filestorePath   string
 	shutdownTrigger context.CancelFunc
 	adminMonitor    *adminmonitor.Monitor
+	backupScheduler *operations.Scheduler
 }
 
 // NewHandler creates an new instance of backup handler
@@ -35,7 +37,7 @@ func NewHandler(
 	shutdownTrigger context.CancelFunc,
 	adminMonitor *adminmonitor.Monitor,
 	demoService *demo.Service,
-
+	backupScheduler *operations.Scheduler,
 ) *Handler {
 
 	h := &Handler{
@@ -46,6 +48,7 @@ func NewHandler(
 		filestorePath:   filestorePath,
 		shutdownTrigger: shutdownTrigger,
 		adminMonitor:    adminMonitor,
+		backupScheduler: backupScheduler,
 	}
 
 	demoRestrictedRouter := h.NewRoute().Subrouter()
@@ -53,6 +56,8 @@ func NewHandler(
 
 	demoRestrictedRouter.Handle("/backup", bouncer.RestrictedAccess(adminAccess(httperror.LoggerHandler(h.backup)))).Methods(http.MethodPost)
 	demoRestrictedRouter.Handle("/restore", bouncer.PublicAccess(httperror.LoggerHandler(h.restore))).Methods(http.MethodPost)
+	demoRestrictedRouter.Handle("/backups", bouncer.AdminAccess(httperror.LoggerHandler(h.backupList))).Methods(http.MethodGet)
+	demoRestrictedRouter.Handle("/backups/{name}/restore", bouncer.AdminAccess(httperror.LoggerHandler(h.backupRestore))).Methods(http.MethodPost)
 
 	return h
 }
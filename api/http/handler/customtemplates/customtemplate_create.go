@@ -15,6 +15,8 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 )
@@ -212,6 +214,10 @@ type customTemplateFromGitRepositoryPayload struct {
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// Identifier of a stored Git credential used instead of RepositoryUsername and RepositoryPassword when RepositoryAuthentication is true.
+	RepositoryGitCredentialID portainer.GitCredentialID `example:"1"`
+	// Options used to clone the Git repository: submodules, Git LFS, sparse checkout and depth
+	RepositoryCloneOptions *gittypes.CloneOptions
 	// Path to the Stack file inside the Git repository
 	ComposeFilePathInRepository string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// Definitions of variables in the stack file
@@ -225,10 +231,10 @@ func (payload *customTemplateFromGitRepositoryPayload) Validate(r *http.Request)
 	if govalidator.IsNull(payload.Description) {
 		return errors.New("Invalid custom template description")
 	}
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && (govalidator.IsNull(payload.RepositoryUsername) || govalidator.IsNull(payload.RepositoryPassword)) {
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID == 0 && (govalidator.IsNull(payload.RepositoryUsername) || govalidator.IsNull(payload.RepositoryPassword)) {
 		return errors.New("Invalid repository credentials. Username and password must be specified when authentication is enabled")
 	}
 	if govalidator.IsNull(payload.ComposeFilePathInRepository) {
@@ -280,14 +286,34 @@ func (handler *Handler) createCustomTemplateFromGitRepository(r *http.Request) (
 	projectPath := handler.FileService.GetCustomTemplateProjectPath(strconv.Itoa(customTemplateID))
 	customTemplate.ProjectPath = projectPath
 
-	repositoryUsername := payload.RepositoryUsername
-	repositoryPassword := payload.RepositoryPassword
-	if !payload.RepositoryAuthentication {
-		repositoryUsername = ""
-		repositoryPassword = ""
+	customTemplate.GitConfig = &gittypes.RepoConfig{
+		URL:            payload.RepositoryURL,
+		ReferenceName:  payload.RepositoryReferenceName,
+		ConfigFilePath: payload.ComposeFilePathInRepository,
+		CloneOptions:   payload.RepositoryCloneOptions,
 	}
 
-	err = handler.GitService.CloneRepository(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, repositoryUsername, repositoryPassword)
+	var repositoryAuth *gittypes.GitAuthentication
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID != 0 {
+		err = handler.checkGitCredentialAccess(r, payload.RepositoryGitCredentialID)
+		if err != nil {
+			return nil, err
+		}
+
+		customTemplate.GitConfig.Authentication = &gittypes.GitAuthentication{GitCredentialID: int(payload.RepositoryGitCredentialID)}
+
+		repositoryAuth, err = git.ResolveAuthentication(handler.DataStore, customTemplate.GitConfig.Authentication)
+		if err != nil {
+			return nil, err
+		}
+	} else if payload.RepositoryAuthentication {
+		repositoryAuth = &gittypes.GitAuthentication{
+			Username: payload.RepositoryUsername,
+			Password: payload.RepositoryPassword,
+		}
+	}
+
+	err = handler.GitService.CloneRepository(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, repositoryAuth, payload.RepositoryCloneOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 	httperror "github.com/portainer/libhttp/error"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
@@ -59,3 +60,23 @@ func userCanAccessTemplate(customTemplate portainer.CustomTemplate, securityCont
 
 	return false
 }
+
+// checkGitCredentialAccess verifies that the Git credential referenced by a custom template exists
+// and that the current user is allowed to use it.
+func (handler *Handler) checkGitCredentialAccess(r *http.Request, gitCredentialID portainer.GitCredentialID) error {
+	credential, err := handler.DataStore.GitCredential().GitCredential(gitCredentialID)
+	if err != nil {
+		return errors.WithMessage(err, "unable to find the Git credential")
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return errors.WithMessage(err, "unable to retrieve info from request context")
+	}
+
+	if !security.AuthorizedGitCredentialAccess(credential, securityContext) {
+		return errors.New("permission denied to use the Git credential")
+	}
+
+	return nil
+}
@@ -15,6 +15,8 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/internal/edge"
 )
 
@@ -180,6 +182,10 @@ type swarmStackFromGitRepositoryPayload struct {
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// Identifier of a stored Git credential used instead of RepositoryUsername and RepositoryPassword when RepositoryAuthentication is true.
+	RepositoryGitCredentialID portainer.GitCredentialID `example:"1"`
+	// Options used to clone the Git repository: submodules, Git LFS, sparse checkout and depth
+	RepositoryCloneOptions *gittypes.CloneOptions
 	// Path to the Stack file inside the Git repository
 	FilePathInRepository string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// List of identifiers of EdgeGroups
@@ -195,10 +201,10 @@ func (payload *swarmStackFromGitRepositoryPayload) Validate(r *http.Request) err
 	if govalidator.IsNull(payload.Name) {
 		return errors.New("Invalid stack name")
 	}
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && (govalidator.IsNull(payload.RepositoryUsername) || govalidator.IsNull(payload.RepositoryPassword)) {
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID == 0 && (govalidator.IsNull(payload.RepositoryUsername) || govalidator.IsNull(payload.RepositoryPassword)) {
 		return errors.New("Invalid repository credentials. Username and password must be specified when authentication is enabled")
 	}
 	if govalidator.IsNull(payload.FilePathInRepository) {
@@ -236,11 +242,26 @@ func (handler *Handler) createSwarmStackFromGitRepository(r *http.Request) (*por
 	projectPath := handler.FileService.GetEdgeStackProjectPath(strconv.Itoa(int(stack.ID)))
 	stack.ProjectPath = projectPath
 
-	repositoryUsername := payload.RepositoryUsername
-	repositoryPassword := payload.RepositoryPassword
-	if !payload.RepositoryAuthentication {
-		repositoryUsername = ""
-		repositoryPassword = ""
+	stack.GitConfig = &gittypes.RepoConfig{
+		URL:            payload.RepositoryURL,
+		ReferenceName:  payload.RepositoryReferenceName,
+		ConfigFilePath: payload.FilePathInRepository,
+		CloneOptions:   payload.RepositoryCloneOptions,
+	}
+
+	var repositoryAuth *gittypes.GitAuthentication
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID != 0 {
+		stack.GitConfig.Authentication = &gittypes.GitAuthentication{GitCredentialID: int(payload.RepositoryGitCredentialID)}
+
+		repositoryAuth, err = git.ResolveAuthentication(handler.DataStore, stack.GitConfig.Authentication)
+		if err != nil {
+			return nil, err
+		}
+	} else if payload.RepositoryAuthentication {
+		repositoryAuth = &gittypes.GitAuthentication{
+			Username: payload.RepositoryUsername,
+			Password: payload.RepositoryPassword,
+		}
 	}
 
 	relationConfig, err := fetchEndpointRelationsConfig(handler.DataStore)
@@ -253,7 +274,7 @@ func (handler *Handler) createSwarmStackFromGitRepository(r *http.Request) (*por
 		return nil, fmt.Errorf("unable to retrieve related endpoints: %w", err)
 	}
 
-	err = handler.GitService.CloneRepository(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, repositoryUsername, repositoryPassword)
+	err = handler.GitService.CloneRepository(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, repositoryAuth, payload.RepositoryCloneOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/notification/events"
 )
 
 type updateStatusPayload struct {
@@ -74,6 +75,8 @@ func (handler *Handler) edgeStackStatusUpdate(w http.ResponseWriter, r *http.Req
 		return &httperror.HandlerError{http.StatusForbidden, "Permission denied to access environment", err}
 	}
 
+	previousStatus := stack.Status[*payload.EndpointID]
+
 	stack.Status[*payload.EndpointID] = portainer.EdgeStackStatus{
 		Type:       *payload.Status,
 		Error:      payload.Error,
@@ -85,6 +88,12 @@ func (handler *Handler) edgeStackStatusUpdate(w http.ResponseWriter, r *http.Req
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to persist the stack changes inside the database", err}
 	}
 
+	// agents may report the same error several times, notify only once
+	isNewError := previousStatus.Type != portainer.StatusError || previousStatus.Error != payload.Error
+	if *payload.Status == portainer.StatusError && isNewError && handler.EventPublisher != nil {
+		handler.EventPublisher.Publish(events.EdgeStackError(stack, *payload.EndpointID, payload.Error))
+	}
+
 	return response.JSON(w, stack)
 
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/datastore"
 	"github.com/portainer/portainer/api/filesystem"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/jwt"
 )
@@ -27,14 +28,18 @@ type gitService struct {
 	id       string
 }
 
-func (g *gitService) CloneRepository(destination, repositoryURL, referenceName, username, password string) error {
+func (g *gitService) CloneRepository(destination string, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOptions *gittypes.CloneOptions) error {
 	return g.cloneErr
 }
 
-func (g *gitService) LatestCommitID(repositoryURL, referenceName, username, password string) (string, error) {
+func (g *gitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication) (string, error) {
 	return g.id, nil
 }
 
+func (g *gitService) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication) ([]string, error) {
+	return nil, nil
+}
+
 // Helpers
 func setupHandler(t *testing.T) (*Handler, string, func()) {
 	t.Helper()
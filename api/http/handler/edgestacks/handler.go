@@ -22,6 +22,7 @@ type Handler struct {
 	FileService        portainer.FileService
 	GitService         portainer.GitService
 	KubernetesDeployer portainer.KubernetesDeployer
+	EventPublisher     portainer.NotificationEventPublisher
 }
 
 // NewHandler creates a handler to manage environment(endpoint) group operations.
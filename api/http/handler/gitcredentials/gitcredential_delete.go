@@ -0,0 +1,93 @@
+package gitcredentials
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
+)
+
+// @id GitCredentialDelete
+// @summary Remove a Git credential
+// @description Remove a Git credential. A credential that is still referenced by a stack, a custom template
+// @description or an Edge stack can't be removed.
+// @description **Access policy**: restricted
+// @tags git_credentials
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Git credential identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Git credential not found"
+// @failure 409 "Git credential is in use"
+// @failure 500 "Server error"
+// @router /git_credentials/{id} [delete]
+func (handler *Handler) gitCredentialDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	credential, httpErr := handler.gitCredential(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	httpErr = handler.checkGitCredentialUnused(credential.ID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.GitCredential().DeleteGitCredential(credential.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the Git credential from the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+func (handler *Handler) checkGitCredentialUnused(credentialID portainer.GitCredentialID) *httperror.HandlerError {
+	stacks, err := handler.DataStore.Stack().Stacks()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve stacks from the database", err)
+	}
+
+	for _, stack := range stacks {
+		if referencesGitCredential(stack.GitConfig, credentialID) {
+			return gitCredentialInUseError("stack", stack.Name)
+		}
+	}
+
+	customTemplates, err := handler.DataStore.CustomTemplate().CustomTemplates()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve custom templates from the database", err)
+	}
+
+	for _, customTemplate := range customTemplates {
+		if referencesGitCredential(customTemplate.GitConfig, credentialID) {
+			return gitCredentialInUseError("custom template", customTemplate.Title)
+		}
+	}
+
+	edgeStacks, err := handler.DataStore.EdgeStack().EdgeStacks()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve Edge stacks from the database", err)
+	}
+
+	for _, edgeStack := range edgeStacks {
+		if referencesGitCredential(edgeStack.GitConfig, credentialID) {
+			return gitCredentialInUseError("Edge stack", edgeStack.Name)
+		}
+	}
+
+	return nil
+}
+
+func referencesGitCredential(gitConfig *gittypes.RepoConfig, credentialID portainer.GitCredentialID) bool {
+	return gitConfig != nil && gitConfig.Authentication != nil && gitConfig.Authentication.GitCredentialID == int(credentialID)
+}
+
+func gitCredentialInUseError(resourceType, resourceName string) *httperror.HandlerError {
+	message := fmt.Sprintf("The Git credential is used by the %s %s", resourceType, resourceName)
+	return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: message, Err: errors.New("Git credential is in use")}
+}
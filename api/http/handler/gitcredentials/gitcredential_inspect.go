@@ -0,0 +1,33 @@
+package gitcredentials
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id GitCredentialInspect
+// @summary Inspect a Git credential
+// @description Retrieve details about a Git credential. Passwords and SSH keys are never returned.
+// @description **Access policy**: restricted
+// @tags git_credentials
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Git credential identifier"
+// @success 200 {object} portainer.GitCredential "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Git credential not found"
+// @failure 500 "Server error"
+// @router /git_credentials/{id} [get]
+func (handler *Handler) gitCredentialInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	credential, httpErr := handler.gitCredential(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	hideFields(credential)
+	return response.JSON(w, credential)
+}
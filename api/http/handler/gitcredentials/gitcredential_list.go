@@ -0,0 +1,44 @@
+package gitcredentials
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+// @id GitCredentialList
+// @summary List Git credentials
+// @description List the Git credentials. Passwords and SSH keys are never returned.
+// @description Non-administrator users only see their own credentials and the credentials of their teams.
+// @description **Access policy**: authenticated
+// @tags git_credentials
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.GitCredential "Success"
+// @failure 500 "Server error"
+// @router /git_credentials [get]
+func (handler *Handler) gitCredentialList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	credentials, err := handler.DataStore.GitCredential().GitCredentials()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the Git credentials from the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	filteredCredentials := make([]portainer.GitCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		if security.AuthorizedGitCredentialAccess(&credential, securityContext) {
+			hideFields(&credential)
+			filteredCredentials = append(filteredCredentials, credential)
+		}
+	}
+
+	return response.JSON(w, filteredCredentials)
+}
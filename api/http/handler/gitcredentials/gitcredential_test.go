@@ -0,0 +1,224 @@
+package gitcredentials
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func Test_gitCredentials(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(adminUser)
+	is.NoError(err, "error creating admin user")
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	h := NewHandler(requestBouncer)
+	h.DataStore = store
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	is.NoError(err)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	hostKey, err := ssh.NewPublicKey(&key.PublicKey)
+	is.NoError(err)
+	knownHosts := knownhosts.Line([]string{"gitea.local"}, hostKey)
+
+	send := func(method, url, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		is.NoError(err)
+
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var credential portainer.GitCredential
+
+	t.Run("a credential requires a password or a valid SSH key", func(t *testing.T) {
+		rr := send(http.MethodPost, "/git_credentials", adminJWT, gitCredentialPayload{Name: "empty"})
+		is.Equal(http.StatusBadRequest, rr.Code)
+
+		rr = send(http.MethodPost, "/git_credentials", adminJWT, gitCredentialPayload{Name: "invalid", SSHPrivateKey: "not a key"})
+		is.Equal(http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("an SSH credential requires known hosts unless the host key verification is skipped", func(t *testing.T) {
+		rr := send(http.MethodPost, "/git_credentials", adminJWT, gitCredentialPayload{Name: "unverified", SSHPrivateKey: privateKey})
+		is.Equal(http.StatusBadRequest, rr.Code)
+
+		rr = send(http.MethodPost, "/git_credentials", adminJWT, gitCredentialPayload{Name: "unverified", SSHPrivateKey: privateKey, InsecureSkipHostKeyVerification: true})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var insecure portainer.GitCredential
+		is.NoError(json.NewDecoder(rr.Body).Decode(&insecure))
+		is.True(insecure.InsecureSkipHostKeyVerification)
+		is.NoError(store.GitCredential().DeleteGitCredential(insecure.ID))
+	})
+
+	t.Run("admin creates an SSH credential and the secrets are not returned", func(t *testing.T) {
+		rr := send(http.MethodPost, "/git_credentials", adminJWT, gitCredentialPayload{Name: "deploy-key", SSHPrivateKey: privateKey, SSHKnownHosts: knownHosts})
+		is.Equal(http.StatusOK, rr.Code)
+
+		is.NoError(json.NewDecoder(rr.Body).Decode(&credential))
+		is.Equal("deploy-key", credential.Name)
+		is.Empty(credential.SSHPrivateKey)
+
+		stored, err := store.GitCredential().GitCredential(credential.ID)
+		is.NoError(err)
+		is.Equal(privateKey, stored.SSHPrivateKey)
+	})
+
+	t.Run("updating a credential keeps the secrets that are not provided", func(t *testing.T) {
+		rr := send(http.MethodPut, fmt.Sprintf("/git_credentials/%d", credential.ID), adminJWT, gitCredentialUpdatePayload{Name: "gitea", Username: "deploy", SSHKnownHosts: knownHosts})
+		is.Equal(http.StatusOK, rr.Code)
+
+		stored, err := store.GitCredential().GitCredential(credential.ID)
+		is.NoError(err)
+		is.Equal("gitea", stored.Name)
+		is.Equal("deploy", stored.Username)
+		is.Equal(privateKey, stored.SSHPrivateKey)
+	})
+
+	t.Run("updating a credential switches it from an SSH key to a password", func(t *testing.T) {
+		empty, password := "", "token"
+		rr := send(http.MethodPut, fmt.Sprintf("/git_credentials/%d", credential.ID), adminJWT, gitCredentialUpdatePayload{Name: "gitea", Username: "deploy", Password: &password, SSHPrivateKey: &empty})
+		is.Equal(http.StatusOK, rr.Code)
+
+		stored, err := store.GitCredential().GitCredential(credential.ID)
+		is.NoError(err)
+		is.Equal("token", stored.Password)
+		is.Empty(stored.SSHPrivateKey)
+		is.Empty(stored.SSHPassphrase)
+	})
+
+	t.Run("standard user can't access a credential owned by another user", func(t *testing.T) {
+		rr := send(http.MethodGet, "/git_credentials", userJWT, nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var credentials []portainer.GitCredential
+		is.NoError(json.NewDecoder(rr.Body).Decode(&credentials))
+		is.Empty(credentials)
+
+		rr = send(http.MethodGet, fmt.Sprintf("/git_credentials/%d", credential.ID), userJWT, nil)
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = send(http.MethodDelete, fmt.Sprintf("/git_credentials/%d", credential.ID), userJWT, nil)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("standard user manages their own credential", func(t *testing.T) {
+		rr := send(http.MethodPost, "/git_credentials", userJWT, gitCredentialPayload{Name: "token", Username: "user", Password: "secret"})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var owned portainer.GitCredential
+		is.NoError(json.NewDecoder(rr.Body).Decode(&owned))
+		is.Equal(user.ID, owned.UserID)
+
+		rr = send(http.MethodGet, "/git_credentials", userJWT, nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var credentials []portainer.GitCredential
+		is.NoError(json.NewDecoder(rr.Body).Decode(&credentials))
+		is.Len(credentials, 1)
+		is.Equal(owned.ID, credentials[0].ID)
+
+		rr = send(http.MethodDelete, fmt.Sprintf("/git_credentials/%d", owned.ID), userJWT, nil)
+		is.Equal(http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("team credentials are shared with the team members", func(t *testing.T) {
+		team := &portainer.Team{ID: 1, Name: "devs"}
+		is.NoError(store.Team().Create(team))
+
+		rr := send(http.MethodPost, "/git_credentials", userJWT, gitCredentialPayload{Name: "team-token", Password: "secret", TeamID: team.ID})
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = send(http.MethodPost, "/git_credentials", adminJWT, gitCredentialPayload{Name: "team-token", Password: "secret", TeamID: team.ID})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var teamCredential portainer.GitCredential
+		is.NoError(json.NewDecoder(rr.Body).Decode(&teamCredential))
+		is.Equal(team.ID, teamCredential.TeamID)
+		is.Zero(teamCredential.UserID)
+
+		is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{ID: 1, UserID: user.ID, TeamID: team.ID, Role: portainer.TeamMember}))
+
+		rr = send(http.MethodGet, fmt.Sprintf("/git_credentials/%d", teamCredential.ID), userJWT, nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		rr = send(http.MethodPut, fmt.Sprintf("/git_credentials/%d", teamCredential.ID), userJWT, gitCredentialUpdatePayload{Name: "renamed"})
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("a credential used by a custom template can't be removed", func(t *testing.T) {
+		err := store.CustomTemplate().Create(&portainer.CustomTemplate{
+			ID:    1,
+			Title: "template",
+			GitConfig: &gittypes.RepoConfig{
+				URL:            "https://gitea.local/org/repo.git",
+				Authentication: &gittypes.GitAuthentication{GitCredentialID: int(credential.ID)},
+			},
+		})
+		is.NoError(err)
+
+		rr := send(http.MethodDelete, fmt.Sprintf("/git_credentials/%d", credential.ID), adminJWT, nil)
+		is.Equal(http.StatusConflict, rr.Code)
+
+		is.NoError(store.CustomTemplate().DeleteCustomTemplate(1))
+	})
+
+	t.Run("a credential used by a stack can't be removed", func(t *testing.T) {
+		err := store.Stack().Create(&portainer.Stack{
+			ID:   1,
+			Name: "app",
+			GitConfig: &gittypes.RepoConfig{
+				URL:            "git@gitea.local:org/repo.git",
+				Authentication: &gittypes.GitAuthentication{GitCredentialID: int(credential.ID)},
+			},
+		})
+		is.NoError(err)
+
+		rr := send(http.MethodDelete, fmt.Sprintf("/git_credentials/%d", credential.ID), adminJWT, nil)
+		is.Equal(http.StatusConflict, rr.Code)
+
+		is.NoError(store.Stack().DeleteStack(1))
+
+		rr = send(http.MethodDelete, fmt.Sprintf("/git_credentials/%d", credential.ID), adminJWT, nil)
+		is.Equal(http.StatusNoContent, rr.Code)
+	})
+}
@@ -0,0 +1,77 @@
+package gitcredentials
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+var errGitCredentialAccessDenied = errors.New("Access denied to the Git credential")
+
+func hideFields(credential *portainer.GitCredential) {
+	credential.Password = ""
+	credential.SSHPrivateKey = ""
+	credential.SSHPassphrase = ""
+}
+
+// Handler is the HTTP handler used to handle Git credential operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage the Git credentials used by Git-based stacks, custom templates and Edge stacks.
+func NewHandler(bouncer *security.RequestBouncer) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.Handle("/git_credentials",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.gitCredentialCreate))).Methods(http.MethodPost)
+	h.Handle("/git_credentials",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.gitCredentialList))).Methods(http.MethodGet)
+	h.Handle("/git_credentials/{id}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.gitCredentialInspect))).Methods(http.MethodGet)
+	h.Handle("/git_credentials/{id}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.gitCredentialUpdate))).Methods(http.MethodPut)
+	h.Handle("/git_credentials/{id}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.gitCredentialDelete))).Methods(http.MethodDelete)
+
+	return h
+}
+
+// gitCredential retrieves the Git credential identified by the request route and verifies that
+// the user can use it, or manage it when manage is true.
+func (handler *Handler) gitCredential(r *http.Request, manage bool) (*portainer.GitCredential, *httperror.HandlerError) {
+	credentialID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid Git credential identifier route variable", err)
+	}
+
+	credential, err := handler.DataStore.GitCredential().GitCredential(portainer.GitCredentialID(credentialID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a Git credential with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a Git credential with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	authorized := security.AuthorizedGitCredentialAccess(credential, securityContext)
+	if manage {
+		authorized = security.AuthorizedGitCredentialManagement(credential, securityContext)
+	}
+	if !authorized {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Access denied to the Git credential", Err: errGitCredentialAccessDenied}
+	}
+
+	return credential, nil
+}
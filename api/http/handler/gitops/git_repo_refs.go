@@ -0,0 +1,90 @@
+package gitops
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+type repositoryRefsPayload struct {
+	// URL of the Git repository
+	Repository string `example:"https://github.com/portainer/portainer-compose" validate:"required"`
+	// Username used in basic authentication
+	Username string `example:"myGitUsername"`
+	// Password used in basic authentication
+	Password string `example:"myGitPassword"`
+	// Identifier of a stored Git credential, takes precedence over the username and password
+	GitCredentialID portainer.GitCredentialID `example:"1"`
+}
+
+func (payload *repositoryRefsPayload) Validate(r *http.Request) error {
+	if !git.IsValidRepositoryURL(payload.Repository) {
+		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
+	}
+
+	return nil
+}
+
+// @id GitOperationRepoRefs
+// @summary List the references of a Git repository
+// @description List the branches and tags of a Git repository, e.g. to pick the reference or the tag constraint of a Git-based stack.
+// @description **Access policy**: authenticated
+// @tags gitops
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body repositoryRefsPayload true "Git repository details"
+// @success 200 {array} string "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 500 "Server error"
+// @router /gitops/repo/refs [post]
+func (handler *Handler) gitOpsRepoRefs(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload repositoryRefsPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	var auth *gittypes.GitAuthentication
+	if payload.GitCredentialID != 0 {
+		credential, err := handler.DataStore.GitCredential().GitCredential(payload.GitCredentialID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a Git credential with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a Git credential with the specified identifier inside the database", err)
+		}
+
+		securityContext, err := security.RetrieveRestrictedRequestContext(r)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve info from request context", err)
+		}
+
+		if !security.AuthorizedGitCredentialAccess(credential, securityContext) {
+			return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Permission denied to use the Git credential", Err: httperrors.ErrResourceAccessDenied}
+		}
+
+		auth, err = git.ResolveAuthentication(handler.DataStore, &gittypes.GitAuthentication{GitCredentialID: int(payload.GitCredentialID)})
+		if err != nil {
+			return httperror.InternalServerError("Unable to resolve the Git credential", err)
+		}
+	} else if payload.Password != "" {
+		auth = &gittypes.GitAuthentication{Username: payload.Username, Password: payload.Password}
+	}
+
+	refs, err := handler.GitService.ListRefs(payload.Repository, auth)
+	if err != nil {
+		return httperror.InternalServerError("Unable to list the references of the Git repository", err)
+	}
+
+	return response.JSON(w, refs)
+}
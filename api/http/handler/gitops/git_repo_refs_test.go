@@ -0,0 +1,97 @@
+package gitops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+type refsGitService struct {
+	refs []string
+	auth *gittypes.GitAuthentication
+}
+
+func (g *refsGitService) CloneRepository(destination, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOptions *gittypes.CloneOptions) error {
+	return nil
+}
+
+func (g *refsGitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication) (string, error) {
+	return "", nil
+}
+
+func (g *refsGitService) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication) ([]string, error) {
+	g.auth = auth
+	return g.refs, nil
+}
+
+func Test_gitOpsRepoRefs(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser), "error creating admin user")
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user), "error creating user")
+
+	credential := &portainer.GitCredential{Name: "token", UserID: adminUser.ID, Username: "deploy", Password: "secret"}
+	is.NoError(store.GitCredential().Create(credential), "error creating a git credential")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	gitService := &refsGitService{refs: []string{"refs/heads/main", "refs/tags/v1.0.0"}}
+	h := NewHandler(requestBouncer)
+	h.DataStore = store
+	h.GitService = gitService
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	send := func(token string, body repositoryRefsPayload) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/gitops/repo/refs", bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("an invalid repository URL is rejected", func(t *testing.T) {
+		rr := send(userJWT, repositoryRefsPayload{Repository: "not a repository"})
+		is.Equal(http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("lists the references of a repository with a stored credential", func(t *testing.T) {
+		rr := send(adminJWT, repositoryRefsPayload{Repository: "https://gitea.local/org/repo.git", GitCredentialID: credential.ID})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var refs []string
+		is.NoError(json.NewDecoder(rr.Body).Decode(&refs))
+		is.Equal(gitService.refs, refs)
+		is.Equal("secret", gitService.auth.Password, "the credential should be resolved")
+	})
+
+	t.Run("standard user can't use a credential owned by another user", func(t *testing.T) {
+		rr := send(userJWT, repositoryRefsPayload{Repository: "https://gitea.local/org/repo.git", GitCredentialID: credential.ID})
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+}
@@ -0,0 +1,29 @@
+package gitops
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+// Handler is the HTTP handler used to query the Git repositories backing Git-based stacks.
+type Handler struct {
+	*mux.Router
+	DataStore  dataservices.DataStore
+	GitService portainer.GitService
+}
+
+// NewHandler creates a handler to query Git repositories.
+func NewHandler(bouncer *security.RequestBouncer) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.Handle("/gitops/repo/refs",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.gitOpsRepoRefs))).Methods(http.MethodPost)
+
+	return h
+}
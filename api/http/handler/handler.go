@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/portainer/portainer/api/http/handler/auditlogs"
 	"github.com/portainer/portainer/api/http/handler/auth"
 	"github.com/portainer/portainer/api/http/handler/backup"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
@@ -17,12 +18,15 @@ import (
 	"github.com/portainer/portainer/api/http/handler/endpointproxy"
 	"github.com/portainer/portainer/api/http/handler/endpoints"
 	"github.com/portainer/portainer/api/http/handler/file"
+	"github.com/portainer/portainer/api/http/handler/gitcredentials"
+	"github.com/portainer/portainer/api/http/handler/gitops"
 	"github.com/portainer/portainer/api/http/handler/helm"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/fdo"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
 	"github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/notifications"
 	"github.com/portainer/portainer/api/http/handler/registries"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
@@ -43,42 +47,47 @@ import (
 
 // Handler is a collection of all the service handlers.
 type Handler struct {
-	AuthHandler            *auth.Handler
-	BackupHandler          *backup.Handler
-	CustomTemplatesHandler *customtemplates.Handler
-	DockerHandler          *docker.Handler
-	EdgeGroupsHandler      *edgegroups.Handler
-	EdgeJobsHandler        *edgejobs.Handler
-	EdgeStacksHandler      *edgestacks.Handler
-	EdgeTemplatesHandler   *edgetemplates.Handler
-	EndpointEdgeHandler    *endpointedge.Handler
-	EndpointGroupHandler   *endpointgroups.Handler
-	EndpointHandler        *endpoints.Handler
-	EndpointHelmHandler    *helm.Handler
-	EndpointProxyHandler   *endpointproxy.Handler
-	HelmTemplatesHandler   *helm.Handler
-	KubernetesHandler      *kubernetes.Handler
-	FileHandler            *file.Handler
-	LDAPHandler            *ldap.Handler
-	MOTDHandler            *motd.Handler
-	RegistryHandler        *registries.Handler
-	ResourceControlHandler *resourcecontrols.Handler
-	RoleHandler            *roles.Handler
-	SettingsHandler        *settings.Handler
-	SSLHandler             *ssl.Handler
-	OpenAMTHandler         *openamt.Handler
-	FDOHandler             *fdo.Handler
-	StackHandler           *stacks.Handler
-	StatusHandler          *status.Handler
-	StorybookHandler       *storybook.Handler
-	TagHandler             *tags.Handler
-	TeamMembershipHandler  *teammemberships.Handler
-	TeamHandler            *teams.Handler
-	TemplatesHandler       *templates.Handler
-	UploadHandler          *upload.Handler
-	UserHandler            *users.Handler
-	WebSocketHandler       *websocket.Handler
-	WebhookHandler         *webhooks.Handler
+	AuditLogsHandler        *auditlogs.Handler
+	AuthHandler             *auth.Handler
+	BackupHandler           *backup.Handler
+	CustomTemplatesHandler  *customtemplates.Handler
+	DockerHandler           *docker.Handler
+	EdgeGroupsHandler       *edgegroups.Handler
+	EdgeJobsHandler         *edgejobs.Handler
+	EdgeStacksHandler       *edgestacks.Handler
+	EdgeTemplatesHandler    *edgetemplates.Handler
+	EndpointEdgeHandler     *endpointedge.Handler
+	EndpointGroupHandler    *endpointgroups.Handler
+	EndpointHandler         *endpoints.Handler
+	EndpointHelmHandler     *helm.Handler
+	EndpointProxyHandler    *endpointproxy.Handler
+	HelmTemplatesHandler    *helm.Handler
+	HelmRepositoriesHandler *helm.Handler
+	KubernetesHandler       *kubernetes.Handler
+	FileHandler             *file.Handler
+	GitCredentialsHandler   *gitcredentials.Handler
+	GitOpsHandler           *gitops.Handler
+	LDAPHandler             *ldap.Handler
+	MOTDHandler             *motd.Handler
+	NotificationsHandler    *notifications.Handler
+	RegistryHandler         *registries.Handler
+	ResourceControlHandler  *resourcecontrols.Handler
+	RoleHandler             *roles.Handler
+	SettingsHandler         *settings.Handler
+	SSLHandler              *ssl.Handler
+	OpenAMTHandler          *openamt.Handler
+	FDOHandler              *fdo.Handler
+	StackHandler            *stacks.Handler
+	StatusHandler           *status.Handler
+	StorybookHandler        *storybook.Handler
+	TagHandler              *tags.Handler
+	TeamMembershipHandler   *teammemberships.Handler
+	TeamHandler             *teams.Handler
+	TemplatesHandler        *templates.Handler
+	UploadHandler           *upload.Handler
+	UserHandler             *users.Handler
+	WebSocketHandler        *websocket.Handler
+	WebhookHandler          *webhooks.Handler
 }
 
 // @title PortainerCE API
@@ -103,6 +112,8 @@ type Handler struct {
 // @in header
 // @name Authorization
 
+// @tag.name audit_logs
+// @tag.description Browse the audit trail of the Portainer instance
 // @tag.name auth
 // @tag.description Authenticate against Portainer HTTP API
 // @tag.name custom_templates
@@ -119,12 +130,16 @@ type Handler struct {
 // @tag.description Manage Edge related environment(endpoint) settings
 // @tag.name endpoints
 // @tag.description Manage Docker environments(endpoints)
+// @tag.name git_credentials
+// @tag.description Manage the credentials used to access Git repositories
 // @tag.name endpoint_groups
 // @tag.description Manage environment(endpoint) groups
 // @tag.name kubernetes
 // @tag.description Manage Kubernetes cluster
 // @tag.name motd
 // @tag.description Fetch the message of the day
+// @tag.name notifications
+// @tag.description Manage the notification channels and browse their delivery log
 // @tag.name registries
 // @tag.description Manage Docker registries
 // @tag.name resource_controls
@@ -159,6 +174,8 @@ type Handler struct {
 // ServeHTTP delegates a request to the appropriate subhandler.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/audit-logs"):
+		http.StripPrefix("/api", h.AuditLogsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/auth"):
 		http.StripPrefix("/api", h.AuthHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/backup"):
@@ -179,6 +196,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/api", h.EdgeTemplatesHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/endpoint_groups"):
 		http.StripPrefix("/api", h.EndpointGroupHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/git_credentials"):
+		http.StripPrefix("/api", h.GitCredentialsHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/helm_repositories"):
+		http.StripPrefix("/api", h.HelmRepositoriesHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/gitops"):
+		http.StripPrefix("/api", h.GitOpsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/kubernetes"):
 		http.StripPrefix("/api", h.KubernetesHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/docker"):
@@ -207,6 +230,10 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/api", h.LDAPHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/motd"):
 		http.StripPrefix("/api", h.MOTDHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/notification_channels"):
+		http.StripPrefix("/api", h.NotificationsHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/notification_deliveries"):
+		http.StripPrefix("/api", h.NotificationsHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/registries"):
 		http.StripPrefix("/api", h.RegistryHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/resource_controls"):
@@ -0,0 +1,224 @@
+package helm
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/asaskevich/govalidator"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+func hideHelmRepositoryFields(repository *portainer.HelmRepository) {
+	repository.Password = ""
+}
+
+type helmRepositoryPayload struct {
+	// Name of the Helm repository
+	Name string `example:"internal-charts" validate:"required"`
+	// URL of the Helm repository
+	URL string `example:"https://charts.mydomain.tld" validate:"required"`
+	// Username used to authenticate against the repository
+	Username string `example:"chartmuseum"`
+	// Password used to authenticate against the repository, kept on update when empty
+	Password string `example:"chartmuseum_password"`
+	// Teams allowed to use the repository, the repository is shared with all the users when empty
+	TeamIDs []portainer.TeamID `example:"1"`
+}
+
+func (payload *helmRepositoryPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("Invalid Helm repository name")
+	}
+	if govalidator.IsNull(payload.URL) {
+		return errors.New("Invalid Helm repository URL")
+	}
+
+	return nil
+}
+
+// validateTeams verifies that the teams of a Helm repository exist
+func (handler *Handler) validateTeams(teamIDs []portainer.TeamID) *httperror.HandlerError {
+	for _, teamID := range teamIDs {
+		_, err := handler.dataStore.Team().Team(teamID)
+		if handler.dataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a team with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a team with the specified identifier inside the database", err)
+		}
+	}
+
+	return nil
+}
+
+// @id HelmRepositoryCreate
+// @summary Create a global Helm repository
+// @description Create a Helm repository shared with teams, or with all the users when no team is specified.
+// @description Its credentials are used to search the repository and to pull its charts, the password is never returned.
+// @description **Access policy**: administrator
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body helmRepositoryPayload true "Helm repository details"
+// @success 200 {object} portainer.HelmRepository "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 500 "Server error"
+// @router /helm_repositories [post]
+func (handler *Handler) helmRepositoryCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload helmRepositoryPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	httpErr := handler.validateTeams(payload.TeamIDs)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err = validateHelmRepository(payload.URL, payload.Username, payload.Password)
+	if err != nil {
+		return httperror.BadRequest("Invalid Helm repository", err)
+	}
+
+	repository := &portainer.HelmRepository{
+		Name:     payload.Name,
+		URL:      normalizeHelmRepositoryURL(payload.URL),
+		Username: payload.Username,
+		Password: payload.Password,
+		TeamIDs:  payload.TeamIDs,
+	}
+
+	err = handler.dataStore.HelmRepository().Create(repository)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the Helm repository inside the database", err)
+	}
+
+	hideHelmRepositoryFields(repository)
+	return response.JSON(w, repository)
+}
+
+// @id HelmRepositoryList
+// @summary List the global Helm repositories
+// @description List the Helm repositories shared by the administrators. Passwords are never returned.
+// @description **Access policy**: administrator
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.HelmRepository "Success"
+// @failure 500 "Server error"
+// @router /helm_repositories [get]
+func (handler *Handler) helmRepositoryList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	repositories, err := handler.dataStore.HelmRepository().HelmRepositories()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the Helm repositories from the database", err)
+	}
+
+	for i := range repositories {
+		hideHelmRepositoryFields(&repositories[i])
+	}
+
+	return response.JSON(w, repositories)
+}
+
+// @id HelmRepositoryUpdate
+// @summary Update a global Helm repository
+// @description Update a Helm repository shared by the administrators. The password is kept when left empty.
+// @description **Access policy**: administrator
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Helm repository identifier"
+// @param body body helmRepositoryPayload true "Helm repository details"
+// @success 200 {object} portainer.HelmRepository "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Helm repository not found"
+// @failure 500 "Server error"
+// @router /helm_repositories/{id} [put]
+func (handler *Handler) helmRepositoryUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	repositoryID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid Helm repository identifier route variable", err)
+	}
+
+	var payload helmRepositoryPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	repository, err := handler.dataStore.HelmRepository().HelmRepository(portainer.HelmRepositoryID(repositoryID))
+	if handler.dataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a Helm repository with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a Helm repository with the specified identifier inside the database", err)
+	}
+
+	httpErr := handler.validateTeams(payload.TeamIDs)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	repository.Name = payload.Name
+	repository.URL = normalizeHelmRepositoryURL(payload.URL)
+	repository.Username = payload.Username
+	repository.TeamIDs = payload.TeamIDs
+	if payload.Password != "" || payload.Username == "" {
+		repository.Password = payload.Password
+	}
+
+	err = validateHelmRepository(repository.URL, repository.Username, repository.Password)
+	if err != nil {
+		return httperror.BadRequest("Invalid Helm repository", err)
+	}
+
+	err = handler.dataStore.HelmRepository().UpdateHelmRepository(repository.ID, repository)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the Helm repository changes inside the database", err)
+	}
+
+	hideHelmRepositoryFields(repository)
+	return response.JSON(w, repository)
+}
+
+// @id HelmRepositoryDelete
+// @summary Remove a global Helm repository
+// @description Remove a Helm repository shared by the administrators.
+// @description **Access policy**: administrator
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Helm repository identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Helm repository not found"
+// @failure 500 "Server error"
+// @router /helm_repositories/{id} [delete]
+func (handler *Handler) helmRepositoryDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	repositoryID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid Helm repository identifier route variable", err)
+	}
+
+	_, err = handler.dataStore.HelmRepository().HelmRepository(portainer.HelmRepositoryID(repositoryID))
+	if handler.dataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a Helm repository with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a Helm repository with the specified identifier inside the database", err)
+	}
+
+	err = handler.dataStore.HelmRepository().DeleteHelmRepository(portainer.HelmRepositoryID(repositoryID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the Helm repository from the database", err)
+	}
+
+	return response.Empty(w)
+}
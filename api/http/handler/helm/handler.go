@@ -16,6 +16,7 @@ import (
 
 type requestBouncer interface {
 	AuthenticatedAccess(h http.Handler) http.Handler
+	AdminAccess(h http.Handler) http.Handler
 }
 
 // Handler is the HTTP handler used to handle environment(endpoint) group operations.
@@ -27,10 +28,11 @@ type Handler struct {
 	kubeClusterAccessService kubernetes.KubeClusterAccessService
 	kubernetesDeployer       portainer.KubernetesDeployer
 	helmPackageManager       libhelm.HelmPackageManager
+	helmReleaseManager       portainer.HelmReleaseManager
 }
 
 // NewHandler creates a handler to manage endpoint group operations.
-func NewHandler(bouncer requestBouncer, dataStore dataservices.DataStore, jwtService dataservices.JWTService, kubernetesDeployer portainer.KubernetesDeployer, helmPackageManager libhelm.HelmPackageManager, kubeClusterAccessService kubernetes.KubeClusterAccessService) *Handler {
+func NewHandler(bouncer requestBouncer, dataStore dataservices.DataStore, jwtService dataservices.JWTService, kubernetesDeployer portainer.KubernetesDeployer, helmPackageManager libhelm.HelmPackageManager, helmReleaseManager portainer.HelmReleaseManager, kubeClusterAccessService kubernetes.KubeClusterAccessService) *Handler {
 	h := &Handler{
 		Router:                   mux.NewRouter(),
 		requestBouncer:           bouncer,
@@ -38,6 +40,7 @@ func NewHandler(bouncer requestBouncer, dataStore dataservices.DataStore, jwtSer
 		jwtService:               jwtService,
 		kubernetesDeployer:       kubernetesDeployer,
 		helmPackageManager:       helmPackageManager,
+		helmReleaseManager:       helmReleaseManager,
 		kubeClusterAccessService: kubeClusterAccessService,
 	}
 
@@ -55,6 +58,18 @@ func NewHandler(bouncer requestBouncer, dataStore dataservices.DataStore, jwtSer
 	h.Handle("/{id}/kubernetes/helm",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmInstall))).Methods(http.MethodPost)
 
+	// `helm upgrade RELEASE_NAME [CHART] flags`
+	h.Handle("/{id}/kubernetes/helm/{release}/upgrade",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmUpgrade))).Methods(http.MethodPost)
+
+	// `helm history RELEASE_NAME`
+	h.Handle("/{id}/kubernetes/helm/{release}/history",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmHistory))).Methods(http.MethodGet)
+
+	// `helm rollback RELEASE_NAME [REVISION]`
+	h.Handle("/{id}/kubernetes/helm/{release}/rollback",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmRollback))).Methods(http.MethodPost)
+
 	h.Handle("/{id}/kubernetes/helm/repositories",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.userGetHelmRepos))).Methods(http.MethodGet)
 	h.Handle("/{id}/kubernetes/helm/repositories",
@@ -64,9 +79,10 @@ func NewHandler(bouncer requestBouncer, dataStore dataservices.DataStore, jwtSer
 }
 
 // NewTemplateHandler creates a template handler to manage environment(endpoint) group operations.
-func NewTemplateHandler(bouncer requestBouncer, helmPackageManager libhelm.HelmPackageManager) *Handler {
+func NewTemplateHandler(bouncer requestBouncer, dataStore dataservices.DataStore, helmPackageManager libhelm.HelmPackageManager) *Handler {
 	h := &Handler{
 		Router:             mux.NewRouter(),
+		dataStore:          dataStore,
 		helmPackageManager: helmPackageManager,
 		requestBouncer:     bouncer,
 	}
@@ -81,6 +97,26 @@ func NewTemplateHandler(bouncer requestBouncer, helmPackageManager libhelm.HelmP
 	return h
 }
 
+// NewRepositoriesHandler creates a handler to manage the Helm repositories shared by the administrators.
+func NewRepositoriesHandler(bouncer requestBouncer, dataStore dataservices.DataStore) *Handler {
+	h := &Handler{
+		Router:         mux.NewRouter(),
+		dataStore:      dataStore,
+		requestBouncer: bouncer,
+	}
+
+	h.Handle("/helm_repositories",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.helmRepositoryCreate))).Methods(http.MethodPost)
+	h.Handle("/helm_repositories",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.helmRepositoryList))).Methods(http.MethodGet)
+	h.Handle("/helm_repositories/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.helmRepositoryUpdate))).Methods(http.MethodPut)
+	h.Handle("/helm_repositories/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.helmRepositoryDelete))).Methods(http.MethodDelete)
+
+	return h
+}
+
 // getHelmClusterAccess obtains the core k8s cluster access details from request.
 // The cluster access includes the cluster server url, the user's bearer token and the tls certificate.
 // The cluster access is passed in as kube config CLI params to helm binary.
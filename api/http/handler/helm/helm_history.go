@@ -0,0 +1,52 @@
+package helm
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id HelmHistory
+// @summary List the revisions of a Helm Release
+// @description
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release"
+// @param namespace query string false "An optional namespace"
+// @success 200 {array} portainer.HelmReleaseRevision "Success"
+// @failure 400 "Invalid environment(endpoint) id or bad request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release}/history [get]
+func (handler *Handler) helmHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	releaseName, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "No release specified", Err: err}
+	}
+
+	clusterAccess, httpErr := handler.getHelmClusterAccess(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	namespace, _ := request.RetrieveQueryParameter(r, "namespace", true)
+
+	revisions, err := handler.helmReleaseManager.History(portainer.HelmHistoryOptions{
+		Name:                    releaseName,
+		Namespace:               namespace,
+		KubernetesClusterAccess: clusterAccess,
+	})
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Helm returned an error", Err: err}
+	}
+
+	return response.JSON(w, revisions)
+}
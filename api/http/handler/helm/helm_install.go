@@ -13,6 +13,8 @@ import (
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/exec"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/kubernetes"
@@ -23,9 +25,11 @@ import (
 type installChartPayload struct {
 	Namespace string `json:"namespace"`
 	Name      string `json:"name"`
-	Chart     string `json:"chart"`
-	Repo      string `json:"repo"`
-	Values    string `json:"values"`
+	// Name of the chart in the repository, or oci:// reference of the chart
+	Chart string `json:"chart"`
+	// Helm repository hosting the chart, must be empty for an oci:// chart
+	Repo   string `json:"repo"`
+	Values string `json:"values"`
 }
 
 var errChartNameInvalid = errors.New("invalid chart name. " +
@@ -35,7 +39,8 @@ var errChartNameInvalid = errors.New("invalid chart name. " +
 
 // @id HelmInstall
 // @summary Install Helm Chart
-// @description
+// @description Install a chart hosted by the global Helm repository, a Helm repository shared with the user, one of the user Helm repositories
+// @description or an OCI registry. The credentials of the repository, or of the registry configured for the host of an oci:// chart, are used to pull it.
 // @description **Access policy**: authenticated
 // @tags helm
 // @security ApiKeyAuth
@@ -46,6 +51,7 @@ var errChartNameInvalid = errors.New("invalid chart name. " +
 // @param payload body installChartPayload true "Chart details"
 // @success 201 {object} release.Release "Created"
 // @failure 401 "Unauthorized"
+// @failure 403 "Permission denied to use the Helm repository"
 // @failure 404 "Environment(Endpoint) or ServiceAccount not found"
 // @failure 500 "Server error"
 // @router /endpoints/{id}/kubernetes/helm [post]
@@ -60,7 +66,12 @@ func (handler *Handler) helmInstall(w http.ResponseWriter, r *http.Request) *htt
 		}
 	}
 
-	release, err := handler.installChart(r, payload)
+	access, httpErr := handler.resolveChartAccess(r, payload.Chart, payload.Repo)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	release, err := handler.installChart(r, payload, access)
 	if err != nil {
 		return &httperror.HandlerError{
 			StatusCode: http.StatusInternalServerError,
@@ -75,7 +86,7 @@ func (handler *Handler) helmInstall(w http.ResponseWriter, r *http.Request) *htt
 
 func (p *installChartPayload) Validate(_ *http.Request) error {
 	var required []string
-	if p.Repo == "" {
+	if p.Repo == "" && !exec.IsOCIChart(p.Chart) {
 		required = append(required, "repo")
 	}
 	if p.Name == "" {
@@ -94,11 +105,14 @@ func (p *installChartPayload) Validate(_ *http.Request) error {
 	if errs := validation.IsDNS1123Subdomain(p.Name); len(errs) > 0 {
 		return errChartNameInvalid
 	}
+	if exec.IsOCIChart(p.Chart) && p.Repo != "" {
+		return errors.New("repo must be empty for an oci:// chart")
+	}
 
 	return nil
 }
 
-func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*release.Release, error) {
+func (handler *Handler) installChart(r *http.Request, p installChartPayload, access *helmRepositoryAccess) (*release.Release, error) {
 	clusterAccess, httperr := handler.getHelmClusterAccess(r)
 	if httperr != nil {
 		return nil, httperr.Err
@@ -116,29 +130,38 @@ func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*r
 	}
 
 	if p.Values != "" {
-		file, err := os.CreateTemp("", "helm-values")
-		if err != nil {
-			return nil, err
-		}
-		defer os.Remove(file.Name())
-		_, err = file.WriteString(p.Values)
+		valuesFile, err := createValuesFile(p.Values)
 		if err != nil {
-			file.Close()
 			return nil, err
 		}
-		err = file.Close()
-		if err != nil {
-			return nil, err
-		}
-		installOpts.ValuesFile = file.Name()
+		defer os.Remove(valuesFile)
+		installOpts.ValuesFile = valuesFile
 	}
 
-	release, err := handler.helmPackageManager.Install(installOpts)
+	var release *release.Release
+	var err error
+	if access.Username != "" || exec.IsOCIChart(p.Chart) {
+		// libhelm can't pass credentials to the helm binary nor pull oci:// charts,
+		// the release is installed with `helm install` which fails when it already exists
+		release, err = handler.helmReleaseManager.Upgrade(portainer.HelmUpgradeOptions{
+			Name:                    installOpts.Name,
+			Chart:                   installOpts.Chart,
+			Namespace:               installOpts.Namespace,
+			Repo:                    installOpts.Repo,
+			ValuesFile:              installOpts.ValuesFile,
+			Install:                 true,
+			Username:                access.Username,
+			Password:                access.Password,
+			KubernetesClusterAccess: installOpts.KubernetesClusterAccess,
+		})
+	} else {
+		release, err = handler.helmPackageManager.Install(installOpts)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	manifest, err := handler.applyPortainerLabelsToHelmAppManifest(r, installOpts, release.Manifest)
+	manifest, err := handler.applyPortainerLabelsToHelmAppManifest(r, installOpts.Name, release.Manifest)
 	if err != nil {
 		return nil, err
 	}
@@ -151,10 +174,31 @@ func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*r
 	return release, nil
 }
 
+// createValuesFile writes the values of a release in a temporary file and returns its path
+func createValuesFile(values string) (string, error) {
+	file, err := os.CreateTemp("", "helm-values")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(values)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	err = file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
 // applyPortainerLabelsToHelmAppManifest will patch all the resources deployed in the helm release manifest
 // with portainer specific labels. This is to mark the resources as managed by portainer - hence the helm apps
 // wont appear external in the portainer UI.
-func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, installOpts options.InstallOptions, manifest string) ([]byte, error) {
+func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, releaseName string, manifest string) ([]byte, error) {
 	// Patch helm release by adding with portainer labels to all deployed resources
 	tokenData, err := security.RetrieveTokenData(r)
 	if err != nil {
@@ -165,7 +209,7 @@ func (handler *Handler) applyPortainerLabelsToHelmAppManifest(r *http.Request, i
 		return nil, errors.Wrap(err, "unable to load user information from the database")
 	}
 
-	appLabels := kubernetes.GetHelmAppLabels(installOpts.Name, user.Username)
+	appLabels := kubernetes.GetHelmAppLabels(releaseName, user.Username)
 	labeledManifest, err := kubernetes.AddAppLabels([]byte(manifest), appLabels)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to label helm release manifest")
@@ -1,13 +1,22 @@
 package helm
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/portainer/libhelm"
+	"github.com/portainer/libhelm/binary"
 	"github.com/portainer/libhelm/options"
 	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/portainer/api/exec"
+	"github.com/portainer/portainer/api/http/security"
+	"gopkg.in/yaml.v3"
 )
 
 // @id HelmRepoSearch
@@ -35,12 +44,27 @@ func (handler *Handler) helmRepoSearch(w http.ResponseWriter, r *http.Request) *
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Bad request", Err: errors.Wrap(err, fmt.Sprintf("provided URL %q is not valid", repo))}
 	}
+	if exec.IsOCIChart(repo) {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Bad request", Err: errors.New("OCI registries don't have a chart index to search")}
+	}
 
-	searchOpts := options.SearchRepoOptions{
-		Repo: repo,
+	// the credentials of the repositories available to the user are used, other repositories are searched anonymously
+	access := &helmRepositoryAccess{}
+	if tokenData, err := security.RetrieveTokenData(r); err == nil {
+		access, err = handler.resolveHelmRepository(tokenData, repo)
+		if err == errHelmRepositoryAccessDenied {
+			access = &helmRepositoryAccess{}
+		} else if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to verify the access to the Helm repository", Err: err}
+		}
 	}
 
-	result, err := handler.helmPackageManager.SearchRepo(searchOpts)
+	var result []byte
+	if access.Username != "" {
+		result, err = searchHelmRepository(repo, access.Username, access.Password)
+	} else {
+		result, err = handler.helmPackageManager.SearchRepo(options.SearchRepoOptions{Repo: repo})
+	}
 	if err != nil {
 		return &httperror.HandlerError{
 			StatusCode: http.StatusInternalServerError,
@@ -54,3 +78,75 @@ func (handler *Handler) helmRepoSearch(w http.ResponseWriter, r *http.Request) *
 
 	return nil
 }
+
+// fetchHelmRepositoryIndex requests the index.yaml file of a Helm repository with basic authentication
+func fetchHelmRepositoryIndex(method, repo, username, password string, timeout time.Duration) (*http.Response, error) {
+	indexURL, err := url.ParseRequestURI(repo)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("invalid helm chart URL: %s", repo))
+	}
+	indexURL.Path = path.Join(indexURL.Path, "index.yaml")
+
+	req, err := http.NewRequest(method, indexURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(username, password)
+
+	client := http.Client{
+		Timeout: timeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get index file")
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("failed to get index file: %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+// searchHelmRepository downloads the index.yaml file of an authenticated Helm repository and returns it as JSON,
+// like the SearchRepo function of libhelm does for public repositories
+func searchHelmRepository(repo, username, password string) ([]byte, error) {
+	resp, err := fetchHelmRepositoryIndex(http.MethodGet, repo, username, password, 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var file binary.File
+	err = yaml.NewDecoder(resp.Body).Decode(&file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode index file")
+	}
+
+	result, err := json.Marshal(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal index file")
+	}
+
+	return result, nil
+}
+
+// validateHelmRepository verifies that a URL is a Helm repository reachable with the credentials
+func validateHelmRepository(repo, username, password string) error {
+	if username == "" {
+		return libhelm.ValidateHelmRepositoryURL(repo)
+	}
+
+	parsedURL, err := url.ParseRequestURI(repo)
+	if err != nil || (!strings.EqualFold(parsedURL.Scheme, "http") && !strings.EqualFold(parsedURL.Scheme, "https")) {
+		return errors.Errorf("invalid helm chart URL: %s", repo)
+	}
+
+	resp, err := fetchHelmRepositoryIndex(http.MethodHead, repo, username, password, 10*time.Second)
+	if err != nil {
+		return errors.Wrapf(err, "%q is not a valid chart repository or cannot be reached", repo)
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -18,7 +18,7 @@ func Test_helmRepoSearch(t *testing.T) {
 	is := assert.New(t)
 
 	helmPackageManager := test.NewMockHelmBinaryPackageManager("")
-	h := NewTemplateHandler(helper.NewTestRequestBouncer(), helmPackageManager)
+	h := NewTemplateHandler(helper.NewTestRequestBouncer(), helper.NewDatastore(), helmPackageManager)
 
 	assert.NotNil(t, h, "Handler should not fail")
 
@@ -0,0 +1,198 @@
+package helm
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/exec"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/registryutils"
+)
+
+var errHelmRepositoryAccessDenied = errors.New("the Helm repository is not available to the user")
+
+// helmRepositoryAccess holds the credentials used to pull the charts of a Helm repository or of an OCI registry
+type helmRepositoryAccess struct {
+	Username string
+	Password string
+}
+
+// normalizeHelmRepositoryURL lowercases a Helm repository URL and removes its trailing slash
+func normalizeHelmRepositoryURL(repo string) string {
+	return strings.TrimSuffix(strings.ToLower(repo), "/")
+}
+
+// helmRepositorySharedWith returns true when a global Helm repository is shared with the user
+func helmRepositorySharedWith(repository *portainer.HelmRepository, role portainer.UserRole, memberships []portainer.TeamMembership) bool {
+	if role == portainer.AdministratorRole || len(repository.TeamIDs) == 0 {
+		return true
+	}
+
+	for _, membership := range memberships {
+		for _, teamID := range repository.TeamIDs {
+			if membership.TeamID == teamID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sharedHelmRepositories returns the global Helm repositories shared with the user
+func (handler *Handler) sharedHelmRepositories(tokenData *portainer.TokenData) ([]portainer.HelmRepository, error) {
+	repositories, err := handler.dataStore.HelmRepository().HelmRepositories()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the Helm repositories from the database")
+	}
+
+	memberships, err := handler.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the team memberships of the user")
+	}
+
+	shared := make([]portainer.HelmRepository, 0, len(repositories))
+	for _, repository := range repositories {
+		if helmRepositorySharedWith(&repository, tokenData.Role, memberships) {
+			shared = append(shared, repository)
+		}
+	}
+
+	return shared, nil
+}
+
+// resolveHelmRepository verifies that a user can pull charts from a Helm repository and returns its credentials.
+// A user can use the global Helm repository of the settings, the repositories shared with all the users
+// or with one of their teams, and their own repositories.
+func (handler *Handler) resolveHelmRepository(tokenData *portainer.TokenData, repo string) (*helmRepositoryAccess, error) {
+	repo = normalizeHelmRepositoryURL(repo)
+
+	settings, err := handler.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve settings from the database")
+	}
+	if normalizeHelmRepositoryURL(settings.HelmRepositoryURL) == repo {
+		return &helmRepositoryAccess{}, nil
+	}
+
+	sharedRepositories, err := handler.sharedHelmRepositories(tokenData)
+	if err != nil {
+		return nil, err
+	}
+	for _, repository := range sharedRepositories {
+		if normalizeHelmRepositoryURL(repository.URL) == repo {
+			return &helmRepositoryAccess{Username: repository.Username, Password: repository.Password}, nil
+		}
+	}
+
+	userRepositories, err := handler.dataStore.HelmUserRepository().HelmUserRepositoryByUserID(tokenData.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get user Helm repositories")
+	}
+	for _, repository := range userRepositories {
+		if normalizeHelmRepositoryURL(repository.URL) == repo {
+			return &helmRepositoryAccess{Username: repository.Username, Password: repository.Password}, nil
+		}
+	}
+
+	return nil, errHelmRepositoryAccessDenied
+}
+
+// registryHost returns the host of a registry URL, e.g. registry.mydomain.tld:5000 for https://registry.mydomain.tld:5000/v2
+func registryHost(registryURL string) string {
+	host := registryURL
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+
+	return strings.ToLower(host)
+}
+
+// resolveOCIRegistry returns the credentials of the registry hosting an oci:// chart.
+// The chart is pulled anonymously when no registry is configured for its host,
+// otherwise the user must have access to the registry in the environment.
+func (handler *Handler) resolveOCIRegistry(r *http.Request, tokenData *portainer.TokenData, chart string) (*helmRepositoryAccess, error) {
+	chartURL, err := url.Parse(chart)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid oci:// chart reference")
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find an endpoint on request context")
+	}
+
+	registries, err := handler.dataStore.Registry().Registries()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the registries from the database")
+	}
+
+	for _, registry := range registries {
+		if registryHost(registry.URL) != strings.ToLower(chartURL.Host) {
+			continue
+		}
+
+		user, err := handler.dataStore.User().User(tokenData.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load user information from the database")
+		}
+
+		memberships, err := handler.dataStore.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to retrieve the team memberships of the user")
+		}
+
+		if !security.AuthorizedRegistryAccess(&registry, user, memberships, endpoint.ID) {
+			return nil, errHelmRepositoryAccessDenied
+		}
+
+		if !registry.Authentication {
+			return &helmRepositoryAccess{}, nil
+		}
+
+		err = registryutils.EnsureRegTokenValid(handler.dataStore, &registry)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to refresh the token of the registry")
+		}
+
+		username, password, err := registryutils.GetRegEffectiveCredential(&registry)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to retrieve the credentials of the registry")
+		}
+
+		return &helmRepositoryAccess{Username: username, Password: password}, nil
+	}
+
+	return &helmRepositoryAccess{}, nil
+}
+
+// resolveChartAccess verifies that the user can pull a chart, from an oci:// registry or from a Helm repository,
+// and returns the credentials used to pull it
+func (handler *Handler) resolveChartAccess(r *http.Request, chart, repo string) (*helmRepositoryAccess, *httperror.HandlerError) {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve user authentication token", Err: err}
+	}
+
+	var access *helmRepositoryAccess
+	if exec.IsOCIChart(chart) {
+		access, err = handler.resolveOCIRegistry(r, tokenData, chart)
+	} else {
+		access, err = handler.resolveHelmRepository(tokenData, repo)
+	}
+	if err == errHelmRepositoryAccessDenied {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Permission denied to use the Helm repository", Err: err}
+	} else if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to verify the access to the Helm repository", Err: err}
+	}
+
+	return access, nil
+}
@@ -0,0 +1,207 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/portainer/libhelm/binary/test"
+	"github.com/portainer/libhelm/release"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/exec/exectest"
+	"github.com/portainer/portainer/api/http/security"
+	helper "github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/kubernetes"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHelmReleaseManager records the options of the upgrades, including the installs done with the helm binary
+type recordingHelmReleaseManager struct {
+	portainer.HelmReleaseManager
+	upgrades []portainer.HelmUpgradeOptions
+}
+
+func (manager *recordingHelmReleaseManager) Upgrade(upgradeOpts portainer.HelmUpgradeOptions) (*release.Release, error) {
+	manager.upgrades = append(manager.upgrades, upgradeOpts)
+	return manager.HelmReleaseManager.Upgrade(upgradeOpts)
+}
+
+// newAuthenticatedHelmRepository serves a Helm repository index requiring basic authentication
+func newAuthenticatedHelmRepository(username, password string) *httptest.Server {
+	index := "apiVersion: v1\nentries:\n  internal-app:\n  - name: internal-app\n    version: 1.0.0\n"
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != username || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(index))
+	}))
+}
+
+func Test_helmRepositoriesCredentials(t *testing.T) {
+	is := assert.New(t)
+
+	repository := newAuthenticatedHelmRepository("chartmuseum", "secret")
+	defer repository.Close()
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1})
+	is.NoError(err, "error creating environment")
+
+	err = store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	is.NoError(err, "error creating a user")
+	err = store.User().Create(&portainer.User{Username: "member", Role: portainer.StandardUserRole})
+	is.NoError(err, "error creating a user")
+	err = store.User().Create(&portainer.User{Username: "outsider", Role: portainer.StandardUserRole})
+	is.NoError(err, "error creating a user")
+
+	err = store.Team().Create(&portainer.Team{Name: "charts"})
+	is.NoError(err, "error creating a team")
+	err = store.TeamMembership().Create(&portainer.TeamMembership{UserID: 2, TeamID: 1, Role: portainer.TeamMember})
+	is.NoError(err, "error creating a team membership")
+
+	err = store.Registry().Create(&portainer.Registry{
+		Type:           portainer.CustomRegistry,
+		URL:            "registry.mydomain.tld",
+		Authentication: true,
+		Username:       "registry-user",
+		Password:       "registry-password",
+		RegistryAccesses: portainer.RegistryAccesses{
+			1: {TeamAccessPolicies: portainer.TeamAccessPolicies{1: {}}},
+		},
+	})
+	is.NoError(err, "error creating a registry")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+
+	releaseManager := &recordingHelmReleaseManager{HelmReleaseManager: exectest.NewHelmReleaseManager()}
+	kubeClusterAccessService := kubernetes.NewKubeClusterAccessService("", "", "")
+	h := NewHandler(helper.NewTestRequestBouncer(), store, jwtService, exectest.NewKubernetesDeployer(), test.NewMockHelmBinaryPackageManager(""), releaseManager, kubeClusterAccessService)
+	repositoriesHandler := NewRepositoriesHandler(helper.NewTestRequestBouncer(), store)
+	templatesHandler := NewTemplateHandler(helper.NewTestRequestBouncer(), store, test.NewMockHelmBinaryPackageManager(""))
+
+	admin := &portainer.TokenData{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	member := &portainer.TokenData{ID: 2, Username: "member", Role: portainer.StandardUserRole}
+	outsider := &portainer.TokenData{ID: 3, Username: "outsider", Role: portainer.StandardUserRole}
+
+	serve := func(handler http.Handler, tokenData *portainer.TokenData, method, url string, payload interface{}) *httptest.ResponseRecorder {
+		var body io.Reader
+		if payload != nil {
+			data, err := json.Marshal(payload)
+			is.NoError(err)
+			body = bytes.NewBuffer(data)
+		}
+
+		req := httptest.NewRequest(method, url, body)
+		req = req.WithContext(security.StoreTokenData(req, tokenData))
+		req.Header.Add("Authorization", "Bearer dummytoken")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("a global repository with invalid credentials is rejected", func(t *testing.T) {
+		rr := serve(repositoriesHandler, admin, http.MethodPost, "/helm_repositories", helmRepositoryPayload{Name: "internal", URL: repository.URL, Username: "chartmuseum", Password: "wrong"})
+		is.Equal(http.StatusBadRequest, rr.Code, "Status should be 400")
+	})
+
+	t.Run("admin shares a global repository with a team and the password is not returned", func(t *testing.T) {
+		rr := serve(repositoriesHandler, admin, http.MethodPost, "/helm_repositories", helmRepositoryPayload{Name: "internal", URL: repository.URL, Username: "chartmuseum", Password: "secret", TeamIDs: []portainer.TeamID{1}})
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		var created portainer.HelmRepository
+		is.NoError(json.NewDecoder(rr.Body).Decode(&created))
+		is.Equal("chartmuseum", created.Username)
+		is.Empty(created.Password)
+
+		rr = serve(repositoriesHandler, admin, http.MethodGet, "/helm_repositories", nil)
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.NotContains(rr.Body.String(), "secret")
+	})
+
+	t.Run("the shared repositories are listed for the members of the team only", func(t *testing.T) {
+		var resp helmUserRepositoryResponse
+		rr := serve(h, member, http.MethodGet, "/1/kubernetes/helm/repositories", nil)
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.Len(resp.SharedRepositories, 1)
+		is.Empty(resp.SharedRepositories[0].Password)
+
+		rr = serve(h, outsider, http.MethodGet, "/1/kubernetes/helm/repositories", nil)
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.Empty(resp.SharedRepositories)
+	})
+
+	t.Run("a member of the team searches the repository with its credentials", func(t *testing.T) {
+		rr := serve(templatesHandler, member, http.MethodGet, "/templates/helm?repo="+url.QueryEscape(repository.URL), nil)
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.Contains(rr.Body.String(), "internal-app")
+
+		// the repository is searched anonymously for the users it isn't shared with
+		rr = serve(templatesHandler, outsider, http.MethodGet, "/templates/helm?repo="+url.QueryEscape(repository.URL), nil)
+		is.NotContains(rr.Body.String(), "internal-app")
+	})
+
+	t.Run("a member of the team installs a chart with the credentials of the repository", func(t *testing.T) {
+		rr := serve(h, member, http.MethodPost, "/1/kubernetes/helm", installChartPayload{Name: "internal-app", Namespace: "default", Chart: "internal-app", Repo: repository.URL + "/"})
+		is.Equal(http.StatusCreated, rr.Code, "Status should be 201")
+
+		is.Len(releaseManager.upgrades, 1)
+		is.True(releaseManager.upgrades[0].Install)
+		is.Equal("chartmuseum", releaseManager.upgrades[0].Username)
+		is.Equal("secret", releaseManager.upgrades[0].Password)
+	})
+
+	t.Run("a user outside of the team can't install a chart of the repository", func(t *testing.T) {
+		rr := serve(h, outsider, http.MethodPost, "/1/kubernetes/helm", installChartPayload{Name: "internal-app-2", Namespace: "default", Chart: "internal-app", Repo: repository.URL})
+		is.Equal(http.StatusForbidden, rr.Code, "Status should be 403")
+	})
+
+	t.Run("a user repository with credentials is used to upgrade a release", func(t *testing.T) {
+		rr := serve(h, outsider, http.MethodPost, "/1/kubernetes/helm/repositories", addHelmRepoUrlPayload{URL: repository.URL, Username: "chartmuseum", Password: "secret"})
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.NotContains(rr.Body.String(), "secret")
+
+		rr = serve(h, outsider, http.MethodPost, "/1/kubernetes/helm/internal-app-3/upgrade", upgradeReleasePayload{Namespace: "default", Chart: "internal-app", Repo: repository.URL})
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.Equal("secret", releaseManager.upgrades[len(releaseManager.upgrades)-1].Password)
+	})
+
+	t.Run("an oci:// chart is installed with the credentials of the registry", func(t *testing.T) {
+		rr := serve(h, member, http.MethodPost, "/1/kubernetes/helm", installChartPayload{Name: "oci-app", Namespace: "default", Chart: "oci://registry.mydomain.tld/charts/app"})
+		is.Equal(http.StatusCreated, rr.Code, "Status should be 201")
+
+		upgrade := releaseManager.upgrades[len(releaseManager.upgrades)-1]
+		is.Equal("oci://registry.mydomain.tld/charts/app", upgrade.Chart)
+		is.Empty(upgrade.Repo)
+		is.Equal("registry-user", upgrade.Username)
+		is.Equal("registry-password", upgrade.Password)
+	})
+
+	t.Run("an oci:// chart of a registry the user can't access is forbidden", func(t *testing.T) {
+		rr := serve(h, outsider, http.MethodPost, "/1/kubernetes/helm", installChartPayload{Name: "oci-app-2", Namespace: "default", Chart: "oci://registry.mydomain.tld/charts/app"})
+		is.Equal(http.StatusForbidden, rr.Code, "Status should be 403")
+	})
+
+	t.Run("an oci:// chart can't be combined with a repository", func(t *testing.T) {
+		rr := serve(h, member, http.MethodPost, "/1/kubernetes/helm", installChartPayload{Name: "oci-app-3", Namespace: "default", Chart: "oci://registry.mydomain.tld/charts/app", Repo: repository.URL})
+		is.Equal(http.StatusBadRequest, rr.Code, "Status should be 400")
+	})
+}
@@ -0,0 +1,81 @@
+package helm
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+type rollbackReleasePayload struct {
+	Namespace string `json:"namespace"`
+	// Revision to roll back to, the previous revision when 0
+	Revision int `json:"revision"`
+}
+
+func (p *rollbackReleasePayload) Validate(_ *http.Request) error {
+	if p.Namespace == "" {
+		return errors.New("required field(s) missing: namespace")
+	}
+	if p.Revision < 0 {
+		return errors.New("invalid revision")
+	}
+
+	return nil
+}
+
+// @id HelmRollback
+// @summary Roll back a Helm Release
+// @description Roll back a Helm release to a previous revision, the restored resources are labeled again as Portainer applications.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release to roll back"
+// @param payload body rollbackReleasePayload true "Revision details"
+// @success 200 {object} release.Release "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release}/rollback [post]
+func (handler *Handler) helmRollback(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	releaseName, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "No release specified", Err: err}
+	}
+
+	var payload rollbackReleasePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid Helm rollback payload", Err: err}
+	}
+
+	clusterAccess, httpErr := handler.getHelmClusterAccess(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	release, err := handler.helmReleaseManager.Rollback(portainer.HelmRollbackOptions{
+		Name:                    releaseName,
+		Namespace:               payload.Namespace,
+		Revision:                payload.Revision,
+		KubernetesClusterAccess: clusterAccess,
+	})
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Helm returned an error", Err: err}
+	}
+
+	err = handler.labelHelmRelease(r, releaseName, payload.Namespace, release.Manifest)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to label the resources of the release", Err: err}
+	}
+
+	return response.JSON(w, release)
+}
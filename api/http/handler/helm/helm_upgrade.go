@@ -0,0 +1,142 @@
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/exec"
+)
+
+type upgradeReleasePayload struct {
+	Namespace string `json:"namespace"`
+	// Name of the chart in the repository, or oci:// reference of the chart
+	Chart string `json:"chart"`
+	// Helm repository hosting the chart, the global Helm repository when empty. Must be empty for an oci:// chart.
+	Repo string `json:"repo"`
+	// Version of the chart, the latest version when empty
+	Version string `json:"version"`
+	Values  string `json:"values"`
+	// Merge the values into the values of the current revision instead of the default values of the chart
+	ReuseValues bool `json:"reuseValues"`
+}
+
+func (p *upgradeReleasePayload) Validate(_ *http.Request) error {
+	var required []string
+	if p.Namespace == "" {
+		required = append(required, "namespace")
+	}
+	if p.Chart == "" {
+		required = append(required, "chart")
+	}
+	if len(required) > 0 {
+		return fmt.Errorf("required field(s) missing: %s", strings.Join(required, ", "))
+	}
+	if exec.IsOCIChart(p.Chart) && p.Repo != "" {
+		return errors.New("repo must be empty for an oci:// chart")
+	}
+
+	return nil
+}
+
+// @id HelmUpgrade
+// @summary Upgrade Helm Release
+// @description Upgrade a Helm release to a new version of its chart or with new values.
+// @description The chart must be hosted by the global Helm repository, a Helm repository shared with the user, one of the user Helm repositories
+// @description or an OCI registry. The credentials of the repository, or of the registry configured for the host of an oci:// chart, are used to pull it.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release to upgrade"
+// @param payload body upgradeReleasePayload true "Chart details"
+// @success 200 {object} release.Release "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Unauthorized"
+// @failure 403 "Permission denied to use the Helm repository"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error or helm error"
+// @router /endpoints/{id}/kubernetes/helm/{release}/upgrade [post]
+func (handler *Handler) helmUpgrade(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	releaseName, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "No release specified", Err: err}
+	}
+
+	var payload upgradeReleasePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid Helm upgrade payload", Err: err}
+	}
+
+	if payload.Repo == "" && !exec.IsOCIChart(payload.Chart) {
+		settings, err := handler.dataStore.Settings().Settings()
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve settings from the database", Err: err}
+		}
+		payload.Repo = settings.HelmRepositoryURL
+	}
+
+	access, httpErr := handler.resolveChartAccess(r, payload.Chart, payload.Repo)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	clusterAccess, httpErr := handler.getHelmClusterAccess(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	upgradeOpts := portainer.HelmUpgradeOptions{
+		Name:                    releaseName,
+		Chart:                   payload.Chart,
+		Namespace:               payload.Namespace,
+		Repo:                    payload.Repo,
+		Version:                 payload.Version,
+		ReuseValues:             payload.ReuseValues,
+		Username:                access.Username,
+		Password:                access.Password,
+		KubernetesClusterAccess: clusterAccess,
+	}
+
+	if payload.Values != "" {
+		valuesFile, err := createValuesFile(payload.Values)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to write the values of the release", Err: err}
+		}
+		defer os.Remove(valuesFile)
+		upgradeOpts.ValuesFile = valuesFile
+	}
+
+	release, err := handler.helmReleaseManager.Upgrade(upgradeOpts)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Helm returned an error", Err: err}
+	}
+
+	// the Portainer labels are not part of the chart, they are applied again to the upgraded resources
+	err = handler.labelHelmRelease(r, releaseName, payload.Namespace, release.Manifest)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to label the resources of the release", Err: err}
+	}
+
+	return response.JSON(w, release)
+}
+
+// labelHelmRelease applies the Portainer labels to the resources of the manifest of a release
+func (handler *Handler) labelHelmRelease(r *http.Request, releaseName, namespace, manifest string) error {
+	labeledManifest, err := handler.applyPortainerLabelsToHelmAppManifest(r, releaseName, manifest)
+	if err != nil {
+		return err
+	}
+
+	return handler.updateHelmAppManifest(r, labeledManifest, namespace)
+}
@@ -0,0 +1,111 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/portainer/libhelm/binary/test"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/exec/exectest"
+	"github.com/portainer/portainer/api/http/security"
+	helper "github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/kubernetes"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_helmUpgradeHistoryRollback(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1})
+	is.NoError(err, "error creating environment")
+
+	err = store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	is.NoError(err, "error creating a user")
+
+	err = store.HelmUserRepository().Create(&portainer.HelmUserRepository{UserID: 1, URL: "https://charts.example.com"})
+	is.NoError(err, "error creating a user Helm repository")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+
+	kubernetesDeployer := exectest.NewKubernetesDeployer()
+	helmPackageManager := test.NewMockHelmBinaryPackageManager("")
+	kubeClusterAccessService := kubernetes.NewKubeClusterAccessService("", "", "")
+	h := NewHandler(helper.NewTestRequestBouncer(), store, jwtService, kubernetesDeployer, helmPackageManager, exectest.NewHelmReleaseManager(), kubeClusterAccessService)
+
+	is.NotNil(h, "Handler should not fail")
+
+	serve := func(method, url string, payload interface{}) *httptest.ResponseRecorder {
+		var body io.Reader
+		if payload != nil {
+			data, err := json.Marshal(payload)
+			is.NoError(err)
+			body = bytes.NewBuffer(data)
+		}
+
+		req := httptest.NewRequest(method, url, body)
+		ctx := security.StoreTokenData(req, &portainer.TokenData{ID: 1, Username: "admin", Role: 1})
+		req = req.WithContext(ctx)
+		req.Header.Add("Authorization", "Bearer dummytoken")
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	history := func() []portainer.HelmReleaseRevision {
+		rr := serve(http.MethodGet, "/1/kubernetes/helm/nginx-1/history?namespace=default", nil)
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		var revisions []portainer.HelmReleaseRevision
+		err := json.NewDecoder(rr.Body).Decode(&revisions)
+		is.NoError(err, "response should be json")
+		return revisions
+	}
+
+	t.Run("history of an unknown release fails", func(t *testing.T) {
+		rr := serve(http.MethodGet, "/1/kubernetes/helm/unknown/history?namespace=default", nil)
+		is.Equal(http.StatusInternalServerError, rr.Code, "Status should be 500")
+	})
+
+	t.Run("upgrade with the global repository succeeds", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-1/upgrade", upgradeReleasePayload{Namespace: "default", Chart: "nginx"})
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.Len(history(), 1)
+	})
+
+	t.Run("upgrade with a user repository succeeds", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-1/upgrade", upgradeReleasePayload{Namespace: "default", Chart: "nginx", Repo: "https://charts.example.com/", Values: "replicaCount: 2"})
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+		is.Len(history(), 2)
+	})
+
+	t.Run("upgrade with an unknown repository is forbidden", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-1/upgrade", upgradeReleasePayload{Namespace: "default", Chart: "nginx", Repo: "https://charts.unknown.com"})
+		is.Equal(http.StatusForbidden, rr.Code, "Status should be 403")
+		is.Len(history(), 2)
+	})
+
+	t.Run("upgrade without a chart fails", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-1/upgrade", upgradeReleasePayload{Namespace: "default"})
+		is.Equal(http.StatusBadRequest, rr.Code, "Status should be 400")
+	})
+
+	t.Run("rollback to the previous revision succeeds", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/1/kubernetes/helm/nginx-1/rollback", rollbackReleasePayload{Namespace: "default"})
+		is.Equal(http.StatusOK, rr.Code, "Status should be 200")
+
+		revisions := history()
+		is.Len(revisions, 3)
+		is.Equal("Rollback to 1", revisions[2].Description)
+	})
+}
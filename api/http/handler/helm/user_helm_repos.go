@@ -6,8 +6,6 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/portainer/libhelm"
-
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
@@ -18,19 +16,25 @@ import (
 type helmUserRepositoryResponse struct {
 	GlobalRepository string                         `json:"GlobalRepository"`
 	UserRepositories []portainer.HelmUserRepository `json:"UserRepositories"`
+	// Helm repositories defined by the administrators and shared with the user
+	SharedRepositories []portainer.HelmRepository `json:"SharedRepositories"`
 }
 
 type addHelmRepoUrlPayload struct {
 	URL string `json:"url"`
+	// Username used to authenticate against the repository
+	Username string `json:"username"`
+	// Password used to authenticate against the repository
+	Password string `json:"password"`
 }
 
 func (p *addHelmRepoUrlPayload) Validate(_ *http.Request) error {
-	return libhelm.ValidateHelmRepositoryURL(p.URL)
+	return validateHelmRepository(p.URL, p.Username, p.Password)
 }
 
 // @id HelmUserRepositoryCreate
 // @summary Create a user helm repository
-// @description Create a user helm repository.
+// @description Create a user helm repository. The credentials are used to search the repository and to pull its charts, the password is never returned.
 // @description **Access policy**: authenticated
 // @tags helm
 // @security ApiKeyAuth
@@ -77,8 +81,10 @@ func (handler *Handler) userCreateHelmRepo(w http.ResponseWriter, r *http.Reques
 	}
 
 	record := portainer.HelmUserRepository{
-		UserID: userID,
-		URL:    p.URL,
+		UserID:   userID,
+		URL:      p.URL,
+		Username: p.Username,
+		Password: p.Password,
 	}
 
 	err = handler.dataStore.HelmUserRepository().Create(&record)
@@ -86,6 +92,7 @@ func (handler *Handler) userCreateHelmRepo(w http.ResponseWriter, r *http.Reques
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to save a user Helm repository URL", err}
 	}
 
+	record.Password = ""
 	return response.JSON(w, record)
 }
 
@@ -120,9 +127,22 @@ func (handler *Handler) userGetHelmRepos(w http.ResponseWriter, r *http.Request)
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to get user Helm repositories", err}
 	}
 
+	sharedRepos, err := handler.sharedHelmRepositories(tokenData)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to get the Helm repositories shared with the user", Err: err}
+	}
+
+	for i := range userRepos {
+		userRepos[i].Password = ""
+	}
+	for i := range sharedRepos {
+		hideHelmRepositoryFields(&sharedRepos[i])
+	}
+
 	resp := helmUserRepositoryResponse{
-		GlobalRepository: settings.HelmRepositoryURL,
-		UserRepositories: userRepos,
+		GlobalRepository:   settings.HelmRepositoryURL,
+		UserRepositories:   userRepos,
+		SharedRepositories: sharedRepos,
 	}
 
 	return response.JSON(w, resp)
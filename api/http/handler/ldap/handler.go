@@ -9,14 +9,16 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/http/security"
+	ldapsync "github.com/portainer/portainer/api/ldap"
 )
 
 // Handler is the HTTP handler used to handle LDAP search Operations
 type Handler struct {
 	*mux.Router
-	DataStore   dataservices.DataStore
-	FileService portainer.FileService
-	LDAPService portainer.LDAPService
+	DataStore    dataservices.DataStore
+	FileService  portainer.FileService
+	LDAPService  portainer.LDAPService
+	Synchronizer *ldapsync.Synchronizer
 }
 
 // NewHandler returns a new Handler
@@ -27,6 +29,10 @@ func NewHandler(bouncer *security.RequestBouncer) *Handler {
 
 	h.Handle("/ldap/check",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.ldapCheck))).Methods(http.MethodPost)
+	h.Handle("/ldap/sync",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.ldapSyncStatus))).Methods(http.MethodGet)
+	h.Handle("/ldap/sync",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.ldapSync))).Methods(http.MethodPost)
 
 	return h
 }
@@ -0,0 +1,54 @@
+package ldap
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	ldapsync "github.com/portainer/portainer/api/ldap"
+)
+
+// @id LDAPSync
+// @summary Synchronize the teams with the LDAP groups
+// @description Add the users to the teams matching their LDAP groups and remove them from the teams matching the groups they left.
+// @description Teams are created for the unmatched groups and the users no longer matching the search settings are disabled when enabled in the LDAP settings.
+// @description With dryRun, the changes are only reported and the status of the last synchronization is left unchanged.
+// @description **Access policy**: administrator
+// @tags ldap
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param dryRun query boolean false "Report the changes without applying them"
+// @success 200 {object} portainer.LDAPSyncReport "Success"
+// @failure 400 "LDAP authentication is not enabled"
+// @failure 500 "Server error"
+// @router /ldap/sync [post]
+func (handler *Handler) ldapSync(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	dryRun, _ := request.RetrieveBooleanQueryParameter(r, "dryRun", true)
+
+	report, err := handler.Synchronizer.Sync(dryRun)
+	if errors.Is(err, ldapsync.ErrLDAPAuthenticationDisabled) {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "LDAP authentication is not enabled", Err: err}
+	} else if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to synchronize the teams with the LDAP groups", Err: err}
+	}
+
+	return response.JSON(w, report)
+}
+
+// @id LDAPSyncStatus
+// @summary Inspect the last LDAP synchronization
+// @description Retrieve the time, the error and the changes of the last synchronization of the teams with the LDAP groups.
+// @description **Access policy**: administrator
+// @tags ldap
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {object} portainer.LDAPSyncStatus "Success"
+// @failure 500 "Server error"
+// @router /ldap/sync [get]
+func (handler *Handler) ldapSyncStatus(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return response.JSON(w, handler.Synchronizer.Status())
+}
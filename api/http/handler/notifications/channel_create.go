@@ -0,0 +1,168 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/asaskevich/govalidator"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+type channelPayload struct {
+	// Name of the channel
+	Name string `example:"ops-team" validate:"required"`
+	// Type of the channel. Valid values are: 1 (generic JSON webhook), 2 (Slack compatible webhook) or 3 (email)
+	Type portainer.NotificationChannelType `example:"1" validate:"required"`
+	// Whether the events are delivered to the channel
+	Enabled bool `example:"true"`
+	// List of the event types the channel is subscribed to
+	Events []portainer.NotificationEventType `example:"stack.deploy.failed,endpoint.down" validate:"required"`
+	// URL the events are posted to, required by the webhook and Slack channels. The current URL is kept on update when omitted
+	URL *string `example:"https://hooks.slack.com/services/T00000000/B00000000/XXXXXXXX"`
+	// Settings of the mail server, required by the email channels
+	SMTPSettings smtpSettingsPayload
+}
+
+type smtpSettingsPayload struct {
+	// Hostname of the mail server
+	Host string `example:"smtp.mydomain.tld"`
+	// Port of the mail server
+	Port int `example:"587"`
+	// Username used to authenticate against the mail server, no authentication when empty
+	Username string `example:"portainer"`
+	// Password used to authenticate against the mail server. The current password is kept on update when omitted
+	Password *string `example:"secret"`
+	// Address the emails are sent from
+	From string `example:"portainer@mydomain.tld"`
+	// Addresses the emails are sent to
+	To []string `example:"ops@mydomain.tld"`
+}
+
+func (payload *channelPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("Invalid channel name")
+	}
+
+	if len(payload.Events) == 0 {
+		return errors.New("The channel must be subscribed to at least one event")
+	}
+
+	for _, event := range payload.Events {
+		if !isValidEventType(event) {
+			return errors.New("Invalid event type. Value must be one of: stack.deploy.failed, endpoint.down, edgestack.error or apikey.expiring")
+		}
+	}
+
+	return nil
+}
+
+// apply updates the channel with the payload, the URL and the mail server password are only changed when specified.
+// The masked URL returned for the Slack channels is ignored.
+func (payload *channelPayload) apply(channel *portainer.NotificationChannel) {
+	channelURL := channel.URL
+	if payload.URL != nil && *payload.URL != maskURL(channel.URL) {
+		channelURL = *payload.URL
+	}
+
+	password := channel.SMTPSettings.Password
+	if payload.SMTPSettings.Password != nil {
+		password = *payload.SMTPSettings.Password
+	}
+
+	channel.Name = payload.Name
+	channel.Type = payload.Type
+	channel.Enabled = payload.Enabled
+	channel.Events = payload.Events
+	channel.URL = channelURL
+	channel.SMTPSettings = portainer.NotificationSMTPSettings{
+		Host:     payload.SMTPSettings.Host,
+		Port:     payload.SMTPSettings.Port,
+		Username: payload.SMTPSettings.Username,
+		Password: password,
+		From:     payload.SMTPSettings.From,
+		To:       payload.SMTPSettings.To,
+	}
+}
+
+// validateChannel checks the settings required by the type of the channel
+func validateChannel(channel *portainer.NotificationChannel) error {
+	switch channel.Type {
+	case portainer.NotificationChannelWebhook, portainer.NotificationChannelSlack:
+		if !govalidator.IsURL(channel.URL) {
+			return errors.New("Invalid channel URL. Must correspond to a valid URL format")
+		}
+	case portainer.NotificationChannelEmail:
+		settings := channel.SMTPSettings
+		if govalidator.IsNull(settings.Host) {
+			return errors.New("Invalid mail server host")
+		}
+		if settings.Port <= 0 || settings.Port > 65535 {
+			return errors.New("Invalid mail server port")
+		}
+		if !govalidator.IsEmail(settings.From) {
+			return errors.New("Invalid sender address")
+		}
+		if len(settings.To) == 0 {
+			return errors.New("At least one recipient address is required")
+		}
+		for _, to := range settings.To {
+			if !govalidator.IsEmail(to) {
+				return errors.New("Invalid recipient address")
+			}
+		}
+	default:
+		return errors.New("Invalid channel type. Value must be one of: 1 (webhook), 2 (Slack) or 3 (email)")
+	}
+
+	return nil
+}
+
+func isValidEventType(eventType portainer.NotificationEventType) bool {
+	switch eventType {
+	case portainer.NotificationEventStackDeployFailed, portainer.NotificationEventEndpointDown, portainer.NotificationEventEdgeStackError,
+		portainer.NotificationEventAPIKeyExpiring:
+		return true
+	}
+	return false
+}
+
+// @id NotificationChannelCreate
+// @summary Create a notification channel
+// @description Create a notification channel the subscribed events are delivered to.
+// @description **Access policy**: administrator
+// @tags notifications
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body channelPayload true "Notification channel details"
+// @success 200 {object} portainer.NotificationChannel "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /notification_channels [post]
+func (handler *Handler) channelCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload channelPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	channel := &portainer.NotificationChannel{}
+	payload.apply(channel)
+
+	err = validateChannel(channel)
+	if err != nil {
+		return httperror.BadRequest("Invalid notification channel", err)
+	}
+
+	err = handler.DataStore.NotificationChannel().Create(channel)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the notification channel inside the database", err)
+	}
+
+	hideFields(channel)
+	return response.JSON(w, channel)
+}
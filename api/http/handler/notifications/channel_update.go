@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id NotificationChannelUpdate
+// @summary Update a notification channel
+// @description Update a notification channel. The URL and the mail server password are kept when omitted,
+// @description an empty mail server password removes the authentication password.
+// @description **Access policy**: administrator
+// @tags notifications
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Notification channel identifier"
+// @param body body channelPayload true "Notification channel details"
+// @success 200 {object} portainer.NotificationChannel "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Notification channel not found"
+// @failure 500 "Server error"
+// @router /notification_channels/{id} [put]
+func (handler *Handler) channelUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	channelID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid notification channel identifier route variable", err)
+	}
+
+	var payload channelPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	channel, err := handler.DataStore.NotificationChannel().NotificationChannel(portainer.NotificationChannelID(channelID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a notification channel with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a notification channel with the specified identifier inside the database", err)
+	}
+
+	payload.apply(channel)
+
+	err = validateChannel(channel)
+	if err != nil {
+		return httperror.BadRequest("Invalid notification channel", err)
+	}
+
+	err = handler.DataStore.NotificationChannel().UpdateNotificationChannel(channel.ID, channel)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the notification channel changes inside the database", err)
+	}
+
+	hideFields(channel)
+	return response.JSON(w, channel)
+}
@@ -0,0 +1,76 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_channelUpdate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	email := &portainer.NotificationChannel{
+		Name:         "email",
+		Type:         portainer.NotificationChannelEmail,
+		Events:       []portainer.NotificationEventType{portainer.NotificationEventEndpointDown},
+		SMTPSettings: portainer.NotificationSMTPSettings{Host: "smtp.local", Port: 25, Username: "portainer", Password: "secret", From: "portainer@local.tld", To: []string{"ops@local.tld"}},
+	}
+	is.NoError(store.NotificationChannel().Create(email))
+
+	const slackURL = "https://hooks.slack.com/services/T00000000/B00000000/XXXXXXXX"
+	slack := &portainer.NotificationChannel{
+		Name:   "slack",
+		Type:   portainer.NotificationChannelSlack,
+		Events: []portainer.NotificationEventType{portainer.NotificationEventEndpointDown},
+		URL:    slackURL,
+	}
+	is.NoError(store.NotificationChannel().Create(slack))
+
+	handler := &Handler{DataStore: store}
+
+	update := func(id portainer.NotificationChannelID, body string) (*httptest.ResponseRecorder, *portainer.NotificationChannel) {
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/notification_channels/%d", id), strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": fmt.Sprint(id)})
+
+		rr := httptest.NewRecorder()
+		is.Nil(handler.channelUpdate(rr, req))
+
+		stored, err := store.NotificationChannel().NotificationChannel(id)
+		is.NoError(err)
+		return rr, stored
+	}
+
+	smtpSettings := `"Host":"smtp.local","Port":25,"From":"portainer@local.tld","To":["ops@local.tld"]`
+
+	t.Run("the mail server password is kept when omitted", func(t *testing.T) {
+		_, stored := update(email.ID, `{"Name":"email","Type":3,"Events":["endpoint.down"],"SMTPSettings":{"Username":"portainer",`+smtpSettings+`}}`)
+		is.Equal("secret", stored.SMTPSettings.Password)
+	})
+
+	t.Run("the mail server password is removed when empty", func(t *testing.T) {
+		_, stored := update(email.ID, `{"Name":"email","Type":3,"Events":["endpoint.down"],"SMTPSettings":{"Password":"",`+smtpSettings+`}}`)
+		is.Empty(stored.SMTPSettings.Password)
+		is.Empty(stored.SMTPSettings.Username)
+	})
+
+	t.Run("the Slack webhook URL is masked and kept when sent back masked", func(t *testing.T) {
+		rr, stored := update(slack.ID, `{"Name":"slack-ops","Type":2,"Events":["endpoint.down"],"URL":"https://hooks.slack.com/********"}`)
+		is.Equal(slackURL, stored.URL)
+		is.Equal("slack-ops", stored.Name)
+
+		var channel portainer.NotificationChannel
+		is.NoError(json.NewDecoder(rr.Body).Decode(&channel))
+		is.Equal("https://hooks.slack.com/********", channel.URL)
+	})
+}
@@ -0,0 +1,88 @@
+package notifications
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// @id NotificationDeliveryList
+// @summary List notification deliveries
+// @description List the delivery log of the notification channels, most recent first.
+// @description **Access policy**: administrator
+// @tags notifications
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param start query int false "Start searching from"
+// @param limit query int false "Limit results to this value"
+// @param channelId query int false "Only return the deliveries of this channel"
+// @param status query string false "Only return the deliveries with this status" Enum("pending", "delivered", "failed")
+// @success 200 {array} portainer.NotificationDelivery "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /notification_deliveries [get]
+func (handler *Handler) deliveryList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	start, _ := request.RetrieveNumericQueryParameter(r, "start", true)
+	if start != 0 {
+		start--
+	}
+
+	limit, _ := request.RetrieveNumericQueryParameter(r, "limit", true)
+
+	channelID, err := request.RetrieveNumericQueryParameter(r, "channelId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: channelId", err)
+	}
+
+	status, _ := request.RetrieveQueryParameter(r, "status", true)
+
+	deliveries, err := handler.DataStore.NotificationDelivery().NotificationDeliveries()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notification deliveries from the database", err)
+	}
+
+	filteredDeliveries := make([]portainer.NotificationDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if channelID != 0 && delivery.ChannelID != portainer.NotificationChannelID(channelID) {
+			continue
+		}
+
+		if status != "" && delivery.Status != portainer.NotificationDeliveryStatus(status) {
+			continue
+		}
+
+		filteredDeliveries = append(filteredDeliveries, delivery)
+	}
+
+	sort.SliceStable(filteredDeliveries, func(i, j int) bool {
+		return filteredDeliveries[i].ID > filteredDeliveries[j].ID
+	})
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(filteredDeliveries)))
+	return response.JSON(w, paginateDeliveries(filteredDeliveries, start, limit))
+}
+
+func paginateDeliveries(deliveries []portainer.NotificationDelivery, start, limit int) []portainer.NotificationDelivery {
+	if limit == 0 {
+		return deliveries
+	}
+
+	deliveryCount := len(deliveries)
+
+	if start > deliveryCount {
+		start = deliveryCount
+	}
+
+	end := start + limit
+	if end > deliveryCount {
+		end = deliveryCount
+	}
+
+	return deliveries[start:end]
+}
@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	httperror "github.com/portainer/libhttp/error"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+func hideFields(channel *portainer.NotificationChannel) {
+	channel.SMTPSettings.Password = ""
+
+	// the path of a Slack webhook URL is its secret token
+	if channel.Type == portainer.NotificationChannelSlack {
+		channel.URL = maskURL(channel.URL)
+	}
+}
+
+// maskURL returns the scheme and the host of a URL, the rest of the URL is replaced by a mask
+func maskURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return urlMask
+	}
+
+	return u.Scheme + "://" + u.Host + "/" + urlMask
+}
+
+const urlMask = "********"
+
+// Handler is the HTTP handler used to handle notification channel operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage the notification channels and browse their delivery log.
+func NewHandler(bouncer *security.RequestBouncer) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.Handle("/notification_channels",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.channelCreate))).Methods(http.MethodPost)
+	h.Handle("/notification_channels",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.channelList))).Methods(http.MethodGet)
+	h.Handle("/notification_channels/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.channelInspect))).Methods(http.MethodGet)
+	h.Handle("/notification_channels/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.channelUpdate))).Methods(http.MethodPut)
+	h.Handle("/notification_channels/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.channelDelete))).Methods(http.MethodDelete)
+	h.Handle("/notification_deliveries",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.deliveryList))).Methods(http.MethodGet)
+
+	return h
+}
@@ -1,18 +1,25 @@
 package roles
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 )
 
+var errBuiltInRole = errors.New("Built-in roles can't be modified")
+
 // Handler is the HTTP handler used to handle role operations.
 type Handler struct {
 	*mux.Router
-	DataStore dataservices.DataStore
+	DataStore            dataservices.DataStore
+	AuthorizationService *authorization.Service
 }
 
 // NewHandler creates a handler to manage role operations.
@@ -22,6 +29,33 @@ func NewHandler(bouncer *security.RequestBouncer) *Handler {
 	}
 	h.Handle("/roles",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.roleList))).Methods(http.MethodGet)
+	h.Handle("/roles",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.roleCreate))).Methods(http.MethodPost)
+	h.Handle("/roles/authorizations",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.roleAuthorizationList))).Methods(http.MethodGet)
+	h.Handle("/roles/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.roleInspect))).Methods(http.MethodGet)
+	h.Handle("/roles/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.roleUpdate))).Methods(http.MethodPut)
+	h.Handle("/roles/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.roleDelete))).Methods(http.MethodDelete)
 
 	return h
 }
+
+// role retrieves the role identified by the request route
+func (handler *Handler) role(r *http.Request) (*portainer.Role, *httperror.HandlerError) {
+	roleID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid role identifier route variable", err)
+	}
+
+	role, err := handler.DataStore.Role().Role(portainer.RoleID(roleID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a role with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a role with the specified identifier inside the database", err)
+	}
+
+	return role, nil
+}
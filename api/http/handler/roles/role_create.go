@@ -0,0 +1,119 @@
+package roles
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/authorization"
+)
+
+type rolePayload struct {
+	// Name of the role
+	Name string `example:"StackOperator" validate:"required"`
+	// Description of the role
+	Description string `example:"Deploy and update the stacks of an environment"`
+	// Authorizations of the role, picked from the list returned by /roles/authorizations
+	Authorizations []portainer.Authorization `example:"DockerContainerList,PortainerStackList" validate:"required"`
+	// Priority of the role, the role with the highest priority applies when several roles are associated to a user in an environment
+	Priority int `example:"5" validate:"required"`
+}
+
+func (payload *rolePayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("Invalid role name")
+	}
+	if len(payload.Authorizations) == 0 {
+		return errors.New("Invalid role authorizations: at least one authorization is required")
+	}
+	if payload.Priority <= 0 {
+		return errors.New("Invalid role priority: must be greater than 0")
+	}
+
+	var unknown []string
+	for _, a := range payload.Authorizations {
+		if !authorization.IsValidAuthorization(a) {
+			unknown = append(unknown, string(a))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("Invalid role authorizations: unknown authorization(s) %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+func (payload *rolePayload) authorizations() portainer.Authorizations {
+	authorizations := make(portainer.Authorizations, len(payload.Authorizations))
+	for _, a := range payload.Authorizations {
+		authorizations[a] = true
+	}
+
+	return authorizations
+}
+
+// checkUniqueName verifies that no other role has the same name
+func (handler *Handler) checkUniqueName(name string, roleID portainer.RoleID) *httperror.HandlerError {
+	roles, err := handler.DataStore.Role().Roles()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve roles from the database", err)
+	}
+
+	for _, role := range roles {
+		if role.ID != roleID && strings.EqualFold(role.Name, name) {
+			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "A role with the same name already exists", Err: errors.New("A role with the same name already exists")}
+		}
+	}
+
+	return nil
+}
+
+// @id RoleCreate
+// @summary Create a custom role
+// @description Create a role with a custom set of authorizations. The role can then be associated to users and teams
+// @description in the access policies of environments and environment groups.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body rolePayload true "Role details"
+// @success 200 {object} portainer.Role "Success"
+// @failure 400 "Invalid request"
+// @failure 409 "A role with the same name already exists"
+// @failure 500 "Server error"
+// @router /roles [post]
+func (handler *Handler) roleCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload rolePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	httpErr := handler.checkUniqueName(payload.Name, 0)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	role := &portainer.Role{
+		Name:           payload.Name,
+		Description:    payload.Description,
+		Authorizations: payload.authorizations(),
+		Priority:       payload.Priority,
+		IsCustom:       true,
+	}
+
+	err = handler.DataStore.Role().Create(role)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the role inside the database", err)
+	}
+
+	return response.JSON(w, role)
+}
@@ -0,0 +1,55 @@
+package roles
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id RoleDelete
+// @summary Remove a custom role
+// @description Remove a custom role. The role can't be removed while access policies of environments or environment groups reference it.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Role identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Built-in roles can't be modified"
+// @failure 404 "Role not found"
+// @failure 409 "The role is used by access policies"
+// @failure 500 "Server error"
+// @router /roles/{id} [delete]
+func (handler *Handler) roleDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	role, httpErr := handler.role(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if !role.IsCustom {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Built-in roles can't be modified", Err: errBuiltInRole}
+	}
+
+	inUse, err := handler.AuthorizationService.RoleInUse(role.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the access policies using the role", err)
+	}
+	if inUse {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The role is used by access policies", Err: errors.New("The role is used by access policies of environments or environment groups")}
+	}
+
+	err = handler.DataStore.Role().DeleteRole(role.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the role from the database", err)
+	}
+
+	err = handler.AuthorizationService.UpdateUsersAuthorizations()
+	if err != nil {
+		return httperror.InternalServerError("Unable to update user authorizations", err)
+	}
+
+	return response.Empty(w)
+}
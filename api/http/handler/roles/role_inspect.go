@@ -0,0 +1,54 @@
+package roles
+
+import (
+	"net/http"
+	"sort"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/authorization"
+)
+
+// @id RoleInspect
+// @summary Inspect a role
+// @description Retrieve details about a role.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Role identifier"
+// @success 200 {object} portainer.Role "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Role not found"
+// @failure 500 "Server error"
+// @router /roles/{id} [get]
+func (handler *Handler) roleInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	role, httpErr := handler.role(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, role)
+}
+
+// @id RoleAuthorizationList
+// @summary List the authorizations
+// @description List the authorizations that can be associated to a custom role.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} string "Success"
+// @router /roles/authorizations [get]
+func (handler *Handler) roleAuthorizationList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	authorizations := make([]portainer.Authorization, 0)
+	for a := range authorization.AllAuthorizations() {
+		authorizations = append(authorizations, a)
+	}
+	sort.Slice(authorizations, func(i, j int) bool { return authorizations[i] < authorizations[j] })
+
+	return response.JSON(w, authorizations)
+}
@@ -0,0 +1,134 @@
+package roles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_customRoles(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(adminUser)
+	is.NoError(err, "error creating admin user")
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	err = store.Role().Create(&portainer.Role{Name: "Read-only user", Priority: 4, Authorizations: portainer.Authorizations{portainer.OperationDockerContainerList: true}})
+	is.NoError(err, "error creating built-in role")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	h := NewHandler(requestBouncer)
+	h.DataStore = store
+	h.AuthorizationService = authorization.NewService(store)
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+
+	send := func(method, url string, body interface{}) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		is.NoError(err)
+
+		req := httptest.NewRequest(method, url, bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", adminJWT))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var role portainer.Role
+
+	t.Run("a role requires known authorizations", func(t *testing.T) {
+		rr := send(http.MethodPost, "/roles", rolePayload{Name: "operator", Priority: 5, Authorizations: []portainer.Authorization{"DockerContainerTeleport"}})
+		is.Equal(http.StatusBadRequest, rr.Code)
+
+		rr = send(http.MethodPost, "/roles", rolePayload{Name: "operator", Priority: 5})
+		is.Equal(http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("admin creates a custom role", func(t *testing.T) {
+		rr := send(http.MethodPost, "/roles", rolePayload{Name: "operator", Priority: 5, Authorizations: []portainer.Authorization{portainer.OperationDockerContainerList, portainer.OperationDockerContainerStart}})
+		is.Equal(http.StatusOK, rr.Code)
+
+		is.NoError(json.NewDecoder(rr.Body).Decode(&role))
+		is.True(role.IsCustom)
+		is.Len(role.Authorizations, 2)
+	})
+
+	t.Run("role names are unique", func(t *testing.T) {
+		rr := send(http.MethodPost, "/roles", rolePayload{Name: "Operator", Priority: 6, Authorizations: []portainer.Authorization{portainer.OperationDockerContainerList}})
+		is.Equal(http.StatusConflict, rr.Code)
+	})
+
+	t.Run("the authorizations are listed", func(t *testing.T) {
+		rr := send(http.MethodGet, "/roles/authorizations", nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var authorizations []portainer.Authorization
+		is.NoError(json.NewDecoder(rr.Body).Decode(&authorizations))
+		is.Greater(len(authorizations), 200)
+		is.Contains(authorizations, portainer.OperationDockerContainerStart)
+	})
+
+	t.Run("updating a role recomputes the authorizations of its users", func(t *testing.T) {
+		err := store.Endpoint().Create(&portainer.Endpoint{ID: 1, UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {RoleID: role.ID}}})
+		is.NoError(err)
+		is.NoError(h.AuthorizationService.UpdateUsersAuthorizations())
+
+		rr := send(http.MethodPut, fmt.Sprintf("/roles/%d", role.ID), rolePayload{Name: "operator", Priority: 5, Authorizations: []portainer.Authorization{portainer.OperationDockerContainerList, portainer.OperationDockerContainerStop}})
+		is.Equal(http.StatusOK, rr.Code)
+
+		updatedUser, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.True(updatedUser.EndpointAuthorizations[1][portainer.OperationDockerContainerStop])
+		is.False(updatedUser.EndpointAuthorizations[1][portainer.OperationDockerContainerStart])
+	})
+
+	t.Run("a role used by access policies can't be removed", func(t *testing.T) {
+		rr := send(http.MethodDelete, fmt.Sprintf("/roles/%d", role.ID), nil)
+		is.Equal(http.StatusConflict, rr.Code)
+	})
+
+	t.Run("built-in roles can't be modified", func(t *testing.T) {
+		rr := send(http.MethodPut, "/roles/1", rolePayload{Name: "reader", Priority: 4, Authorizations: []portainer.Authorization{portainer.OperationDockerContainerList}})
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = send(http.MethodDelete, "/roles/1", nil)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("an unused role is removed", func(t *testing.T) {
+		endpoint, err := store.Endpoint().Endpoint(1)
+		is.NoError(err)
+		endpoint.UserAccessPolicies = portainer.UserAccessPolicies{}
+		is.NoError(store.Endpoint().UpdateEndpoint(1, endpoint))
+
+		rr := send(http.MethodDelete, fmt.Sprintf("/roles/%d", role.ID), nil)
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		updatedUser, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.Empty(updatedUser.EndpointAuthorizations[1])
+	})
+}
@@ -0,0 +1,67 @@
+package roles
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+)
+
+// @id RoleUpdate
+// @summary Update a custom role
+// @description Update the name, description, authorizations and priority of a custom role.
+// @description The authorizations of the users associated to the role are recomputed.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Role identifier"
+// @param body body rolePayload true "Role details"
+// @success 200 {object} portainer.Role "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Built-in roles can't be modified"
+// @failure 404 "Role not found"
+// @failure 409 "A role with the same name already exists"
+// @failure 500 "Server error"
+// @router /roles/{id} [put]
+func (handler *Handler) roleUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload rolePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	role, httpErr := handler.role(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if !role.IsCustom {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Built-in roles can't be modified", Err: errBuiltInRole}
+	}
+
+	httpErr = handler.checkUniqueName(payload.Name, role.ID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	role.Name = payload.Name
+	role.Description = payload.Description
+	role.Authorizations = payload.authorizations()
+	role.Priority = payload.Priority
+
+	err = handler.DataStore.Role().UpdateRole(role.ID, role)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the role changes inside the database", err)
+	}
+
+	err = handler.AuthorizationService.UpdateUsersAuthorizations()
+	if err != nil {
+		return httperror.InternalServerError("Unable to update user authorizations", err)
+	}
+
+	return response.JSON(w, role)
+}
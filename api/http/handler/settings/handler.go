@@ -6,26 +6,33 @@ import (
 	"github.com/gorilla/mux"
 	httperror "github.com/portainer/libhttp/error"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/backup"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/ldap"
 )
 
 func hideFields(settings *portainer.Settings) {
 	settings.LDAPSettings.Password = ""
 	settings.OAuthSettings.ClientSecret = ""
 	settings.OAuthSettings.KubeSecretKey = nil
+	settings.SAMLSettings.SPPrivateKey = ""
+	settings.ScheduledBackupSettings.Password = ""
+	settings.ScheduledBackupSettings.S3Settings.SecretAccessKey = ""
 }
 
 // Handler is the HTTP handler used to handle settings operations.
 type Handler struct {
 	*mux.Router
-	DataStore       dataservices.DataStore
-	FileService     portainer.FileService
-	JWTService      dataservices.JWTService
-	LDAPService     portainer.LDAPService
-	SnapshotService portainer.SnapshotService
-	demoService     *demo.Service
+	DataStore        dataservices.DataStore
+	FileService      portainer.FileService
+	JWTService       dataservices.JWTService
+	LDAPService      portainer.LDAPService
+	SnapshotService  portainer.SnapshotService
+	BackupScheduler  *backup.Scheduler
+	LDAPSynchronizer *ldap.Synchronizer
+	demoService      *demo.Service
 }
 
 // NewHandler creates a handler to manage settings operations.
@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_scheduledBackupSettingsPayload_apply(t *testing.T) {
+	is := assert.New(t)
+
+	current := portainer.ScheduledBackupSettings{
+		Enabled:     true,
+		CronRule:    "0 2 * * *",
+		Password:    "secret",
+		Destination: portainer.BackupDestinationS3,
+		S3Settings:  portainer.BackupS3Settings{BucketName: "backups", AccessKeyID: "key", SecretAccessKey: "s3-secret"},
+	}
+
+	apply := func(body string) portainer.ScheduledBackupSettings {
+		var payload scheduledBackupSettingsPayload
+		is.NoError(json.Unmarshal([]byte(body), &payload))
+
+		settings := current
+		payload.apply(&settings)
+		return settings
+	}
+
+	settings := apply(`{"Enabled":true,"CronRule":"0 3 * * *","Destination":2,"S3Settings":{"BucketName":"backups","AccessKeyID":"key"}}`)
+	is.Equal("0 3 * * *", settings.CronRule)
+	is.Equal("secret", settings.Password, "the password should be kept when omitted")
+	is.Equal("s3-secret", settings.S3Settings.SecretAccessKey, "the secret access key should be kept when omitted")
+
+	settings = apply(`{"Enabled":true,"CronRule":"0 2 * * *","Password":"","Destination":2,"S3Settings":{"BucketName":"backups","AccessKeyID":"","SecretAccessKey":""}}`)
+	is.Empty(settings.Password, "an empty password should disable the encryption")
+	is.Empty(settings.S3Settings.SecretAccessKey, "an empty secret access key should clear the key")
+
+	settings = apply(`{"Enabled":true,"CronRule":"0 2 * * *","Password":"new-secret","Destination":2,"S3Settings":{"BucketName":"backups","SecretAccessKey":"new-s3-secret"}}`)
+	is.Equal("new-secret", settings.Password)
+	is.Equal("new-s3-secret", settings.S3Settings.SecretAccessKey)
+}
+
+func Test_settingsUpdate_shouldHideTheSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+
+	settings.SAMLSettings.SPPrivateKey = "sp-private-key"
+	settings.LDAPSettings.Password = "ldap-password"
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	handler := &Handler{DataStore: store, FileService: fileService, demoService: demo.NewService()}
+
+	req := httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(`{"EnableTelemetry":false}`))
+	rr := httptest.NewRecorder()
+	is.Nil(handler.settingsUpdate(rr, req))
+
+	var updated portainer.Settings
+	is.NoError(json.NewDecoder(rr.Body).Decode(&updated))
+	is.Empty(updated.SAMLSettings.SPPrivateKey)
+	is.Empty(updated.LDAPSettings.Password)
+
+	stored, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Equal("sp-private-key", stored.SAMLSettings.SPPrivateKey, "the secrets should only be hidden from the response")
+}
+
+func Test_settingsUpdate_shouldHideTheBackupSecrets(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	handler := &Handler{DataStore: store, FileService: fileService, demoService: demo.NewService()}
+
+	body := `{"ScheduledBackupSettings":{"Enabled":true,"CronRule":"0 2 * * *","Password":"secret","Destination":2,"S3Settings":{"BucketName":"backups","AccessKeyID":"key","SecretAccessKey":"s3-secret"}}}`
+	req := httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	is.Nil(handler.settingsUpdate(rr, req))
+
+	var updated portainer.Settings
+	is.NoError(json.NewDecoder(rr.Body).Decode(&updated))
+	is.Empty(updated.ScheduledBackupSettings.Password)
+	is.Empty(updated.ScheduledBackupSettings.S3Settings.SecretAccessKey)
+	is.Equal("key", updated.ScheduledBackupSettings.S3Settings.AccessKeyID)
+
+	stored, err := store.Settings().Settings()
+	is.NoError(err)
+	is.Equal("secret", stored.ScheduledBackupSettings.Password)
+	is.Equal("s3-secret", stored.ScheduledBackupSettings.S3Settings.SecretAccessKey)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/stackutils"
@@ -91,6 +92,11 @@ func (handler *Handler) createComposeStackFromFileContent(w http.ResponseWriter,
 		}
 		for _, stack := range stacks {
 			if stack.Type != portainer.DockerComposeStack && stack.EndpointID == endpoint.ID {
+				// a dry run must not change anything, the swarm stack is only removed on deployment
+				if isDryRun(r) {
+					continue
+				}
+
 				err := handler.checkAndCleanStackDupFromSwarm(w, r, endpoint, userID, &stack)
 				if err != nil {
 					return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
@@ -129,6 +135,10 @@ func (handler *Handler) createComposeStackFromFileContent(w http.ResponseWriter,
 		return configErr
 	}
 
+	if isDryRun(r) {
+		return handler.composeStackDryRun(w, stack, nil, endpoint, config.user, config.registries)
+	}
+
 	err = handler.deployComposeStack(config, false)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: err.Error(), Err: err}
@@ -141,6 +151,8 @@ func (handler *Handler) createComposeStackFromFileContent(w http.ResponseWriter,
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 	return handler.decorateStackResponse(w, stack, userID)
 }
@@ -152,12 +164,18 @@ type composeStackFromGitRepositoryPayload struct {
 	RepositoryURL string `example:"https://github.com/openfaas/faas" validate:"required"`
 	// Reference name of a Git repository hosting the Stack file
 	RepositoryReferenceName string `example:"refs/heads/master"`
+	// Semver constraint over the tags of the Git repository, e.g. ~1.4 or >=2.0.0 <3. When set, the highest matching tag is deployed instead of RepositoryReferenceName
+	RepositoryTagConstraint string `example:"~1.4"`
 	// Use basic authentication to clone the Git repository
 	RepositoryAuthentication bool `example:"true"`
 	// Username used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// Identifier of a stored Git credential used instead of RepositoryUsername and RepositoryPassword when RepositoryAuthentication is true.
+	RepositoryGitCredentialID portainer.GitCredentialID `example:"1"`
+	// Options used to clone the Git repository: submodules, Git LFS, sparse checkout and depth
+	RepositoryCloneOptions *gittypes.CloneOptions
 	// Path to the Stack file inside the Git repository
 	ComposeFile string `example:"docker-compose.yml" default:"docker-compose.yml"`
 	// Applicable when deploying with multiple stack files
@@ -174,11 +192,14 @@ func (payload *composeStackFromGitRepositoryPayload) Validate(r *http.Request) e
 	if govalidator.IsNull(payload.Name) {
 		return errors.New("Invalid stack name")
 	}
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID == 0 && govalidator.IsNull(payload.RepositoryPassword) {
+		return errors.New("Invalid repository credentials. Password or Git credential must be specified when authentication is enabled")
+	}
+	if payload.RepositoryTagConstraint != "" && git.ValidateTagConstraint(payload.RepositoryTagConstraint) != nil {
+		return errors.New("Invalid repository tag constraint")
 	}
 	if err := validateStackAutoUpdate(payload.AutoUpdate); err != nil {
 		return err
@@ -210,6 +231,11 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		}
 		for _, stack := range stacks {
 			if stack.Type != portainer.DockerComposeStack && stack.EndpointID == endpoint.ID {
+				// a dry run must not change anything, the swarm stack is only removed on deployment
+				if isDryRun(r) {
+					continue
+				}
+
 				err := handler.checkAndCleanStackDupFromSwarm(w, r, endpoint, userID, &stack)
 				if err != nil {
 					return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
@@ -246,15 +272,19 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 			URL:            payload.RepositoryURL,
 			ReferenceName:  payload.RepositoryReferenceName,
 			ConfigFilePath: payload.ComposeFile,
+			CloneOptions:   payload.RepositoryCloneOptions,
 		},
 		Status:       portainer.StackStatusActive,
 		CreationDate: time.Now().Unix(),
 	}
 
 	if payload.RepositoryAuthentication {
-		stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryUsername,
-			Password: payload.RepositoryPassword,
+		stack.GitConfig.Authentication = newGitAuthentication(payload.RepositoryUsername, payload.RepositoryPassword, payload.RepositoryGitCredentialID)
+	}
+
+	if payload.RepositoryAuthentication {
+		if httpErr := handler.checkGitCredentialAccess(payload.RepositoryGitCredentialID, userID); httpErr != nil {
+			return httpErr
 		}
 	}
 
@@ -264,12 +294,20 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 	doCleanUp := true
 	defer handler.cleanUp(stack, &doCleanUp)
 
-	err = handler.clone(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)
+	if payload.RepositoryTagConstraint != "" {
+		stack.GitConfig.TagConstraint = payload.RepositoryTagConstraint
+		stack.GitConfig.ReferenceName, err = handler.resolveTagConstraint(payload.RepositoryURL, payload.RepositoryTagConstraint, stack.GitConfig.Authentication)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to resolve the tag constraint", Err: err}
+		}
+	}
+
+	err = handler.clone(projectPath, payload.RepositoryURL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication, stack.GitConfig.CloneOptions)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to clone git repository", Err: err}
 	}
 
-	commitID, err := handler.latestCommitID(payload.RepositoryURL, payload.RepositoryReferenceName, payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)
+	commitID, err := handler.latestCommitID(payload.RepositoryURL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to fetch git repository id", Err: err}
 	}
@@ -280,6 +318,10 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		return configErr
 	}
 
+	if isDryRun(r) {
+		return handler.composeStackDryRun(w, stack, nil, endpoint, config.user, config.registries)
+	}
+
 	err = handler.deployComposeStack(config, false)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: err.Error(), Err: err}
@@ -301,6 +343,8 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 	return handler.decorateStackResponse(w, stack, userID)
 }
@@ -354,6 +398,11 @@ func (handler *Handler) createComposeStackFromFileUpload(w http.ResponseWriter,
 		}
 		for _, stack := range stacks {
 			if stack.Type != portainer.DockerComposeStack && stack.EndpointID == endpoint.ID {
+				// a dry run must not change anything, the swarm stack is only removed on deployment
+				if isDryRun(r) {
+					continue
+				}
+
 				err := handler.checkAndCleanStackDupFromSwarm(w, r, endpoint, userID, &stack)
 				if err != nil {
 					return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
@@ -391,6 +440,10 @@ func (handler *Handler) createComposeStackFromFileUpload(w http.ResponseWriter,
 		return configErr
 	}
 
+	if isDryRun(r) {
+		return handler.composeStackDryRun(w, stack, nil, endpoint, config.user, config.registries)
+	}
+
 	err = handler.deployComposeStack(config, false)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: err.Error(), Err: err}
@@ -403,6 +456,8 @@ func (handler *Handler) createComposeStackFromFileUpload(w http.ResponseWriter,
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 	return handler.decorateStackResponse(w, stack, userID)
 }
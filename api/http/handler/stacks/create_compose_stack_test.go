@@ -0,0 +1,76 @@
+package stacks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/stackutils"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_createComposeStack_dryRunShouldKeepTheSwarmStack(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	// the docker environment has no container, the name collision comes from the swarm stack record
+	dockerAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer dockerAPI.Close()
+
+	endpoint := &portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment, URL: strings.Replace(dockerAPI.URL, "http://", "tcp://", 1)}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	is.NoError(err)
+
+	projectPath, err := fileService.StoreStackFileFromBytes("1", filesystem.ComposeFileDefaultName, []byte("version: '3'\nservices:\n  web:\n    image: nginx\n"))
+	is.NoError(err)
+
+	swarmStack := &portainer.Stack{ID: 1, Name: "web", Type: portainer.DockerSwarmStack, EndpointID: endpoint.ID, EntryPoint: filesystem.ComposeFileDefaultName, ProjectPath: projectPath}
+	is.NoError(store.Stack().Create(swarmStack))
+
+	resourceControl := &portainer.ResourceControl{ResourceID: stackutils.ResourceControlID(endpoint.ID, swarmStack.Name), Type: portainer.StackResourceControl, AdministratorsOnly: true}
+	is.NoError(store.ResourceControl().Create(resourceControl))
+
+	h := NewHandler(nil)
+	h.DataStore = store
+	h.FileService = fileService
+	h.ComposeStackManager = testhelpers.NewComposeStackManager()
+	h.DockerClientFactory = docker.NewClientFactory(nil, nil)
+
+	body := `{"Name":"web","StackFileContent":"version: '3'\nservices:\n  web:\n    image: nginx:1.23\n"}`
+	req := httptest.NewRequest(http.MethodPost, "/stacks?type=2&method=string&endpointId=1&dryRun=true", strings.NewReader(body))
+	req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{IsAdmin: true, UserID: admin.ID}))
+
+	rr := httptest.NewRecorder()
+	httpErr := h.createComposeStackFromFileContent(rr, req, endpoint, admin.ID)
+	is.Nil(httpErr)
+	is.Equal(http.StatusOK, rr.Code)
+
+	stacks, err := store.Stack().Stacks()
+	is.NoError(err)
+	is.Len(stacks, 1, "the dry run should not create nor remove a stack")
+	is.Equal(portainer.DockerSwarmStack, stacks[0].Type)
+
+	_, err = store.ResourceControl().ResourceControl(resourceControl.ID)
+	is.NoError(err, "the resource control of the swarm stack should be kept")
+
+	exists, err := fileService.FileExists(projectPath)
+	is.NoError(err)
+	is.True(exists, "the files of the swarm stack should be kept")
+}
@@ -16,10 +16,12 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/api/internal/stackutils"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/notification/events"
 )
 
 type kubernetesStringDeploymentPayload struct {
@@ -30,17 +32,25 @@ type kubernetesStringDeploymentPayload struct {
 }
 
 type kubernetesGitDeploymentPayload struct {
-	StackName                string
-	ComposeFormat            bool
-	Namespace                string
-	RepositoryURL            string
-	RepositoryReferenceName  string
-	RepositoryAuthentication bool
-	RepositoryUsername       string
-	RepositoryPassword       string
-	ManifestFile             string
-	AdditionalFiles          []string
-	AutoUpdate               *portainer.StackAutoUpdate
+	StackName               string
+	ComposeFormat           bool
+	Namespace               string
+	RepositoryURL           string
+	RepositoryReferenceName string
+	// Semver constraint over the tags of the Git repository, e.g. ~1.4. When set, the highest matching tag is deployed instead of RepositoryReferenceName
+	RepositoryTagConstraint   string
+	RepositoryAuthentication  bool
+	RepositoryUsername        string
+	RepositoryPassword        string
+	RepositoryGitCredentialID portainer.GitCredentialID
+	// Options used to clone the Git repository: submodules, Git LFS, sparse checkout and depth
+	RepositoryCloneOptions *gittypes.CloneOptions
+	ManifestFile           string
+	AdditionalFiles        []string
+	AutoUpdate             *portainer.StackAutoUpdate
+	// Format of the repository files: manifests by default, "kustomize" when ManifestFile is a Kustomize directory
+	// or "helm" when ManifestFile is a Helm chart directory and AdditionalFiles are its values files
+	Format portainer.KubernetesStackFormat
 }
 
 type kubernetesManifestURLDeploymentPayload struct {
@@ -61,15 +71,30 @@ func (payload *kubernetesStringDeploymentPayload) Validate(r *http.Request) erro
 }
 
 func (payload *kubernetesGitDeploymentPayload) Validate(r *http.Request) error {
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID == 0 && govalidator.IsNull(payload.RepositoryPassword) {
+		return errors.New("Invalid repository credentials. Password or Git credential must be specified when authentication is enabled")
 	}
 	if govalidator.IsNull(payload.ManifestFile) {
 		return errors.New("Invalid manifest file in repository")
 	}
+	switch payload.Format {
+	case portainer.KubernetesStackManifest:
+	case portainer.KubernetesStackKustomize, portainer.KubernetesStackHelmChart:
+		if payload.ComposeFormat {
+			return errors.New("A Kustomize or Helm chart stack can't be in the Compose format")
+		}
+		if payload.Format == portainer.KubernetesStackKustomize && len(payload.AdditionalFiles) > 0 {
+			return errors.New("A Kustomize stack doesn't support additional files")
+		}
+	default:
+		return errors.New("Invalid stack format. Valid values are: kustomize or helm")
+	}
+	if payload.RepositoryTagConstraint != "" && git.ValidateTagConstraint(payload.RepositoryTagConstraint) != nil {
+		return errors.New("Invalid repository tag constraint")
+	}
 	if err := validateStackAutoUpdate(payload.AutoUpdate); err != nil {
 		return err
 	}
@@ -140,6 +165,10 @@ func (handler *Handler) createKubernetesStackFromFileContent(w http.ResponseWrit
 	doCleanUp := true
 	defer handler.cleanUp(stack, &doCleanUp)
 
+	if isDryRun(r) {
+		return handler.kubernetesStackDryRun(w, stack, endpoint, user)
+	}
+
 	output, err := handler.deployKubernetesStack(user.ID, endpoint, stack, k.KubeAppLabels{
 		StackID:   stackID,
 		StackName: stack.Name,
@@ -156,6 +185,8 @@ func (handler *Handler) createKubernetesStackFromFileContent(w http.ResponseWrit
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the Kubernetes stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	resp := &createKubernetesStackResponse{
 		Output: output,
 	}
@@ -203,22 +234,25 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 			URL:            payload.RepositoryURL,
 			ReferenceName:  payload.RepositoryReferenceName,
 			ConfigFilePath: payload.ManifestFile,
+			CloneOptions:   payload.RepositoryCloneOptions,
 		},
-		Namespace:       payload.Namespace,
-		Name:            payload.StackName,
-		Status:          portainer.StackStatusActive,
-		CreationDate:    time.Now().Unix(),
-		CreatedBy:       user.Username,
-		IsComposeFormat: payload.ComposeFormat,
-		AutoUpdate:      payload.AutoUpdate,
-		AdditionalFiles: payload.AdditionalFiles,
+		Namespace:        payload.Namespace,
+		Name:             payload.StackName,
+		Status:           portainer.StackStatusActive,
+		CreationDate:     time.Now().Unix(),
+		CreatedBy:        user.Username,
+		IsComposeFormat:  payload.ComposeFormat,
+		AutoUpdate:       payload.AutoUpdate,
+		AdditionalFiles:  payload.AdditionalFiles,
+		KubernetesFormat: payload.Format,
 	}
 
 	if payload.RepositoryAuthentication {
-		stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryUsername,
-			Password: payload.RepositoryPassword,
+		if httpErr := handler.checkGitCredentialAccess(payload.RepositoryGitCredentialID, userID); httpErr != nil {
+			return httpErr
 		}
+
+		stack.GitConfig.Authentication = newGitAuthentication(payload.RepositoryUsername, payload.RepositoryPassword, payload.RepositoryGitCredentialID)
 	}
 
 	projectPath := handler.FileService.GetStackProjectPath(strconv.Itoa(int(stack.ID)))
@@ -227,24 +261,29 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 	doCleanUp := true
 	defer handler.cleanUp(stack, &doCleanUp)
 
-	commitID, err := handler.latestCommitID(payload.RepositoryURL, payload.RepositoryReferenceName, payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)
+	if payload.RepositoryTagConstraint != "" {
+		stack.GitConfig.TagConstraint = payload.RepositoryTagConstraint
+		stack.GitConfig.ReferenceName, err = handler.resolveTagConstraint(payload.RepositoryURL, payload.RepositoryTagConstraint, stack.GitConfig.Authentication)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to resolve the tag constraint", Err: err}
+		}
+	}
+
+	commitID, err := handler.latestCommitID(payload.RepositoryURL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to fetch git repository id", Err: err}
 	}
 	stack.GitConfig.ConfigHash = commitID
 
-	repositoryUsername := payload.RepositoryUsername
-	repositoryPassword := payload.RepositoryPassword
-	if !payload.RepositoryAuthentication {
-		repositoryUsername = ""
-		repositoryPassword = ""
-	}
-
-	err = handler.GitService.CloneRepository(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, repositoryUsername, repositoryPassword)
+	err = handler.clone(projectPath, payload.RepositoryURL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication, stack.GitConfig.CloneOptions)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Failed to clone git repository", Err: err}
 	}
 
+	if isDryRun(r) {
+		return handler.kubernetesStackDryRun(w, stack, endpoint, user)
+	}
+
 	output, err := handler.deployKubernetesStack(user.ID, endpoint, stack, k.KubeAppLabels{
 		StackID:   stackID,
 		StackName: stack.Name,
@@ -270,6 +309,8 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	resp := &createKubernetesStackResponse{
 		Output: output,
 	}
@@ -326,6 +367,10 @@ func (handler *Handler) createKubernetesStackFromManifestURL(w http.ResponseWrit
 	doCleanUp := true
 	defer handler.cleanUp(stack, &doCleanUp)
 
+	if isDryRun(r) {
+		return handler.kubernetesStackDryRun(w, stack, endpoint, user)
+	}
+
 	output, err := handler.deployKubernetesStack(user.ID, endpoint, stack, k.KubeAppLabels{
 		StackID:   stackID,
 		StackName: stack.Name,
@@ -341,6 +386,8 @@ func (handler *Handler) createKubernetesStackFromManifestURL(w http.ResponseWrit
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the Kubernetes stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 
 	resp := &createKubernetesStackResponse{
@@ -359,5 +406,11 @@ func (handler *Handler) deployKubernetesStack(userID portainer.UserID, endpoint
 		return "", errors.Wrap(err, "failed to create temp kub deployment files")
 	}
 	defer os.RemoveAll(tempDir)
-	return handler.KubernetesDeployer.Deploy(userID, endpoint, manifestFilePaths, stack.Namespace)
+
+	output, err := handler.KubernetesDeployer.Deploy(userID, endpoint, manifestFilePaths, stack.Namespace)
+	if err != nil && handler.EventPublisher != nil {
+		handler.EventPublisher.Publish(events.StackDeployFailed(stack, err))
+	}
+
+	return output, err
 }
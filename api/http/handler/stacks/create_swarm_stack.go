@@ -13,6 +13,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/git"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/http/security"
 )
@@ -90,6 +91,10 @@ func (handler *Handler) createSwarmStackFromFileContent(w http.ResponseWriter, r
 		return configErr
 	}
 
+	if isDryRun(r) {
+		return handler.composeStackDryRun(w, stack, nil, endpoint, config.user, config.registries)
+	}
+
 	err = handler.deploySwarmStack(config)
 	if err != nil {
 		return &httperror.HandlerError{http.StatusInternalServerError, err.Error(), err}
@@ -102,6 +107,8 @@ func (handler *Handler) createSwarmStackFromFileContent(w http.ResponseWriter, r
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to persist the stack inside the database", err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 	return handler.decorateStackResponse(w, stack, userID)
 }
@@ -118,12 +125,18 @@ type swarmStackFromGitRepositoryPayload struct {
 	RepositoryURL string `example:"https://github.com/openfaas/faas" validate:"required"`
 	// Reference name of a Git repository hosting the Stack file
 	RepositoryReferenceName string `example:"refs/heads/master"`
+	// Semver constraint over the tags of the Git repository, e.g. ~1.4 or >=2.0.0 <3. When set, the highest matching tag is deployed instead of RepositoryReferenceName
+	RepositoryTagConstraint string `example:"~1.4"`
 	// Use basic authentication to clone the Git repository
 	RepositoryAuthentication bool `example:"true"`
 	// Username used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryUsername string `example:"myGitUsername"`
 	// Password used in basic authentication. Required when RepositoryAuthentication is true.
 	RepositoryPassword string `example:"myGitPassword"`
+	// Identifier of a stored Git credential used instead of RepositoryUsername and RepositoryPassword when RepositoryAuthentication is true.
+	RepositoryGitCredentialID portainer.GitCredentialID `example:"1"`
+	// Options used to clone the Git repository: submodules, Git LFS, sparse checkout and depth
+	RepositoryCloneOptions *gittypes.CloneOptions
 	// Whether the stack is from a app template
 	FromAppTemplate bool `example:"false"`
 	// Path to the Stack file inside the Git repository
@@ -141,11 +154,14 @@ func (payload *swarmStackFromGitRepositoryPayload) Validate(r *http.Request) err
 	if govalidator.IsNull(payload.SwarmID) {
 		return errors.New("Invalid Swarm ID")
 	}
-	if govalidator.IsNull(payload.RepositoryURL) || !govalidator.IsURL(payload.RepositoryURL) {
+	if govalidator.IsNull(payload.RepositoryURL) || !git.IsValidRepositoryURL(payload.RepositoryURL) {
 		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
 	}
-	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
-		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	if payload.RepositoryAuthentication && payload.RepositoryGitCredentialID == 0 && govalidator.IsNull(payload.RepositoryPassword) {
+		return errors.New("Invalid repository credentials. Password or Git credential must be specified when authentication is enabled")
+	}
+	if payload.RepositoryTagConstraint != "" && git.ValidateTagConstraint(payload.RepositoryTagConstraint) != nil {
+		return errors.New("Invalid repository tag constraint")
 	}
 	if err := validateStackAutoUpdate(payload.AutoUpdate); err != nil {
 		return err
@@ -196,6 +212,7 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 			URL:            payload.RepositoryURL,
 			ReferenceName:  payload.RepositoryReferenceName,
 			ConfigFilePath: payload.ComposeFile,
+			CloneOptions:   payload.RepositoryCloneOptions,
 		},
 		Env:          payload.Env,
 		Status:       portainer.StackStatusActive,
@@ -203,9 +220,12 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 	}
 
 	if payload.RepositoryAuthentication {
-		stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryUsername,
-			Password: payload.RepositoryPassword,
+		stack.GitConfig.Authentication = newGitAuthentication(payload.RepositoryUsername, payload.RepositoryPassword, payload.RepositoryGitCredentialID)
+	}
+
+	if payload.RepositoryAuthentication {
+		if httpErr := handler.checkGitCredentialAccess(payload.RepositoryGitCredentialID, userID); httpErr != nil {
+			return httpErr
 		}
 	}
 
@@ -215,12 +235,20 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 	doCleanUp := true
 	defer handler.cleanUp(stack, &doCleanUp)
 
-	err = handler.clone(projectPath, payload.RepositoryURL, payload.RepositoryReferenceName, payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)
+	if payload.RepositoryTagConstraint != "" {
+		stack.GitConfig.TagConstraint = payload.RepositoryTagConstraint
+		stack.GitConfig.ReferenceName, err = handler.resolveTagConstraint(payload.RepositoryURL, payload.RepositoryTagConstraint, stack.GitConfig.Authentication)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to resolve the tag constraint", Err: err}
+		}
+	}
+
+	err = handler.clone(projectPath, payload.RepositoryURL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication, stack.GitConfig.CloneOptions)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to clone git repository", Err: err}
 	}
 
-	commitID, err := handler.latestCommitID(payload.RepositoryURL, payload.RepositoryReferenceName, payload.RepositoryAuthentication, payload.RepositoryUsername, payload.RepositoryPassword)
+	commitID, err := handler.latestCommitID(payload.RepositoryURL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to fetch git repository id", Err: err}
 	}
@@ -231,6 +259,10 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 		return configErr
 	}
 
+	if isDryRun(r) {
+		return handler.composeStackDryRun(w, stack, nil, endpoint, config.user, config.registries)
+	}
+
 	err = handler.deploySwarmStack(config)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: err.Error(), Err: err}
@@ -252,6 +284,8 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack inside the database", Err: err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 	return handler.decorateStackResponse(w, stack, userID)
 }
@@ -337,6 +371,10 @@ func (handler *Handler) createSwarmStackFromFileUpload(w http.ResponseWriter, r
 		return configErr
 	}
 
+	if isDryRun(r) {
+		return handler.composeStackDryRun(w, stack, nil, endpoint, config.user, config.registries)
+	}
+
 	err = handler.deploySwarmStack(config)
 	if err != nil {
 		return &httperror.HandlerError{http.StatusInternalServerError, err.Error(), err}
@@ -349,6 +387,8 @@ func (handler *Handler) createSwarmStackFromFileUpload(w http.ResponseWriter, r
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to persist the stack inside the database", err}
 	}
 
+	handler.recordStackVersion(stack, stack.CreatedBy)
+
 	doCleanUp = false
 	return handler.decorateStackResponse(w, stack, userID)
 }
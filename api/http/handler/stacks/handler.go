@@ -3,6 +3,7 @@ package stacks
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -16,6 +17,9 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/kubernetes/cli"
@@ -45,6 +49,7 @@ type Handler struct {
 	KubernetesClientFactory *cli.ClientFactory
 	Scheduler               *scheduler.Scheduler
 	StackDeployer           stacks.StackDeployer
+	EventPublisher          portainer.NotificationEventPublisher
 }
 
 func stackExistsError(name string) *httperror.HandlerError {
@@ -85,6 +90,16 @@ func NewHandler(bouncer *security.RequestBouncer) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackStart))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/stop",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackStop))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/diff",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDiff))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/versions",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionList))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/versions/diff",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionDiff))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/versions/{version}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackVersionInspect))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/rollback",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackRollback))).Methods(http.MethodPost)
 	h.Handle("/stacks/webhooks/{webhookID}",
 		httperror.LoggerHandler(h.webhookInvoke)).Methods(http.MethodPost)
 
@@ -238,13 +253,13 @@ func (handler *Handler) checkUniqueWebhookID(webhookID string) (bool, error) {
 	return false, err
 }
 
-func (handler *Handler) clone(projectPath, repositoryURL, refName string, auth bool, username, password string) error {
-	if !auth {
-		username = ""
-		password = ""
+func (handler *Handler) clone(projectPath, repositoryURL, refName string, auth *gittypes.GitAuthentication, options *gittypes.CloneOptions) error {
+	auth, err := git.ResolveAuthentication(handler.DataStore, auth)
+	if err != nil {
+		return err
 	}
 
-	err := handler.GitService.CloneRepository(projectPath, repositoryURL, refName, username, password)
+	err = handler.GitService.CloneRepository(projectPath, repositoryURL, refName, auth, options)
 	if err != nil {
 		return fmt.Errorf("unable to clone git repository: %w", err)
 	}
@@ -252,11 +267,95 @@ func (handler *Handler) clone(projectPath, repositoryURL, refName string, auth b
 	return nil
 }
 
-func (handler *Handler) latestCommitID(repositoryURL, refName string, auth bool, username, password string) (string, error) {
-	if !auth {
-		username = ""
-		password = ""
+func (handler *Handler) latestCommitID(repositoryURL, refName string, auth *gittypes.GitAuthentication) (string, error) {
+	auth, err := git.ResolveAuthentication(handler.DataStore, auth)
+	if err != nil {
+		return "", err
+	}
+
+	return handler.GitService.LatestCommitID(repositoryURL, refName, auth)
+}
+
+// resolveTagConstraint returns the tag reference of the highest version of the repository satisfying the constraint
+func (handler *Handler) resolveTagConstraint(repositoryURL, constraint string, auth *gittypes.GitAuthentication) (string, error) {
+	auth, err := git.ResolveAuthentication(handler.DataStore, auth)
+	if err != nil {
+		return "", err
+	}
+
+	refs, err := handler.GitService.ListRefs(repositoryURL, auth)
+	if err != nil {
+		return "", err
+	}
+
+	return git.ResolveTagConstraint(refs, constraint)
+}
+
+// recordStackVersion stores the deployed files of a stack as a new version of the stack.
+// A failure is only logged as the stack is already deployed.
+func (handler *Handler) recordStackVersion(stack *portainer.Stack, author string) {
+	_, err := stacks.RecordStackVersion(handler.DataStore, stack, author)
+	if err != nil {
+		log.Printf("[WARN] [http,stacks] [message: unable to record the stack version] [err: %s]", err)
+	}
+}
+
+// checkGitCredentialAccess verifies that the Git credential referenced by a request exists
+// and that the user is allowed to use it: administrators, the owner of the credential and the members of its team.
+func (handler *Handler) checkGitCredentialAccess(gitCredentialID portainer.GitCredentialID, userID portainer.UserID) *httperror.HandlerError {
+	if gitCredentialID == 0 {
+		return nil
+	}
+
+	credential, err := handler.DataStore.GitCredential().GitCredential(gitCredentialID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.BadRequest("Unable to find a Git credential with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a Git credential with the specified identifier inside the database", err)
+	}
+
+	user, err := handler.DataStore.User().User(userID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from the database", err)
+	}
+
+	memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user team memberships from the database", err)
+	}
+
+	securityContext := &security.RestrictedRequestContext{
+		IsAdmin:         user.Role == portainer.AdministratorRole,
+		UserID:          user.ID,
+		UserMemberships: memberships,
+	}
+
+	if !security.AuthorizedGitCredentialAccess(credential, securityContext) {
+		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Permission denied to use the Git credential", Err: httperrors.ErrResourceAccessDenied}
+	}
+
+	return nil
+}
+
+// newGitAuthentication returns the Git authentication described by a request payload,
+// a stored Git credential takes precedence over the username and password.
+func newGitAuthentication(username, password string, gitCredentialID portainer.GitCredentialID) *gittypes.GitAuthentication {
+	if gitCredentialID != 0 {
+		return &gittypes.GitAuthentication{GitCredentialID: int(gitCredentialID)}
+	}
+
+	return &gittypes.GitAuthentication{
+		Username: username,
+		Password: password,
+	}
+}
+
+// updatedGitAuthentication returns the Git authentication of a stack after an update,
+// the current password is kept when the request doesn't provide a new one.
+func updatedGitAuthentication(current *gittypes.GitAuthentication, username, password string, gitCredentialID portainer.GitCredentialID) *gittypes.GitAuthentication {
+	if gitCredentialID == 0 && password == "" && current != nil {
+		password = current.Password
 	}
 
-	return handler.GitService.LatestCommitID(repositoryURL, refName, username, password)
+	return newGitAuthentication(username, password, gitCredentialID)
 }
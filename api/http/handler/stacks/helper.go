@@ -6,8 +6,20 @@ import (
 	"github.com/asaskevich/govalidator"
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
 )
 
+// hideStackFields sanitizes the secrets of the stack in the http responses to minimise possible security leaks
+func hideStackFields(stack *portainer.Stack) {
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
+		stack.GitConfig.Authentication.Password = ""
+	}
+
+	if stack.AutoUpdate != nil {
+		stack.AutoUpdate.WebhookSecret = ""
+	}
+}
+
 func validateStackAutoUpdate(autoUpdate *portainer.StackAutoUpdate) error {
 	if autoUpdate == nil {
 		return nil
@@ -15,6 +27,14 @@ func validateStackAutoUpdate(autoUpdate *portainer.StackAutoUpdate) error {
 	if autoUpdate.Webhook != "" && !govalidator.IsUUID(autoUpdate.Webhook) {
 		return errors.New("invalid Webhook format")
 	}
+	if autoUpdate.WebhookProvider != "" {
+		if !git.IsValidWebhookProvider(autoUpdate.WebhookProvider) {
+			return errors.New("invalid WebhookProvider, must be one of github, gitlab, gitea or bitbucket")
+		}
+		if autoUpdate.WebhookSecret == "" {
+			return errors.New("a WebhookSecret is required with a WebhookProvider")
+		}
+	}
 	if autoUpdate.Interval != "" {
 		if _, err := time.ParseDuration(autoUpdate.Interval); err != nil {
 			return errors.New("invalid Interval format")
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,6 +24,16 @@ func Test_ValidateStackAutoUpdate(t *testing.T) {
 			value:   &portainer.StackAutoUpdate{Interval: "1dd2hh3mm"},
 			wantErr: true,
 		},
+		{
+			name:    "unknown webhook provider",
+			value:   &portainer.StackAutoUpdate{Webhook: "8dce8c2f-9ca1-482b-ad20-271e86536ada", WebhookProvider: "svn", WebhookSecret: "secret"},
+			wantErr: true,
+		},
+		{
+			name:    "webhook provider without secret",
+			value:   &portainer.StackAutoUpdate{Webhook: "8dce8c2f-9ca1-482b-ad20-271e86536ada", WebhookProvider: "github"},
+			wantErr: true,
+		},
 		{
 			name: "valid auto update",
 			value: &portainer.StackAutoUpdate{
@@ -31,6 +42,15 @@ func Test_ValidateStackAutoUpdate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid auto update with a webhook provider",
+			value: &portainer.StackAutoUpdate{
+				Webhook:         "8dce8c2f-9ca1-482b-ad20-271e86536ada",
+				WebhookProvider: "gitlab",
+				WebhookSecret:   "secret",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -40,3 +60,19 @@ func Test_ValidateStackAutoUpdate(t *testing.T) {
 		})
 	}
 }
+
+func Test_hideStackFields(t *testing.T) {
+	stack := &portainer.Stack{
+		GitConfig:  &gittypes.RepoConfig{Authentication: &gittypes.GitAuthentication{Username: "user", Password: "password"}},
+		AutoUpdate: &portainer.StackAutoUpdate{WebhookProvider: "github", WebhookSecret: "secret"},
+	}
+
+	hideStackFields(stack)
+
+	assert.Empty(t, stack.GitConfig.Authentication.Password)
+	assert.Equal(t, "user", stack.GitConfig.Authentication.Username)
+	assert.Empty(t, stack.AutoUpdate.WebhookSecret)
+	assert.Equal(t, "github", stack.AutoUpdate.WebhookProvider)
+
+	hideStackFields(&portainer.Stack{})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/stackutils"
+	"github.com/portainer/portainer/api/stacks"
 )
 
 func (handler *Handler) cleanUp(stack *portainer.Stack, doCleanUp *bool) error {
@@ -40,6 +41,7 @@ func (handler *Handler) cleanUp(stack *portainer.Stack, doCleanUp *bool) error {
 // @param type query int true "Stack deployment type. Possible values: 1 (Swarm stack) or 2 (Compose stack)." Enums(1,2)
 // @param method query string true "Stack deployment method. Possible values: file, string or repository." Enums(string, file, repository)
 // @param endpointId query int true "Identifier of the environment(endpoint) that will be used to deploy the stack"
+// @param dryRun query boolean false "Validate the stack and return a stackDryRunResponse without creating nor deploying it"
 // @param body_swarm_string body swarmStackFromFileContentPayload false "Required when using method=string and type=1"
 // @param body_swarm_repository body swarmStackFromGitRepositoryPayload false "Required when using method=repository and type=1"
 // @param body_compose_string body composeStackFromFileContentPayload false "Required when using method=string and type=2"
@@ -173,34 +175,10 @@ func (handler *Handler) isValidStackFile(stackFileContent []byte, securitySettin
 		return err
 	}
 
-	for key := range composeConfig.Services {
-		service := composeConfig.Services[key]
-		if !securitySettings.AllowBindMountsForRegularUsers {
-			for _, volume := range service.Volumes {
-				if volume.Type == "bind" {
-					return errors.New("bind-mount disabled for non administrator users")
-				}
-			}
-		}
-
-		if !securitySettings.AllowPrivilegedModeForRegularUsers && service.Privileged == true {
-			return errors.New("privileged mode disabled for non administrator users")
-		}
-
-		if !securitySettings.AllowHostNamespaceForRegularUsers && service.Pid == "host" {
-			return errors.New("pid host disabled for non administrator users")
-		}
-
-		if !securitySettings.AllowDeviceMappingForRegularUsers && service.Devices != nil && len(service.Devices) > 0 {
-			return errors.New("device mapping disabled for non administrator users")
-		}
-
-		if !securitySettings.AllowSysctlSettingForRegularUsers && service.Sysctls != nil && len(service.Sysctls) > 0 {
-			return errors.New("sysctl setting disabled for non administrator users")
-		}
-
-		if !securitySettings.AllowContainerCapabilitiesForRegularUsers && (len(service.CapAdd) > 0 || len(service.CapDrop) > 0) {
-			return errors.New("container capabilities disabled for non administrator users")
+	for _, service := range composeConfig.Services {
+		violations := stacks.ServiceSecurityViolations(service, securitySettings)
+		if len(violations) > 0 {
+			return errors.New(violations[0])
 		}
 	}
 
@@ -228,10 +206,7 @@ func (handler *Handler) decorateStackResponse(w http.ResponseWriter, stack *port
 
 	stack.ResourceControl = resourceControl
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	hideStackFields(stack)
 
 	return response.JSON(w, stack)
 }
@@ -17,6 +17,7 @@ import (
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/stackutils"
+	"github.com/portainer/portainer/api/stacks"
 )
 
 // @id StackDelete
@@ -127,6 +128,11 @@ func (handler *Handler) stackDelete(w http.ResponseWriter, r *http.Request) *htt
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to remove the stack from the database", Err: err}
 	}
 
+	err = stacks.DeleteStackVersions(handler.DataStore, stack.ID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to remove the stack versions from the database", Err: err}
+	}
+
 	if resourceControl != nil {
 		err = handler.DataStore.ResourceControl().DeleteResourceControl(resourceControl.ID)
 		if err != nil {
@@ -197,7 +203,25 @@ func (handler *Handler) deleteStack(userID portainer.UserID, stack *portainer.St
 
 		//if it is a compose format kub stack, create a temp dir and convert the manifest files into it
 		//then process the remove operation
-		if stack.IsComposeFormat {
+		if stackutils.IsRenderedKubernetesStack(stack) {
+			tmpDir, err := ioutil.TempDir("", "kub_delete")
+			if err != nil {
+				return errors.Wrap(err, "failed to create temp directory for deleting kub stack")
+			}
+			defer os.RemoveAll(tmpDir)
+
+			manifestContent, err := stackutils.RenderKubernetesManifest(stack, handler.KubernetesDeployer)
+			if err != nil {
+				return err
+			}
+
+			manifestFilePath := filesystem.JoinPaths(tmpDir, filesystem.ManifestFileDefaultName)
+			err = filesystem.WriteToFile(manifestFilePath, manifestContent)
+			if err != nil {
+				return errors.Wrap(err, "failed to create temp manifest file")
+			}
+			manifestFiles = append(manifestFiles, manifestFilePath)
+		} else if stack.IsComposeFormat {
 			fileNames := append([]string{stack.EntryPoint}, stack.AdditionalFiles...)
 			tmpDir, err := ioutil.TempDir("", "kub_delete")
 			if err != nil {
@@ -0,0 +1,143 @@
+package stacks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/stackutils"
+	"github.com/portainer/portainer/api/stacks"
+)
+
+type stackDiffPayload struct {
+	// Proposed content of the stack file. When empty, the stack is compared with its Git repository
+	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx"`
+	// Proposed environment variables, the current ones are kept when not set
+	Env []portainer.Pair
+	// Git reference compared with the deployed stack, defaults to the reference tracked by the stack
+	RepositoryReferenceName string `example:"refs/heads/master"`
+}
+
+func (payload *stackDiffPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+type stackDiffResponse struct {
+	// Commit of the proposed Git reference, only set when the stack is compared with its Git repository
+	CommitHash string `example:"bc4c183d756879ea4d173315338110b31004b8e0"`
+	// Unified diff of each file of the deployed and the proposed stack
+	Files []stacks.FileDiff
+	// Environment variables added, removed or updated by the proposed stack
+	Env []stacks.EnvChange
+	// Services or Kubernetes resources created, updated or removed by the proposed stack
+	Resources []stacks.ResourceChange
+}
+
+// @id StackDiff
+// @summary Preview the changes of a stack update
+// @description Compare the deployed stack with a proposed stack file or with a reference of its Git repository, without deploying anything.
+// @description When no stack file content is given, the Git repository of the stack is cloned at the requested reference,
+// @description or at the tracked reference to preview what the next auto update will deploy.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param body body stackDiffPayload true "Proposed stack"
+// @success 200 {object} stackDiffResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/diff [post]
+func (handler *Handler) stackDiff(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackDiffPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	stack, _, httpErr := handler.managedStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if payload.StackFileContent == "" && stack.GitConfig == nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid stack file content", Err: errors.New("a stack file content is required for a stack that is not deployed from a Git repository")}
+	}
+
+	if payload.StackFileContent != "" && stackutils.IsRenderedKubernetesStack(stack) {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid stack file content", Err: errors.New("a Kustomize or Helm chart stack can only be compared with its Git repository")}
+	}
+
+	current, err := stacks.SnapshotStack(stack)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to read the current stack files", Err: err}
+	}
+
+	resp := &stackDiffResponse{}
+
+	var proposed *portainer.StackVersion
+	if payload.StackFileContent != "" {
+		proposed = &portainer.StackVersion{
+			EntryPoint:      current.EntryPoint,
+			AdditionalFiles: current.AdditionalFiles,
+			Files:           make(map[string]string, len(current.Files)),
+			Env:             current.Env,
+		}
+		for fileName, content := range current.Files {
+			proposed.Files[fileName] = content
+		}
+		proposed.Files[stack.EntryPoint] = payload.StackFileContent
+	} else {
+		referenceName := payload.RepositoryReferenceName
+		if referenceName == "" {
+			referenceName = stack.GitConfig.ReferenceName
+		}
+
+		tempDir, err := ioutil.TempDir("", "stack_diff")
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to create a temporary directory", Err: err}
+		}
+		defer os.RemoveAll(tempDir)
+
+		err = handler.clone(tempDir, stack.GitConfig.URL, referenceName, stack.GitConfig.Authentication, stack.GitConfig.CloneOptions)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to clone git repository", Err: err}
+		}
+
+		resp.CommitHash, err = handler.latestCommitID(stack.GitConfig.URL, referenceName, stack.GitConfig.Authentication)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to fetch git repository", Err: err}
+		}
+
+		clonedStack := *stack
+		clonedStack.ProjectPath = tempDir
+		proposed, err = stacks.SnapshotStack(&clonedStack)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to read the stack files from the git repository", Err: err}
+		}
+	}
+
+	if payload.Env != nil {
+		proposed.Env = payload.Env
+	}
+
+	diff, err := stacks.DiffStack(stack, current, proposed, "deployed", "proposed", handler.KubernetesDeployer)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to compare the stack files", Err: err}
+	}
+
+	resp.Files = diff.Files
+	resp.Env = diff.Env
+	resp.Resources = diff.Resources
+
+	return response.JSON(w, resp)
+}
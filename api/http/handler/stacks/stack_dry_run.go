@@ -0,0 +1,113 @@
+package stacks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/stacks"
+)
+
+type stackDryRunResponse struct {
+	// Whether the stack can be deployed, false when at least one error is found
+	Valid bool `example:"false"`
+	// Issues found for each service. For Kubernetes stacks, it also contains the change applied to each resource
+	Diagnostics []stacks.Diagnostic
+}
+
+// isDryRun returns true when the request asks to validate the stack without deploying it
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := request.RetrieveBooleanQueryParameter(r, "dryRun", true)
+	return dryRun
+}
+
+// composeStackDryRun validates a Compose or Swarm stack and writes the diagnostics to the response.
+// The files of the stack are read from its project folder when files is nil.
+func (handler *Handler) composeStackDryRun(w http.ResponseWriter, stack *portainer.Stack, files []stacks.StackFile, endpoint *portainer.Endpoint, user *portainer.User, registries []portainer.Registry) *httperror.HandlerError {
+	if files == nil {
+		var err error
+		files, err = stacks.ReadStackFiles(stack)
+		if err != nil {
+			return dryRunResponse(w, []stacks.Diagnostic{{Severity: stacks.DiagnosticError, Message: err.Error()}})
+		}
+	}
+
+	isAdminOrEndpointAdmin, err := handler.userIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to verify user authorizations to validate the stack", Err: err}
+	}
+
+	var securitySettings *portainer.EndpointSecuritySettings
+	if !isAdminOrEndpointAdmin {
+		securitySettings = &endpoint.SecuritySettings
+	}
+
+	maxVersion := handler.ComposeStackManager.ComposeSyntaxMaxVersion()
+
+	return dryRunResponse(w, stacks.ValidateComposeStack(files, stack.Env, maxVersion, registries, securitySettings))
+}
+
+// kubernetesStackDryRun submits the manifests of a Kubernetes stack to the cluster
+// without persisting them and writes the diagnostics to the response
+func (handler *Handler) kubernetesStackDryRun(w http.ResponseWriter, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) *httperror.HandlerError {
+	return dryRunResponse(w, stacks.DryRunKubernetesStack(handler.KubernetesDeployer, stack, endpoint, user))
+}
+
+// stackUpdateDryRun validates the payload of a stack update without writing the stack files nor deploying them
+func (handler *Handler) stackUpdateDryRun(w http.ResponseWriter, r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) *httperror.HandlerError {
+	if stack.Type == portainer.KubernetesStack && stack.GitConfig != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Dry run is only available for file based Kubernetes stacks", Err: errors.New("dry run is not supported for git based Kubernetes stacks")}
+	}
+
+	// the payload of a swarm stack update is a superset of the other payloads
+	var payload updateSwarmStackPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	proposed := *stack
+
+	if stack.Type == portainer.KubernetesStack {
+		tempFileDir, err := ioutil.TempDir("", "kub_file_content")
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Failed to create a temp directory", Err: err}
+		}
+		defer os.RemoveAll(tempFileDir)
+
+		if err := filesystem.WriteToFile(filesystem.JoinPaths(tempFileDir, stack.EntryPoint), []byte(payload.StackFileContent)); err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Failed to persist deployment file in a temp directory", Err: err}
+		}
+		proposed.ProjectPath = tempFileDir
+
+		return handler.kubernetesStackDryRun(w, &proposed, endpoint, user)
+	}
+
+	proposed.Env = payload.Env
+
+	files, err := stacks.ReadStackFiles(stack)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to read the stack files", Err: err}
+	}
+	files[0].Content = []byte(payload.StackFileContent)
+
+	config, httpErr := handler.createComposeDeployConfig(r, &proposed, endpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return handler.composeStackDryRun(w, &proposed, files, endpoint, user, config.registries)
+}
+
+func dryRunResponse(w http.ResponseWriter, diagnostics []stacks.Diagnostic) *httperror.HandlerError {
+	return response.JSON(w, &stackDryRunResponse{
+		Valid:       !stacks.HasErrors(diagnostics),
+		Diagnostics: diagnostics,
+	})
+}
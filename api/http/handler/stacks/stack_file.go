@@ -20,7 +20,7 @@ type stackFileResponse struct {
 
 // @id StackFileInspect
 // @summary Retrieve the content of the Stack file for the specified stack
-// @description Get Stack file content.
+// @description Get Stack file content. For a Kustomize or Helm chart Kubernetes stack, the manifest rendered from its files is returned.
 // @description **Access policy**: restricted
 // @tags stacks
 // @security ApiKeyAuth
@@ -91,6 +91,15 @@ func (handler *Handler) stackFile(w http.ResponseWriter, r *http.Request) *httpe
 		}
 	}
 
+	if stackutils.IsRenderedKubernetesStack(stack) {
+		manifest, err := stackutils.RenderKubernetesManifest(stack, handler.KubernetesDeployer)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to render the Kubernetes manifest", Err: err}
+		}
+
+		return response.JSON(w, &stackFileResponse{StackFileContent: string(manifest)})
+	}
+
 	stackFileContent, err := handler.FileService.GetFileContent(stack.ProjectPath, stack.EntryPoint)
 	if err != nil {
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to retrieve Compose file from disk", err}
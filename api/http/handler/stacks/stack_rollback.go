@@ -0,0 +1,146 @@
+package stacks
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks"
+)
+
+type stackRollbackPayload struct {
+	// Version number to redeploy
+	Version int `example:"2" validate:"required"`
+}
+
+func (payload *stackRollbackPayload) Validate(r *http.Request) error {
+	if payload.Version <= 0 {
+		return errors.New("Invalid version number")
+	}
+	return nil
+}
+
+// @id StackRollback
+// @summary Rollback a stack to a previous version
+// @description Restore the files and environment variables of a stored version of a stack and redeploy the stack.
+// @description The redeployment is recorded as a new version of the stack.
+// @description A Git-based stack with auto update enabled is brought back to the latest commit on the next update.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param body body stackRollbackPayload true "Version to redeploy"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or version not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/rollback [post]
+func (handler *Handler) stackRollback(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackRollbackPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	stack, endpoint, httpErr := handler.managedStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	version, httpErr := handler.stackVersion(stack.ID, payload.Version)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve info from request context", Err: err}
+	}
+
+	user, err := handler.DataStore.User().User(securityContext.UserID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to load user information from the database", Err: err}
+	}
+
+	current, err := stacks.SnapshotStack(stack)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to read the current stack files", Err: err}
+	}
+
+	err = stacks.RestoreStackVersion(stack, version)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to restore the stack files", Err: err}
+	}
+
+	httpErr = handler.redeployStackVersion(r, stack, endpoint, user)
+	if httpErr != nil {
+		if restoreErr := stacks.RestoreStackVersion(stack, current); restoreErr != nil {
+			log.Printf("[WARN] [stack,rollback] [message: unable to restore the stack files] [err: %s]", restoreErr)
+		}
+		return httpErr
+	}
+
+	stack.UpdatedBy = user.Username
+	stack.UpdateDate = time.Now().Unix()
+	stack.Status = portainer.StackStatusActive
+
+	err = handler.DataStore.Stack().UpdateStack(stack.ID, stack)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack changes inside the database", Err: err}
+	}
+
+	handler.recordStackVersion(stack, stack.UpdatedBy)
+
+	hideStackFields(stack)
+
+	return response.JSON(w, stack)
+}
+
+// redeployStackVersion deploys the restored files of a stack through the stack deployer
+func (handler *Handler) redeployStackVersion(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) *httperror.HandlerError {
+	switch stack.Type {
+	case portainer.DockerSwarmStack:
+		prune := false
+		if stack.Option != nil {
+			prune = stack.Option.Prune
+		}
+		config, httpErr := handler.createSwarmDeployConfig(r, stack, endpoint, prune)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		if err := handler.deploySwarmStack(config); err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: err.Error(), Err: err}
+		}
+
+	case portainer.DockerComposeStack:
+		config, httpErr := handler.createComposeDeployConfig(r, stack, endpoint)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		if err := handler.deployComposeStack(config, false); err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: err.Error(), Err: err}
+		}
+
+	case portainer.KubernetesStack:
+		if err := handler.StackDeployer.DeployKubernetesStack(stack, endpoint, user); err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to redeploy Kubernetes stack", Err: err}
+		}
+
+	default:
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unsupported stack", Err: errors.New("unsupported stack type")}
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/stackutils"
+	"github.com/portainer/portainer/api/notification/events"
 
 	httperror "github.com/portainer/libhttp/error"
 	"github.com/portainer/libhttp/request"
@@ -121,20 +122,23 @@ func (handler *Handler) stackStart(w http.ResponseWriter, r *http.Request) *http
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to update stack status", Err: err}
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	hideStackFields(stack)
 
 	return response.JSON(w, stack)
 }
 
 func (handler *Handler) startStack(stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+	var err error
 	switch stack.Type {
 	case portainer.DockerComposeStack:
-		return handler.ComposeStackManager.Up(context.TODO(), stack, endpoint, false)
+		err = handler.ComposeStackManager.Up(context.TODO(), stack, endpoint, false)
 	case portainer.DockerSwarmStack:
-		return handler.SwarmStackManager.Deploy(stack, true, endpoint)
+		err = handler.SwarmStackManager.Deploy(stack, true, endpoint)
+	}
+
+	if err != nil && handler.EventPublisher != nil {
+		handler.EventPublisher.Publish(events.StackDeployFailed(stack, err))
 	}
-	return nil
+
+	return err
 }
@@ -59,8 +59,9 @@ func (payload *updateSwarmStackPayload) Validate(r *http.Request) error {
 // @produce json
 // @param id path int true "Stack identifier"
 // @param endpointId query int false "Stacks created before version 1.18.0 might not have an associated environment(endpoint) identifier. Use this optional parameter to set the environment(endpoint) identifier used by the stack."
+// @param dryRun query boolean false "Validate the stack without writing its files nor deploying it"
 // @param body body updateSwarmStackPayload true "Stack details"
-// @success 200 {object} portainer.Stack "Success"
+// @success 200 {object} portainer.Stack "Success, or stackDryRunResponse when dryRun is set"
 // @failure 400 "Invalid request"
 // @failure 403 "Permission denied"
 // @failure 404 "Not found"
@@ -133,15 +134,20 @@ func (handler *Handler) stackUpdate(w http.ResponseWriter, r *http.Request) *htt
 		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: errMsg, Err: errors.New(errMsg)}
 	}
 
-	updateError := handler.updateAndDeployStack(r, stack, endpoint)
-	if updateError != nil {
-		return updateError
-	}
-
 	user, err := handler.DataStore.User().User(securityContext.UserID)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Cannot find context user", Err: errors.Wrap(err, "failed to fetch the user")}
 	}
+
+	if isDryRun(r) {
+		return handler.stackUpdateDryRun(w, r, stack, endpoint, user)
+	}
+
+	updateError := handler.updateAndDeployStack(r, stack, endpoint, securityContext.UserID)
+	if updateError != nil {
+		return updateError
+	}
+
 	stack.UpdatedBy = user.Username
 	stack.UpdateDate = time.Now().Unix()
 	stack.Status = portainer.StackStatusActive
@@ -151,21 +157,20 @@ func (handler *Handler) stackUpdate(w http.ResponseWriter, r *http.Request) *htt
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack changes inside the database", Err: err}
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	handler.recordStackVersion(stack, stack.UpdatedBy)
+
+	hideStackFields(stack)
 
 	return response.JSON(w, stack)
 }
 
-func (handler *Handler) updateAndDeployStack(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint) *httperror.HandlerError {
+func (handler *Handler) updateAndDeployStack(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
 	if stack.Type == portainer.DockerSwarmStack {
 		return handler.updateSwarmStack(r, stack, endpoint)
 	} else if stack.Type == portainer.DockerComposeStack {
 		return handler.updateComposeStack(r, stack, endpoint)
 	} else if stack.Type == portainer.KubernetesStack {
-		return handler.updateKubernetesStack(r, stack, endpoint)
+		return handler.updateKubernetesStack(r, stack, endpoint, userID)
 	} else {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unsupported stack", Err: errors.Errorf("unsupported stack type: %v", stack.Type)}
 	}
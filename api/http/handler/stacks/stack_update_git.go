@@ -9,25 +9,27 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
-	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/stackutils"
 )
 
 type stackGitUpdatePayload struct {
-	AutoUpdate               *portainer.StackAutoUpdate
-	Env                      []portainer.Pair
-	Prune                    bool
-	RepositoryReferenceName  string
-	RepositoryAuthentication bool
-	RepositoryUsername       string
-	RepositoryPassword       string
+	AutoUpdate                *portainer.StackAutoUpdate
+	Env                       []portainer.Pair
+	Prune                     bool
+	RepositoryReferenceName   string
+	RepositoryTagConstraint   string
+	RepositoryAuthentication  bool
+	RepositoryUsername        string
+	RepositoryPassword        string
+	RepositoryGitCredentialID portainer.GitCredentialID
 }
 
 func (payload *stackGitUpdatePayload) Validate(r *http.Request) error {
-	if err := validateStackAutoUpdate(payload.AutoUpdate); err != nil {
-		return err
+	if payload.RepositoryTagConstraint != "" && git.ValidateTagConstraint(payload.RepositoryTagConstraint) != nil {
+		return errors.New("Invalid repository tag constraint")
 	}
 	return nil
 }
@@ -129,6 +131,15 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: errMsg, Err: errors.New(errMsg)}
 	}
 
+	// the webhook secret is not returned by the API, the current one is kept when it is not provided
+	if payload.AutoUpdate != nil && payload.AutoUpdate.WebhookSecret == "" && stack.AutoUpdate != nil && payload.AutoUpdate.WebhookProvider == stack.AutoUpdate.WebhookProvider {
+		payload.AutoUpdate.WebhookSecret = stack.AutoUpdate.WebhookSecret
+	}
+
+	if err := validateStackAutoUpdate(payload.AutoUpdate); err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
 	//stop the autoupdate job if there is any
 	if stack.AutoUpdate != nil {
 		stopAutoupdate(stack.ID, stack.AutoUpdate.JobID, *handler.Scheduler)
@@ -148,15 +159,12 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 	}
 
 	if payload.RepositoryAuthentication {
-		password := payload.RepositoryPassword
-		if password == "" && stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
-			password = stack.GitConfig.Authentication.Password
+		if httpErr := handler.checkGitCredentialAccess(payload.RepositoryGitCredentialID, user.ID); httpErr != nil {
+			return httpErr
 		}
-		stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-			Username: payload.RepositoryUsername,
-			Password: password,
-		}
-		_, err = handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication.Username, stack.GitConfig.Authentication.Password)
+
+		stack.GitConfig.Authentication = updatedGitAuthentication(stack.GitConfig.Authentication, payload.RepositoryUsername, payload.RepositoryPassword, payload.RepositoryGitCredentialID)
+		_, err = handler.latestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication)
 		if err != nil {
 			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to fetch git repository", Err: err}
 		}
@@ -164,6 +172,15 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		stack.GitConfig.Authentication = nil
 	}
 
+	stack.GitConfig.TagConstraint = payload.RepositoryTagConstraint
+	if stack.GitConfig.TagConstraint != "" {
+		referenceName, err := handler.resolveTagConstraint(stack.GitConfig.URL, stack.GitConfig.TagConstraint, stack.GitConfig.Authentication)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to resolve the tag constraint", Err: err}
+		}
+		stack.GitConfig.ReferenceName = referenceName
+	}
+
 	if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" {
 		jobID, e := startAutoupdate(stack.ID, stack.AutoUpdate.Interval, handler.Scheduler, handler.StackDeployer, handler.DataStore, handler.GitService)
 		if e != nil {
@@ -179,10 +196,7 @@ func (handler *Handler) stackUpdateGit(w http.ResponseWriter, r *http.Request) *
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack changes inside the database", Err: err}
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	hideStackFields(stack)
 
 	return response.JSON(w, stack)
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/stackutils"
@@ -19,12 +20,13 @@ import (
 )
 
 type stackGitRedployPayload struct {
-	RepositoryReferenceName  string
-	RepositoryAuthentication bool
-	RepositoryUsername       string
-	RepositoryPassword       string
-	Env                      []portainer.Pair
-	Prune                    bool
+	RepositoryReferenceName   string
+	RepositoryAuthentication  bool
+	RepositoryUsername        string
+	RepositoryPassword        string
+	RepositoryGitCredentialID portainer.GitCredentialID
+	Env                       []portainer.Pair
+	Prune                     bool
 }
 
 func (payload *stackGitRedployPayload) Validate(r *http.Request) error {
@@ -140,17 +142,16 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to move git repository directory", Err: err}
 	}
 
-	repositoryUsername := ""
-	repositoryPassword := ""
+	var repositoryAuth *gittypes.GitAuthentication
 	if payload.RepositoryAuthentication {
-		repositoryPassword = payload.RepositoryPassword
-		if repositoryPassword == "" && stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
-			repositoryPassword = stack.GitConfig.Authentication.Password
+		if httpErr := handler.checkGitCredentialAccess(payload.RepositoryGitCredentialID, securityContext.UserID); httpErr != nil {
+			return httpErr
 		}
-		repositoryUsername = payload.RepositoryUsername
+
+		repositoryAuth = updatedGitAuthentication(stack.GitConfig.Authentication, payload.RepositoryUsername, payload.RepositoryPassword, payload.RepositoryGitCredentialID)
 	}
 
-	err = handler.GitService.CloneRepository(stack.ProjectPath, stack.GitConfig.URL, payload.RepositoryReferenceName, repositoryUsername, repositoryPassword)
+	err = handler.clone(stack.ProjectPath, stack.GitConfig.URL, payload.RepositoryReferenceName, repositoryAuth, stack.GitConfig.CloneOptions)
 	if err != nil {
 		restoreError := filesystem.MoveDirectory(backupProjectPath, stack.ProjectPath)
 		if restoreError != nil {
@@ -172,7 +173,7 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return httpErr
 	}
 
-	newHash, err := handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, repositoryUsername, repositoryPassword)
+	newHash, err := handler.latestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, repositoryAuth)
 	if err != nil {
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable get latest commit id", Err: errors.WithMessagef(err, "failed to fetch latest commit id of the stack %v", stack.ID)}
 	}
@@ -191,10 +192,9 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist the stack changes inside the database", Err: errors.Wrap(err, "failed to update the stack")}
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	handler.recordStackVersion(stack, stack.UpdatedBy)
+
+	hideStackFields(stack)
 
 	return response.JSON(w, stack)
 }
@@ -0,0 +1,241 @@
+package stacks
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/stackutils"
+	"github.com/portainer/portainer/api/stacks"
+)
+
+type stackVersionDiffResponse struct {
+	// Version used as the base of the diff
+	From int `example:"1"`
+	// Version compared to the base
+	To int `example:"2"`
+	// Unified diff of each file of both versions
+	Files []stacks.FileDiff
+	// Environment variables added, removed or updated between both versions
+	Env []stacks.EnvChange
+	// Services or Kubernetes resources created, updated or removed between both versions
+	Resources []stacks.ResourceChange
+}
+
+// @id StackVersionList
+// @summary List the versions of a stack
+// @description List the successful deployments of a stack, from the oldest to the most recent one.
+// @description The content of the files is not returned, use the inspect operation to retrieve it.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {array} portainer.StackVersion "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions [get]
+func (handler *Handler) stackVersionList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, _, httpErr := handler.managedStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	versions, err := stacks.StackVersions(handler.DataStore, stack.ID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve the stack versions from the database", Err: err}
+	}
+
+	for i := range versions {
+		versions[i].Files = nil
+	}
+
+	return response.JSON(w, versions)
+}
+
+// @id StackVersionInspect
+// @summary Inspect a version of a stack
+// @description Retrieve a version of a stack, including the content of its files.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param version path int true "Version number"
+// @success 200 {object} portainer.StackVersion "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or version not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions/{version} [get]
+func (handler *Handler) stackVersionInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	versionNumber, err := request.RetrieveNumericRouteVariableValue(r, "version")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid version route variable", Err: err}
+	}
+
+	stack, _, httpErr := handler.managedStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	version, httpErr := handler.stackVersion(stack.ID, versionNumber)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, version)
+}
+
+// @id StackVersionDiff
+// @summary Compare two versions of a stack
+// @description Return the unified diff of the files, the environment variables changes and the services or resources changes between two versions of a stack.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param from query int true "Version used as the base of the diff"
+// @param to query int false "Version compared to the base, defaults to the most recent version"
+// @success 200 {object} stackVersionDiffResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack or version not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/versions/diff [get]
+func (handler *Handler) stackVersionDiff(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	fromNumber, err := request.RetrieveNumericQueryParameter(r, "from", false)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid query parameter: from", Err: err}
+	}
+
+	toNumber, err := request.RetrieveNumericQueryParameter(r, "to", true)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid query parameter: to", Err: err}
+	}
+
+	stack, _, httpErr := handler.managedStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if toNumber == 0 {
+		versions, err := stacks.StackVersions(handler.DataStore, stack.ID)
+		if err != nil {
+			return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve the stack versions from the database", Err: err}
+		}
+
+		if len(versions) > 0 {
+			toNumber = versions[len(versions)-1].Version
+		}
+	}
+
+	from, httpErr := handler.stackVersion(stack.ID, fromNumber)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	to, httpErr := handler.stackVersion(stack.ID, toNumber)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	diff, err := stacks.DiffStack(stack, from, to, fmt.Sprintf("version %d", from.Version), fmt.Sprintf("version %d", to.Version), handler.KubernetesDeployer)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to compare the stack versions", Err: err}
+	}
+
+	return response.JSON(w, &stackVersionDiffResponse{
+		From:      from.Version,
+		To:        to.Version,
+		Files:     diff.Files,
+		Env:       diff.Env,
+		Resources: diff.Resources,
+	})
+}
+
+// stackVersion returns the version of a stack matching the version number
+func (handler *Handler) stackVersion(stackID portainer.StackID, versionNumber int) (*portainer.StackVersion, *httperror.HandlerError) {
+	versions, err := stacks.StackVersions(handler.DataStore, stackID)
+	if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve the stack versions from the database", Err: err}
+	}
+
+	for _, version := range versions {
+		if version.Version == versionNumber {
+			return &version, nil
+		}
+	}
+
+	return nil, &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find the stack version", Err: errors.Errorf("version %d of the stack %v not found", versionNumber, stackID)}
+}
+
+// managedStack returns the stack matching the id route variable and its environment
+// when the current user is allowed to manage it
+func (handler *Handler) managedStack(r *http.Request) (*portainer.Stack, *portainer.Endpoint, *httperror.HandlerError) {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid stack identifier route variable", Err: err}
+	}
+
+	stack, err := handler.DataStore.Stack().Stack(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find a stack with the specified identifier inside the database", Err: err}
+	} else if err != nil {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to find a stack with the specified identifier inside the database", Err: err}
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find the environment associated to the stack inside the database", Err: err}
+	} else if err != nil {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to find the environment associated to the stack inside the database", Err: err}
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Permission denied to access environment", Err: err}
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve info from request context", Err: err}
+	}
+
+	if stack.Type == portainer.DockerSwarmStack || stack.Type == portainer.DockerComposeStack {
+		resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+		if err != nil {
+			return nil, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve a resource control associated to the stack", Err: err}
+		}
+
+		access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+		if err != nil {
+			return nil, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to verify user authorizations to validate stack access", Err: err}
+		}
+		if !access {
+			return nil, nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Access denied to resource", Err: httperrors.ErrResourceAccessDenied}
+		}
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to verify user authorizations to validate stack management", Err: err}
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users"
+		return nil, nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: errMsg, Err: errors.New(errMsg)}
+	}
+
+	return stack, endpoint, nil
+}
@@ -14,7 +14,7 @@ import (
 	"github.com/portainer/libhttp/request"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
-	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/http/security"
 	k "github.com/portainer/portainer/api/kubernetes"
 )
@@ -24,11 +24,13 @@ type kubernetesFileStackUpdatePayload struct {
 }
 
 type kubernetesGitStackUpdatePayload struct {
-	RepositoryReferenceName  string
-	RepositoryAuthentication bool
-	RepositoryUsername       string
-	RepositoryPassword       string
-	AutoUpdate               *portainer.StackAutoUpdate
+	RepositoryReferenceName   string
+	RepositoryTagConstraint   string
+	RepositoryAuthentication  bool
+	RepositoryUsername        string
+	RepositoryPassword        string
+	RepositoryGitCredentialID portainer.GitCredentialID
+	AutoUpdate                *portainer.StackAutoUpdate
 }
 
 func (payload *kubernetesFileStackUpdatePayload) Validate(r *http.Request) error {
@@ -39,13 +41,16 @@ func (payload *kubernetesFileStackUpdatePayload) Validate(r *http.Request) error
 }
 
 func (payload *kubernetesGitStackUpdatePayload) Validate(r *http.Request) error {
+	if payload.RepositoryTagConstraint != "" && git.ValidateTagConstraint(payload.RepositoryTagConstraint) != nil {
+		return errors.New("Invalid repository tag constraint")
+	}
 	if err := validateStackAutoUpdate(payload.AutoUpdate); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint) *httperror.HandlerError {
+func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
 
 	if stack.GitConfig != nil {
 		//stop the autoupdate job if there is any
@@ -63,15 +68,12 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 		stack.AutoUpdate = payload.AutoUpdate
 
 		if payload.RepositoryAuthentication {
-			password := payload.RepositoryPassword
-			if password == "" && stack.GitConfig != nil && stack.GitConfig.Authentication != nil {
-				password = stack.GitConfig.Authentication.Password
-			}
-			stack.GitConfig.Authentication = &gittypes.GitAuthentication{
-				Username: payload.RepositoryUsername,
-				Password: password,
+			if httpErr := handler.checkGitCredentialAccess(payload.RepositoryGitCredentialID, userID); httpErr != nil {
+				return httpErr
 			}
-			_, err := handler.GitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication.Username, stack.GitConfig.Authentication.Password)
+
+			stack.GitConfig.Authentication = updatedGitAuthentication(stack.GitConfig.Authentication, payload.RepositoryUsername, payload.RepositoryPassword, payload.RepositoryGitCredentialID)
+			_, err := handler.latestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, stack.GitConfig.Authentication)
 			if err != nil {
 				return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to fetch git repository", Err: err}
 			}
@@ -79,6 +81,15 @@ func (handler *Handler) updateKubernetesStack(r *http.Request, stack *portainer.
 			stack.GitConfig.Authentication = nil
 		}
 
+		stack.GitConfig.TagConstraint = payload.RepositoryTagConstraint
+		if stack.GitConfig.TagConstraint != "" {
+			referenceName, err := handler.resolveTagConstraint(stack.GitConfig.URL, stack.GitConfig.TagConstraint, stack.GitConfig.Authentication)
+			if err != nil {
+				return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to resolve the tag constraint", Err: err}
+			}
+			stack.GitConfig.ReferenceName = referenceName
+		}
+
 		if payload.AutoUpdate != nil && payload.AutoUpdate.Interval != "" {
 			jobID, e := startAutoupdate(stack.ID, stack.AutoUpdate.Interval, handler.Scheduler, handler.StackDeployer, handler.DataStore, handler.GitService)
 			if e != nil {
@@ -1,6 +1,7 @@
 package stacks
 
 import (
+	"io/ioutil"
 	"net/http"
 
 	"github.com/gofrs/uuid"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/portainer/libhttp/response"
 
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/stacks"
 
 	httperror "github.com/portainer/libhttp/error"
@@ -16,11 +19,15 @@ import (
 
 // @id WebhookInvoke
 // @summary Webhook for triggering stack updates from git
+// @description When the auto update of the stack defines a webhook provider, the request must be a push webhook of this provider
+// @description signed with the webhook secret. The stack is only redeployed when the pushed reference is the reference of the stack
+// @description and, if the paths filter is enabled, when the push changes one of the stack files.
 // @description **Access policy**: public
 // @tags stacks
 // @param webhookID path string true "Stack identifier"
 // @success 200 "Success"
 // @failure 400 "Invalid request"
+// @failure 401 "Invalid webhook signature"
 // @failure 409 "Conflict"
 // @failure 500 "Server error"
 // @router /stacks/webhooks/{webhookID} [post]
@@ -39,6 +46,16 @@ func (handler *Handler) webhookInvoke(w http.ResponseWriter, r *http.Request) *h
 		return &httperror.HandlerError{StatusCode: statusCode, Message: "Unable to find the stack by webhook ID", Err: err}
 	}
 
+	if stack.AutoUpdate != nil && stack.AutoUpdate.WebhookProvider != "" {
+		redeploy, httpErr := verifyPushWebhook(r, stack)
+		if httpErr != nil {
+			return httpErr
+		}
+		if !redeploy {
+			return response.Empty(w)
+		}
+	}
+
 	if err = stacks.RedeployWhenChanged(stack.ID, handler.StackDeployer, handler.DataStore, handler.GitService); err != nil {
 		if _, ok := err.(*stacks.StackAuthorMissingErr); ok {
 			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Autoupdate for the stack isn't available", Err: err}
@@ -50,6 +67,42 @@ func (handler *Handler) webhookInvoke(w http.ResponseWriter, r *http.Request) *h
 	return response.Empty(w)
 }
 
+// webhookMaxPayloadSize caps the size of the push webhooks read to verify their signature
+const webhookMaxPayloadSize = 1 << 20
+
+// verifyPushWebhook verifies the signature of a push webhook sent by the Git provider of a stack
+// and returns true when the push should redeploy the stack
+func verifyPushWebhook(r *http.Request, stack *portainer.Stack) (bool, *httperror.HandlerError) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, webhookMaxPayloadSize))
+	if err != nil {
+		return false, &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Unable to read the webhook payload", Err: err}
+	}
+
+	event, err := git.ParsePushEvent(stack.AutoUpdate.WebhookProvider, r.Header, body, stack.AutoUpdate.WebhookSecret)
+	if err == git.ErrInvalidWebhookSignature {
+		return false, &httperror.HandlerError{StatusCode: http.StatusUnauthorized, Message: "Invalid webhook signature", Err: err}
+	} else if err == git.ErrNotPushEvent {
+		return false, nil
+	} else if err != nil {
+		return false, &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid webhook payload", Err: err}
+	}
+
+	if stack.GitConfig != nil && stack.GitConfig.TagConstraint != "" {
+		// a pushed tag does not carry the changed files, the path filter does not apply
+		return event.MatchesTagConstraint(stack.GitConfig.TagConstraint), nil
+	}
+
+	if stack.GitConfig == nil || !event.MatchesReference(stack.GitConfig.ReferenceName) {
+		return false, nil
+	}
+
+	if stack.AutoUpdate.WebhookFilterPaths && !event.ChangesAnyFile(append([]string{stack.EntryPoint}, stack.AdditionalFiles...)) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
 func retrieveUUIDRouteVariableValue(r *http.Request, name string) (uuid.UUID, error) {
 	webhookID, err := request.RetrieveRouteVariableValue(r, name)
 	if err != nil {
@@ -3,12 +3,14 @@ package stacks
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/portainer/portainer/api/datastore"
 
 	"github.com/gofrs/uuid"
 	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,6 +48,50 @@ func TestHandler_webhookInvoke(t *testing.T) {
 	})
 }
 
+func TestHandler_webhookInvoke_withProvider(t *testing.T) {
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	webhookID := newGuidString(t)
+	store.StackService.Create(&portainer.Stack{
+		ID:         1,
+		EntryPoint: "docker-compose.yml",
+		GitConfig:  &gittypes.RepoConfig{ReferenceName: "refs/heads/main"},
+		AutoUpdate: &portainer.StackAutoUpdate{
+			Webhook:            webhookID,
+			WebhookProvider:    "gitlab",
+			WebhookSecret:      "secret",
+			WebhookFilterPaths: true,
+		},
+	})
+
+	h := NewHandler(nil)
+	h.DataStore = store
+
+	newPushRequest := func(token, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/stacks/webhooks/"+webhookID, strings.NewReader(body))
+		req.Header.Set("X-Gitlab-Token", token)
+		req.Header.Set("X-Gitlab-Event", "Push Hook")
+		return req
+	}
+
+	t.Run("invalid token results in http.StatusUnauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.Router.ServeHTTP(w, newPushRequest("other", `{"ref":"refs/heads/main"}`))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+	t.Run("push to another branch is ignored", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.Router.ServeHTTP(w, newPushRequest("secret", `{"ref":"refs/heads/develop"}`))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+	t.Run("push without changes to the stack files is ignored", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.Router.ServeHTTP(w, newPushRequest("secret", `{"ref":"refs/heads/main","commits":[{"modified":["README.md"]}]}`))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
 func newGuidString(t *testing.T) string {
 	uuid, err := uuid.NewV4()
 	assert.NoError(t, err)
@@ -3,6 +3,7 @@ package users
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	httperror "github.com/portainer/libhttp/error"
@@ -62,8 +63,9 @@ func (handler *Handler) adminInit(w http.ResponseWriter, r *http.Request) *httpe
 	}
 
 	user := &portainer.User{
-		Username: payload.Username,
-		Role:     portainer.AdministratorRole,
+		Username:          payload.Username,
+		Role:              portainer.AdministratorRole,
+		PasswordChangedAt: time.Now().Unix(),
 	}
 
 	user.Password, err = handler.CryptoService.Hash(payload.Password)
@@ -20,12 +20,17 @@ var (
 	errUserAlreadyExists          = errors.New("User already exists")
 	errAdminAlreadyInitialized    = errors.New("An administrator user already exists")
 	errAdminCannotRemoveSelf      = errors.New("Cannot remove your own user account. Contact another administrator")
+	errCannotDisableSelf          = errors.New("Cannot disable your own user account")
 	errCannotRemoveLastLocalAdmin = errors.New("Cannot remove the last local administrator account")
 	errCryptoHashFailure          = errors.New("Unable to hash data")
+	errPasswordReused             = errors.New("Password was used recently")
+	errSessionNotFound            = errors.New("Session not found")
 )
 
 func hideFields(user *portainer.User) {
 	user.Password = ""
+	user.PasswordHistory = nil
+	user.TOTP = portainer.UserTOTP{Enabled: user.TOTP.Enabled}
 }
 
 // Handler is the HTTP handler used to handle user operations.
@@ -36,6 +41,7 @@ type Handler struct {
 	demoService             *demo.Service
 	DataStore               dataservices.DataStore
 	CryptoService           portainer.CryptoService
+	JWTService              dataservices.JWTService
 	passwordStrengthChecker security.PasswordStrengthChecker
 }
 
@@ -73,6 +79,12 @@ func NewHandler(bouncer *security.RequestBouncer, rateLimiter *security.RateLimi
 	restrictedRouter.Handle("/users/{id}/tokens", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userCreateAccessToken))).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users/{id}/tokens/{keyID}", httperror.LoggerHandler(h.userRemoveAccessToken)).Methods(http.MethodDelete)
 	restrictedRouter.Handle("/users/{id}/memberships", httperror.LoggerHandler(h.userMemberships)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/users/{id}/totp", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userEnrollTOTP))).Methods(http.MethodPost)
+	authenticatedRouter.Handle("/users/{id}/totp/verify", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userVerifyTOTP))).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/totp", httperror.LoggerHandler(h.userResetTOTP)).Methods(http.MethodDelete)
+	authenticatedRouter.Handle("/users/{id}/sessions", httperror.LoggerHandler(h.userSessionList)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/users/{id}/sessions", httperror.LoggerHandler(h.userSessionDeleteAll)).Methods(http.MethodDelete)
+	authenticatedRouter.Handle("/users/{id}/sessions/{sessionID}", httperror.LoggerHandler(h.userSessionDelete)).Methods(http.MethodDelete)
 	authenticatedRouter.Handle("/users/{id}/passwd", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userUpdatePassword))).Methods(http.MethodPut)
 	publicRouter.Handle("/users/admin/check", httperror.LoggerHandler(h.adminCheck)).Methods(http.MethodGet)
 	publicRouter.Handle("/users/admin/init", httperror.LoggerHandler(h.adminInit)).Methods(http.MethodPost)
@@ -3,6 +3,7 @@ package users
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	httperror "github.com/portainer/libhttp/error"
@@ -15,6 +16,10 @@ import (
 
 type userAccessTokenCreatePayload struct {
 	Description string `validate:"required" example:"github-api-key" json:"description"`
+	// Unix timestamp (UTC) after which the API key can no longer be used, the key never expires when omitted
+	ExpiresAt int64 `example:"1587399600" json:"expiresAt"`
+	// Restrictions applied to the API key on top of the permissions of the user
+	Scope portainer.APIKeyScope `json:"scope"`
 }
 
 func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
@@ -27,6 +32,9 @@ func (payload *userAccessTokenCreatePayload) Validate(r *http.Request) error {
 	if govalidator.MinStringLength(payload.Description, "128") {
 		return errors.New("invalid description. cannot be longer than 128 characters")
 	}
+	if payload.ExpiresAt != 0 && payload.ExpiresAt <= time.Now().Unix() {
+		return errors.New("invalid expiration date. must be in the future")
+	}
 	return nil
 }
 
@@ -39,6 +47,8 @@ type accessTokenResponse struct {
 // @summary Generate an API key for a user
 // @description Generates an API key for a user.
 // @description Only the calling user can generate a token for themselves.
+// @description The API key can expire and be restricted to read-only requests, to a set of environments(endpoints) and to a set of authorizations.
+// @description A key restricted to a set of environments(endpoints) is denied the requests that do not target one of them.
 // @description **Access policy**: restricted
 // @tags users
 // @security jwt
@@ -84,7 +94,14 @@ func (handler *Handler) userCreateAccessToken(w http.ResponseWriter, r *http.Req
 		return &httperror.HandlerError{http.StatusBadRequest, "Unable to find a user", err}
 	}
 
-	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateApiKey(*user, payload.Description)
+	for _, endpointID := range payload.Scope.EndpointIDs {
+		_, err := handler.DataStore.Endpoint().Endpoint(endpointID)
+		if err != nil {
+			return &httperror.HandlerError{http.StatusBadRequest, "Unable to find an environment with the specified identifier inside the database", err}
+		}
+	}
+
+	rawAPIKey, apiKey, err := handler.apiKeyService.GenerateScopedApiKey(*user, payload.Description, payload.ExpiresAt, payload.Scope)
 	if err != nil {
 		return &httperror.HandlerError{http.StatusInternalServerError, "Internal Server Error", err}
 	}
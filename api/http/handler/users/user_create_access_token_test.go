@@ -71,6 +71,50 @@ func Test_userCreateAccessToken(t *testing.T) {
 		is.NotEmpty(resp.RawAPIKey)
 	})
 
+	t.Run("standard user successfully generates an expiring and scoped API key", func(t *testing.T) {
+		endpoint := &portainer.Endpoint{ID: 1, Name: "local"}
+		is.NoError(store.Endpoint().Create(endpoint))
+
+		expiresAt := time.Now().Add(24 * time.Hour).Unix()
+		data := userAccessTokenCreatePayload{
+			Description: "test-scoped-token",
+			ExpiresAt:   expiresAt,
+			Scope:       portainer.APIKeyScope{ReadOnly: true, EndpointIDs: []portainer.EndpointID{endpoint.ID}},
+		}
+		payload, err := json.Marshal(data)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/2/tokens", bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		is.Equal(http.StatusCreated, rr.Code)
+
+		var resp accessTokenResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.Equal(expiresAt, resp.APIKey.ExpiresAt)
+		is.Equal(data.Scope, resp.APIKey.Scope)
+	})
+
+	t.Run("API key cannot be scoped to an unknown environment", func(t *testing.T) {
+		data := userAccessTokenCreatePayload{
+			Description: "test-unknown-endpoint",
+			Scope:       portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{42}},
+		}
+		payload, err := json.Marshal(data)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/2/tokens", bytes.NewBuffer(payload))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		is.Equal(http.StatusBadRequest, rr.Code)
+	})
+
 	t.Run("admin cannot generate API key for standard user", func(t *testing.T) {
 		data := userAccessTokenCreatePayload{Description: "test-token-admin"}
 		payload, err := json.Marshal(data)
@@ -144,6 +188,14 @@ this string is longer than 128 characters and hence this will fail.
 `},
 			shouldFail: true,
 		},
+		{
+			payload:    userAccessTokenCreatePayload{Description: "test-token", ExpiresAt: time.Now().Add(time.Hour).Unix()},
+			shouldFail: false,
+		},
+		{
+			payload:    userAccessTokenCreatePayload{Description: "test-token", ExpiresAt: time.Now().Add(-time.Hour).Unix()},
+			shouldFail: true,
+		},
 	}
 
 	for _, test := range tests {
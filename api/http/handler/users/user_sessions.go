@@ -0,0 +1,144 @@
+package users
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+type userSessionResponse struct {
+	portainer.Session
+	// Whether the session is the one of the JWT used to list the sessions
+	Current bool `json:"current" example:"true"`
+}
+
+// userSessionsOwner returns the user targeted by a session operation, only the calling user or an administrator
+// can manage the sessions of a user
+func (handler *Handler) userSessionsOwner(r *http.Request) (*portainer.TokenData, portainer.UserID, *httperror.HandlerError) {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, 0, &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid user identifier route variable", Err: err}
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, 0, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve user authentication token", Err: err}
+	}
+
+	if tokenData.Role != portainer.AdministratorRole && tokenData.ID != portainer.UserID(userID) {
+		return nil, 0, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Permission denied to manage user sessions", Err: httperrors.ErrUnauthorized}
+	}
+
+	_, err = handler.DataStore.User().User(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, 0, &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find a user with the specified identifier inside the database", Err: err}
+	} else if err != nil {
+		return nil, 0, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to find a user with the specified identifier inside the database", Err: err}
+	}
+
+	return tokenData, portainer.UserID(userID), nil
+}
+
+// @id UserSessionList
+// @summary List the sessions of a user
+// @description List the active sessions of a user, the most recently used first.
+// @description Only the calling user or an administrator can list the sessions.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {array} userSessionResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/sessions [get]
+func (handler *Handler) userSessionList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenData, userID, handlerErr := handler.userSessionsOwner(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	sessions := handler.JWTService.UserSessions(userID)
+
+	result := make([]userSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, userSessionResponse{
+			Session: session,
+			Current: tokenData.SessionID != "" && session.ID == tokenData.SessionID,
+		})
+	}
+
+	return response.JSON(w, result)
+}
+
+// @id UserSessionDelete
+// @summary Revoke a session of a user
+// @description Revoke a session of a user, the JWTs of the session are rejected immediately.
+// @description Only the calling user or an administrator can revoke the session.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @param sessionID path string true "Session identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User or session not found"
+// @failure 500 "Server error"
+// @router /users/{id}/sessions/{sessionID} [delete]
+func (handler *Handler) userSessionDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	_, userID, handlerErr := handler.userSessionsOwner(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	sessionID, err := request.RetrieveRouteVariableValue(r, "sessionID")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid session identifier route variable", Err: err}
+	}
+
+	if !handler.JWTService.RevokeSession(userID, sessionID) {
+		return &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find a session with the specified identifier", Err: errSessionNotFound}
+	}
+
+	return response.Empty(w)
+}
+
+// @id UserSessionDeleteAll
+// @summary Revoke all the sessions of a user
+// @description Revoke all the sessions of a user, including the session of the caller when revoking their own sessions.
+// @description The kubeconfig tokens of the user, which are not bound to a session, are revoked too.
+// @description Only the calling user or an administrator can revoke the sessions.
+// @description **Access policy**: authenticated
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/sessions [delete]
+func (handler *Handler) userSessionDeleteAll(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	_, userID, handlerErr := handler.userSessionsOwner(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	err := handler.JWTService.RevokeUserSessions(userID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to revoke the sessions of the user", Err: err}
+	}
+
+	return response.Empty(w)
+}
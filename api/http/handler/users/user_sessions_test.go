@@ -0,0 +1,113 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userSessions(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	adminUser := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+	otherUser := &portainer.User{Username: "other", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(otherUser))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, nil, passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+
+	login := func(u *portainer.User) (string, string) {
+		session, err := jwtService.CreateSession(u.ID, "10.0.0.10", "Mozilla/5.0")
+		is.NoError(err)
+
+		token, err := jwtService.GenerateToken(&portainer.TokenData{ID: u.ID, Username: u.Username, Role: u.Role, SessionID: session.ID})
+		is.NoError(err)
+
+		return session.ID, token
+	}
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	adminSessionID, adminJWT := login(adminUser)
+	userSessionID, userJWT := login(user)
+	secondSessionID, secondJWT := login(user)
+	_, otherJWT := login(otherUser)
+
+	sessionsPath := fmt.Sprintf("/users/%d/sessions", user.ID)
+
+	t.Run("users list their sessions", func(t *testing.T) {
+		rr := do(http.MethodGet, sessionsPath, userJWT)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var sessions []userSessionResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&sessions))
+		is.Len(sessions, 2)
+
+		for _, session := range sessions {
+			is.Equal(session.ID == userSessionID, session.Current)
+		}
+	})
+
+	t.Run("users cannot manage the sessions of other users", func(t *testing.T) {
+		rr := do(http.MethodGet, sessionsPath, otherJWT)
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodDelete, sessionsPath+"/"+userSessionID, otherJWT)
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodDelete, fmt.Sprintf("/users/%d/sessions/%s", otherUser.ID, adminSessionID), otherJWT)
+		is.Equal(http.StatusNotFound, rr.Code, "the session must belong to the user")
+	})
+
+	t.Run("a revoked session is rejected immediately", func(t *testing.T) {
+		rr := do(http.MethodDelete, sessionsPath+"/"+secondSessionID, userJWT)
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		rr = do(http.MethodGet, sessionsPath, secondJWT)
+		is.Equal(http.StatusUnauthorized, rr.Code)
+
+		rr = do(http.MethodGet, sessionsPath, userJWT)
+		is.Equal(http.StatusOK, rr.Code)
+	})
+
+	t.Run("administrators revoke all the sessions of a user", func(t *testing.T) {
+		rr := do(http.MethodDelete, sessionsPath, adminJWT)
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		rr = do(http.MethodGet, sessionsPath, userJWT)
+		is.Equal(http.StatusUnauthorized, rr.Code)
+
+		is.Empty(jwtService.UserSessions(user.ID))
+	})
+}
@@ -0,0 +1,199 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	httperror "github.com/portainer/libhttp/error"
+	"github.com/portainer/libhttp/request"
+	"github.com/portainer/libhttp/response"
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/totp"
+)
+
+type userTOTPEnrollResponse struct {
+	// Base32 encoded secret to register in the authenticator application
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// otpauth URI of the secret, usually displayed as a QR code
+	ProvisioningURI string `json:"provisioningUri" example:"otpauth://totp/Portainer:admin?secret=JBSWY3DPEHPK3PXP"`
+}
+
+type userTOTPVerifyPayload struct {
+	// One-time password generated by the authenticator application
+	Code string `example:"123456" validate:"required"`
+}
+
+func (payload *userTOTPVerifyPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Code) {
+		return errors.New("Invalid one-time password")
+	}
+	return nil
+}
+
+type userTOTPVerifyResponse struct {
+	// Single-use codes accepted instead of a one-time password, only returned once
+	RecoveryCodes []string `json:"recoveryCodes" example:"4e5f8-a0c9d"`
+}
+
+// userTOTPOwner returns the user targeted by a TOTP enrollment, only the calling user can enroll themselves
+// and the enrollment requires a JWT authentication.
+func (handler *Handler) userTOTPOwner(r *http.Request) (*portainer.User, *httperror.HandlerError) {
+	if jwt := handler.bouncer.JWTAuthLookup(r); jwt == nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusUnauthorized, Message: "Auth not supported", Err: errors.New("JWT Authentication required")}
+	}
+
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid user identifier route variable", Err: err}
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to retrieve user authentication token", Err: err}
+	}
+
+	if tokenData.ID != portainer.UserID(userID) {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusForbidden, Message: "Permission denied to enroll user in two-factor authentication", Err: httperrors.ErrUnauthorized}
+	}
+
+	user, err := handler.DataStore.User().User(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find a user with the specified identifier inside the database", Err: err}
+	} else if err != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to find a user with the specified identifier inside the database", Err: err}
+	}
+
+	if user.TOTP.Enabled {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Two-factor authentication is already enabled for this user", Err: errors.New("Two-factor authentication is already enabled")}
+	}
+
+	return user, nil
+}
+
+// @id UserEnrollTOTP
+// @summary Enroll a user in the two-factor authentication
+// @description Generate the TOTP secret of the user, the two-factor authentication is enabled once a first one-time password is verified.
+// @description Only the calling user can enroll themselves.
+// @description **Access policy**: authenticated
+// @tags users
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} userTOTPEnrollResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Unauthorized"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "Two-factor authentication already enabled"
+// @failure 500 "Server error"
+// @router /users/{id}/totp [post]
+func (handler *Handler) userEnrollTOTP(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, handlerErr := handler.userTOTPOwner(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	secret, err := totp.Enroll(&user.TOTP)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to generate the TOTP secret", Err: err}
+	}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user changes inside the database", Err: err}
+	}
+
+	return response.JSON(w, &userTOTPEnrollResponse{Secret: secret, ProvisioningURI: totp.ProvisioningURI(user.Username, secret)})
+}
+
+// @id UserVerifyTOTP
+// @summary Enable the two-factor authentication of a user
+// @description Verify a first one-time password generated with the secret of the enrollment and enable the two-factor authentication.
+// @description The recovery codes are only returned by this operation.
+// @description Only the calling user can enroll themselves.
+// @description **Access policy**: authenticated
+// @tags users
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body userTOTPVerifyPayload true "One-time password"
+// @success 200 {object} userTOTPVerifyResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Unauthorized"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "Two-factor authentication already enabled"
+// @failure 422 "Invalid one-time password"
+// @failure 500 "Server error"
+// @router /users/{id}/totp/verify [post]
+func (handler *Handler) userVerifyTOTP(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload userTOTPVerifyPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid request payload", Err: err}
+	}
+
+	user, handlerErr := handler.userTOTPOwner(r)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	recoveryCodes, err := totp.Activate(&user.TOTP, payload.Code, time.Now())
+	if err == totp.ErrNoPendingEnrollment {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "No pending two-factor authentication enrollment", Err: err}
+	} else if err == totp.ErrInvalidCode {
+		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid one-time password", Err: err}
+	} else if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to enable the two-factor authentication", Err: err}
+	}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user changes inside the database", Err: err}
+	}
+
+	return response.JSON(w, &userTOTPVerifyResponse{RecoveryCodes: recoveryCodes})
+}
+
+// @id UserResetTOTP
+// @summary Reset the two-factor authentication of a user
+// @description Disable the two-factor authentication of the user and remove its secret and recovery codes.
+// @description The user has to enroll again when the two-factor authentication is enforced.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/totp [delete]
+func (handler *Handler) userResetTOTP(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusBadRequest, Message: "Invalid user identifier route variable", Err: err}
+	}
+
+	user, err := handler.DataStore.User().User(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Unable to find a user with the specified identifier inside the database", Err: err}
+	} else if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to find a user with the specified identifier inside the database", Err: err}
+	}
+
+	user.TOTP = portainer.UserTOTP{}
+
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to persist user changes inside the database", Err: err}
+	}
+
+	return response.Empty(w)
+}
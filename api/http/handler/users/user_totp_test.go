@@ -0,0 +1,108 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userTOTP(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, nil, passwordChecker)
+	h.DataStore = store
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("users cannot enroll other users", func(t *testing.T) {
+		rr := do(http.MethodPost, "/users/2/totp", adminJWT, "")
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("user enables the two-factor authentication", func(t *testing.T) {
+		rr := do(http.MethodPost, "/users/2/totp", userJWT, "")
+		is.Equal(http.StatusOK, rr.Code)
+
+		var enrollment userTOTPEnrollResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&enrollment))
+		is.NotEmpty(enrollment.Secret)
+
+		rr = do(http.MethodPost, "/users/2/totp/verify", userJWT, `{"Code":"000000"}`)
+		is.Equal(http.StatusUnprocessableEntity, rr.Code)
+
+		code, err := totp.GenerateCode(enrollment.Secret, time.Now())
+		is.NoError(err)
+
+		rr = do(http.MethodPost, "/users/2/totp/verify", userJWT, `{"Code":"`+code+`"}`)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var verification userTOTPVerifyResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&verification))
+		is.NotEmpty(verification.RecoveryCodes)
+
+		updated, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.True(updated.TOTP.Enabled)
+
+		rr = do(http.MethodPost, "/users/2/totp", userJWT, "")
+		is.Equal(http.StatusConflict, rr.Code)
+	})
+
+	t.Run("the TOTP secret is not exposed", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/2", adminJWT, "")
+		is.Equal(http.StatusOK, rr.Code)
+
+		var inspected portainer.User
+		is.NoError(json.NewDecoder(rr.Body).Decode(&inspected))
+		is.Equal(portainer.UserTOTP{Enabled: true}, inspected.TOTP)
+	})
+
+	t.Run("only administrators reset the two-factor authentication", func(t *testing.T) {
+		rr := do(http.MethodDelete, "/users/2/totp", userJWT, "")
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodDelete, "/users/2/totp", adminJWT, "")
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		updated, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.Equal(portainer.UserTOTP{}, updated.TOTP)
+	})
+}
@@ -3,7 +3,6 @@ package users
 import (
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/asaskevich/govalidator"
 	httperror "github.com/portainer/libhttp/error"
@@ -20,6 +19,8 @@ type userUpdatePayload struct {
 	UserTheme string `example:"dark"`
 	// User role (1 for administrator account and 2 for regular account)
 	Role int `validate:"required" enums:"1,2" example:"2"`
+	// Whether the user is prevented from logging in, only administrators can change it
+	Disabled *bool `example:"false"`
 }
 
 func (payload *userUpdatePayload) Validate(r *http.Request) error {
@@ -36,6 +37,7 @@ func (payload *userUpdatePayload) Validate(r *http.Request) error {
 // @id UserUpdate
 // @summary Update a user
 // @description Update user details. A regular user account can only update his details.
+// @description Only administrators can disable or enable an account, such as an account disabled by the LDAP group synchronization.
 // @description **Access policy**: authenticated
 // @tags users
 // @security ApiKeyAuth
@@ -80,6 +82,16 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		return &httperror.HandlerError{http.StatusForbidden, "Permission denied to update user to administrator role", httperrors.ErrResourceAccessDenied}
 	}
 
+	if payload.Disabled != nil {
+		if tokenData.Role != portainer.AdministratorRole {
+			return &httperror.HandlerError{http.StatusForbidden, "Permission denied to enable or disable user", httperrors.ErrResourceAccessDenied}
+		}
+
+		if *payload.Disabled && tokenData.ID == portainer.UserID(userID) {
+			return &httperror.HandlerError{http.StatusForbidden, "Administrators cannot disable their own account", errCannotDisableSelf}
+		}
+	}
+
 	user, err := handler.DataStore.User().User(portainer.UserID(userID))
 	if handler.DataStore.IsErrObjectNotFound(err) {
 		return &httperror.HandlerError{http.StatusNotFound, "Unable to find a user with the specified identifier inside the database", err}
@@ -100,11 +112,10 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 	}
 
 	if payload.Password != "" {
-		user.Password, err = handler.CryptoService.Hash(payload.Password)
-		if err != nil {
-			return &httperror.HandlerError{http.StatusInternalServerError, "Unable to hash user password", errCryptoHashFailure}
+		handlerErr := handler.changePassword(user, payload.Password)
+		if handlerErr != nil {
+			return handlerErr
 		}
-		user.TokenIssueAt = time.Now().Unix()
 	}
 
 	if payload.Role != 0 {
@@ -115,6 +126,11 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		user.UserTheme = payload.UserTheme
 	}
 
+	if payload.Disabled != nil {
+		user.Disabled = *payload.Disabled
+		user.DisabledByLDAPSync = false
+	}
+
 	err = handler.DataStore.User().UpdateUser(user.ID, user)
 	if err != nil {
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to persist user changes inside the database", err}
@@ -122,5 +138,7 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 
 	// remove all of the users persisted API keys
 	handler.apiKeyService.InvalidateUserKeyCache(user.ID)
+
+	hideFields(user)
 	return response.JSON(w, user)
 }
@@ -43,7 +43,7 @@ func (payload *userUpdatePasswordPayload) Validate(r *http.Request) error {
 // @param id path int true "identifier"
 // @param body body userUpdatePasswordPayload true "details"
 // @success 204 "Success"
-// @failure 400 "Invalid request"
+// @failure 400 "Invalid request, or the new password does not meet the requirements or was used recently"
 // @failure 403 "Permission denied"
 // @failure 404 "User not found"
 // @failure 500 "Server error"
@@ -85,21 +85,44 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 		return &httperror.HandlerError{http.StatusForbidden, "Current password doesn't match", errors.New("Current password does not match the password provided. Please try again")}
 	}
 
-	if !handler.passwordStrengthChecker.Check(payload.NewPassword) {
-		return &httperror.HandlerError{http.StatusBadRequest, "Password does not meet the requirements", nil}
+	handlerErr := handler.changePassword(user, payload.NewPassword)
+	if handlerErr != nil {
+		return handlerErr
 	}
 
-	user.Password, err = handler.CryptoService.Hash(payload.NewPassword)
+	err = handler.DataStore.User().UpdateUser(user.ID, user)
 	if err != nil {
-		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to hash user password", errCryptoHashFailure}
+		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to persist user changes inside the database", err}
 	}
 
-	user.TokenIssueAt = time.Now().Unix()
+	return response.Empty(w)
+}
 
-	err = handler.DataStore.User().UpdateUser(user.ID, user)
+// changePassword validates the new password of the user against the password policy and replaces the password,
+// keeping the previous one in the password history
+func (handler *Handler) changePassword(user *portainer.User, password string) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
 	if err != nil {
-		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to persist user changes inside the database", err}
+		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to retrieve settings from the database", err}
 	}
 
-	return response.Empty(w)
+	if !handler.passwordStrengthChecker.Check(password) {
+		return &httperror.HandlerError{http.StatusBadRequest, "Password does not meet the requirements", nil}
+	}
+
+	historySize := settings.InternalAuthSettings.PasswordHistorySize
+	if security.PasswordReused(handler.CryptoService, user, password, historySize) {
+		return &httperror.HandlerError{http.StatusBadRequest, "Password was used recently, please choose a different one", errPasswordReused}
+	}
+
+	hash, err := handler.CryptoService.Hash(password)
+	if err != nil {
+		return &httperror.HandlerError{http.StatusInternalServerError, "Unable to hash user password", errCryptoHashFailure}
+	}
+
+	now := time.Now()
+	security.RecordPasswordChange(user, hash, historySize, now)
+	user.TokenIssueAt = now.Unix()
+
+	return nil
 }
@@ -0,0 +1,87 @@
+package users
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userUpdatePasswordPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	cryptoService := &crypto.Service{}
+	password, err := cryptoService.Hash("Passw0rd!Passw0rd!")
+	is.NoError(err)
+
+	user := &portainer.User{Username: "standard", Password: password, Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.InternalAuthSettings = portainer.InternalAuthSettings{
+		RequiredPasswordLength:   12,
+		RequiredCharacterClasses: 3,
+		BannedPasswords:          []string{"Portainer1234"},
+		PasswordHistorySize:      3,
+	}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+
+	changePassword := func(current, new string) int {
+		// a password change revokes the tokens issued before it
+		userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/passwd", user.ID), strings.NewReader(`{"Password":"`+current+`","NewPassword":"`+new+`"}`))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", userJWT))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("passwords not matching the policy are rejected", func(t *testing.T) {
+		is.Equal(http.StatusBadRequest, changePassword("Passw0rd!Passw0rd!", "passwordpassword"))
+		is.Equal(http.StatusBadRequest, changePassword("Passw0rd!Passw0rd!", "PORTAINER1234"))
+	})
+
+	t.Run("recent passwords cannot be reused", func(t *testing.T) {
+		is.Equal(http.StatusBadRequest, changePassword("Passw0rd!Passw0rd!", "Passw0rd!Passw0rd!"))
+
+		is.Equal(http.StatusNoContent, changePassword("Passw0rd!Passw0rd!", "Second!Passw0rd"))
+		is.Equal(http.StatusNoContent, changePassword("Second!Passw0rd", "Third!Passw0rd"))
+		is.Equal(http.StatusBadRequest, changePassword("Third!Passw0rd", "Passw0rd!Passw0rd!"))
+
+		is.Equal(http.StatusNoContent, changePassword("Third!Passw0rd", "Fourth!Passw0rd"))
+		is.Equal(http.StatusNoContent, changePassword("Fourth!Passw0rd", "Passw0rd!Passw0rd!"))
+
+		updated, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.Len(updated.PasswordHistory, 2)
+		is.NotZero(updated.PasswordChangedAt)
+	})
+}
@@ -1,14 +1,17 @@
 package users
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
 	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/jwt"
 	"github.com/stretchr/testify/assert"
@@ -55,3 +58,59 @@ func Test_updateUserRemovesAccessTokens(t *testing.T) {
 		is.Equal(0, len(keys))
 	})
 }
+
+func Test_updateUserDisabled(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	adminUser := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole, Disabled: true}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+
+	adminJWT, err := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	is.NoError(err)
+
+	update := func(id portainer.UserID, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", id), strings.NewReader(body))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("administrators enable a disabled account", func(t *testing.T) {
+		rr := update(user.ID, adminJWT, `{"Disabled":false}`)
+		is.Equal(http.StatusOK, rr.Code)
+
+		updated, err := store.User().User(user.ID)
+		is.NoError(err)
+		is.False(updated.Disabled)
+	})
+
+	t.Run("standard users cannot change the state of their account", func(t *testing.T) {
+		userJWT, err := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+		is.NoError(err)
+
+		rr := update(user.ID, userJWT, `{"Disabled":false}`)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("administrators cannot disable their own account", func(t *testing.T) {
+		rr := update(adminUser.ID, adminJWT, `{"Disabled":true}`)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+}
@@ -0,0 +1,226 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// containerClient is the subset of the Docker client used to recreate a container.
+type containerClient interface {
+	ContainerInspect(ctx context.Context, containerID string) (dockertypes.ContainerJSON, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error)
+	ContainerStart(ctx context.Context, containerID string, options dockertypes.ContainerStartOptions) error
+	ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error
+	ContainerRename(ctx context.Context, containerID, newContainerName string) error
+	ContainerRemove(ctx context.Context, containerID string, options dockertypes.ContainerRemoveOptions) error
+	NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error
+	ImagePull(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (dockertypes.ImageInspect, []byte, error)
+}
+
+// containerImageName returns the name of the image a container was created from. When the container was created
+// from an image identifier, the name is taken from the repository tags or digests of the image.
+func containerImageName(ctx context.Context, client containerClient, ctr dockertypes.ContainerJSON) (string, error) {
+	name := ctr.Config.Image
+	if !isImageID(name, ctr.Image) {
+		return name, nil
+	}
+
+	image, _, err := client.ImageInspectWithRaw(ctx, ctr.Image)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to inspect the image of the container")
+	}
+
+	if len(image.RepoTags) > 0 {
+		return image.RepoTags[0], nil
+	}
+
+	if len(image.RepoDigests) > 0 {
+		return image.RepoDigests[0], nil
+	}
+
+	return "", errors.Errorf("the image %s of the container has no repository to pull it from", name)
+}
+
+// isImageID returns true when name is the full or short form of the image identifier imageID.
+func isImageID(name, imageID string) bool {
+	name = strings.TrimPrefix(name, "sha256:")
+	if name == "" || strings.Trim(name, "0123456789abcdef") != "" {
+		return false
+	}
+
+	return strings.HasPrefix(strings.TrimPrefix(imageID, "sha256:"), name)
+}
+
+// containerImage returns the image reference of a container, using imageTag as tag when it is not empty.
+func containerImage(image string, imageTag string) string {
+	imageName := strings.Split(image, "@sha")[0]
+	if imageTag == "" {
+		return imageName
+	}
+
+	// a colon before the last slash separates a registry host from its port, not a tag
+	tagIndex := strings.LastIndex(imageName, ":")
+	if tagIndex == -1 || tagIndex < strings.LastIndex(imageName, "/") {
+		tagIndex = len(imageName)
+	}
+
+	return imageName[:tagIndex] + ":" + imageTag
+}
+
+// pullImage pulls an image and waits for the pull to complete.
+func pullImage(ctx context.Context, client containerClient, image, registryAuth string) error {
+	rc, err := client.ImagePull(ctx, image, dockertypes.ImagePullOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// the pull is only complete once the progress stream has been consumed
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
+
+// recreateContainer replaces a container with a new one created from image, keeping its name, configuration
+// and networks. The original container is restored when the new one can't be created or started.
+// Returns the identifier of the new container.
+func recreateContainer(ctx context.Context, client containerClient, containerID, image string) (string, error) {
+	original, err := client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to inspect the container")
+	}
+
+	name := strings.TrimPrefix(original.Name, "/")
+	wasRunning := original.State != nil && original.State.Running
+	shortID := original.ID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+
+	config := *original.Config
+	config.Image = image
+	if config.Hostname == shortID {
+		// let the new container use its own identifier as hostname
+		config.Hostname = ""
+	}
+
+	networkingConfig, additionalNetworks := containerNetworks(original, shortID)
+
+	backupName := fmt.Sprintf("%s-portainer-backup-%d", name, time.Now().Unix())
+	err = client.ContainerRename(ctx, original.ID, backupName)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to rename the container")
+	}
+
+	rollback := func(newContainerID string) {
+		if newContainerID != "" {
+			err := client.ContainerRemove(ctx, newContainerID, dockertypes.ContainerRemoveOptions{Force: true})
+			if err != nil {
+				logrus.WithError(err).WithField("container", newContainerID).Warn("unable to remove the recreated container")
+			}
+		}
+
+		err := client.ContainerRename(ctx, original.ID, name)
+		if err != nil {
+			logrus.WithError(err).WithField("container", original.ID).Error("unable to restore the name of the original container")
+		}
+
+		if wasRunning {
+			err = client.ContainerStart(ctx, original.ID, dockertypes.ContainerStartOptions{})
+			if err != nil {
+				logrus.WithError(err).WithField("container", original.ID).Error("unable to restart the original container")
+			}
+		}
+	}
+
+	if wasRunning {
+		err = client.ContainerStop(ctx, original.ID, nil)
+		if err != nil {
+			rollback("")
+			return "", errors.Wrap(err, "unable to stop the container")
+		}
+	}
+
+	created, err := client.ContainerCreate(ctx, &config, original.HostConfig, networkingConfig, nil, name)
+	if err != nil {
+		rollback("")
+		return "", errors.Wrap(err, "unable to create the new container")
+	}
+
+	for networkName, settings := range additionalNetworks {
+		err = client.NetworkConnect(ctx, networkName, created.ID, settings)
+		if err != nil {
+			rollback(created.ID)
+			return "", errors.Wrapf(err, "unable to connect the new container to the network %s", networkName)
+		}
+	}
+
+	if wasRunning {
+		err = client.ContainerStart(ctx, created.ID, dockertypes.ContainerStartOptions{})
+		if err != nil {
+			rollback(created.ID)
+			return "", errors.Wrap(err, "unable to start the new container")
+		}
+	}
+
+	err = client.ContainerRemove(ctx, original.ID, dockertypes.ContainerRemoveOptions{Force: true})
+	if err != nil {
+		logrus.WithError(err).WithField("container", original.ID).Warn("unable to remove the original container")
+	}
+
+	return created.ID, nil
+}
+
+// containerNetworks splits the networks of a container between the one it must be created with
+// and the ones it must be connected to once created.
+func containerNetworks(original dockertypes.ContainerJSON, shortID string) (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	networkingConfig := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	additionalNetworks := map[string]*network.EndpointSettings{}
+
+	if original.NetworkSettings == nil || original.HostConfig == nil {
+		return networkingConfig, additionalNetworks
+	}
+
+	networkMode := original.HostConfig.NetworkMode
+	if networkMode.IsHost() || networkMode.IsNone() || networkMode.IsContainer() {
+		return networkingConfig, additionalNetworks
+	}
+
+	primaryNetwork := string(networkMode)
+	if networkMode.IsDefault() {
+		primaryNetwork = "bridge"
+	}
+
+	for networkName, settings := range original.NetworkSettings.Networks {
+		endpointSettings := &network.EndpointSettings{
+			IPAMConfig: settings.IPAMConfig,
+			Links:      settings.Links,
+			DriverOpts: settings.DriverOpts,
+		}
+
+		for _, alias := range settings.Aliases {
+			if alias != shortID {
+				endpointSettings.Aliases = append(endpointSettings.Aliases, alias)
+			}
+		}
+
+		if networkName == primaryNetwork {
+			networkingConfig.EndpointsConfig[networkName] = endpointSettings
+			continue
+		}
+
+		additionalNetworks[networkName] = endpointSettings
+	}
+
+	return networkingConfig, additionalNetworks
+}
@@ -0,0 +1,252 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+const originalID = "0123456789abcdef0123456789abcdef"
+
+type fakeContainerClient struct {
+	containers    map[string]dockertypes.ContainerJSON
+	images        map[string]dockertypes.ImageInspect
+	calls         []string
+	created       *container.Config
+	createdNet    *network.NetworkingConfig
+	connected     []string
+	pulled        string
+	failNewStart  bool
+	nextID        string
+	startedOrigin bool
+}
+
+func newFakeContainerClient() *fakeContainerClient {
+	return &fakeContainerClient{
+		nextID: "fedcba9876543210fedcba9876543210",
+		containers: map[string]dockertypes.ContainerJSON{
+			originalID: {
+				ContainerJSONBase: &dockertypes.ContainerJSONBase{
+					ID:         originalID,
+					Name:       "/web",
+					State:      &dockertypes.ContainerState{Running: true},
+					HostConfig: &container.HostConfig{NetworkMode: "frontend"},
+				},
+				Config: &container.Config{Image: "nginx:1.21", Hostname: originalID[:12], Env: []string{"A=B"}},
+				NetworkSettings: &dockertypes.NetworkSettings{
+					Networks: map[string]*network.EndpointSettings{
+						"frontend": {Aliases: []string{"web", originalID[:12]}, NetworkID: "n1"},
+						"backend":  {NetworkID: "n2"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *fakeContainerClient) ContainerInspect(ctx context.Context, containerID string) (dockertypes.ContainerJSON, error) {
+	ctr, ok := c.containers[containerID]
+	if !ok {
+		return dockertypes.ContainerJSON{}, errors.New("no such container")
+	}
+	return ctr, nil
+}
+
+func (c *fakeContainerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
+	c.calls = append(c.calls, "create "+containerName)
+	c.created = config
+	c.createdNet = networkingConfig
+	return container.ContainerCreateCreatedBody{ID: c.nextID}, nil
+}
+
+func (c *fakeContainerClient) ContainerStart(ctx context.Context, containerID string, options dockertypes.ContainerStartOptions) error {
+	c.calls = append(c.calls, "start "+containerID)
+	if containerID == originalID {
+		c.startedOrigin = true
+		return nil
+	}
+	if c.failNewStart {
+		return errors.New("port is already allocated")
+	}
+	return nil
+}
+
+func (c *fakeContainerClient) ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error {
+	c.calls = append(c.calls, "stop "+containerID)
+	return nil
+}
+
+func (c *fakeContainerClient) ContainerRename(ctx context.Context, containerID, newContainerName string) error {
+	if strings.Contains(newContainerName, "-portainer-backup-") {
+		newContainerName = "backup"
+	}
+	c.calls = append(c.calls, "rename "+containerID+" "+newContainerName)
+	return nil
+}
+
+func (c *fakeContainerClient) ContainerRemove(ctx context.Context, containerID string, options dockertypes.ContainerRemoveOptions) error {
+	c.calls = append(c.calls, "remove "+containerID)
+	return nil
+}
+
+func (c *fakeContainerClient) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
+	c.connected = append(c.connected, networkID)
+	return nil
+}
+
+func (c *fakeContainerClient) ImagePull(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+	c.pulled = ref
+	return io.NopCloser(strings.NewReader("{}")), nil
+}
+
+func (c *fakeContainerClient) ImageInspectWithRaw(ctx context.Context, imageID string) (dockertypes.ImageInspect, []byte, error) {
+	image, ok := c.images[imageID]
+	if !ok {
+		return dockertypes.ImageInspect{}, nil, errors.New("no such image")
+	}
+	return image, nil, nil
+}
+
+func Test_containerImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		tag      string
+		expected string
+	}{
+		{"nginx", "", "nginx"},
+		{"nginx:1.21", "", "nginx:1.21"},
+		{"nginx:1.21@sha256:abcdef", "", "nginx:1.21"},
+		{"nginx:1.21", "1.23", "nginx:1.23"},
+		{"nginx", "1.23", "nginx:1.23"},
+		{"registry.local:5000/team/app", "v2", "registry.local:5000/team/app:v2"},
+		{"registry.local:5000/team/app:v1", "v2", "registry.local:5000/team/app:v2"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, containerImage(test.image, test.tag), "image %s with tag %q", test.image, test.tag)
+	}
+}
+
+func Test_containerImageName(t *testing.T) {
+	is := assert.New(t)
+
+	const imageID = "sha256:4bb46517cac397bdb0bab6eba09b0e1f8e90ddd17cf99662997c3253531136f8"
+	client := newFakeContainerClient()
+	client.images = map[string]dockertypes.ImageInspect{
+		imageID: {ID: imageID, RepoTags: []string{"nginx:1.21"}, RepoDigests: []string{"nginx@sha256:abcdef"}},
+	}
+
+	ctr := dockertypes.ContainerJSON{
+		ContainerJSONBase: &dockertypes.ContainerJSONBase{Image: imageID},
+		Config:            &container.Config{Image: "registry.local:5000/nginx:1.21"},
+	}
+
+	name, err := containerImageName(context.Background(), client, ctr)
+	is.NoError(err)
+	is.Equal("registry.local:5000/nginx:1.21", name, "the image name of the container should be kept")
+
+	for _, id := range []string{imageID, imageID[len("sha256:"):], imageID[len("sha256:") : len("sha256:")+12]} {
+		ctr.Config.Image = id
+
+		name, err := containerImageName(context.Background(), client, ctr)
+		is.NoError(err)
+		is.Equal("nginx:1.21", name, "the repository tag of the image should be used for %s", id)
+	}
+
+	client.images[imageID] = dockertypes.ImageInspect{ID: imageID, RepoDigests: []string{"nginx@sha256:abcdef"}}
+	name, err = containerImageName(context.Background(), client, ctr)
+	is.NoError(err)
+	is.Equal("nginx", containerImage(name, ""), "the repository digest of the image should be used without tag")
+	is.Equal("nginx:1.23", containerImage(name, "1.23"))
+
+	client.images[imageID] = dockertypes.ImageInspect{ID: imageID}
+	_, err = containerImageName(context.Background(), client, ctr)
+	is.Error(err, "an image without repository can't be pulled")
+}
+
+func Test_recreateContainer_shouldKeepNameConfigAndNetworks(t *testing.T) {
+	is := assert.New(t)
+	client := newFakeContainerClient()
+
+	newID, err := recreateContainer(context.Background(), client, originalID, "nginx:1.23")
+	is.NoError(err)
+	is.Equal(client.nextID, newID)
+
+	is.Equal([]string{
+		"rename " + originalID + " backup",
+		"stop " + originalID,
+		"create web",
+		"start " + client.nextID,
+		"remove " + originalID,
+	}, client.calls)
+
+	is.Equal("nginx:1.23", client.created.Image)
+	is.Equal([]string{"A=B"}, client.created.Env)
+	is.Empty(client.created.Hostname, "the generated hostname should not be reused")
+
+	is.Contains(client.createdNet.EndpointsConfig, "frontend")
+	is.Equal([]string{"web"}, client.createdNet.EndpointsConfig["frontend"].Aliases)
+	is.Equal([]string{"backend"}, client.connected)
+}
+
+func Test_recreateContainer_shouldRollbackWhenTheNewContainerFailsToStart(t *testing.T) {
+	is := assert.New(t)
+	client := newFakeContainerClient()
+	client.failNewStart = true
+
+	_, err := recreateContainer(context.Background(), client, originalID, "nginx:1.23")
+	is.Error(err)
+
+	is.Equal([]string{
+		"rename " + originalID + " backup",
+		"stop " + originalID,
+		"create web",
+		"start " + client.nextID,
+		"remove " + client.nextID,
+		"rename " + originalID + " web",
+		"start " + originalID,
+	}, client.calls)
+	is.True(client.startedOrigin)
+}
+
+func Test_recreateWebhookContainer_shouldMoveTheResourceControlAndTheWebhook(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	resourceControl := &portainer.ResourceControl{ResourceID: originalID, Type: portainer.ContainerResourceControl, UserAccesses: []portainer.UserResourceAccess{{UserID: 2}}}
+	is.NoError(store.ResourceControl().Create(resourceControl))
+
+	webhook := &portainer.Webhook{Token: "token", ResourceID: originalID, EndpointID: 1, WebhookType: portainer.ContainerWebhook}
+	is.NoError(store.Webhook().Create(webhook))
+
+	client := newFakeContainerClient()
+	handler := &Handler{DataStore: store}
+
+	rr := httptest.NewRecorder()
+	httpErr := handler.recreateWebhookContainer(rr, client, webhook, "1.23")
+	is.Nil(httpErr)
+	is.Equal("nginx:1.23", client.pulled)
+
+	updatedResourceControl, err := store.ResourceControl().ResourceControl(resourceControl.ID)
+	is.NoError(err)
+	is.Equal(client.nextID, updatedResourceControl.ResourceID)
+	is.Equal(resourceControl.UserAccesses, updatedResourceControl.UserAccesses)
+
+	updatedWebhook, err := store.Webhook().WebhookByToken("token")
+	is.NoError(err)
+	is.Equal(client.nextID, updatedWebhook.ResourceID)
+}
@@ -28,8 +28,8 @@ func (payload *webhookCreatePayload) Validate(r *http.Request) error {
 	if payload.EndpointID == 0 {
 		return errors.New("Invalid EndpointID")
 	}
-	if payload.WebhookType != 1 {
-		return errors.New("Invalid WebhookType")
+	if payload.WebhookType != int(portainer.ServiceWebhook) && payload.WebhookType != int(portainer.ContainerWebhook) {
+		return errors.New("Invalid WebhookType. Value must be one of: 1 (service) or 2 (container)")
 	}
 	return nil
 }
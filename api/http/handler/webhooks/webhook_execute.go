@@ -13,13 +13,16 @@ import (
 	"github.com/portainer/libhttp/request"
 	"github.com/portainer/libhttp/response"
 	portainer "github.com/portainer/portainer/api"
+	"github.com/sirupsen/logrus"
 )
 
 // @summary Execute a webhook
-// @description Acts on a passed in token UUID to restart the docker service
+// @description Acts on a passed in token UUID to restart the docker service or to recreate the standalone docker container
+// @description with the latest version of its image. A new image tag can be specified with the tag query parameter.
 // @description **Access policy**: public
 // @tags webhooks
 // @param token path string true "Webhook token"
+// @param tag query string false "Image tag to use instead of the current one"
 // @success 202 "Webhook executed"
 // @failure 400
 // @failure 500
@@ -57,6 +60,8 @@ func (handler *Handler) webhookExecute(w http.ResponseWriter, r *http.Request) *
 	switch webhookType {
 	case portainer.ServiceWebhook:
 		return handler.executeServiceWebhook(w, endpoint, resourceID, registryID, imageTag)
+	case portainer.ContainerWebhook:
+		return handler.executeContainerWebhook(w, webhook, endpoint, imageTag)
 	default:
 		return &httperror.HandlerError{http.StatusInternalServerError, "Unsupported webhook type", errors.New("Webhooks for this resource are not currently supported")}
 	}
@@ -98,19 +103,10 @@ func (handler *Handler) executeServiceWebhook(
 		QueryRegistry: true,
 	}
 
-	if registryID != 0 {
-		registry, err := handler.DataStore.Registry().Registry(registryID)
-		if err != nil {
-			return &httperror.HandlerError{http.StatusInternalServerError, "Error getting registry", err}
-		}
-
-		if registry.Authentication {
-			registryutils.EnsureRegTokenValid(handler.DataStore, registry)
-			serviceUpdateOptions.EncodedRegistryAuth, err = registryutils.GetRegistryAuthHeader(registry)
-			if err != nil {
-				return &httperror.HandlerError{http.StatusInternalServerError, "Error getting registry auth header", err}
-			}
-		}
+	var httpErr *httperror.HandlerError
+	serviceUpdateOptions.EncodedRegistryAuth, httpErr = handler.registryAuthHeader(registryID)
+	if httpErr != nil {
+		return httpErr
 	}
 	if imageTag != "" {
 		rc, err := dockerClient.ImagePull(context.Background(), service.Spec.TaskTemplate.ContainerSpec.Image, dockertypes.ImagePullOptions{RegistryAuth: serviceUpdateOptions.EncodedRegistryAuth})
@@ -128,3 +124,113 @@ func (handler *Handler) executeServiceWebhook(
 	}
 	return response.Empty(w)
 }
+
+func (handler *Handler) executeContainerWebhook(
+	w http.ResponseWriter,
+	webhook *portainer.Webhook,
+	endpoint *portainer.Endpoint,
+	imageTag string,
+) *httperror.HandlerError {
+	dockerClient, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Error creating docker client", Err: err}
+	}
+	defer dockerClient.Close()
+
+	return handler.recreateWebhookContainer(w, dockerClient, webhook, imageTag)
+}
+
+func (handler *Handler) recreateWebhookContainer(w http.ResponseWriter, dockerClient containerClient, webhook *portainer.Webhook, imageTag string) *httperror.HandlerError {
+	ctx := context.Background()
+
+	container, err := dockerClient.ContainerInspect(ctx, webhook.ResourceID)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Error looking up container", Err: err}
+	}
+
+	registryAuth, httpErr := handler.registryAuthHeader(webhook.RegistryID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	imageName, err := containerImageName(ctx, dockerClient, container)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Unable to find the image of the container", Err: err}
+	}
+
+	image := containerImage(imageName, imageTag)
+	err = pullImage(ctx, dockerClient, image, registryAuth)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusNotFound, Message: "Error pulling image", Err: err}
+	}
+
+	newContainerID, err := recreateContainer(ctx, dockerClient, container.ID, image)
+	if err != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Error recreating container", Err: err}
+	}
+
+	// the access control and the webhook follow the new container. The container is already recreated at this
+	// point, so both updates are attempted and a failure is reported as a partial success
+	resourceControlErr := handler.moveContainerResourceControl(container.ID, newContainerID)
+	if resourceControlErr != nil {
+		logrus.WithError(resourceControlErr).WithField("container", newContainerID).Error("unable to move the resource control to the recreated container")
+	}
+
+	webhook.ResourceID = newContainerID
+	webhookErr := handler.DataStore.Webhook().UpdateWebhook(webhook.ID, webhook)
+	if webhookErr != nil {
+		logrus.WithError(webhookErr).WithField("container", newContainerID).Error("unable to move the webhook to the recreated container")
+	}
+
+	if resourceControlErr != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "The container was recreated but its access control could not be updated", Err: resourceControlErr}
+	}
+
+	if webhookErr != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "The container was recreated but the webhook could not be updated", Err: webhookErr}
+	}
+
+	return response.Empty(w)
+}
+
+// moveContainerResourceControl moves the resource control of a container, if any, to the container which replaced it.
+func (handler *Handler) moveContainerResourceControl(containerID, newContainerID string) error {
+	resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(containerID, portainer.ContainerResourceControl)
+	if err != nil {
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if resourceControl == nil {
+		return nil
+	}
+
+	resourceControl.ResourceID = newContainerID
+	return handler.DataStore.ResourceControl().UpdateResourceControl(resourceControl.ID, resourceControl)
+}
+
+// registryAuthHeader returns the encoded credentials of the registry, empty when the registry doesn't require authentication.
+func (handler *Handler) registryAuthHeader(registryID portainer.RegistryID) (string, *httperror.HandlerError) {
+	if registryID == 0 {
+		return "", nil
+	}
+
+	registry, err := handler.DataStore.Registry().Registry(registryID)
+	if err != nil {
+		return "", &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Error getting registry", Err: err}
+	}
+
+	if !registry.Authentication {
+		return "", nil
+	}
+
+	registryutils.EnsureRegTokenValid(handler.DataStore, registry)
+	authHeader, err := registryutils.GetRegistryAuthHeader(registry)
+	if err != nil {
+		return "", &httperror.HandlerError{StatusCode: http.StatusInternalServerError, Message: "Error getting registry auth header", Err: err}
+	}
+
+	return authHeader, nil
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/audit"
 	"github.com/portainer/portainer/api/dataservices"
 	dataerrors "github.com/portainer/portainer/api/dataservices/errors"
 	"github.com/portainer/portainer/api/docker"
@@ -88,6 +89,9 @@ func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Res
 	requestPath := apiVersionRe.ReplaceAllString(request.URL.Path, "")
 	request.URL.Path = requestPath
 
+	auditResourceType, auditResourceID := dockerAuditResource(requestPath)
+	audit.SetResource(request.Context(), auditResourceType, auditResourceID)
+
 	if transport.endpoint.Type == portainer.AgentOnDockerEnvironment || transport.endpoint.Type == portainer.EdgeAgentOnDockerEnvironment {
 		signature, err := transport.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
 		if err != nil {
@@ -128,6 +132,24 @@ func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Res
 	}
 }
 
+// dockerAuditResource returns the resource type and identifier targeted by a Docker API path
+// e.g. /containers/abc/start => containers, abc
+func dockerAuditResource(requestPath string) (string, string) {
+	segments := strings.Split(strings.Trim(requestPath, "/"), "/")
+
+	resourceType := segments[0]
+	if len(segments) < 2 {
+		return resourceType, ""
+	}
+
+	switch segments[1] {
+	case "create", "prune", "json", "load", "search":
+		return resourceType, ""
+	}
+
+	return resourceType, segments[1]
+}
+
 func (transport *Transport) executeDockerRequest(request *http.Request) (*http.Response, error) {
 	response, err := transport.HTTPTransport.RoundTrip(request)
 
@@ -394,7 +416,7 @@ func (transport *Transport) updateDefaultGitBranch(request *http.Request) error
 	remote := request.URL.Query().Get("remote")
 	if strings.HasSuffix(remote, ".git") {
 		repositoryURL := remote[:len(remote)-4]
-		latestCommitID, err := transport.gitService.LatestCommitID(repositoryURL, "", "", "")
+		latestCommitID, err := transport.gitService.LatestCommitID(repositoryURL, "", nil)
 		if err != nil {
 			return err
 		}
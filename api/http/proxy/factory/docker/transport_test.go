@@ -2,6 +2,7 @@ package docker
 
 import (
 	portainer "github.com/portainer/portainer/api"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
@@ -10,13 +11,17 @@ import (
 
 type noopGitService struct{}
 
-func (s *noopGitService) CloneRepository(destination string, repositoryURL, referenceName, username, password string) error {
+func (s *noopGitService) CloneRepository(destination string, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOptions *gittypes.CloneOptions) error {
 	return nil
 }
-func (s *noopGitService) LatestCommitID(repositoryURL, referenceName, username, password string) (string, error) {
+func (s *noopGitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication) (string, error) {
 	return "my-latest-commit-id", nil
 }
 
+func (s *noopGitService) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication) ([]string, error) {
+	return nil, nil
+}
+
 func TestTransport_updateDefaultGitBranch(t *testing.T) {
 	type fields struct {
 		gitService portainer.GitService
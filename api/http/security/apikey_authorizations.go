@@ -0,0 +1,260 @@
+package security
+
+import (
+	"net/http"
+	"regexp"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+type authorizationRule struct {
+	method        string
+	pattern       *regexp.Regexp
+	authorization portainer.Authorization
+}
+
+func rule(method, pattern string, authorization portainer.Authorization) authorizationRule {
+	return authorizationRule{method: method, pattern: regexp.MustCompile("^" + pattern + "$"), authorization: authorization}
+}
+
+var (
+	// dockerPathPattern matches the requests proxied to the Docker API, or to the agent, of an environment(endpoint)
+	// and captures the path of the Docker API without its version prefix
+	dockerPathPattern = regexp.MustCompile(`^/api/(?:endpoints/\d+(?:/agent)?/docker|docker/\d+)(?:/v[\d.]+)?(/.*)?$`)
+
+	dockerAuthorizationRules = []authorizationRule{
+		rule(http.MethodGet, `/containers/json`, portainer.OperationDockerContainerList),
+		rule(http.MethodPost, `/containers/create`, portainer.OperationDockerContainerCreate),
+		rule(http.MethodPost, `/containers/prune`, portainer.OperationDockerContainerPrune),
+		rule(http.MethodGet, `/containers/[^/]+/json`, portainer.OperationDockerContainerInspect),
+		rule(http.MethodGet, `/containers/[^/]+/top`, portainer.OperationDockerContainerTop),
+		rule(http.MethodGet, `/containers/[^/]+/logs`, portainer.OperationDockerContainerLogs),
+		rule(http.MethodGet, `/containers/[^/]+/changes`, portainer.OperationDockerContainerChanges),
+		rule(http.MethodGet, `/containers/[^/]+/export`, portainer.OperationDockerContainerExport),
+		rule(http.MethodGet, `/containers/[^/]+/stats`, portainer.OperationDockerContainerStats),
+		rule(http.MethodGet, `/containers/[^/]+/attach/ws`, portainer.OperationDockerContainerAttachWebsocket),
+		rule(http.MethodHead, `/containers/[^/]+/archive`, portainer.OperationDockerContainerArchiveInfo),
+		rule(http.MethodGet, `/containers/[^/]+/archive`, portainer.OperationDockerContainerArchive),
+		rule(http.MethodPut, `/containers/[^/]+/archive`, portainer.OperationDockerContainerPutContainerArchive),
+		rule(http.MethodPost, `/containers/[^/]+/resize`, portainer.OperationDockerContainerResize),
+		rule(http.MethodPost, `/containers/[^/]+/start`, portainer.OperationDockerContainerStart),
+		rule(http.MethodPost, `/containers/[^/]+/stop`, portainer.OperationDockerContainerStop),
+		rule(http.MethodPost, `/containers/[^/]+/restart`, portainer.OperationDockerContainerRestart),
+		rule(http.MethodPost, `/containers/[^/]+/kill`, portainer.OperationDockerContainerKill),
+		rule(http.MethodPost, `/containers/[^/]+/update`, portainer.OperationDockerContainerUpdate),
+		rule(http.MethodPost, `/containers/[^/]+/rename`, portainer.OperationDockerContainerRename),
+		rule(http.MethodPost, `/containers/[^/]+/pause`, portainer.OperationDockerContainerPause),
+		rule(http.MethodPost, `/containers/[^/]+/unpause`, portainer.OperationDockerContainerUnpause),
+		rule(http.MethodPost, `/containers/[^/]+/wait`, portainer.OperationDockerContainerWait),
+		rule(http.MethodPost, `/containers/[^/]+/attach`, portainer.OperationDockerContainerAttach),
+		rule(http.MethodPost, `/containers/[^/]+/exec`, portainer.OperationDockerContainerExec),
+		rule(http.MethodDelete, `/containers/[^/]+`, portainer.OperationDockerContainerDelete),
+
+		rule(http.MethodGet, `/exec/[^/]+/json`, portainer.OperationDockerExecInspect),
+		rule(http.MethodPost, `/exec/[^/]+/start`, portainer.OperationDockerExecStart),
+		rule(http.MethodPost, `/exec/[^/]+/resize`, portainer.OperationDockerExecResize),
+
+		rule(http.MethodGet, `/images/json`, portainer.OperationDockerImageList),
+		rule(http.MethodGet, `/images/search`, portainer.OperationDockerImageSearch),
+		rule(http.MethodGet, `/images/get`, portainer.OperationDockerImageGetAll),
+		rule(http.MethodPost, `/images/load`, portainer.OperationDockerImageLoad),
+		rule(http.MethodPost, `/images/create`, portainer.OperationDockerImageCreate),
+		rule(http.MethodPost, `/images/prune`, portainer.OperationDockerImagePrune),
+		rule(http.MethodGet, `/images/.+/get`, portainer.OperationDockerImageGet),
+		rule(http.MethodGet, `/images/.+/history`, portainer.OperationDockerImageHistory),
+		rule(http.MethodGet, `/images/.+/json`, portainer.OperationDockerImageInspect),
+		rule(http.MethodPost, `/images/.+/push`, portainer.OperationDockerImagePush),
+		rule(http.MethodPost, `/images/.+/tag`, portainer.OperationDockerImageTag),
+		rule(http.MethodDelete, `/images/.+`, portainer.OperationDockerImageDelete),
+		rule(http.MethodPost, `/commit`, portainer.OperationDockerImageCommit),
+		rule(http.MethodPost, `/build`, portainer.OperationDockerImageBuild),
+		rule(http.MethodPost, `/build/prune`, portainer.OperationDockerBuildPrune),
+		rule(http.MethodPost, `/build/cancel`, portainer.OperationDockerBuildCancel),
+
+		rule(http.MethodGet, `/networks`, portainer.OperationDockerNetworkList),
+		rule(http.MethodPost, `/networks/create`, portainer.OperationDockerNetworkCreate),
+		rule(http.MethodPost, `/networks/prune`, portainer.OperationDockerNetworkPrune),
+		rule(http.MethodGet, `/networks/[^/]+`, portainer.OperationDockerNetworkInspect),
+		rule(http.MethodPost, `/networks/[^/]+/connect`, portainer.OperationDockerNetworkConnect),
+		rule(http.MethodPost, `/networks/[^/]+/disconnect`, portainer.OperationDockerNetworkDisconnect),
+		rule(http.MethodDelete, `/networks/[^/]+`, portainer.OperationDockerNetworkDelete),
+
+		rule(http.MethodGet, `/volumes`, portainer.OperationDockerVolumeList),
+		rule(http.MethodPost, `/volumes/create`, portainer.OperationDockerVolumeCreate),
+		rule(http.MethodPost, `/volumes/prune`, portainer.OperationDockerVolumePrune),
+		rule(http.MethodGet, `/volumes/[^/]+`, portainer.OperationDockerVolumeInspect),
+		rule(http.MethodDelete, `/volumes/[^/]+`, portainer.OperationDockerVolumeDelete),
+
+		rule(http.MethodGet, `/swarm`, portainer.OperationDockerSwarmInspect),
+		rule(http.MethodGet, `/swarm/unlockkey`, portainer.OperationDockerSwarmUnlockKey),
+		rule(http.MethodPost, `/swarm/init`, portainer.OperationDockerSwarmInit),
+		rule(http.MethodPost, `/swarm/join`, portainer.OperationDockerSwarmJoin),
+		rule(http.MethodPost, `/swarm/leave`, portainer.OperationDockerSwarmLeave),
+		rule(http.MethodPost, `/swarm/update`, portainer.OperationDockerSwarmUpdate),
+		rule(http.MethodPost, `/swarm/unlock`, portainer.OperationDockerSwarmUnlock),
+
+		rule(http.MethodGet, `/nodes`, portainer.OperationDockerNodeList),
+		rule(http.MethodGet, `/nodes/[^/]+`, portainer.OperationDockerNodeInspect),
+		rule(http.MethodPost, `/nodes/[^/]+/update`, portainer.OperationDockerNodeUpdate),
+		rule(http.MethodDelete, `/nodes/[^/]+`, portainer.OperationDockerNodeDelete),
+
+		rule(http.MethodGet, `/services`, portainer.OperationDockerServiceList),
+		rule(http.MethodPost, `/services/create`, portainer.OperationDockerServiceCreate),
+		rule(http.MethodGet, `/services/[^/]+`, portainer.OperationDockerServiceInspect),
+		rule(http.MethodGet, `/services/[^/]+/logs`, portainer.OperationDockerServiceLogs),
+		rule(http.MethodPost, `/services/[^/]+/update`, portainer.OperationDockerServiceUpdate),
+		rule(http.MethodDelete, `/services/[^/]+`, portainer.OperationDockerServiceDelete),
+
+		rule(http.MethodGet, `/secrets`, portainer.OperationDockerSecretList),
+		rule(http.MethodPost, `/secrets/create`, portainer.OperationDockerSecretCreate),
+		rule(http.MethodGet, `/secrets/[^/]+`, portainer.OperationDockerSecretInspect),
+		rule(http.MethodPost, `/secrets/[^/]+/update`, portainer.OperationDockerSecretUpdate),
+		rule(http.MethodDelete, `/secrets/[^/]+`, portainer.OperationDockerSecretDelete),
+
+		rule(http.MethodGet, `/configs`, portainer.OperationDockerConfigList),
+		rule(http.MethodPost, `/configs/create`, portainer.OperationDockerConfigCreate),
+		rule(http.MethodGet, `/configs/[^/]+`, portainer.OperationDockerConfigInspect),
+		rule(http.MethodPost, `/configs/[^/]+/update`, portainer.OperationDockerConfigUpdate),
+		rule(http.MethodDelete, `/configs/[^/]+`, portainer.OperationDockerConfigDelete),
+
+		rule(http.MethodGet, `/tasks`, portainer.OperationDockerTaskList),
+		rule(http.MethodGet, `/tasks/[^/]+`, portainer.OperationDockerTaskInspect),
+		rule(http.MethodGet, `/tasks/[^/]+/logs`, portainer.OperationDockerTaskLogs),
+
+		rule(http.MethodGet, `/plugins`, portainer.OperationDockerPluginList),
+		rule(http.MethodGet, `/plugins/privileges`, portainer.OperationDockerPluginPrivileges),
+		rule(http.MethodPost, `/plugins/pull`, portainer.OperationDockerPluginPull),
+		rule(http.MethodPost, `/plugins/create`, portainer.OperationDockerPluginCreate),
+		rule(http.MethodGet, `/plugins/.+/json`, portainer.OperationDockerPluginInspect),
+		rule(http.MethodPost, `/plugins/.+/enable`, portainer.OperationDockerPluginEnable),
+		rule(http.MethodPost, `/plugins/.+/disable`, portainer.OperationDockerPluginDisable),
+		rule(http.MethodPost, `/plugins/.+/push`, portainer.OperationDockerPluginPush),
+		rule(http.MethodPost, `/plugins/.+/upgrade`, portainer.OperationDockerPluginUpgrade),
+		rule(http.MethodPost, `/plugins/.+/set`, portainer.OperationDockerPluginSet),
+		rule(http.MethodDelete, `/plugins/.+`, portainer.OperationDockerPluginDelete),
+
+		rule(http.MethodPost, `/session`, portainer.OperationDockerSessionStart),
+		rule(http.MethodGet, `/distribution/.+/json`, portainer.OperationDockerDistributionInspect),
+		rule(http.MethodGet, `/_ping`, portainer.OperationDockerPing),
+		rule(http.MethodHead, `/_ping`, portainer.OperationDockerPing),
+		rule(http.MethodGet, `/info`, portainer.OperationDockerInfo),
+		rule(http.MethodGet, `/events`, portainer.OperationDockerEvents),
+		rule(http.MethodGet, `/system/df`, portainer.OperationDockerSystem),
+		rule(http.MethodGet, `/version`, portainer.OperationDockerVersion),
+
+		rule(http.MethodGet, `/ping`, portainer.OperationDockerAgentPing),
+		rule(http.MethodGet, `/agents`, portainer.OperationDockerAgentList),
+		rule(http.MethodGet, `/host/info`, portainer.OperationDockerAgentHostInfo),
+		rule(http.MethodGet, `/browse/ls`, portainer.OperationDockerAgentBrowseList),
+		rule(http.MethodGet, `/browse/get`, portainer.OperationDockerAgentBrowseGet),
+		rule(http.MethodPost, `/browse/put`, portainer.OperationDockerAgentBrowsePut),
+		rule(http.MethodPut, `/browse/rename`, portainer.OperationDockerAgentBrowseRename),
+		rule(http.MethodDelete, `/browse/delete`, portainer.OperationDockerAgentBrowseDelete),
+	}
+
+	portainerAuthorizationRules = []authorizationRule{
+		rule(http.MethodGet, `/api/endpoints`, portainer.OperationPortainerEndpointList),
+		rule(http.MethodPost, `/api/endpoints`, portainer.OperationPortainerEndpointCreate),
+		rule(http.MethodPost, `/api/endpoints/snapshot`, portainer.OperationPortainerEndpointSnapshots),
+		rule(http.MethodGet, `/api/endpoints/\d+`, portainer.OperationPortainerEndpointInspect),
+		rule(http.MethodPut, `/api/endpoints/\d+`, portainer.OperationPortainerEndpointUpdate),
+		rule(http.MethodDelete, `/api/endpoints/\d+`, portainer.OperationPortainerEndpointDelete),
+		rule(http.MethodPost, `/api/endpoints/\d+/snapshot`, portainer.OperationPortainerEndpointSnapshot),
+
+		rule(http.MethodGet, `/api/endpoint_groups`, portainer.OperationPortainerEndpointGroupList),
+		rule(http.MethodPost, `/api/endpoint_groups`, portainer.OperationPortainerEndpointGroupCreate),
+		rule(http.MethodGet, `/api/endpoint_groups/\d+`, portainer.OperationPortainerEndpointGroupInspect),
+		rule(http.MethodPut, `/api/endpoint_groups/\d+(?:/endpoints/\d+)?`, portainer.OperationPortainerEndpointGroupUpdate),
+		rule(http.MethodDelete, `/api/endpoint_groups/\d+/endpoints/\d+`, portainer.OperationPortainerEndpointGroupUpdate),
+		rule(http.MethodDelete, `/api/endpoint_groups/\d+`, portainer.OperationPortainerEndpointGroupDelete),
+
+		rule(http.MethodGet, `/api/registries`, portainer.OperationPortainerRegistryList),
+		rule(http.MethodPost, `/api/registries`, portainer.OperationPortainerRegistryCreate),
+		rule(http.MethodGet, `/api/registries/\d+`, portainer.OperationPortainerRegistryInspect),
+		rule(http.MethodPut, `/api/registries/\d+`, portainer.OperationPortainerRegistryUpdate),
+		rule(http.MethodPost, `/api/registries/\d+/configure`, portainer.OperationPortainerRegistryConfigure),
+		rule(http.MethodDelete, `/api/registries/\d+`, portainer.OperationPortainerRegistryDelete),
+
+		rule(http.MethodPost, `/api/resource_controls`, portainer.OperationPortainerResourceControlCreate),
+		rule(http.MethodPut, `/api/resource_controls/\d+`, portainer.OperationPortainerResourceControlUpdate),
+		rule(http.MethodDelete, `/api/resource_controls/\d+`, portainer.OperationPortainerResourceControlDelete),
+
+		rule(http.MethodGet, `/api/roles`, portainer.OperationPortainerRoleList),
+
+		rule(http.MethodGet, `/api/settings`, portainer.OperationPortainerSettingsInspect),
+		rule(http.MethodPut, `/api/settings`, portainer.OperationPortainerSettingsUpdate),
+
+		rule(http.MethodGet, `/api/stacks`, portainer.OperationPortainerStackList),
+		rule(http.MethodGet, `/api/stacks/\d+/file`, portainer.OperationPortainerStackFile),
+		rule(http.MethodGet, `/api/stacks/\d+(?:/.*)?`, portainer.OperationPortainerStackInspect),
+		rule(http.MethodPost, `/api/stacks/\d+/migrate`, portainer.OperationPortainerStackMigrate),
+		rule(http.MethodPost, `/api/stacks/\d+/.+`, portainer.OperationPortainerStackUpdate),
+		rule(http.MethodPut, `/api/stacks/\d+(?:/.*)?`, portainer.OperationPortainerStackUpdate),
+		rule(http.MethodPost, `/api/stacks(?:/.*)?`, portainer.OperationPortainerStackCreate),
+		rule(http.MethodDelete, `/api/stacks/.+`, portainer.OperationPortainerStackDelete),
+
+		rule(http.MethodGet, `/api/tags`, portainer.OperationPortainerTagList),
+		rule(http.MethodPost, `/api/tags`, portainer.OperationPortainerTagCreate),
+		rule(http.MethodDelete, `/api/tags/\d+`, portainer.OperationPortainerTagDelete),
+
+		rule(http.MethodGet, `/api/team_memberships`, portainer.OperationPortainerTeamMembershipList),
+		rule(http.MethodPost, `/api/team_memberships`, portainer.OperationPortainerTeamMembershipCreate),
+		rule(http.MethodPut, `/api/team_memberships/\d+`, portainer.OperationPortainerTeamMembershipUpdate),
+		rule(http.MethodDelete, `/api/team_memberships/\d+`, portainer.OperationPortainerTeamMembershipDelete),
+
+		rule(http.MethodGet, `/api/teams`, portainer.OperationPortainerTeamList),
+		rule(http.MethodPost, `/api/teams`, portainer.OperationPortainerTeamCreate),
+		rule(http.MethodGet, `/api/teams/\d+`, portainer.OperationPortainerTeamInspect),
+		rule(http.MethodGet, `/api/teams/\d+/memberships`, portainer.OperationPortainerTeamMemberships),
+		rule(http.MethodPut, `/api/teams/\d+`, portainer.OperationPortainerTeamUpdate),
+		rule(http.MethodDelete, `/api/teams/\d+`, portainer.OperationPortainerTeamDelete),
+
+		rule(http.MethodGet, `/api/(?:custom_)?templates`, portainer.OperationPortainerTemplateList),
+		rule(http.MethodGet, `/api/custom_templates/\d+(?:/file)?`, portainer.OperationPortainerTemplateInspect),
+		rule(http.MethodPost, `/api/custom_templates(?:/.*)?`, portainer.OperationPortainerTemplateCreate),
+		rule(http.MethodPut, `/api/custom_templates/\d+`, portainer.OperationPortainerTemplateUpdate),
+		rule(http.MethodDelete, `/api/custom_templates/\d+`, portainer.OperationPortainerTemplateDelete),
+
+		rule(http.MethodPost, `/api/upload/tls/.+`, portainer.OperationPortainerUploadTLS),
+
+		rule(http.MethodGet, `/api/users`, portainer.OperationPortainerUserList),
+		rule(http.MethodPost, `/api/users`, portainer.OperationPortainerUserCreate),
+		rule(http.MethodGet, `/api/users/\d+`, portainer.OperationPortainerUserInspect),
+		rule(http.MethodGet, `/api/users/\d+/memberships`, portainer.OperationPortainerUserMemberships),
+		rule(http.MethodGet, `/api/users/\d+/tokens`, portainer.OperationPortainerUserListToken),
+		rule(http.MethodPost, `/api/users/\d+/tokens`, portainer.OperationPortainerUserCreateToken),
+		rule(http.MethodDelete, `/api/users/\d+/tokens/\d+`, portainer.OperationPortainerUserRevokeToken),
+		rule(http.MethodPut, `/api/users/\d+`, portainer.OperationPortainerUserUpdate),
+		rule(http.MethodPut, `/api/users/\d+/passwd`, portainer.OperationPortainerUserUpdatePassword),
+		rule(http.MethodDelete, `/api/users/\d+`, portainer.OperationPortainerUserDelete),
+
+		rule(http.MethodGet, `/api/websocket/.+`, portainer.OperationPortainerWebsocketExec),
+
+		rule(http.MethodGet, `/api/webhooks`, portainer.OperationPortainerWebhookList),
+		rule(http.MethodPost, `/api/webhooks`, portainer.OperationPortainerWebhookCreate),
+		rule(http.MethodDelete, `/api/webhooks/\d+`, portainer.OperationPortainerWebhookDelete),
+
+		rule(http.MethodGet, `/api/motd`, portainer.OperationPortainerMOTD),
+	}
+)
+
+// requestAuthorization returns the authorization required by a request, the requests without a dedicated
+// authorization require OperationDockerUndefined when they target the Docker API of an environment(endpoint),
+// OperationPortainerUndefined otherwise.
+func requestAuthorization(method, path string) portainer.Authorization {
+	if match := dockerPathPattern.FindStringSubmatch(path); match != nil {
+		return matchAuthorization(dockerAuthorizationRules, method, match[1], portainer.OperationDockerUndefined)
+	}
+
+	return matchAuthorization(portainerAuthorizationRules, method, path, portainer.OperationPortainerUndefined)
+}
+
+func matchAuthorization(rules []authorizationRule, method, path string, fallback portainer.Authorization) portainer.Authorization {
+	for _, rule := range rules {
+		if rule.method == method && rule.pattern.MatchString(path) {
+			return rule.authorization
+		}
+	}
+
+	return fallback
+}
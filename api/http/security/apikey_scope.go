@@ -0,0 +1,136 @@
+package security
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+var (
+	// endpointPathPattern matches the environment(endpoint) identifier of the routes targeting an environment(endpoint)
+	endpointPathPattern = regexp.MustCompile(`^/api/(?:endpoints|docker|kubernetes)/(\d+)(?:/|$)`)
+	// stackPathPattern matches the stack identifier of the stack routes
+	stackPathPattern = regexp.MustCompile(`^/api/stacks/(\d+)(?:/|$)`)
+)
+
+// stackEndpointResolver returns the environment(endpoint) of a stack, 0 when the stack does not exist
+type stackEndpointResolver func(stackID portainer.StackID) portainer.EndpointID
+
+// apiKeyTokenScope returns the scope of an API key to store in the token data,
+// nil when the API key is not restricted.
+func apiKeyTokenScope(apiKey portainer.APIKey) *portainer.APIKeyScope {
+	if !apiKey.Scope.ReadOnly && len(apiKey.Scope.EndpointIDs) == 0 && len(apiKey.Scope.Authorizations) == 0 {
+		return nil
+	}
+
+	scope := apiKey.Scope
+	return &scope
+}
+
+// apiKeyScopeAllowsRequest returns true when the request is allowed by the scope of the API key used to authenticate it.
+// Read-only keys are limited to the safe HTTP methods and cannot open websockets (used to attach to containers and execute commands).
+// Keys restricted to a set of environments(endpoints) can only send requests resolving to these environments(endpoints), through
+// the path, the stack targeted by the request or the endpointId query parameter. Any other request is denied.
+// Keys restricted to a set of authorizations can only send the requests requiring one of them.
+func apiKeyScopeAllowsRequest(scope *portainer.APIKeyScope, r *http.Request, stackEndpoint stackEndpointResolver) bool {
+	if scope == nil {
+		return true
+	}
+
+	if scope.ReadOnly && !isReadOnlyRequest(r) {
+		return false
+	}
+
+	path := requestPath(r)
+
+	if len(scope.Authorizations) > 0 && !scope.Authorizations[requestAuthorization(r.Method, path)] {
+		return false
+	}
+
+	if len(scope.EndpointIDs) == 0 {
+		return true
+	}
+
+	endpointIDs := requestEndpointIDs(r, path, stackEndpoint)
+	if len(endpointIDs) == 0 {
+		return false
+	}
+
+	for _, endpointID := range endpointIDs {
+		if !apiKeyScopeAllowsEndpoint(scope, endpointID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// apiKeyScopeAllowsEndpoint returns true when the scope does not restrict the access to the environment(endpoint).
+func apiKeyScopeAllowsEndpoint(scope *portainer.APIKeyScope, endpointID portainer.EndpointID) bool {
+	if scope == nil || len(scope.EndpointIDs) == 0 {
+		return true
+	}
+
+	for _, id := range scope.EndpointIDs {
+		if id == endpointID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isReadOnlyRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return !strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+	}
+
+	return false
+}
+
+// requestPath returns the path of the request as sent by the client, before the API prefix is stripped by the main handler
+func requestPath(r *http.Request) string {
+	path := r.URL.Path
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		path = u.Path
+	}
+
+	if !strings.HasPrefix(path, "/api/") {
+		path = "/api" + path
+	}
+
+	return path
+}
+
+func requestEndpointIDs(r *http.Request, path string, stackEndpoint stackEndpointResolver) []portainer.EndpointID {
+	endpointIDs := make([]portainer.EndpointID, 0)
+
+	if match := endpointPathPattern.FindStringSubmatch(path); match != nil {
+		endpointIDs = append(endpointIDs, portainer.EndpointID(parseIdentifier(match[1])))
+	}
+
+	if match := stackPathPattern.FindStringSubmatch(path); match != nil {
+		endpointIDs = append(endpointIDs, stackEndpoint(portainer.StackID(parseIdentifier(match[1]))))
+	}
+
+	if value := r.URL.Query().Get("endpointId"); value != "" {
+		endpointIDs = append(endpointIDs, portainer.EndpointID(parseIdentifier(value)))
+	}
+
+	return endpointIDs
+}
+
+// parseIdentifier returns 0, which never matches an environment(endpoint) or a stack, when the value is not a valid identifier
+func parseIdentifier(value string) int {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
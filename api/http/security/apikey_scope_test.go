@@ -0,0 +1,99 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_apiKeyScopeAllowsRequest(t *testing.T) {
+	readOnly := &portainer.APIKeyScope{ReadOnly: true}
+	endpoints := &portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1, 3}}
+	authorizations := &portainer.APIKeyScope{Authorizations: portainer.Authorizations{
+		portainer.OperationDockerContainerList: true,
+		portainer.OperationPortainerStackList:  true,
+	}}
+
+	stackEndpoint := func(stackID portainer.StackID) portainer.EndpointID {
+		return map[portainer.StackID]portainer.EndpointID{1: 1, 2: 2}[stackID]
+	}
+
+	tests := []struct {
+		name     string
+		scope    *portainer.APIKeyScope
+		method   string
+		url      string
+		upgrade  bool
+		expected bool
+	}{
+		{name: "unrestricted key", scope: nil, method: http.MethodDelete, url: "/endpoints/2", expected: true},
+		{name: "read-only key reads", scope: readOnly, method: http.MethodGet, url: "/stacks", expected: true},
+		{name: "read-only key writes", scope: readOnly, method: http.MethodPut, url: "/stacks/1", expected: false},
+		{name: "read-only key opens a websocket", scope: readOnly, method: http.MethodGet, url: "/websocket/exec?endpointId=1", upgrade: true, expected: false},
+		{name: "allowed environment in path", scope: endpoints, method: http.MethodPost, url: "/endpoints/3/docker/containers/create", expected: true},
+		{name: "allowed environment in API path", scope: endpoints, method: http.MethodGet, url: "/api/endpoints/1", expected: true},
+		{name: "other environment in path", scope: endpoints, method: http.MethodGet, url: "/endpoints/2/kubernetes/api", expected: false},
+		{name: "other environment in query", scope: endpoints, method: http.MethodGet, url: "/stacks?endpointId=2", expected: false},
+		{name: "invalid environment in query", scope: endpoints, method: http.MethodGet, url: "/stacks?endpointId=abc", expected: false},
+		{name: "no environment", scope: endpoints, method: http.MethodGet, url: "/endpoints", expected: false},
+		{name: "global route", scope: endpoints, method: http.MethodGet, url: "/users", expected: false},
+		{name: "stack of an allowed environment", scope: endpoints, method: http.MethodPut, url: "/api/stacks/1", expected: true},
+		{name: "stack of another environment", scope: endpoints, method: http.MethodDelete, url: "/api/stacks/2?endpointId=1", expected: false},
+		{name: "unknown stack", scope: endpoints, method: http.MethodPut, url: "/api/stacks/5", expected: false},
+		{name: "other environment in kubernetes path", scope: endpoints, method: http.MethodGet, url: "/api/kubernetes/2/namespaces", expected: false},
+		{name: "allowed authorization", scope: authorizations, method: http.MethodGet, url: "/api/endpoints/1/docker/v1.41/containers/json", expected: true},
+		{name: "allowed Portainer authorization", scope: authorizations, method: http.MethodGet, url: "/api/stacks", expected: true},
+		{name: "other authorization", scope: authorizations, method: http.MethodPost, url: "/api/endpoints/1/docker/containers/abc/stop", expected: false},
+		{name: "request without a dedicated authorization", scope: authorizations, method: http.MethodGet, url: "/api/endpoints/1/kubernetes/api/v1/pods", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.url, nil)
+			if tt.upgrade {
+				r.Header.Set("Upgrade", "websocket")
+			}
+
+			assert.Equal(t, tt.expected, apiKeyScopeAllowsRequest(tt.scope, r, stackEndpoint))
+		})
+	}
+}
+
+func Test_apiKeyScopeAllowsStrippedRequest(t *testing.T) {
+	scope := &portainer.APIKeyScope{EndpointIDs: []portainer.EndpointID{1}}
+
+	// the main handler strips the API prefix before the request reaches the proxy handlers
+	r := httptest.NewRequest(http.MethodGet, "/api/endpoints/2/docker/containers/json", nil)
+	r.URL.Path = "/2/docker/containers/json"
+
+	assert.False(t, apiKeyScopeAllowsRequest(scope, r, nil))
+}
+
+func Test_requestAuthorization(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected portainer.Authorization
+	}{
+		{http.MethodGet, "/api/endpoints/1/docker/containers/json", portainer.OperationDockerContainerList},
+		{http.MethodDelete, "/api/endpoints/1/docker/v1.41/containers/abc", portainer.OperationDockerContainerDelete},
+		{http.MethodGet, "/api/endpoints/1/docker/images/library/nginx:latest/json", portainer.OperationDockerImageInspect},
+		{http.MethodGet, "/api/endpoints/1/agent/docker/browse/ls", portainer.OperationDockerAgentBrowseList},
+		{http.MethodPost, "/api/endpoints/1/docker/unknown", portainer.OperationDockerUndefined},
+		{http.MethodGet, "/api/endpoints", portainer.OperationPortainerEndpointList},
+		{http.MethodPost, "/api/stacks/1/migrate", portainer.OperationPortainerStackMigrate},
+		{http.MethodPost, "/api/stacks/1/start", portainer.OperationPortainerStackUpdate},
+		{http.MethodPost, "/api/stacks/create/standalone/string", portainer.OperationPortainerStackCreate},
+		{http.MethodPut, "/api/users/1/passwd", portainer.OperationPortainerUserUpdatePassword},
+		{http.MethodGet, "/api/edge_stacks", portainer.OperationPortainerUndefined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, requestAuthorization(tt.method, tt.path))
+		})
+	}
+}
@@ -103,6 +103,42 @@ func AuthorizedTeamManagement(teamID portainer.TeamID, context *RestrictedReques
 	return false
 }
 
+// AuthorizedGitCredentialAccess ensure that the user can use the specified Git credential.
+// It will check if the user is an administrator, the owner of the credential or
+// a member of the team owning the credential.
+func AuthorizedGitCredentialAccess(credential *portainer.GitCredential, context *RestrictedRequestContext) bool {
+	if context.IsAdmin {
+		return true
+	}
+
+	if credential.UserID != 0 && credential.UserID == context.UserID {
+		return true
+	}
+
+	for _, membership := range context.UserMemberships {
+		if credential.TeamID != 0 && membership.TeamID == credential.TeamID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AuthorizedGitCredentialManagement ensure that the user can update or remove the specified Git credential.
+// It will check if the user is an administrator, the owner of the credential or
+// a leader of the team owning the credential.
+func AuthorizedGitCredentialManagement(credential *portainer.GitCredential, context *RestrictedRequestContext) bool {
+	if context.IsAdmin {
+		return true
+	}
+
+	if credential.UserID != 0 && credential.UserID == context.UserID {
+		return true
+	}
+
+	return credential.TeamID != 0 && AuthorizedTeamManagement(credential.TeamID, context)
+}
+
 // AuthorizedIsTeamLeader ensure that the user is an admin or a team leader
 func AuthorizedIsTeamLeader(context *RestrictedRequestContext) bool {
 	return context.IsAdmin || context.IsTeamLeader
@@ -10,6 +10,7 @@ import (
 	httperror "github.com/portainer/libhttp/error"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/audit"
 	"github.com/portainer/portainer/api/dataservices"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 )
@@ -112,6 +113,10 @@ func (bouncer *RequestBouncer) AuthorizedEndpointOperation(r *http.Request, endp
 		return err
 	}
 
+	if !apiKeyScopeAllowsEndpoint(tokenData.APIKeyScope, endpoint.ID) {
+		return httperrors.ErrEndpointAccessDenied
+	}
+
 	if tokenData.Role == portainer.AdministratorRole {
 		return nil
 	}
@@ -269,7 +274,7 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 			return
 		}
 
-		_, err := bouncer.dataStore.User().User(token.ID)
+		user, err := bouncer.dataStore.User().User(token.ID)
 		if err != nil && bouncer.dataStore.IsErrObjectNotFound(err) {
 			httperror.WriteError(w, http.StatusUnauthorized, "Unauthorized", httperrors.ErrUnauthorized)
 			return
@@ -278,11 +283,38 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 			return
 		}
 
+		if user.Disabled {
+			httperror.WriteError(w, http.StatusUnauthorized, "User account is disabled", httperrors.ErrUnauthorized)
+			return
+		}
+
+		if !passwordChangeAllowsRequest(token, r) {
+			httperror.WriteError(w, http.StatusForbidden, "The password of the user must be changed", httperrors.ErrUnauthorized)
+			return
+		}
+
+		if !apiKeyScopeAllowsRequest(token.APIKeyScope, r, bouncer.stackEndpointID) {
+			httperror.WriteError(w, http.StatusForbidden, "The scope of the API key does not allow this request", httperrors.ErrUnauthorized)
+			return
+		}
+
+		audit.SetUser(r.Context(), token.ID, token.Username)
+
 		ctx := StoreTokenData(r, token)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// stackEndpointID returns the environment(endpoint) of the stack, used to check the scope of the API keys
+func (bouncer *RequestBouncer) stackEndpointID(stackID portainer.StackID) portainer.EndpointID {
+	stack, err := bouncer.dataStore.Stack().Stack(stackID)
+	if err != nil {
+		return 0
+	}
+
+	return stack.EndpointID
+}
+
 // JWTAuthLookup looks up a valid bearer in the request.
 func (bouncer *RequestBouncer) JWTAuthLookup(r *http.Request) *portainer.TokenData {
 	// get token from the Authorization header or query parameter
@@ -303,6 +335,7 @@ func (bouncer *RequestBouncer) JWTAuthLookup(r *http.Request) *portainer.TokenDa
 // - computing the digest of the raw api-key
 // - verifying it exists in cache/database
 // - matching the key to a user (ID, Role)
+// - rejecting the key when it has expired
 // If the key is valid/verified, the last updated time of the key is updated.
 // Successful verification of the key will return a TokenData object - since the downstream handlers
 // utilise the token injected in the request context.
@@ -320,14 +353,17 @@ func (bouncer *RequestBouncer) apiKeyLookup(r *http.Request) *portainer.TokenDat
 	}
 
 	tokenData := &portainer.TokenData{
-		ID:       user.ID,
-		Username: user.Username,
-		Role:     user.Role,
+		ID:          user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		APIKeyScope: apiKeyTokenScope(apiKey),
 	}
 	if _, err := bouncer.jwtService.GenerateToken(tokenData); err != nil {
 		return nil
 	}
 
+	audit.SetAPIKey(r.Context(), apiKey.ID)
+
 	// update the last used time of the key
 	apiKey.LastUsed = time.Now().UTC().Unix()
 	bouncer.apiKeyService.UpdateAPIKey(&apiKey)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/apikey"
@@ -29,6 +30,14 @@ func tokenLookupSucceed(dataStore dataservices.DataStore, jwtService dataservice
 	}
 }
 
+func tokenLookupDisabledUser(dataStore dataservices.DataStore) tokenLookup {
+	return func(r *http.Request) *portainer.TokenData {
+		user := &portainer.User{Username: "disabled", Disabled: true}
+		dataStore.User().Create(user)
+		return &portainer.TokenData{ID: user.ID}
+	}
+}
+
 func tokenLookupFail(r *http.Request) *portainer.TokenData {
 	return nil
 }
@@ -86,6 +95,13 @@ func Test_mwAuthenticateFirst(t *testing.T) {
 			},
 			wantStatusCode: http.StatusOK,
 		},
+		{
+			name: "mwAuthenticateFirst fails when the user is disabled",
+			verificationMiddlwares: []tokenLookup{
+				tokenLookupDisabledUser(store),
+			},
+			wantStatusCode: http.StatusUnauthorized,
+		},
 	}
 
 	for _, tt := range tests {
@@ -332,4 +348,95 @@ func Test_apiKeyLookup(t *testing.T) {
 
 		is.True(apiKeyUpdated.LastUsed > apiKey.LastUsed)
 	})
+
+	t.Run("expired x-api-key header fails api-key lookup", func(t *testing.T) {
+		rawAPIKey, apiKey, err := apiKeyService.GenerateScopedApiKey(*user, "test", time.Now().Add(-time.Minute).Unix(), portainer.APIKeyScope{})
+		is.NoError(err)
+		defer apiKeyService.DeleteAPIKey(apiKey.ID)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Add("x-api-key", rawAPIKey)
+
+		token := bouncer.apiKeyLookup(req)
+		is.Nil(token)
+	})
+
+	t.Run("scoped x-api-key header is restricted to its scope", func(t *testing.T) {
+		scope := portainer.APIKeyScope{ReadOnly: true, EndpointIDs: []portainer.EndpointID{1}}
+		rawAPIKey, apiKey, err := apiKeyService.GenerateScopedApiKey(*user, "test", 0, scope)
+		is.NoError(err)
+		defer apiKeyService.DeleteAPIKey(apiKey.ID)
+
+		h := bouncer.mwAuthenticateFirst([]tokenLookup{bouncer.apiKeyLookup}, testHandler200)
+
+		for _, tt := range []struct {
+			method       string
+			url          string
+			expectedCode int
+		}{
+			{method: http.MethodGet, url: "/endpoints/1/docker/containers/json", expectedCode: http.StatusOK},
+			{method: http.MethodPost, url: "/endpoints/1/docker/containers/create", expectedCode: http.StatusForbidden},
+			{method: http.MethodGet, url: "/endpoints/2/docker/containers/json", expectedCode: http.StatusForbidden},
+			{method: http.MethodGet, url: "/stacks?endpointId=2", expectedCode: http.StatusForbidden},
+		} {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			req.Header.Add("x-api-key", rawAPIKey)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			is.Equal(tt.expectedCode, rr.Code, "%s %s", tt.method, tt.url)
+		}
+	})
+}
+
+func Test_mwAuthenticateFirst_passwordChangeRequired(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	user := &portainer.User{Username: "expired", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+
+	bouncer := NewRequestBouncer(store, jwtService, apikey.NewAPIKeyService(nil, nil))
+	handler := bouncer.mwAuthenticateFirst([]tokenLookup{bouncer.JWTAuthLookup}, testHandler200)
+
+	forcedJWT, err := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, ForceChangePassword: true, PasswordChangeRequired: true})
+	is.NoError(err)
+	weakJWT, err := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, ForceChangePassword: true})
+	is.NoError(err)
+	validJWT, err := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+	is.NoError(err)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		wantStatusCode int
+	}{
+		{"password change is allowed", http.MethodPut, fmt.Sprintf("/api/users/%d/passwd", user.ID), forcedJWT, http.StatusOK},
+		{"logout is allowed", http.MethodPost, "/api/auth/logout", forcedJWT, http.StatusOK},
+		{"password change of another user is denied", http.MethodPut, fmt.Sprintf("/api/users/%d/passwd", user.ID+1), forcedJWT, http.StatusForbidden},
+		{"reading the user is denied", http.MethodGet, fmt.Sprintf("/api/users/%d", user.ID), forcedJWT, http.StatusForbidden},
+		{"environment routes are denied", http.MethodGet, "/api/endpoints/1/docker/containers/json", forcedJWT, http.StatusForbidden},
+		{"tokens without the flag are not restricted", http.MethodGet, "/api/endpoints", validJWT, http.StatusOK},
+		{"a password that doesn't match the policy can be changed later", http.MethodGet, "/api/endpoints", weakJWT, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			rr := httptest.NewRecorder()
+			http.StripPrefix("/api", handler).ServeHTTP(rr, req)
+
+			is.Equal(tt.wantStatusCode, rr.Code)
+		})
+	}
 }
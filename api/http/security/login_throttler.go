@@ -0,0 +1,258 @@
+package security
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// loginDelayThreshold is the number of failed attempts allowed before the next attempts are delayed
+	loginDelayThreshold = 3
+	// loginMaxDelay caps the delay between two attempts, doubled after each failed attempt
+	loginMaxDelay = 30 * time.Second
+	// loginLockoutThreshold is the number of failed attempts after which the account is locked
+	loginLockoutThreshold = 10
+	// loginLockoutDuration is the duration of the lockout of an account
+	loginLockoutDuration = 15 * time.Minute
+	// loginAttemptsRetention is the duration after which the failed attempts of an account are forgotten
+	loginAttemptsRetention = time.Hour
+	// loginMaxTrackedAccounts caps the number of accounts tracked, the failed attempts can target any username
+	loginMaxTrackedAccounts = 10000
+)
+
+// ErrAccountLocked is returned when an account is locked after too many failed login attempts
+var ErrAccountLocked = errors.New("Account temporarily locked after too many failed login attempts")
+
+type (
+	// LoginThrottler tracks the failed login attempts per username. The attempts following a few failures are
+	// progressively delayed and the account is temporarily locked after too many failures, whatever the address
+	// of the clients sending them. The attempts in progress count as failures until they are released, so that
+	// concurrent attempts cannot bypass the delays and the lockout.
+	LoginThrottler struct {
+		mu          sync.Mutex
+		accounts    map[string]*loginAttempts
+		lastCleanup time.Time
+		now         func() time.Time
+	}
+
+	loginAttempts struct {
+		failures    int
+		inFlight    int
+		lastFailure time.Time
+		lockedUntil time.Time
+	}
+
+	// LockedAccount represents an account locked after too many failed login attempts
+	LockedAccount struct {
+		Username string `json:"Username" example:"admin"`
+		// Number of failed login attempts
+		FailedAttempts int `json:"FailedAttempts" example:"10"`
+		// Unix timestamp (UTC) of the end of the lockout
+		LockedUntil int64 `json:"LockedUntil" example:"1587399600"`
+	}
+)
+
+// NewLoginThrottler initializes a new LoginThrottler
+func NewLoginThrottler() *LoginThrottler {
+	return &LoginThrottler{
+		accounts: map[string]*loginAttempts{},
+		now:      time.Now,
+	}
+}
+
+// Check returns ErrAccountLocked when the account is locked, otherwise the time left before the next
+// login attempt is allowed. When the attempt is allowed, it is reserved until Release is called.
+func (throttler *LoginThrottler) Check(username string) (time.Duration, error) {
+	throttler.mu.Lock()
+	defer throttler.mu.Unlock()
+
+	now := throttler.now()
+	key := normalizeUsername(username)
+
+	attempts, ok := throttler.accounts[key]
+	if ok {
+		if now.Before(attempts.lockedUntil) {
+			return attempts.lockedUntil.Sub(now), ErrAccountLocked
+		}
+
+		wait := attempts.lastFailure.Add(loginDelay(attempts.failures)).Sub(now)
+		if wait > 0 {
+			return wait, nil
+		}
+
+		// past the delay threshold, the attempts are sent one at a time
+		pending := attempts.failures + attempts.inFlight
+		if attempts.inFlight > 0 && pending >= loginDelayThreshold {
+			return loginDelay(pending), nil
+		}
+	}
+
+	throttler.track(key, now).inFlight++
+	return 0, nil
+}
+
+// Release ends an attempt reserved by Check, after its failure or success was recorded
+func (throttler *LoginThrottler) Release(username string) {
+	throttler.mu.Lock()
+	defer throttler.mu.Unlock()
+
+	key := normalizeUsername(username)
+	attempts, ok := throttler.accounts[key]
+	if !ok || attempts.inFlight == 0 {
+		return
+	}
+
+	attempts.inFlight--
+	if attempts.inFlight == 0 && attempts.failures == 0 {
+		delete(throttler.accounts, key)
+	}
+}
+
+// Fail records a failed login attempt and locks the account when there are too many
+func (throttler *LoginThrottler) Fail(username string) {
+	throttler.mu.Lock()
+	defer throttler.mu.Unlock()
+
+	now := throttler.now()
+	throttler.cleanup(now, false)
+
+	attempts := throttler.track(normalizeUsername(username), now)
+	if now.Sub(attempts.lastFailure) > loginAttemptsRetention {
+		attempts.failures = 0
+	}
+
+	attempts.failures++
+	attempts.lastFailure = now
+
+	if attempts.failures >= loginLockoutThreshold {
+		attempts.lockedUntil = now.Add(loginLockoutDuration)
+	}
+}
+
+// Succeed forgets the failed login attempts of the account
+func (throttler *LoginThrottler) Succeed(username string) {
+	throttler.mu.Lock()
+	defer throttler.mu.Unlock()
+
+	key := normalizeUsername(username)
+	attempts, ok := throttler.accounts[key]
+	if !ok {
+		return
+	}
+
+	if attempts.inFlight == 0 {
+		delete(throttler.accounts, key)
+		return
+	}
+
+	*attempts = loginAttempts{inFlight: attempts.inFlight}
+}
+
+// LockedAccounts returns the accounts currently locked, sorted by username
+func (throttler *LoginThrottler) LockedAccounts() []LockedAccount {
+	throttler.mu.Lock()
+	defer throttler.mu.Unlock()
+
+	now := throttler.now()
+	locked := make([]LockedAccount, 0)
+	for username, attempts := range throttler.accounts {
+		if now.Before(attempts.lockedUntil) {
+			locked = append(locked, LockedAccount{
+				Username:       username,
+				FailedAttempts: attempts.failures,
+				LockedUntil:    attempts.lockedUntil.Unix(),
+			})
+		}
+	}
+
+	sort.Slice(locked, func(i, j int) bool {
+		return locked[i].Username < locked[j].Username
+	})
+
+	return locked
+}
+
+// Unlock forgets the failed login attempts of a locked account, it returns false when the account is not locked
+func (throttler *LoginThrottler) Unlock(username string) bool {
+	throttler.mu.Lock()
+	defer throttler.mu.Unlock()
+
+	key := normalizeUsername(username)
+	attempts, ok := throttler.accounts[key]
+	if !ok || !throttler.now().Before(attempts.lockedUntil) {
+		return false
+	}
+
+	delete(throttler.accounts, key)
+	return true
+}
+
+// track returns the attempts of the account, tracking it when needed. When too many accounts are tracked,
+// the account with the oldest failure and no attempt in progress is forgotten.
+func (throttler *LoginThrottler) track(key string, now time.Time) *loginAttempts {
+	attempts, ok := throttler.accounts[key]
+	if ok {
+		return attempts
+	}
+
+	if len(throttler.accounts) >= loginMaxTrackedAccounts {
+		throttler.cleanup(now, true)
+	}
+
+	if len(throttler.accounts) >= loginMaxTrackedAccounts {
+		oldest := ""
+		for username, candidate := range throttler.accounts {
+			if candidate.inFlight == 0 && (oldest == "" || candidate.lastFailure.Before(throttler.accounts[oldest].lastFailure)) {
+				oldest = username
+			}
+		}
+
+		if oldest != "" {
+			delete(throttler.accounts, oldest)
+		}
+	}
+
+	attempts = &loginAttempts{}
+	throttler.accounts[key] = attempts
+	return attempts
+}
+
+// cleanup forgets the expired attempts, at most once per minute unless forced
+func (throttler *LoginThrottler) cleanup(now time.Time, force bool) {
+	if !force && now.Sub(throttler.lastCleanup) < time.Minute {
+		return
+	}
+	throttler.lastCleanup = now
+
+	for username, attempts := range throttler.accounts {
+		if attempts.inFlight == 0 && now.Sub(attempts.lastFailure) > loginAttemptsRetention && !now.Before(attempts.lockedUntil) {
+			delete(throttler.accounts, username)
+		}
+	}
+}
+
+// loginDelay returns the delay required after the last failed attempt, doubled after each failure past the threshold
+func loginDelay(failures int) time.Duration {
+	if failures < loginDelayThreshold {
+		return 0
+	}
+
+	delay := time.Second
+	for i := loginDelayThreshold; i < failures && delay < loginMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+
+	return delay
+}
+
+// normalizeUsername matches the case insensitive lookup of the users by username
+func normalizeUsername(username string) string {
+	return strings.ToLower(username)
+}
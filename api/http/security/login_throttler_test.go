@@ -0,0 +1,170 @@
+package security
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginThrottler(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Now()
+	throttler := NewLoginThrottler()
+	throttler.now = func() time.Time { return now }
+
+	// check releases the allowed attempts, as a login would once its outcome is recorded
+	check := func(username string) (time.Duration, error) {
+		wait, err := throttler.Check(username)
+		if wait == 0 && err == nil {
+			throttler.Release(username)
+		}
+		return wait, err
+	}
+
+	t.Run("the first failed attempts are not delayed", func(t *testing.T) {
+		for i := 0; i < loginDelayThreshold-1; i++ {
+			throttler.Fail("Alice")
+		}
+
+		wait, err := check("alice")
+		is.NoError(err)
+		is.Zero(wait)
+	})
+
+	t.Run("the next attempts are progressively delayed", func(t *testing.T) {
+		throttler.Fail("alice")
+		wait, err := check("alice")
+		is.NoError(err)
+		is.Equal(time.Second, wait)
+
+		throttler.Fail("alice")
+		wait, _ = check("alice")
+		is.Equal(2*time.Second, wait)
+
+		now = now.Add(2 * time.Second)
+		wait, _ = check("alice")
+		is.Zero(wait)
+	})
+
+	t.Run("the account is locked after too many failed attempts", func(t *testing.T) {
+		for i := loginDelayThreshold + 1; i < loginLockoutThreshold; i++ {
+			throttler.Fail("alice")
+		}
+
+		wait, err := check("alice")
+		is.ErrorIs(err, ErrAccountLocked)
+		is.Equal(loginLockoutDuration, wait)
+
+		locked := throttler.LockedAccounts()
+		is.Len(locked, 1)
+		is.Equal("alice", locked[0].Username)
+		is.Equal(loginLockoutThreshold, locked[0].FailedAttempts)
+
+		// other accounts are not affected
+		_, err = check("bob")
+		is.NoError(err)
+	})
+
+	t.Run("an administrator unlocks the account", func(t *testing.T) {
+		is.True(throttler.Unlock("ALICE"))
+		is.False(throttler.Unlock("alice"))
+
+		wait, err := check("alice")
+		is.NoError(err)
+		is.Zero(wait)
+		is.Empty(throttler.LockedAccounts())
+	})
+
+	t.Run("a successful login forgets the failed attempts", func(t *testing.T) {
+		for i := 0; i < loginDelayThreshold; i++ {
+			throttler.Fail("bob")
+		}
+		throttler.Succeed("bob")
+
+		wait, err := check("bob")
+		is.NoError(err)
+		is.Zero(wait)
+	})
+
+	t.Run("the failed attempts are forgotten after a while", func(t *testing.T) {
+		for i := 0; i < loginDelayThreshold; i++ {
+			throttler.Fail("carol")
+		}
+
+		now = now.Add(loginAttemptsRetention + time.Minute)
+		throttler.Fail("carol")
+
+		wait, err := check("carol")
+		is.NoError(err)
+		is.Zero(wait)
+	})
+
+	t.Run("the attempts in progress count as failures", func(t *testing.T) {
+		for i := 0; i < loginDelayThreshold; i++ {
+			wait, err := throttler.Check("dave")
+			is.NoError(err)
+			is.Zero(wait)
+		}
+
+		wait, err := throttler.Check("dave")
+		is.NoError(err)
+		is.NotZero(wait, "concurrent attempts past the delay threshold must wait for the attempts in progress")
+
+		for i := 0; i < loginDelayThreshold; i++ {
+			throttler.Release("dave")
+		}
+
+		wait, err = check("dave")
+		is.NoError(err)
+		is.Zero(wait)
+	})
+
+	t.Run("parallel attempts cannot bypass the lockout", func(t *testing.T) {
+		allowed := 0
+		for i := 0; i < 10*loginLockoutThreshold; i++ {
+			wait, err := throttler.Check("erin")
+			if err == nil && wait == 0 {
+				allowed++
+			}
+		}
+		is.Equal(loginDelayThreshold, allowed)
+
+		for i := 0; i < allowed; i++ {
+			throttler.Fail("erin")
+			throttler.Release("erin")
+		}
+
+		wait, err := check("erin")
+		is.NoError(err)
+		is.Equal(time.Second, wait)
+	})
+}
+
+func Test_LoginThrottler_tracksALimitedNumberOfAccounts(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Now()
+	throttler := NewLoginThrottler()
+	throttler.now = func() time.Time { return now }
+
+	for i := 0; i < loginMaxTrackedAccounts+100; i++ {
+		now = now.Add(time.Millisecond)
+		throttler.Fail(fmt.Sprintf("unknown-%d", i))
+	}
+
+	is.Len(throttler.accounts, loginMaxTrackedAccounts)
+	is.NotContains(throttler.accounts, "unknown-0", "the oldest failures are forgotten first")
+	is.Contains(throttler.accounts, fmt.Sprintf("unknown-%d", loginMaxTrackedAccounts+99))
+}
+
+func Test_loginDelay(t *testing.T) {
+	is := assert.New(t)
+
+	is.Zero(loginDelay(loginDelayThreshold - 1))
+	is.Equal(time.Second, loginDelay(loginDelayThreshold))
+	is.Equal(4*time.Second, loginDelay(loginDelayThreshold+2))
+	is.Equal(loginMaxDelay, loginDelay(loginDelayThreshold+20))
+}
@@ -1,6 +1,9 @@
 package security
 
 import (
+	"strings"
+	"unicode"
+
 	portainer "github.com/portainer/portainer/api"
 	"github.com/sirupsen/logrus"
 )
@@ -27,7 +30,54 @@ func (c *passwordStrengthChecker) Check(password string) bool {
 		return true
 	}
 
-	return len(password) >= s.InternalAuthSettings.RequiredPasswordLength
+	return checkPasswordPolicy(&s.InternalAuthSettings, password)
+}
+
+// checkPasswordPolicy returns true if the password matches the length, character classes and banned passwords
+// of the internal authentication settings
+func checkPasswordPolicy(settings *portainer.InternalAuthSettings, password string) bool {
+	if len(password) < settings.RequiredPasswordLength {
+		return false
+	}
+
+	if countCharacterClasses(password) < settings.RequiredCharacterClasses {
+		return false
+	}
+
+	for _, banned := range settings.BannedPasswords {
+		if strings.EqualFold(password, banned) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// countCharacterClasses returns the number of character classes (lowercase, uppercase, digits and symbols)
+// used in the password
+func countCharacterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, used := range []bool{lower, upper, digit, symbol} {
+		if used {
+			count++
+		}
+	}
+
+	return count
 }
 
 type settingsService interface {
@@ -36,6 +36,35 @@ func TestStrengthCheck(t *testing.T) {
 	}
 }
 
+func TestPasswordPolicyCheck(t *testing.T) {
+	settings := &portainer.InternalAuthSettings{
+		RequiredPasswordLength:   8,
+		RequiredCharacterClasses: 3,
+		BannedPasswords:          []string{"Portainer123"},
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		wantStrong bool
+	}{
+		{"Single class", "portainer", false},
+		{"Two classes", "portainer123", false},
+		{"Three classes", "portainer123!", true},
+		{"Four classes", "Portainer123!", true},
+		{"Too short", "Pt1!", false},
+		{"Banned password", "Portainer123", false},
+		{"Banned password in a different case", "PORTAINER123", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if gotStrong := checkPasswordPolicy(settings, tt.password); gotStrong != tt.wantStrong {
+				t.Errorf("checkPasswordPolicy() = %v, want %v", gotStrong, tt.wantStrong)
+			}
+		})
+	}
+}
+
 type settingsStub struct {
 	minLength int
 }
@@ -0,0 +1,26 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// passwordChangeAllowsRequest returns true when the request can be sent with the token. The tokens of the users
+// whose password expired can only be used to change it or to log out.
+func passwordChangeAllowsRequest(token *portainer.TokenData, r *http.Request) bool {
+	if !token.PasswordChangeRequired {
+		return true
+	}
+
+	path := requestPath(r)
+	switch {
+	case r.Method == http.MethodPut && path == fmt.Sprintf("/api/users/%d/passwd", token.ID):
+		return true
+	case r.Method == http.MethodPost && path == "/api/auth/logout":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,60 @@
+package security
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// PasswordExpired returns true when the password of the user is older than the maximum password age
+func PasswordExpired(user *portainer.User, settings *portainer.InternalAuthSettings, now time.Time) bool {
+	if settings.MaxPasswordAge <= 0 || user.PasswordChangedAt == 0 {
+		return false
+	}
+
+	maxAge := time.Duration(settings.MaxPasswordAge) * 24 * time.Hour
+	return now.Sub(time.Unix(user.PasswordChangedAt, 0)) > maxAge
+}
+
+// PasswordReused returns true when the password matches the current password of the user or one of the
+// previous passwords kept in the history
+func PasswordReused(cryptoService portainer.CryptoService, user *portainer.User, password string, historySize int) bool {
+	if historySize <= 0 {
+		return false
+	}
+
+	if user.Password != "" && cryptoService.CompareHashAndData(user.Password, password) == nil {
+		return true
+	}
+
+	for i, hash := range user.PasswordHistory {
+		if i >= historySize-1 {
+			break
+		}
+
+		if cryptoService.CompareHashAndData(hash, password) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RecordPasswordChange replaces the password hash of the user, keeps the previous hash in the history
+// and resets the age of the password
+func RecordPasswordChange(user *portainer.User, hash string, historySize int, now time.Time) {
+	history := user.PasswordHistory
+	if user.Password != "" {
+		history = append([]string{user.Password}, history...)
+	}
+
+	if historySize <= 1 {
+		history = nil
+	} else if len(history) > historySize-1 {
+		history = history[:historySize-1]
+	}
+
+	user.Password = hash
+	user.PasswordHistory = history
+	user.PasswordChangedAt = now.Unix()
+}
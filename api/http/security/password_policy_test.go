@@ -0,0 +1,57 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordExpired(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Now()
+	settings := &portainer.InternalAuthSettings{MaxPasswordAge: 90}
+
+	is.False(PasswordExpired(&portainer.User{PasswordChangedAt: now.AddDate(0, 0, -89).Unix()}, settings, now))
+	is.True(PasswordExpired(&portainer.User{PasswordChangedAt: now.AddDate(0, 0, -91).Unix()}, settings, now))
+	is.False(PasswordExpired(&portainer.User{}, settings, now), "passwords without a change date do not expire")
+	is.False(PasswordExpired(&portainer.User{PasswordChangedAt: now.AddDate(-1, 0, 0).Unix()}, &portainer.InternalAuthSettings{}, now), "the expiry is disabled by default")
+}
+
+func TestPasswordHistory(t *testing.T) {
+	is := assert.New(t)
+
+	cryptoService := &crypto.Service{}
+	user := &portainer.User{}
+
+	change := func(password string, historySize int) {
+		hash, err := cryptoService.Hash(password)
+		is.NoError(err)
+		RecordPasswordChange(user, hash, historySize, time.Now())
+	}
+
+	change("first", 3)
+	change("second", 3)
+	change("third", 3)
+	is.Len(user.PasswordHistory, 2)
+	is.NotZero(user.PasswordChangedAt)
+
+	is.True(PasswordReused(cryptoService, user, "third", 3), "the current password cannot be reused")
+	is.True(PasswordReused(cryptoService, user, "second", 3))
+	is.True(PasswordReused(cryptoService, user, "first", 3))
+	is.False(PasswordReused(cryptoService, user, "fourth", 3))
+
+	change("fourth", 3)
+	is.Len(user.PasswordHistory, 2)
+	is.False(PasswordReused(cryptoService, user, "first", 3), "the oldest password is dropped from the history")
+
+	is.True(PasswordReused(cryptoService, user, "third", 2))
+	is.False(PasswordReused(cryptoService, user, "second", 2), "a smaller history size only checks the most recent passwords")
+	is.False(PasswordReused(cryptoService, user, "fourth", 0), "the history is disabled by default")
+
+	change("fifth", 0)
+	is.Empty(user.PasswordHistory)
+}
@@ -1,6 +1,8 @@
 package security
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -13,6 +15,7 @@ import (
 // RateLimiter represents an entity that manages request rate limiting
 type RateLimiter struct {
 	*defender.Defender
+	trustedProxies []*net.IPNet
 }
 
 // NewRateLimiter initializes a new RateLimiter
@@ -21,14 +24,84 @@ func NewRateLimiter(maxRequests int, duration time.Duration, banDuration time.Du
 	limiter := defender.New(maxRequests, duration, banDuration)
 	go limiter.CleanupTask(messages)
 	return &RateLimiter{
-		limiter,
+		Defender: limiter,
 	}
 }
 
+// TrustProxies makes the rate limiter identify the clients with the X-Forwarded-For header of the requests
+// sent by the specified proxies. Each proxy is either an IP address or a CIDR range.
+func (limiter *RateLimiter) TrustProxies(proxies []string) error {
+	trustedProxies := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy address: %s", proxy)
+			}
+			trustedProxies = append(trustedProxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy range: %s", proxy)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+
+	limiter.trustedProxies = trustedProxies
+	return nil
+}
+
+// ClientIP returns the address of the client sending the request. The X-Forwarded-For header is only used
+// when the request comes from a trusted proxy, the client is then the last address of the header that is not
+// a trusted proxy itself.
+func (limiter *RateLimiter) ClientIP(r *http.Request) string {
+	ip := StripAddrPort(r.RemoteAddr)
+	if !limiter.isTrustedProxy(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(forwarded[i])
+		if address == "" {
+			continue
+		}
+
+		if net.ParseIP(address) == nil {
+			// the header was not written by a trusted proxy from this point
+			return ip
+		}
+
+		if !limiter.isTrustedProxy(address) {
+			return address
+		}
+		ip = address
+	}
+
+	return ip
+}
+
+func (limiter *RateLimiter) isTrustedProxy(address string) bool {
+	ip := net.ParseIP(strings.Trim(address, "[]"))
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range limiter.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // LimitAccess wraps current request with check if remote address does not goes above the defined limits
 func (limiter *RateLimiter) LimitAccess(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := StripAddrPort(r.RemoteAddr)
+		ip := limiter.ClientIP(r)
 		if banned := limiter.Inc(ip); banned == true {
 			httperror.WriteError(w, http.StatusForbidden, "Access denied", errors.ErrResourceAccessDenied)
 			return
@@ -67,3 +67,51 @@ func TestStripAddrPort(t *testing.T) {
 		}
 	})
 }
+
+func TestClientIP(t *testing.T) {
+	rateLimiter := NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	err := rateLimiter.TrustProxies([]string{"10.0.0.1", "172.16.0.0/12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expectedIP   string
+	}{
+		{name: "direct client", remoteAddr: "192.168.1.10:1000", expectedIP: "192.168.1.10"},
+		{name: "forwarded header from an untrusted client", remoteAddr: "192.168.1.10:1000", forwardedFor: []string{"1.2.3.4"}, expectedIP: "192.168.1.10"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1000", forwardedFor: []string{"1.2.3.4"}, expectedIP: "1.2.3.4"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.1:1000", forwardedFor: []string{"1.2.3.4, 172.16.5.4", "172.20.0.1"}, expectedIP: "1.2.3.4"},
+		{name: "spoofed header behind a trusted proxy", remoteAddr: "10.0.0.1:1000", forwardedFor: []string{"6.6.6.6, 1.2.3.4"}, expectedIP: "1.2.3.4"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.1:1000", expectedIP: "10.0.0.1"},
+		{name: "invalid header", remoteAddr: "10.0.0.1:1000", forwardedFor: []string{"unknown"}, expectedIP: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			result := rateLimiter.ClientIP(req)
+			if result != tt.expectedIP {
+				t.Errorf("Expected client IP to be '%s', but it was %s instead", tt.expectedIP, result)
+			}
+		})
+	}
+}
+
+func TestTrustProxies(t *testing.T) {
+	rateLimiter := NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+
+	for _, proxies := range [][]string{{"proxy.local"}, {"10.0.0.0/33"}} {
+		if err := rateLimiter.TrustProxies(proxies); err == nil {
+			t.Errorf("Expected an error for the trusted proxies %v", proxies)
+		}
+	}
+}
@@ -13,11 +13,14 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/adminmonitor"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/audit"
+	operations "github.com/portainer/portainer/api/backup"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/docker"
 	"github.com/portainer/portainer/api/http/handler"
+	"github.com/portainer/portainer/api/http/handler/auditlogs"
 	"github.com/portainer/portainer/api/http/handler/auth"
 	"github.com/portainer/portainer/api/http/handler/backup"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
@@ -31,12 +34,15 @@ import (
 	"github.com/portainer/portainer/api/http/handler/endpointproxy"
 	"github.com/portainer/portainer/api/http/handler/endpoints"
 	"github.com/portainer/portainer/api/http/handler/file"
+	"github.com/portainer/portainer/api/http/handler/gitcredentials"
+	"github.com/portainer/portainer/api/http/handler/gitops"
 	"github.com/portainer/portainer/api/http/handler/helm"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/fdo"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
 	kubehandler "github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/notifications"
 	"github.com/portainer/portainer/api/http/handler/registries"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
@@ -61,6 +67,8 @@ import (
 	"github.com/portainer/portainer/api/internal/ssl"
 	k8s "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	ldapsync "github.com/portainer/portainer/api/ldap"
+	"github.com/portainer/portainer/api/notification"
 	"github.com/portainer/portainer/api/scheduler"
 	stackdeployer "github.com/portainer/portainer/api/stacks"
 )
@@ -68,6 +76,8 @@ import (
 // Server implements the portainer.Server interface
 type Server struct {
 	AuthorizationService        *authorization.Service
+	AuditService                *audit.Service
+	EventBus                    *notification.EventBus
 	BindAddress                 string
 	BindAddressHTTPS            string
 	HTTPEnabled                 bool
@@ -86,6 +96,7 @@ type Server struct {
 	JWTService                  dataservices.JWTService
 	LDAPService                 portainer.LDAPService
 	OAuthService                portainer.OAuthService
+	SAMLService                 portainer.SAMLService
 	SwarmStackManager           portainer.SwarmStackManager
 	ProxyManager                *proxy.Manager
 	KubernetesTokenCacheManager *kubernetes.TokenCacheManager
@@ -96,11 +107,13 @@ type Server struct {
 	KubernetesClientFactory     *cli.ClientFactory
 	KubernetesDeployer          portainer.KubernetesDeployer
 	HelmPackageManager          libhelm.HelmPackageManager
+	HelmReleaseManager          portainer.HelmReleaseManager
 	Scheduler                   *scheduler.Scheduler
 	ShutdownCtx                 context.Context
 	ShutdownTrigger             context.CancelFunc
 	StackDeployer               stackdeployer.StackDeployer
 	DemoService                 *demo.Service
+	TrustedProxies              []string
 }
 
 // Start starts the HTTP server
@@ -110,11 +123,19 @@ func (server *Server) Start() error {
 	requestBouncer := security.NewRequestBouncer(server.DataStore, server.JWTService, server.APIKeyService)
 
 	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	err := rateLimiter.TrustProxies(server.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	loginThrottler := security.NewLoginThrottler()
 	offlineGate := offlinegate.NewOfflineGate()
 
 	passwordStrengthChecker := security.NewPasswordStrengthChecker(server.DataStore.Settings())
 
-	var authHandler = auth.NewHandler(requestBouncer, rateLimiter, passwordStrengthChecker)
+	var auditLogsHandler = auditlogs.NewHandler(requestBouncer)
+	auditLogsHandler.DataStore = server.DataStore
+
+	var authHandler = auth.NewHandler(requestBouncer, rateLimiter, loginThrottler, passwordStrengthChecker)
 	authHandler.DataStore = server.DataStore
 	authHandler.CryptoService = server.CryptoService
 	authHandler.JWTService = server.JWTService
@@ -122,10 +143,23 @@ func (server *Server) Start() error {
 	authHandler.ProxyManager = server.ProxyManager
 	authHandler.KubernetesTokenCacheManager = kubernetesTokenCacheManager
 	authHandler.OAuthService = server.OAuthService
+	authHandler.SAMLService = server.SAMLService
 
 	adminMonitor := adminmonitor.New(5*time.Minute, server.DataStore, server.ShutdownCtx)
 	adminMonitor.Start()
 
+	backupScheduler := operations.NewScheduler(server.Scheduler, server.DataStore, offlineGate, server.FileService.GetDatastorePath())
+	err = backupScheduler.Start()
+	if err != nil {
+		log.Printf("[ERROR] [http,backup] [message: unable to schedule the automatic backups] [err: %s]", err)
+	}
+
+	ldapSynchronizer := ldapsync.NewSynchronizer(server.Scheduler, server.DataStore, server.LDAPService)
+	err = ldapSynchronizer.Start()
+	if err != nil {
+		log.Printf("[ERROR] [http,ldap] [message: unable to schedule the LDAP group synchronization] [err: %s]", err)
+	}
+
 	var backupHandler = backup.NewHandler(
 		requestBouncer,
 		server.DataStore,
@@ -134,10 +168,12 @@ func (server *Server) Start() error {
 		server.ShutdownTrigger,
 		adminMonitor,
 		server.DemoService,
+		backupScheduler,
 	)
 
 	var roleHandler = roles.NewHandler(requestBouncer)
 	roleHandler.DataStore = server.DataStore
+	roleHandler.AuthorizationService = server.AuthorizationService
 
 	var customTemplatesHandler = customtemplates.NewHandler(requestBouncer)
 	customTemplatesHandler.DataStore = server.DataStore
@@ -156,6 +192,7 @@ func (server *Server) Start() error {
 	edgeStacksHandler.FileService = server.FileService
 	edgeStacksHandler.GitService = server.GitService
 	edgeStacksHandler.KubernetesDeployer = server.KubernetesDeployer
+	edgeStacksHandler.EventPublisher = server.EventBus
 
 	var edgeTemplatesHandler = edgetemplates.NewHandler(requestBouncer)
 	edgeTemplatesHandler.DataStore = server.DataStore
@@ -189,17 +226,30 @@ func (server *Server) Start() error {
 
 	var fileHandler = file.NewHandler(filepath.Join(server.AssetsPath, "public"), adminMonitor.WasInstanceDisabled)
 
-	var endpointHelmHandler = helm.NewHandler(requestBouncer, server.DataStore, server.JWTService, server.KubernetesDeployer, server.HelmPackageManager, server.KubeClusterAccessService)
+	var endpointHelmHandler = helm.NewHandler(requestBouncer, server.DataStore, server.JWTService, server.KubernetesDeployer, server.HelmPackageManager, server.HelmReleaseManager, server.KubeClusterAccessService)
+
+	var gitCredentialsHandler = gitcredentials.NewHandler(requestBouncer)
+	gitCredentialsHandler.DataStore = server.DataStore
 
-	var helmTemplatesHandler = helm.NewTemplateHandler(requestBouncer, server.HelmPackageManager)
+	var gitOpsHandler = gitops.NewHandler(requestBouncer)
+	gitOpsHandler.DataStore = server.DataStore
+	gitOpsHandler.GitService = server.GitService
+
+	var helmTemplatesHandler = helm.NewTemplateHandler(requestBouncer, server.DataStore, server.HelmPackageManager)
+
+	var helmRepositoriesHandler = helm.NewRepositoriesHandler(requestBouncer, server.DataStore)
 
 	var ldapHandler = ldap.NewHandler(requestBouncer)
 	ldapHandler.DataStore = server.DataStore
 	ldapHandler.FileService = server.FileService
 	ldapHandler.LDAPService = server.LDAPService
+	ldapHandler.Synchronizer = ldapSynchronizer
 
 	var motdHandler = motd.NewHandler(requestBouncer)
 
+	var notificationsHandler = notifications.NewHandler(requestBouncer)
+	notificationsHandler.DataStore = server.DataStore
+
 	var registryHandler = registries.NewHandler(requestBouncer)
 	registryHandler.DataStore = server.DataStore
 	registryHandler.FileService = server.FileService
@@ -215,6 +265,8 @@ func (server *Server) Start() error {
 	settingsHandler.JWTService = server.JWTService
 	settingsHandler.LDAPService = server.LDAPService
 	settingsHandler.SnapshotService = server.SnapshotService
+	settingsHandler.BackupScheduler = backupScheduler
+	settingsHandler.LDAPSynchronizer = ldapSynchronizer
 
 	var sslHandler = sslhandler.NewHandler(requestBouncer)
 	sslHandler.SSLService = server.SSLService
@@ -237,6 +289,7 @@ func (server *Server) Start() error {
 	stackHandler.SwarmStackManager = server.SwarmStackManager
 	stackHandler.ComposeStackManager = server.ComposeStackManager
 	stackHandler.StackDeployer = server.StackDeployer
+	stackHandler.EventPublisher = server.EventBus
 
 	var storybookHandler = storybook.NewHandler(server.AssetsPath)
 
@@ -262,6 +315,7 @@ func (server *Server) Start() error {
 	var userHandler = users.NewHandler(requestBouncer, rateLimiter, server.APIKeyService, server.DemoService, passwordStrengthChecker)
 	userHandler.DataStore = server.DataStore
 	userHandler.CryptoService = server.CryptoService
+	userHandler.JWTService = server.JWTService
 
 	var websocketHandler = websocket.NewHandler(server.KubernetesTokenCacheManager, requestBouncer)
 	websocketHandler.DataStore = server.DataStore
@@ -274,45 +328,50 @@ func (server *Server) Start() error {
 	webhookHandler.DockerClientFactory = server.DockerClientFactory
 
 	server.Handler = &handler.Handler{
-		RoleHandler:            roleHandler,
-		AuthHandler:            authHandler,
-		BackupHandler:          backupHandler,
-		CustomTemplatesHandler: customTemplatesHandler,
-		DockerHandler:          dockerHandler,
-		EdgeGroupsHandler:      edgeGroupsHandler,
-		EdgeJobsHandler:        edgeJobsHandler,
-		EdgeStacksHandler:      edgeStacksHandler,
-		EdgeTemplatesHandler:   edgeTemplatesHandler,
-		EndpointGroupHandler:   endpointGroupHandler,
-		EndpointHandler:        endpointHandler,
-		EndpointHelmHandler:    endpointHelmHandler,
-		EndpointEdgeHandler:    endpointEdgeHandler,
-		EndpointProxyHandler:   endpointProxyHandler,
-		FileHandler:            fileHandler,
-		LDAPHandler:            ldapHandler,
-		HelmTemplatesHandler:   helmTemplatesHandler,
-		KubernetesHandler:      kubernetesHandler,
-		MOTDHandler:            motdHandler,
-		OpenAMTHandler:         openAMTHandler,
-		FDOHandler:             fdoHandler,
-		RegistryHandler:        registryHandler,
-		ResourceControlHandler: resourceControlHandler,
-		SettingsHandler:        settingsHandler,
-		SSLHandler:             sslHandler,
-		StatusHandler:          statusHandler,
-		StackHandler:           stackHandler,
-		StorybookHandler:       storybookHandler,
-		TagHandler:             tagHandler,
-		TeamHandler:            teamHandler,
-		TeamMembershipHandler:  teamMembershipHandler,
-		TemplatesHandler:       templatesHandler,
-		UploadHandler:          uploadHandler,
-		UserHandler:            userHandler,
-		WebSocketHandler:       websocketHandler,
-		WebhookHandler:         webhookHandler,
+		RoleHandler:             roleHandler,
+		AuditLogsHandler:        auditLogsHandler,
+		AuthHandler:             authHandler,
+		BackupHandler:           backupHandler,
+		CustomTemplatesHandler:  customTemplatesHandler,
+		DockerHandler:           dockerHandler,
+		EdgeGroupsHandler:       edgeGroupsHandler,
+		EdgeJobsHandler:         edgeJobsHandler,
+		EdgeStacksHandler:       edgeStacksHandler,
+		EdgeTemplatesHandler:    edgeTemplatesHandler,
+		EndpointGroupHandler:    endpointGroupHandler,
+		EndpointHandler:         endpointHandler,
+		EndpointHelmHandler:     endpointHelmHandler,
+		EndpointEdgeHandler:     endpointEdgeHandler,
+		EndpointProxyHandler:    endpointProxyHandler,
+		FileHandler:             fileHandler,
+		GitCredentialsHandler:   gitCredentialsHandler,
+		GitOpsHandler:           gitOpsHandler,
+		LDAPHandler:             ldapHandler,
+		HelmTemplatesHandler:    helmTemplatesHandler,
+		HelmRepositoriesHandler: helmRepositoriesHandler,
+		KubernetesHandler:       kubernetesHandler,
+		MOTDHandler:             motdHandler,
+		NotificationsHandler:    notificationsHandler,
+		OpenAMTHandler:          openAMTHandler,
+		FDOHandler:              fdoHandler,
+		RegistryHandler:         registryHandler,
+		ResourceControlHandler:  resourceControlHandler,
+		SettingsHandler:         settingsHandler,
+		SSLHandler:              sslHandler,
+		StatusHandler:           statusHandler,
+		StackHandler:            stackHandler,
+		StorybookHandler:        storybookHandler,
+		TagHandler:              tagHandler,
+		TeamHandler:             teamHandler,
+		TeamMembershipHandler:   teamMembershipHandler,
+		TemplatesHandler:        templatesHandler,
+		UploadHandler:           uploadHandler,
+		UserHandler:             userHandler,
+		WebSocketHandler:        websocketHandler,
+		WebhookHandler:          webhookHandler,
 	}
 
-	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, server.Handler))
+	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, server.AuditService.Middleware(server.Handler)))
 	if server.HTTPEnabled {
 		go func() {
 			log.Printf("[INFO] [http,server] [message: starting HTTP server on port %s]", server.BindAddress)
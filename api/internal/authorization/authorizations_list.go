@@ -0,0 +1,220 @@
+package authorization
+
+import portainer "github.com/portainer/portainer/api"
+
+// AllAuthorizations returns all the authorizations that can be associated to a role, deprecated operations excluded.
+func AllAuthorizations() portainer.Authorizations {
+	return map[portainer.Authorization]bool{
+		portainer.OperationDockerContainerArchiveInfo:         true,
+		portainer.OperationDockerContainerList:                true,
+		portainer.OperationDockerContainerExport:              true,
+		portainer.OperationDockerContainerChanges:             true,
+		portainer.OperationDockerContainerInspect:             true,
+		portainer.OperationDockerContainerTop:                 true,
+		portainer.OperationDockerContainerLogs:                true,
+		portainer.OperationDockerContainerStats:               true,
+		portainer.OperationDockerContainerAttachWebsocket:     true,
+		portainer.OperationDockerContainerArchive:             true,
+		portainer.OperationDockerContainerCreate:              true,
+		portainer.OperationDockerContainerPrune:               true,
+		portainer.OperationDockerContainerKill:                true,
+		portainer.OperationDockerContainerPause:               true,
+		portainer.OperationDockerContainerUnpause:             true,
+		portainer.OperationDockerContainerRestart:             true,
+		portainer.OperationDockerContainerStart:               true,
+		portainer.OperationDockerContainerStop:                true,
+		portainer.OperationDockerContainerWait:                true,
+		portainer.OperationDockerContainerResize:              true,
+		portainer.OperationDockerContainerAttach:              true,
+		portainer.OperationDockerContainerExec:                true,
+		portainer.OperationDockerContainerRename:              true,
+		portainer.OperationDockerContainerUpdate:              true,
+		portainer.OperationDockerContainerPutContainerArchive: true,
+		portainer.OperationDockerContainerDelete:              true,
+		portainer.OperationDockerImageList:                    true,
+		portainer.OperationDockerImageSearch:                  true,
+		portainer.OperationDockerImageGetAll:                  true,
+		portainer.OperationDockerImageGet:                     true,
+		portainer.OperationDockerImageHistory:                 true,
+		portainer.OperationDockerImageInspect:                 true,
+		portainer.OperationDockerImageLoad:                    true,
+		portainer.OperationDockerImageCreate:                  true,
+		portainer.OperationDockerImagePrune:                   true,
+		portainer.OperationDockerImagePush:                    true,
+		portainer.OperationDockerImageTag:                     true,
+		portainer.OperationDockerImageDelete:                  true,
+		portainer.OperationDockerImageCommit:                  true,
+		portainer.OperationDockerImageBuild:                   true,
+		portainer.OperationDockerNetworkList:                  true,
+		portainer.OperationDockerNetworkInspect:               true,
+		portainer.OperationDockerNetworkCreate:                true,
+		portainer.OperationDockerNetworkConnect:               true,
+		portainer.OperationDockerNetworkDisconnect:            true,
+		portainer.OperationDockerNetworkPrune:                 true,
+		portainer.OperationDockerNetworkDelete:                true,
+		portainer.OperationDockerVolumeList:                   true,
+		portainer.OperationDockerVolumeInspect:                true,
+		portainer.OperationDockerVolumeCreate:                 true,
+		portainer.OperationDockerVolumePrune:                  true,
+		portainer.OperationDockerVolumeDelete:                 true,
+		portainer.OperationDockerExecInspect:                  true,
+		portainer.OperationDockerExecStart:                    true,
+		portainer.OperationDockerExecResize:                   true,
+		portainer.OperationDockerSwarmInspect:                 true,
+		portainer.OperationDockerSwarmUnlockKey:               true,
+		portainer.OperationDockerSwarmInit:                    true,
+		portainer.OperationDockerSwarmJoin:                    true,
+		portainer.OperationDockerSwarmLeave:                   true,
+		portainer.OperationDockerSwarmUpdate:                  true,
+		portainer.OperationDockerSwarmUnlock:                  true,
+		portainer.OperationDockerNodeList:                     true,
+		portainer.OperationDockerNodeInspect:                  true,
+		portainer.OperationDockerNodeUpdate:                   true,
+		portainer.OperationDockerNodeDelete:                   true,
+		portainer.OperationDockerServiceList:                  true,
+		portainer.OperationDockerServiceInspect:               true,
+		portainer.OperationDockerServiceLogs:                  true,
+		portainer.OperationDockerServiceCreate:                true,
+		portainer.OperationDockerServiceUpdate:                true,
+		portainer.OperationDockerServiceDelete:                true,
+		portainer.OperationDockerSecretList:                   true,
+		portainer.OperationDockerSecretInspect:                true,
+		portainer.OperationDockerSecretCreate:                 true,
+		portainer.OperationDockerSecretUpdate:                 true,
+		portainer.OperationDockerSecretDelete:                 true,
+		portainer.OperationDockerConfigList:                   true,
+		portainer.OperationDockerConfigInspect:                true,
+		portainer.OperationDockerConfigCreate:                 true,
+		portainer.OperationDockerConfigUpdate:                 true,
+		portainer.OperationDockerConfigDelete:                 true,
+		portainer.OperationDockerTaskList:                     true,
+		portainer.OperationDockerTaskInspect:                  true,
+		portainer.OperationDockerTaskLogs:                     true,
+		portainer.OperationDockerPluginList:                   true,
+		portainer.OperationDockerPluginPrivileges:             true,
+		portainer.OperationDockerPluginInspect:                true,
+		portainer.OperationDockerPluginPull:                   true,
+		portainer.OperationDockerPluginCreate:                 true,
+		portainer.OperationDockerPluginEnable:                 true,
+		portainer.OperationDockerPluginDisable:                true,
+		portainer.OperationDockerPluginPush:                   true,
+		portainer.OperationDockerPluginUpgrade:                true,
+		portainer.OperationDockerPluginSet:                    true,
+		portainer.OperationDockerPluginDelete:                 true,
+		portainer.OperationDockerSessionStart:                 true,
+		portainer.OperationDockerDistributionInspect:          true,
+		portainer.OperationDockerBuildPrune:                   true,
+		portainer.OperationDockerBuildCancel:                  true,
+		portainer.OperationDockerPing:                         true,
+		portainer.OperationDockerInfo:                         true,
+		portainer.OperationDockerEvents:                       true,
+		portainer.OperationDockerSystem:                       true,
+		portainer.OperationDockerVersion:                      true,
+		portainer.OperationDockerAgentPing:                    true,
+		portainer.OperationDockerAgentList:                    true,
+		portainer.OperationDockerAgentHostInfo:                true,
+		portainer.OperationDockerAgentBrowseDelete:            true,
+		portainer.OperationDockerAgentBrowseGet:               true,
+		portainer.OperationDockerAgentBrowseList:              true,
+		portainer.OperationDockerAgentBrowsePut:               true,
+		portainer.OperationDockerAgentBrowseRename:            true,
+		portainer.OperationPortainerDockerHubInspect:          true,
+		portainer.OperationPortainerDockerHubUpdate:           true,
+		portainer.OperationPortainerEndpointGroupCreate:       true,
+		portainer.OperationPortainerEndpointGroupList:         true,
+		portainer.OperationPortainerEndpointGroupDelete:       true,
+		portainer.OperationPortainerEndpointGroupInspect:      true,
+		portainer.OperationPortainerEndpointGroupUpdate:       true,
+		portainer.OperationPortainerEndpointGroupAccess:       true,
+		portainer.OperationPortainerEndpointList:              true,
+		portainer.OperationPortainerEndpointInspect:           true,
+		portainer.OperationPortainerEndpointCreate:            true,
+		portainer.OperationPortainerEndpointJob:               true,
+		portainer.OperationPortainerEndpointSnapshots:         true,
+		portainer.OperationPortainerEndpointSnapshot:          true,
+		portainer.OperationPortainerEndpointUpdate:            true,
+		portainer.OperationPortainerEndpointUpdateAccess:      true,
+		portainer.OperationPortainerEndpointDelete:            true,
+		portainer.OperationPortainerExtensionList:             true,
+		portainer.OperationPortainerExtensionInspect:          true,
+		portainer.OperationPortainerExtensionCreate:           true,
+		portainer.OperationPortainerExtensionUpdate:           true,
+		portainer.OperationPortainerExtensionDelete:           true,
+		portainer.OperationPortainerMOTD:                      true,
+		portainer.OperationPortainerRegistryList:              true,
+		portainer.OperationPortainerRegistryInspect:           true,
+		portainer.OperationPortainerRegistryCreate:            true,
+		portainer.OperationPortainerRegistryConfigure:         true,
+		portainer.OperationPortainerRegistryUpdate:            true,
+		portainer.OperationPortainerRegistryUpdateAccess:      true,
+		portainer.OperationPortainerRegistryDelete:            true,
+		portainer.OperationPortainerResourceControlCreate:     true,
+		portainer.OperationPortainerResourceControlUpdate:     true,
+		portainer.OperationPortainerResourceControlDelete:     true,
+		portainer.OperationPortainerRoleList:                  true,
+		portainer.OperationPortainerRoleInspect:               true,
+		portainer.OperationPortainerRoleCreate:                true,
+		portainer.OperationPortainerRoleUpdate:                true,
+		portainer.OperationPortainerRoleDelete:                true,
+		portainer.OperationPortainerScheduleList:              true,
+		portainer.OperationPortainerScheduleInspect:           true,
+		portainer.OperationPortainerScheduleFile:              true,
+		portainer.OperationPortainerScheduleTasks:             true,
+		portainer.OperationPortainerScheduleCreate:            true,
+		portainer.OperationPortainerScheduleUpdate:            true,
+		portainer.OperationPortainerScheduleDelete:            true,
+		portainer.OperationPortainerSettingsInspect:           true,
+		portainer.OperationPortainerSettingsUpdate:            true,
+		portainer.OperationPortainerSettingsLDAPCheck:         true,
+		portainer.OperationPortainerStackList:                 true,
+		portainer.OperationPortainerStackInspect:              true,
+		portainer.OperationPortainerStackFile:                 true,
+		portainer.OperationPortainerStackCreate:               true,
+		portainer.OperationPortainerStackMigrate:              true,
+		portainer.OperationPortainerStackUpdate:               true,
+		portainer.OperationPortainerStackDelete:               true,
+		portainer.OperationPortainerTagList:                   true,
+		portainer.OperationPortainerTagCreate:                 true,
+		portainer.OperationPortainerTagDelete:                 true,
+		portainer.OperationPortainerTeamMembershipList:        true,
+		portainer.OperationPortainerTeamMembershipCreate:      true,
+		portainer.OperationPortainerTeamMembershipUpdate:      true,
+		portainer.OperationPortainerTeamMembershipDelete:      true,
+		portainer.OperationPortainerTeamList:                  true,
+		portainer.OperationPortainerTeamInspect:               true,
+		portainer.OperationPortainerTeamMemberships:           true,
+		portainer.OperationPortainerTeamCreate:                true,
+		portainer.OperationPortainerTeamUpdate:                true,
+		portainer.OperationPortainerTeamDelete:                true,
+		portainer.OperationPortainerTemplateList:              true,
+		portainer.OperationPortainerTemplateInspect:           true,
+		portainer.OperationPortainerTemplateCreate:            true,
+		portainer.OperationPortainerTemplateUpdate:            true,
+		portainer.OperationPortainerTemplateDelete:            true,
+		portainer.OperationPortainerUploadTLS:                 true,
+		portainer.OperationPortainerUserList:                  true,
+		portainer.OperationPortainerUserInspect:               true,
+		portainer.OperationPortainerUserMemberships:           true,
+		portainer.OperationPortainerUserCreate:                true,
+		portainer.OperationPortainerUserListToken:             true,
+		portainer.OperationPortainerUserCreateToken:           true,
+		portainer.OperationPortainerUserRevokeToken:           true,
+		portainer.OperationPortainerUserUpdate:                true,
+		portainer.OperationPortainerUserUpdatePassword:        true,
+		portainer.OperationPortainerUserDelete:                true,
+		portainer.OperationPortainerWebsocketExec:             true,
+		portainer.OperationPortainerWebhookList:               true,
+		portainer.OperationPortainerWebhookCreate:             true,
+		portainer.OperationPortainerWebhookDelete:             true,
+		portainer.OperationDockerUndefined:                    true,
+		portainer.OperationDockerAgentUndefined:               true,
+		portainer.OperationPortainerUndefined:                 true,
+		portainer.EndpointResourcesAccess:                     true,
+	}
+}
+
+// IsValidAuthorization returns true when an authorization can be associated to a role
+func IsValidAuthorization(authorization portainer.Authorization) bool {
+	_, ok := AllAuthorizations()[authorization]
+	return ok
+}
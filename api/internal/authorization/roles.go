@@ -0,0 +1,81 @@
+package authorization
+
+import (
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ValidateAccessPolicies verifies that the roles referenced by access policies exist.
+// A policy without role is valid, it grants access to the environment(endpoint) without authorizations.
+func (service *Service) ValidateAccessPolicies(userAccessPolicies portainer.UserAccessPolicies, teamAccessPolicies portainer.TeamAccessPolicies) error {
+	roles, err := service.dataStore.Role().Roles()
+	if err != nil {
+		return err
+	}
+
+	roleExists := func(roleID portainer.RoleID) bool {
+		if roleID == 0 {
+			return true
+		}
+		for _, role := range roles {
+			if role.ID == roleID {
+				return true
+			}
+		}
+		return false
+	}
+
+	for userID, policy := range userAccessPolicies {
+		if !roleExists(policy.RoleID) {
+			return fmt.Errorf("unknown role %d in the access policy of the user %d", policy.RoleID, userID)
+		}
+	}
+	for teamID, policy := range teamAccessPolicies {
+		if !roleExists(policy.RoleID) {
+			return fmt.Errorf("unknown role %d in the access policy of the team %d", policy.RoleID, teamID)
+		}
+	}
+
+	return nil
+}
+
+// RoleInUse returns true when a role is referenced by the access policies of an environment(endpoint) or of an environment(endpoint) group.
+func (service *Service) RoleInUse(roleID portainer.RoleID) (bool, error) {
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return false, err
+	}
+	for _, endpoint := range endpoints {
+		if policiesUseRole(endpoint.UserAccessPolicies, endpoint.TeamAccessPolicies, roleID) {
+			return true, nil
+		}
+	}
+
+	endpointGroups, err := service.dataStore.EndpointGroup().EndpointGroups()
+	if err != nil {
+		return false, err
+	}
+	for _, endpointGroup := range endpointGroups {
+		if policiesUseRole(endpointGroup.UserAccessPolicies, endpointGroup.TeamAccessPolicies, roleID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func policiesUseRole(userAccessPolicies portainer.UserAccessPolicies, teamAccessPolicies portainer.TeamAccessPolicies, roleID portainer.RoleID) bool {
+	for _, policy := range userAccessPolicies {
+		if policy.RoleID == roleID {
+			return true
+		}
+	}
+	for _, policy := range teamAccessPolicies {
+		if policy.RoleID == roleID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/portainer/portainer/api/agent"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/notification/events"
 )
 
 // Service repesents a service to manage environment(endpoint) snapshots.
@@ -22,11 +23,12 @@ type Service struct {
 	snapshotIntervalInSeconds float64
 	dockerSnapshotter         portainer.DockerSnapshotter
 	kubernetesSnapshotter     portainer.KubernetesSnapshotter
+	eventPublisher            portainer.NotificationEventPublisher
 	shutdownCtx               context.Context
 }
 
 // NewService creates a new instance of a service
-func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStore, dockerSnapshotter portainer.DockerSnapshotter, kubernetesSnapshotter portainer.KubernetesSnapshotter, shutdownCtx context.Context) (*Service, error) {
+func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStore, dockerSnapshotter portainer.DockerSnapshotter, kubernetesSnapshotter portainer.KubernetesSnapshotter, eventPublisher portainer.NotificationEventPublisher, shutdownCtx context.Context) (*Service, error) {
 	interval, err := parseSnapshotFrequency(snapshotIntervalFromFlag, dataStore)
 	if err != nil {
 		return nil, err
@@ -38,6 +40,7 @@ func NewService(snapshotIntervalFromFlag string, dataStore dataservices.DataStor
 		snapshotIntervalInSeconds: interval,
 		dockerSnapshotter:         dockerSnapshotter,
 		kubernetesSnapshotter:     kubernetesSnapshotter,
+		eventPublisher:            eventPublisher,
 		shutdownCtx:               shutdownCtx,
 	}, nil
 }
@@ -188,10 +191,16 @@ func (service *Service) snapshotEndpoints() error {
 			continue
 		}
 
+		previousStatus := latestEndpointReference.Status
 		latestEndpointReference.Status = portainer.EndpointStatusUp
 		if snapshotError != nil {
 			log.Printf("background schedule error (environment snapshot). Unable to create snapshot (endpoint=%s, URL=%s) (err=%s)\n", endpoint.Name, endpoint.URL, snapshotError)
 			latestEndpointReference.Status = portainer.EndpointStatusDown
+
+			// only notify when the environment goes down, not at every failed snapshot
+			if previousStatus != portainer.EndpointStatusDown && service.eventPublisher != nil {
+				service.eventPublisher.Publish(events.EndpointDown(latestEndpointReference, snapshotError))
+			}
 		}
 
 		latestEndpointReference.Snapshots = endpoint.Snapshots
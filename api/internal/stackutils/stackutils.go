@@ -35,6 +35,23 @@ func CreateTempK8SDeploymentFiles(stack *portainer.Stack, kubeDeployer portainer
 		return nil, "", errors.Wrap(err, "failed to create temp kub deployment directory")
 	}
 
+	if IsRenderedKubernetesStack(stack) {
+		manifestContent, err := RenderKubernetesManifest(stack, kubeDeployer)
+		if err != nil {
+			return nil, "", err
+		}
+		manifestContent, err = k.AddAppLabels(manifestContent, appLabels.ToMap())
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to add application labels")
+		}
+		manifestFilePath := filesystem.JoinPaths(tmpDir, filesystem.ManifestFileDefaultName)
+		err = filesystem.WriteToFile(manifestFilePath, manifestContent)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to create temp manifest file")
+		}
+		return []string{manifestFilePath}, tmpDir, nil
+	}
+
 	for _, fileName := range fileNames {
 		manifestFilePath := filesystem.JoinPaths(tmpDir, fileName)
 		manifestContent, err := ioutil.ReadFile(filesystem.JoinPaths(stack.ProjectPath, fileName))
@@ -59,3 +76,31 @@ func CreateTempK8SDeploymentFiles(stack *portainer.Stack, kubeDeployer portainer
 	}
 	return manifestFilePaths, tmpDir, nil
 }
+
+// IsRenderedKubernetesStack returns true when the manifest of a Kubernetes stack is rendered
+// from a Kustomize or a Helm chart directory
+func IsRenderedKubernetesStack(stack *portainer.Stack) bool {
+	return stack.Type == portainer.KubernetesStack &&
+		(stack.KubernetesFormat == portainer.KubernetesStackKustomize || stack.KubernetesFormat == portainer.KubernetesStackHelmChart)
+}
+
+// RenderKubernetesManifest renders the manifest of a Kustomize or a Helm chart stack from the files of its project path.
+// A Helm chart is rendered with the stack name as release name and the additional files of the stack as values files.
+func RenderKubernetesManifest(stack *portainer.Stack, kubeDeployer portainer.KubernetesDeployer) ([]byte, error) {
+	directory := filesystem.JoinPaths(stack.ProjectPath, stack.EntryPoint)
+
+	switch stack.KubernetesFormat {
+	case portainer.KubernetesStackKustomize:
+		manifest, err := kubeDeployer.RenderKustomize(directory)
+		return manifest, errors.WithMessage(err, "failed to render the Kustomize directory")
+	case portainer.KubernetesStackHelmChart:
+		valuesFiles := make([]string, 0, len(stack.AdditionalFiles))
+		for _, valuesFile := range stack.AdditionalFiles {
+			valuesFiles = append(valuesFiles, filesystem.JoinPaths(stack.ProjectPath, valuesFile))
+		}
+		manifest, err := kubeDeployer.RenderHelmChart(stack.Name, stack.Namespace, directory, valuesFiles)
+		return manifest, errors.WithMessage(err, "failed to render the Helm chart")
+	}
+
+	return nil, errors.Errorf("unsupported Kubernetes stack format %q", stack.KubernetesFormat)
+}
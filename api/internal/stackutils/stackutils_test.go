@@ -1,9 +1,11 @@
 package stackutils
 
 import (
+	"os"
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	k "github.com/portainer/portainer/api/kubernetes"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,3 +26,50 @@ func Test_GetStackFilePaths(t *testing.T) {
 		assert.ElementsMatch(t, expected, GetStackFilePaths(stack))
 	})
 }
+
+// helmDeployer renders a fixed manifest and records the arguments of the rendering
+type helmDeployer struct {
+	portainer.KubernetesDeployer
+	releaseName    string
+	namespace      string
+	chartDirectory string
+	valuesFiles    []string
+}
+
+func (deployer *helmDeployer) RenderHelmChart(releaseName, namespace, chartDirectory string, valuesFiles []string) ([]byte, error) {
+	deployer.releaseName = releaseName
+	deployer.namespace = namespace
+	deployer.chartDirectory = chartDirectory
+	deployer.valuesFiles = valuesFiles
+	return []byte("---\n# Source: app/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: app\n"), nil
+}
+
+func Test_CreateTempK8SDeploymentFiles_helmChart(t *testing.T) {
+	is := assert.New(t)
+
+	stack := &portainer.Stack{
+		ID:               1,
+		Name:             "app",
+		Type:             portainer.KubernetesStack,
+		KubernetesFormat: portainer.KubernetesStackHelmChart,
+		Namespace:        "production",
+		ProjectPath:      "/tmp/stack/1",
+		EntryPoint:       "charts/app",
+		AdditionalFiles:  []string{"values/production.yaml"},
+	}
+	deployer := &helmDeployer{}
+
+	manifestFilePaths, tempDir, err := CreateTempK8SDeploymentFiles(stack, deployer, k.KubeAppLabels{StackID: 1, StackName: "app", Owner: "admin", Kind: "git"})
+	is.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	is.Equal("app", deployer.releaseName)
+	is.Equal("production", deployer.namespace)
+	is.Equal("/tmp/stack/1/charts/app", deployer.chartDirectory)
+	is.Equal([]string{"/tmp/stack/1/values/production.yaml"}, deployer.valuesFiles)
+
+	is.Len(manifestFilePaths, 1)
+	manifest, err := os.ReadFile(manifestFilePaths[0])
+	is.NoError(err)
+	is.Contains(string(manifest), "io.portainer.kubernetes.application.stack: app", "the rendered resources should be labeled")
+}
@@ -9,6 +9,7 @@ import (
 )
 
 type testDatastore struct {
+	auditLog                dataservices.AuditLogService
 	customTemplate          dataservices.CustomTemplateService
 	edgeGroup               dataservices.EdgeGroupService
 	edgeJob                 dataservices.EdgeJobService
@@ -17,7 +18,11 @@ type testDatastore struct {
 	endpointGroup           dataservices.EndpointGroupService
 	endpointRelation        dataservices.EndpointRelationService
 	fdoProfile              dataservices.FDOProfileService
+	gitCredential           dataservices.GitCredentialService
+	helmRepository          dataservices.HelmRepositoryService
 	helmUserRepository      dataservices.HelmUserRepositoryService
+	notificationChannel     dataservices.NotificationChannelService
+	notificationDelivery    dataservices.NotificationDeliveryService
 	registry                dataservices.RegistryService
 	resourceControl         dataservices.ResourceControlService
 	apiKeyRepositoryService dataservices.APIKeyRepository
@@ -25,6 +30,7 @@ type testDatastore struct {
 	sslSettings             dataservices.SSLSettingsService
 	settings                dataservices.SettingsService
 	stack                   dataservices.StackService
+	stackVersion            dataservices.StackVersionService
 	tag                     dataservices.TagService
 	teamMembership          dataservices.TeamMembershipService
 	team                    dataservices.TeamService
@@ -41,6 +47,7 @@ func (d *testDatastore) Close() error                                       { re
 func (d *testDatastore) CheckCurrentEdition() error                         { return nil }
 func (d *testDatastore) MigrateData() error                                 { return nil }
 func (d *testDatastore) Rollback(force bool) error                          { return nil }
+func (d *testDatastore) AuditLog() dataservices.AuditLogService             { return d.auditLog }
 func (d *testDatastore) CustomTemplate() dataservices.CustomTemplateService { return d.customTemplate }
 func (d *testDatastore) EdgeGroup() dataservices.EdgeGroupService           { return d.edgeGroup }
 func (d *testDatastore) EdgeJob() dataservices.EdgeJobService               { return d.edgeJob }
@@ -50,12 +57,24 @@ func (d *testDatastore) EndpointGroup() dataservices.EndpointGroupService   { re
 func (d *testDatastore) FDOProfile() dataservices.FDOProfileService {
 	return d.fdoProfile
 }
+func (d *testDatastore) GitCredential() dataservices.GitCredentialService {
+	return d.gitCredential
+}
 func (d *testDatastore) EndpointRelation() dataservices.EndpointRelationService {
 	return d.endpointRelation
 }
+func (d *testDatastore) HelmRepository() dataservices.HelmRepositoryService {
+	return d.helmRepository
+}
 func (d *testDatastore) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return d.helmUserRepository
 }
+func (d *testDatastore) NotificationChannel() dataservices.NotificationChannelService {
+	return d.notificationChannel
+}
+func (d *testDatastore) NotificationDelivery() dataservices.NotificationDeliveryService {
+	return d.notificationDelivery
+}
 func (d *testDatastore) Registry() dataservices.RegistryService { return d.registry }
 func (d *testDatastore) ResourceControl() dataservices.ResourceControlService {
 	return d.resourceControl
@@ -67,6 +86,7 @@ func (d *testDatastore) APIKeyRepository() dataservices.APIKeyRepository {
 func (d *testDatastore) Settings() dataservices.SettingsService             { return d.settings }
 func (d *testDatastore) SSLSettings() dataservices.SSLSettingsService       { return d.sslSettings }
 func (d *testDatastore) Stack() dataservices.StackService                   { return d.stack }
+func (d *testDatastore) StackVersion() dataservices.StackVersionService     { return d.stackVersion }
 func (d *testDatastore) Tag() dataservices.TagService                       { return d.tag }
 func (d *testDatastore) TeamMembership() dataservices.TeamMembershipService { return d.teamMembership }
 func (d *testDatastore) Team() dataservices.TeamService                     { return d.team }
@@ -1,5 +1,7 @@
 package testhelpers
 
+import gittypes "github.com/portainer/portainer/api/git/types"
+
 type gitService struct{}
 
 // NewGitService creates new mock for portainer.GitService.
@@ -7,6 +9,6 @@ func NewGitService() *gitService {
 	return &gitService{}
 }
 
-func (service *gitService) CloneRepository(destination string, repositoryURL, referenceName string, username, password string) error {
+func (service *gitService) CloneRepository(destination string, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOptions *gittypes.CloneOptions) error {
 	return nil
 }
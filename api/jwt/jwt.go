@@ -21,14 +21,17 @@ type Service struct {
 	secrets            map[scope][]byte
 	userSessionTimeout time.Duration
 	dataStore          dataservices.DataStore
+	sessions           *sessionRegistry
 }
 
 type claims struct {
-	UserID              int    `json:"id"`
-	Username            string `json:"username"`
-	Role                int    `json:"role"`
-	Scope               scope  `json:"scope"`
-	ForceChangePassword bool   `json:"forceChangePassword"`
+	UserID                 int    `json:"id"`
+	Username               string `json:"username"`
+	Role                   int    `json:"role"`
+	Scope                  scope  `json:"scope"`
+	ForceChangePassword    bool   `json:"forceChangePassword"`
+	PasswordChangeRequired bool   `json:"passwordChangeRequired"`
+	SessionID              string `json:"sessionId,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -66,6 +69,7 @@ func NewService(userSessionDuration string, dataStore dataservices.DataStore) (*
 		},
 		userSessionTimeout,
 		dataStore,
+		newSessionRegistry(),
 	}
 	return service, nil
 }
@@ -127,15 +131,23 @@ func (service *Service) ParseAndVerifyToken(token string) (*portainer.TokenData,
 
 			user, err := service.dataStore.User().User(portainer.UserID(cl.UserID))
 			if err != nil {
+				service.sessions.revoke(portainer.UserID(cl.UserID), cl.SessionID)
 				return nil, errInvalidJWTToken
 			}
 			if user.TokenIssueAt > cl.StandardClaims.IssuedAt {
+				service.sessions.revoke(user.ID, cl.SessionID)
+				return nil, errInvalidJWTToken
+			}
+			if cl.SessionID != "" && !service.sessions.touch(cl.SessionID, user.ID, time.Now()) {
 				return nil, errInvalidJWTToken
 			}
 			return &portainer.TokenData{
-				ID:       portainer.UserID(cl.UserID),
-				Username: cl.Username,
-				Role:     portainer.UserRole(cl.Role),
+				ID:                     portainer.UserID(cl.UserID),
+				Username:               cl.Username,
+				Role:                   portainer.UserRole(cl.Role),
+				ForceChangePassword:    cl.ForceChangePassword,
+				PasswordChangeRequired: cl.PasswordChangeRequired,
+				SessionID:              cl.SessionID,
 			}, nil
 		}
 	}
@@ -172,12 +184,20 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		expiresAt = time.Now().Add(time.Hour * 8760 * 99).Unix()
 	}
 
+	// the kubeconfig tokens are not bound to the session they were requested from, RevokeUserSessions revokes them
+	sessionID := data.SessionID
+	if scope != defaultScope {
+		sessionID = ""
+	}
+
 	cl := claims{
-		UserID:              int(data.ID),
-		Username:            data.Username,
-		Role:                int(data.Role),
-		Scope:               scope,
-		ForceChangePassword: data.ForceChangePassword,
+		UserID:                 int(data.ID),
+		Username:               data.Username,
+		Role:                   int(data.Role),
+		Scope:                  scope,
+		ForceChangePassword:    data.ForceChangePassword,
+		PasswordChangeRequired: data.PasswordChangeRequired,
+		SessionID:              sessionID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expiresAt,
 			IssuedAt:  time.Now().Unix(),
@@ -190,5 +210,39 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		return "", err
 	}
 
+	if sessionID != "" {
+		service.sessions.extend(sessionID, expiresAt)
+	}
+
 	return signedToken, nil
 }
+
+// CreateSession registers a new session of the user, the JWTs issued with its identifier are valid until the session
+// expires or is revoked
+func (service *Service) CreateSession(userID portainer.UserID, ipAddress, userAgent string) (*portainer.Session, error) {
+	return service.sessions.create(userID, ipAddress, userAgent, time.Now(), service.defaultExpireAt())
+}
+
+// UserSessions returns the active sessions of the user, the most recently used first
+func (service *Service) UserSessions(userID portainer.UserID) []portainer.Session {
+	return service.sessions.userSessions(userID, time.Now())
+}
+
+// RevokeSession revokes a session of the user, it returns false when the user has no such session
+func (service *Service) RevokeSession(userID portainer.UserID, sessionID string) bool {
+	return service.sessions.revoke(userID, sessionID)
+}
+
+// RevokeUserSessions revokes all the sessions of the user. The tokens which are not bound to a session, such as the
+// kubeconfig tokens, are revoked too by rejecting every token issued before now.
+func (service *Service) RevokeUserSessions(userID portainer.UserID) error {
+	service.sessions.revokeUser(userID)
+
+	user, err := service.dataStore.User().User(userID)
+	if err != nil {
+		return err
+	}
+
+	user.TokenIssueAt = time.Now().Unix()
+	return service.dataStore.User().UpdateUser(user.ID, user)
+}
@@ -0,0 +1,130 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// sessionRegistry keeps track of the sessions of the users. The registry lives in memory along with the secret
+// used to sign the JWTs, the sessions do not need to outlive the tokens issued for them.
+type sessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]*portainer.Session
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{sessions: map[string]*portainer.Session{}}
+}
+
+func (registry *sessionRegistry) create(userID portainer.UserID, ipAddress, userAgent string, now time.Time, expiresAt int64) (*portainer.Session, error) {
+	data := make([]byte, 16)
+	_, err := rand.Read(data)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &portainer.Session{
+		ID:         hex.EncodeToString(data),
+		UserID:     userID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  now.Unix(),
+		LastSeenAt: now.Unix(),
+		ExpiresAt:  expiresAt,
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.removeExpired(now)
+	registry.sessions[session.ID] = session
+
+	created := *session
+	return &created, nil
+}
+
+// extend pushes the expiry of the session to the expiry of a JWT issued for it
+func (registry *sessionRegistry) extend(sessionID string, expiresAt int64) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	session, ok := registry.sessions[sessionID]
+	if ok && expiresAt > session.ExpiresAt {
+		session.ExpiresAt = expiresAt
+	}
+}
+
+// touch records a request authenticated with the session, it returns false when the session does not exist,
+// was revoked or does not belong to the user
+func (registry *sessionRegistry) touch(sessionID string, userID portainer.UserID, now time.Time) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	session, ok := registry.sessions[sessionID]
+	if !ok || session.UserID != userID {
+		return false
+	}
+
+	session.LastSeenAt = now.Unix()
+	return true
+}
+
+// userSessions returns the sessions of the user, the most recently used first
+func (registry *sessionRegistry) userSessions(userID portainer.UserID, now time.Time) []portainer.Session {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.removeExpired(now)
+
+	sessions := make([]portainer.Session, 0)
+	for _, session := range registry.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt > sessions[j].LastSeenAt
+	})
+
+	return sessions
+}
+
+// revoke removes the session of the user, it returns false when the user has no such session
+func (registry *sessionRegistry) revoke(userID portainer.UserID, sessionID string) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	session, ok := registry.sessions[sessionID]
+	if !ok || session.UserID != userID {
+		return false
+	}
+
+	delete(registry.sessions, sessionID)
+	return true
+}
+
+// revokeUser removes all the sessions of the user
+func (registry *sessionRegistry) revokeUser(userID portainer.UserID) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for id, session := range registry.sessions {
+		if session.UserID == userID {
+			delete(registry.sessions, id)
+		}
+	}
+}
+
+func (registry *sessionRegistry) removeExpired(now time.Time) {
+	for id, session := range registry.sessions {
+		if now.Unix() > session.ExpiresAt {
+			delete(registry.sessions, id)
+		}
+	}
+}
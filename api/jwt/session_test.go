@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	user := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	svc, err := NewService("1h", store)
+	is.NoError(err)
+
+	login := func() (*portainer.Session, string) {
+		session, err := svc.CreateSession(user.ID, "10.0.0.10", "Mozilla/5.0")
+		is.NoError(err)
+
+		token, err := svc.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, SessionID: session.ID})
+		is.NoError(err)
+
+		return session, token
+	}
+
+	t.Run("the tokens of a session carry its identifier", func(t *testing.T) {
+		session, token := login()
+
+		tokenData, err := svc.ParseAndVerifyToken(token)
+		is.NoError(err)
+		is.Equal(session.ID, tokenData.SessionID)
+
+		sessions := svc.UserSessions(user.ID)
+		is.Len(sessions, 1)
+		is.Equal("10.0.0.10", sessions[0].IPAddress)
+		is.Equal("Mozilla/5.0", sessions[0].UserAgent)
+		is.NotZero(sessions[0].LastSeenAt)
+
+		is.NoError(svc.RevokeUserSessions(user.ID))
+	})
+
+	t.Run("the tokens of a revoked session are rejected", func(t *testing.T) {
+		session, token := login()
+		_, other := login()
+
+		is.False(svc.RevokeSession(user.ID+1, session.ID), "a session can only be revoked for its user")
+		is.True(svc.RevokeSession(user.ID, session.ID))
+		is.False(svc.RevokeSession(user.ID, session.ID))
+
+		_, err := svc.ParseAndVerifyToken(token)
+		is.Error(err)
+
+		_, err = svc.ParseAndVerifyToken(other)
+		is.NoError(err, "the other sessions of the user are kept")
+
+		is.NoError(svc.RevokeUserSessions(user.ID))
+
+		_, err = svc.ParseAndVerifyToken(other)
+		is.Error(err)
+		is.Empty(svc.UserSessions(user.ID))
+	})
+
+	t.Run("kubeconfig tokens are not bound to the session but revoked with all the sessions", func(t *testing.T) {
+		session, _ := login()
+
+		token, err := svc.GenerateTokenForKubeconfig(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role, SessionID: session.ID})
+		is.NoError(err)
+
+		is.True(svc.RevokeSession(user.ID, session.ID))
+
+		tokenData, err := svc.ParseAndVerifyToken(token)
+		is.NoError(err, "the kubeconfig token outlives the session it was requested from")
+		is.Empty(tokenData.SessionID)
+
+		// the tokens are rejected when issued before the revocation, with a precision of one second
+		time.Sleep(time.Until(time.Unix(time.Now().Unix()+1, 0)))
+		is.NoError(svc.RevokeUserSessions(user.ID))
+
+		_, err = svc.ParseAndVerifyToken(token)
+		is.Error(err)
+
+		_, token = login()
+		_, err = svc.ParseAndVerifyToken(token)
+		is.NoError(err, "the user can login again")
+
+		is.NoError(svc.RevokeUserSessions(user.ID))
+	})
+
+	t.Run("expired sessions are forgotten", func(t *testing.T) {
+		now := time.Now()
+		_, err := svc.sessions.create(user.ID, "", "", now.Add(-2*time.Hour), now.Add(-time.Hour).Unix())
+		is.NoError(err)
+
+		is.Empty(svc.UserSessions(user.ID))
+	})
+}
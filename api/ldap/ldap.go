@@ -158,7 +158,8 @@ func (*Service) SearchUsers(settings *portainer.LDAPSettings) ([]string, error)
 	return usersList, nil
 }
 
-// SearchGroups searches for groups with the specified settings
+// SearchGroups searches for groups with the specified settings.
+// The group members referenced by the DN of a user matching the search settings are returned by username.
 func (*Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAPUser, error) {
 	type groupSet map[string]bool
 
@@ -175,6 +176,11 @@ func (*Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAP
 		}
 	}
 
+	usernames, err := searchUsernamesByDN(connection, settings.SearchSettings)
+	if err != nil {
+		return nil, err
+	}
+
 	userGroups := map[string]groupSet{}
 
 	for _, searchSettings := range settings.GroupSearchSettings {
@@ -193,8 +199,14 @@ func (*Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAP
 
 		for _, entry := range sr.Entries {
 			members := entry.GetAttributeValues(searchSettings.GroupAttribute)
-			for _, username := range members {
-				_, ok := userGroups[username]
+			for _, member := range members {
+				// members are either referenced by their DN or directly by their username
+				username, ok := usernames[normalizeDN(member)]
+				if !ok {
+					username = member
+				}
+
+				_, ok = userGroups[username]
 				if !ok {
 					userGroups[username] = groupSet{}
 				}
@@ -220,6 +232,53 @@ func (*Service) SearchGroups(settings *portainer.LDAPSettings) ([]portainer.LDAP
 	return users, nil
 }
 
+// searchUsernamesByDN returns the usernames of the users matching the search settings indexed by their normalized DN
+func searchUsernamesByDN(conn *ldap.Conn, settings []portainer.LDAPSearchSettings) (map[string]string, error) {
+	usernames := map[string]string{}
+
+	for _, searchSettings := range settings {
+		searchRequest := ldap.NewSearchRequest(
+			searchSettings.BaseDN,
+			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			searchSettings.Filter,
+			[]string{"dn", searchSettings.UserNameAttribute},
+			nil,
+		)
+
+		sr, err := conn.Search(searchRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range sr.Entries {
+			username := user.GetAttributeValue(searchSettings.UserNameAttribute)
+			if username != "" {
+				usernames[normalizeDN(user.DN)] = username
+			}
+		}
+	}
+
+	return usernames, nil
+}
+
+// normalizeDN returns a lower cased DN without the spaces surrounding its components, so that
+// equivalent DNs can be compared. Values that are not DNs are only lower cased.
+func normalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 {
+		return strings.ToLower(dn)
+	}
+
+	rdns := []string{}
+	for _, rdn := range parsed.RDNs {
+		for _, attribute := range rdn.Attributes {
+			rdns = append(rdns, strings.ToLower(attribute.Type)+"="+strings.ToLower(attribute.Value))
+		}
+	}
+
+	return strings.Join(rdns, ",")
+}
+
 func searchUser(username string, conn *ldap.Conn, settings []portainer.LDAPSearchSettings) (string, error) {
 	var userDN string
 	found := false
@@ -0,0 +1,56 @@
+package ldap
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServiceWithTestDirectory(t *testing.T) {
+	is := assert.New(t)
+
+	server := newTestDirectory(t)
+	defer server.Close()
+
+	settings := &portainer.LDAPSettings{
+		ReaderDN: "cn=reader,dc=example,dc=org",
+		Password: "reader-password",
+		URL:      server.URL(),
+		SearchSettings: []portainer.LDAPSearchSettings{
+			{BaseDN: "ou=people,dc=example,dc=org", Filter: "(objectClass=inetOrgPerson)", UserNameAttribute: "uid"},
+		},
+		GroupSearchSettings: []portainer.LDAPGroupSearchSettings{
+			{GroupBaseDN: "ou=groups,dc=example,dc=org", GroupFilter: "(objectClass=groupOfNames)", GroupAttribute: "member"},
+		},
+	}
+
+	service := &Service{}
+
+	is.NoError(service.TestConnectivity(settings))
+	is.NoError(service.AuthenticateUser("alice", "alice-password", settings))
+	is.Error(service.AuthenticateUser("alice", "bob-password", settings))
+	is.Error(service.AuthenticateUser("carol", "carol-password", settings))
+
+	groups, err := service.GetUserGroups("alice", settings)
+	is.NoError(err)
+	is.ElementsMatch([]string{"developers", "ops"}, groups)
+
+	users, err := service.SearchUsers(settings)
+	is.NoError(err)
+	is.ElementsMatch([]string{"alice", "bob"}, users)
+
+	members, err := service.SearchGroups(settings)
+	is.NoError(err)
+	is.Len(members, 2)
+	for _, member := range members {
+		switch member.Name {
+		case "alice":
+			is.ElementsMatch([]string{"developers", "ops"}, member.Groups)
+		case "bob":
+			is.ElementsMatch([]string{"developers"}, member.Groups)
+		default:
+			t.Errorf("unexpected group member %s", member.Name)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package ldaptest
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	ldap "github.com/go-ldap/ldap/v3"
+)
+
+var errUnsupportedFilter = errors.New("unsupported search filter")
+
+// Entry represents an entry of the directory served by the stand-in server.
+// The "userPassword" attribute is used to authenticate the binds made with the entry DN.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Server is an in-process LDAP server implementing the subset of the protocol used by Portainer:
+// simple binds, searches and unbinds. It is meant to be used in tests only.
+type Server struct {
+	mu       sync.Mutex
+	listener net.Listener
+	entries  map[string]Entry
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server listening on a random local port and serving the given entries.
+func NewServer(entries ...Entry) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		listener: listener,
+		entries:  make(map[string]Entry),
+	}
+
+	for _, entry := range entries {
+		server.Add(entry)
+	}
+
+	server.wg.Add(1)
+	go server.serve()
+
+	return server, nil
+}
+
+// URL returns the address of the server, as expected by the LDAP settings.
+func (server *Server) URL() string {
+	return server.listener.Addr().String()
+}
+
+// Close stops the server.
+func (server *Server) Close() {
+	server.listener.Close()
+	server.wg.Wait()
+}
+
+// Add adds an entry to the directory, replacing the entry with the same DN if any.
+func (server *Server) Add(entry Entry) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.entries[normalizeDN(entry.DN)] = entry
+}
+
+// Remove removes the entry with the given DN from the directory.
+func (server *Server) Remove(dn string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	delete(server.entries, normalizeDN(dn))
+}
+
+func (server *Server) serve() {
+	defer server.wg.Done()
+
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go server.handle(conn)
+	}
+}
+
+func (server *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageID, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+
+		request := packet.Children[1]
+		if request.ClassType != ber.ClassApplication {
+			return
+		}
+
+		var responses []*ber.Packet
+		switch request.Tag {
+		case ldap.ApplicationBindRequest:
+			responses = []*ber.Packet{server.bind(request)}
+		case ldap.ApplicationSearchRequest:
+			responses = server.search(request)
+		default:
+			// unbind requests and unsupported operations end the session
+			return
+		}
+
+		for _, response := range responses {
+			envelope := ber.NewSequence("LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+			envelope.AppendChild(response)
+
+			_, err = conn.Write(envelope.Bytes())
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (server *Server) bind(request *ber.Packet) *ber.Packet {
+	if len(request.Children) < 3 {
+		return result(ldap.ApplicationBindResponse, ldap.LDAPResultProtocolError)
+	}
+
+	name, _ := request.Children[1].Value.(string)
+	password := request.Children[2].Data.String()
+
+	if name == "" && password == "" {
+		return result(ldap.ApplicationBindResponse, ldap.LDAPResultSuccess)
+	}
+
+	server.mu.Lock()
+	entry, ok := server.entries[normalizeDN(name)]
+	server.mu.Unlock()
+
+	if !ok || password == "" || !containsValue(entry.Attributes["userPassword"], password, false) {
+		return result(ldap.ApplicationBindResponse, ldap.LDAPResultInvalidCredentials)
+	}
+
+	return result(ldap.ApplicationBindResponse, ldap.LDAPResultSuccess)
+}
+
+func (server *Server) search(request *ber.Packet) []*ber.Packet {
+	if len(request.Children) < 8 {
+		return []*ber.Packet{result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)}
+	}
+
+	baseDN, _ := request.Children[0].Value.(string)
+	scope, _ := request.Children[1].Value.(int64)
+	filter := request.Children[6]
+
+	attributes := []string{}
+	for _, attribute := range request.Children[7].Children {
+		name, _ := attribute.Value.(string)
+		attributes = append(attributes, name)
+	}
+
+	base, err := ldap.ParseDN(baseDN)
+	if err != nil {
+		return []*ber.Packet{result(ldap.ApplicationSearchResultDone, ldap.LDAPResultInvalidDNSyntax)}
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	responses := []*ber.Packet{}
+	for _, entry := range server.entries {
+		dn, err := ldap.ParseDN(entry.DN)
+		if err != nil || !inScope(base, dn, scope) {
+			continue
+		}
+
+		match, err := matches(entry, filter)
+		if err != nil {
+			return []*ber.Packet{result(ldap.ApplicationSearchResultDone, ldap.LDAPResultFilterError)}
+		}
+
+		if match {
+			responses = append(responses, searchResultEntry(entry, attributes))
+		}
+	}
+
+	return append(responses, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+}
+
+func inScope(base, dn *ldap.DN, scope int64) bool {
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return base.Equal(dn)
+	case ldap.ScopeSingleLevel:
+		return base.AncestorOf(dn) && len(dn.RDNs) == len(base.RDNs)+1
+	default:
+		return base.Equal(dn) || base.AncestorOf(dn)
+	}
+}
+
+// matches evaluates the equality, presence, substrings, and, or and not filters against the entry.
+// The values are compared without regard to case.
+func matches(entry Entry, filter *ber.Packet) (bool, error) {
+	if filter.ClassType != ber.ClassContext {
+		return false, errUnsupportedFilter
+	}
+
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			match, err := matches(entry, child)
+			if err != nil || !match {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			match, err := matches(entry, child)
+			if err != nil || match {
+				return match, err
+			}
+		}
+		return false, nil
+
+	case ldap.FilterNot:
+		if len(filter.Children) != 1 {
+			return false, errUnsupportedFilter
+		}
+		match, err := matches(entry, filter.Children[0])
+		return !match, err
+
+	case ldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false, errUnsupportedFilter
+		}
+		attribute, _ := filter.Children[0].Value.(string)
+		value, _ := filter.Children[1].Value.(string)
+		return containsValue(attributeValues(entry, attribute), value, true), nil
+
+	case ldap.FilterPresent:
+		return len(attributeValues(entry, filter.Data.String())) > 0, nil
+
+	case ldap.FilterSubstrings:
+		if len(filter.Children) != 2 {
+			return false, errUnsupportedFilter
+		}
+		attribute, _ := filter.Children[0].Value.(string)
+		for _, value := range attributeValues(entry, attribute) {
+			if matchesSubstrings(strings.ToLower(value), filter.Children[1].Children) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	return false, errUnsupportedFilter
+}
+
+func matchesSubstrings(value string, substrings []*ber.Packet) bool {
+	for _, substring := range substrings {
+		part := strings.ToLower(substring.Data.String())
+
+		switch substring.Tag {
+		case ldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, part) {
+				return false
+			}
+			value = value[len(part):]
+		case ldap.FilterSubstringsFinal:
+			return strings.HasSuffix(value, part)
+		default:
+			index := strings.Index(value, part)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(part):]
+		}
+	}
+
+	return true
+}
+
+func attributeValues(entry Entry, attribute string) []string {
+	if strings.EqualFold(attribute, "dn") {
+		return []string{entry.DN}
+	}
+
+	for name, values := range entry.Attributes {
+		if strings.EqualFold(name, attribute) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+func containsValue(values []string, value string, ignoreCase bool) bool {
+	for _, v := range values {
+		if v == value || (ignoreCase && strings.EqualFold(v, value)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func searchResultEntry(entry Entry, attributes []string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+
+	list := ber.NewSequence("Attributes")
+	for name, values := range entry.Attributes {
+		if !requested(name, attributes) {
+			continue
+		}
+
+		attribute := ber.NewSequence("Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+
+		list.AppendChild(attribute)
+	}
+	packet.AppendChild(list)
+
+	return packet
+}
+
+// requested returns true when the attribute is part of the requested attributes,
+// all the attributes but the passwords are returned when none is requested
+func requested(attribute string, attributes []string) bool {
+	if len(attributes) == 0 || containsValue(attributes, "*", false) {
+		return !strings.EqualFold(attribute, "userPassword")
+	}
+
+	return containsValue(attributes, attribute, true)
+}
+
+func result(application ber.Tag, code int) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, "Result")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+
+	return packet
+}
+
+func normalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(dn)
+	}
+
+	rdns := []string{}
+	for _, rdn := range parsed.RDNs {
+		for _, attribute := range rdn.Attributes {
+			rdns = append(rdns, strings.ToLower(attribute.Type)+"="+strings.ToLower(attribute.Value))
+		}
+	}
+
+	return strings.Join(rdns, ",")
+}
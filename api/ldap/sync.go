@@ -0,0 +1,326 @@
+package ldap
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrLDAPAuthenticationDisabled defines an error raised when a synchronization is requested
+	// while the users are not authenticated against a LDAP server.
+	ErrLDAPAuthenticationDisabled = errors.New("LDAP authentication is not enabled")
+	// errNoUserMatched defines an error raised when no user matches the search settings, the synchronization
+	// is aborted as it would otherwise remove every membership and disable every user.
+	errNoUserMatched = errors.New("No LDAP user matches the search settings")
+)
+
+// Synchronizer reconciles the team memberships of the users with their LDAP groups,
+// on the schedule defined in the LDAP settings or on demand.
+type Synchronizer struct {
+	mu          sync.Mutex
+	runMu       sync.Mutex
+	scheduler   *scheduler.Scheduler
+	dataStore   dataservices.DataStore
+	ldapService portainer.LDAPService
+	jobID       string
+	status      portainer.LDAPSyncStatus
+}
+
+// NewSynchronizer creates a new instance of the LDAP group synchronizer.
+func NewSynchronizer(scheduler *scheduler.Scheduler, dataStore dataservices.DataStore, ldapService portainer.LDAPService) *Synchronizer {
+	return &Synchronizer{
+		scheduler:   scheduler,
+		dataStore:   dataStore,
+		ldapService: ldapService,
+	}
+}
+
+// Start schedules the synchronization using the settings stored in the database.
+func (s *Synchronizer) Start() error {
+	settings, err := s.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return s.Reschedule(settings.LDAPSettings.GroupSync)
+}
+
+// Reschedule replaces the current synchronization job with one matching the given settings.
+// No job is scheduled when the synchronization is disabled.
+func (s *Synchronizer) Reschedule(settings portainer.LDAPGroupSyncSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jobID != "" {
+		s.scheduler.StopJob(s.jobID)
+		s.jobID = ""
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	jobID, err := s.scheduler.StartJobWithCronRule(settings.CronRule, func() error {
+		_, err := s.Sync(false)
+		if err != nil {
+			logrus.WithError(err).Error("scheduled LDAP group synchronization failed")
+		}
+
+		// never stop the job, the next run may succeed
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.jobID = jobID
+
+	return nil
+}
+
+// Status returns the outcome of the last synchronization, dry runs excluded.
+func (s *Synchronizer) Status() portainer.LDAPSyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// Sync reconciles the team memberships of the users with their LDAP groups and returns the changes.
+// Teams are matched to the LDAP groups by name, regardless of case. The users are added to the teams
+// matching their groups and removed from the teams matching the groups they left, the memberships of the
+// teams not matching any LDAP group are left untouched. The initial administrator, who is always
+// authenticated internally, is ignored.
+// When dryRun is true the changes are only computed.
+func (s *Synchronizer) Sync(dryRun bool) (*portainer.LDAPSyncReport, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	report, err := s.sync(dryRun)
+
+	if !dryRun {
+		s.mu.Lock()
+		s.status = portainer.LDAPSyncStatus{LastSync: time.Now().Unix(), Report: report}
+		if err != nil {
+			s.status.Error = err.Error()
+		}
+		s.mu.Unlock()
+	}
+
+	return report, err
+}
+
+func (s *Synchronizer) sync(dryRun bool) (*portainer.LDAPSyncReport, error) {
+	settings, err := s.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.AuthenticationMethod != portainer.AuthenticationLDAP {
+		return nil, ErrLDAPAuthenticationDisabled
+	}
+	ldapSettings := &settings.LDAPSettings
+
+	ldapUsers, err := s.ldapService.SearchUsers(ldapSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to search the LDAP users")
+	}
+
+	if len(ldapUsers) == 0 {
+		return nil, errNoUserMatched
+	}
+
+	ldapMembers, err := s.ldapService.SearchGroups(ldapSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to search the LDAP groups")
+	}
+
+	matchedUsers := map[string]bool{}
+	for _, username := range ldapUsers {
+		matchedUsers[strings.ToLower(username)] = true
+	}
+
+	userGroups := map[string][]string{}
+	groupNames := map[string]string{}
+	for _, member := range ldapMembers {
+		key := strings.ToLower(member.Name)
+		userGroups[key] = append(userGroups[key], member.Groups...)
+
+		for _, group := range member.Groups {
+			if _, ok := groupNames[strings.ToLower(group)]; !ok {
+				groupNames[strings.ToLower(group)] = group
+			}
+		}
+	}
+
+	report := &portainer.LDAPSyncReport{
+		DryRun:             dryRun,
+		CreatedTeams:       []string{},
+		AddedMemberships:   []portainer.LDAPSyncMembership{},
+		RemovedMemberships: []portainer.LDAPSyncMembership{},
+		DisabledUsers:      []string{},
+		EnabledUsers:       []string{},
+	}
+
+	teams, err := s.dataStore.Team().Teams()
+	if err != nil {
+		return report, err
+	}
+
+	managedTeams := map[string]*portainer.Team{}
+	for i := range teams {
+		key := strings.ToLower(teams[i].Name)
+		if _, ok := groupNames[key]; ok {
+			managedTeams[key] = &teams[i]
+		}
+	}
+
+	if ldapSettings.GroupSync.AutoCreateTeams {
+		for _, key := range sortedKeys(groupNames) {
+			if _, ok := managedTeams[key]; ok {
+				continue
+			}
+
+			team := &portainer.Team{Name: groupNames[key]}
+			if !dryRun {
+				err := s.dataStore.Team().Create(team)
+				if err != nil {
+					return report, errors.Wrapf(err, "unable to create the team %s", team.Name)
+				}
+			}
+
+			managedTeams[key] = team
+			report.CreatedTeams = append(report.CreatedTeams, team.Name)
+		}
+	}
+
+	managedTeamsByID := map[portainer.TeamID]*portainer.Team{}
+	for _, team := range managedTeams {
+		if team.ID != 0 {
+			managedTeamsByID[team.ID] = team
+		}
+	}
+
+	memberships, err := s.dataStore.TeamMembership().TeamMemberships()
+	if err != nil {
+		return report, err
+	}
+
+	userMemberships := map[portainer.UserID][]portainer.TeamMembership{}
+	for _, membership := range memberships {
+		userMemberships[membership.UserID] = append(userMemberships[membership.UserID], membership)
+	}
+
+	users, err := s.dataStore.User().Users()
+	if err != nil {
+		return report, err
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+
+	for i := range users {
+		user := &users[i]
+		if user.ID == 1 {
+			continue
+		}
+
+		key := strings.ToLower(user.Username)
+		matched := matchedUsers[key]
+
+		expectedTeams := map[string]string{}
+		if matched {
+			for _, group := range userGroups[key] {
+				if team, ok := managedTeams[strings.ToLower(group)]; ok {
+					expectedTeams[strings.ToLower(group)] = team.Name
+				}
+			}
+		}
+
+		currentTeams := map[string]bool{}
+		for _, membership := range userMemberships[user.ID] {
+			team, ok := managedTeamsByID[membership.TeamID]
+			if !ok {
+				continue
+			}
+
+			teamKey := strings.ToLower(team.Name)
+			currentTeams[teamKey] = true
+
+			if _, ok := expectedTeams[teamKey]; ok {
+				continue
+			}
+
+			if !dryRun {
+				err := s.dataStore.TeamMembership().DeleteTeamMembership(membership.ID)
+				if err != nil {
+					return report, errors.Wrapf(err, "unable to remove the user %s from the team %s", user.Username, team.Name)
+				}
+			}
+
+			report.RemovedMemberships = append(report.RemovedMemberships, portainer.LDAPSyncMembership{Username: user.Username, TeamName: team.Name})
+		}
+
+		for _, teamKey := range sortedKeys(expectedTeams) {
+			if currentTeams[teamKey] {
+				continue
+			}
+
+			team := managedTeams[teamKey]
+			if !dryRun {
+				membership := &portainer.TeamMembership{
+					UserID: user.ID,
+					TeamID: team.ID,
+					Role:   portainer.TeamMember,
+				}
+
+				err := s.dataStore.TeamMembership().Create(membership)
+				if err != nil {
+					return report, errors.Wrapf(err, "unable to add the user %s to the team %s", user.Username, team.Name)
+				}
+			}
+
+			report.AddedMemberships = append(report.AddedMemberships, portainer.LDAPSyncMembership{Username: user.Username, TeamName: team.Name})
+		}
+
+		// the users disabled by an administrator are left disabled
+		switch {
+		case matched && user.Disabled && user.DisabledByLDAPSync:
+			report.EnabledUsers = append(report.EnabledUsers, user.Username)
+		case !matched && !user.Disabled && ldapSettings.GroupSync.DisableUnmatchedUsers:
+			report.DisabledUsers = append(report.DisabledUsers, user.Username)
+		default:
+			continue
+		}
+
+		if !dryRun {
+			user.Disabled = !matched
+			user.DisabledByLDAPSync = !matched
+			err := s.dataStore.User().UpdateUser(user.ID, user)
+			if err != nil {
+				return report, errors.Wrapf(err, "unable to update the user %s", user.Username)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,264 @@
+package ldap
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/ldap/ldaptest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	aliceDN = "uid=alice,ou=people,dc=example,dc=org"
+	bobDN   = "uid=bob,ou=people,dc=example,dc=org"
+)
+
+func person(dn, uid string) ldaptest.Entry {
+	return ldaptest.Entry{
+		DN: dn,
+		Attributes: map[string][]string{
+			"objectClass":  {"inetOrgPerson"},
+			"uid":          {uid},
+			"userPassword": {uid + "-password"},
+		},
+	}
+}
+
+func groupOfNames(name string, members ...string) ldaptest.Entry {
+	return ldaptest.Entry{
+		DN: "cn=" + name + ",ou=groups,dc=example,dc=org",
+		Attributes: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"cn":          {name},
+			"member":      members,
+		},
+	}
+}
+
+func newTestDirectory(t *testing.T) *ldaptest.Server {
+	server, err := ldaptest.NewServer(
+		ldaptest.Entry{
+			DN:         "cn=reader,dc=example,dc=org",
+			Attributes: map[string][]string{"cn": {"reader"}, "userPassword": {"reader-password"}},
+		},
+		person(aliceDN, "alice"),
+		person(bobDN, "bob"),
+		groupOfNames("developers", aliceDN, bobDN),
+		groupOfNames("ops", aliceDN),
+		ldaptest.Entry{
+			DN: "cn=qa,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"objectClass": {"posixGroup"},
+				"cn":          {"qa"},
+				"memberUid":   {"bob"},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to start the LDAP server: %s", err)
+	}
+
+	return server
+}
+
+func setupLDAPSettings(t *testing.T, store dataservices.DataStore, url string) {
+	settings, err := store.Settings().Settings()
+	assert.NoError(t, err)
+
+	settings.AuthenticationMethod = portainer.AuthenticationLDAP
+	settings.LDAPSettings = portainer.LDAPSettings{
+		ReaderDN: "cn=reader,dc=example,dc=org",
+		Password: "reader-password",
+		URL:      url,
+		SearchSettings: []portainer.LDAPSearchSettings{
+			{BaseDN: "ou=people,dc=example,dc=org", Filter: "(objectClass=inetOrgPerson)", UserNameAttribute: "uid"},
+		},
+		GroupSearchSettings: []portainer.LDAPGroupSearchSettings{
+			{GroupBaseDN: "ou=groups,dc=example,dc=org", GroupFilter: "(objectClass=groupOfNames)", GroupAttribute: "member"},
+			{GroupBaseDN: "ou=groups,dc=example,dc=org", GroupFilter: "(objectClass=posixGroup)", GroupAttribute: "memberUid"},
+		},
+		GroupSync: portainer.LDAPGroupSyncSettings{
+			AutoCreateTeams:       true,
+			DisableUnmatchedUsers: true,
+		},
+	}
+
+	err = store.Settings().UpdateSettings(settings)
+	assert.NoError(t, err)
+}
+
+func userTeams(t *testing.T, store dataservices.DataStore, user *portainer.User) []string {
+	memberships, err := store.TeamMembership().TeamMembershipsByUserID(user.ID)
+	assert.NoError(t, err)
+
+	teams := []string{}
+	for _, membership := range memberships {
+		team, err := store.Team().Team(membership.TeamID)
+		assert.NoError(t, err)
+		teams = append(teams, team.Name)
+	}
+
+	return teams
+}
+
+func Test_Synchronizer(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	server := newTestDirectory(t)
+	defer server.Close()
+
+	setupLDAPSettings(t, store, server.URL())
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	alice := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	bob := &portainer.User{Username: "bob", Role: portainer.StandardUserRole}
+	carol := &portainer.User{Username: "carol", Role: portainer.StandardUserRole}
+	for _, user := range []*portainer.User{admin, alice, bob, carol} {
+		is.NoError(store.User().Create(user))
+	}
+
+	developers := &portainer.Team{Name: "Developers"}
+	manual := &portainer.Team{Name: "manual"}
+	for _, team := range []*portainer.Team{developers, manual} {
+		is.NoError(store.Team().Create(team))
+	}
+
+	for _, membership := range []*portainer.TeamMembership{
+		{UserID: carol.ID, TeamID: developers.ID, Role: portainer.TeamMember},
+		{UserID: carol.ID, TeamID: manual.ID, Role: portainer.TeamMember},
+		{UserID: admin.ID, TeamID: manual.ID, Role: portainer.TeamLeader},
+	} {
+		is.NoError(store.TeamMembership().Create(membership))
+	}
+
+	synchronizer := NewSynchronizer(nil, store, &Service{})
+
+	expectedReport := portainer.LDAPSyncReport{
+		DryRun:       true,
+		CreatedTeams: []string{"ops", "qa"},
+		AddedMemberships: []portainer.LDAPSyncMembership{
+			{Username: "alice", TeamName: "Developers"},
+			{Username: "alice", TeamName: "ops"},
+			{Username: "bob", TeamName: "Developers"},
+			{Username: "bob", TeamName: "qa"},
+		},
+		RemovedMemberships: []portainer.LDAPSyncMembership{
+			{Username: "carol", TeamName: "Developers"},
+		},
+		DisabledUsers: []string{"carol"},
+		EnabledUsers:  []string{},
+	}
+
+	t.Run("dry run reports the changes without applying them", func(t *testing.T) {
+		report, err := synchronizer.Sync(true)
+		is.NoError(err)
+		is.Equal(expectedReport, *report)
+
+		teams, err := store.Team().Teams()
+		is.NoError(err)
+		is.Len(teams, 2)
+		is.ElementsMatch([]string{"Developers", "manual"}, userTeams(t, store, carol))
+		is.Empty(userTeams(t, store, alice))
+		is.Equal(int64(0), synchronizer.Status().LastSync)
+	})
+
+	t.Run("sync reconciles the memberships and disables the unmatched users", func(t *testing.T) {
+		report, err := synchronizer.Sync(false)
+		is.NoError(err)
+
+		expectedReport.DryRun = false
+		is.Equal(expectedReport, *report)
+
+		is.ElementsMatch([]string{"Developers", "ops"}, userTeams(t, store, alice))
+		is.ElementsMatch([]string{"Developers", "qa"}, userTeams(t, store, bob))
+		is.ElementsMatch([]string{"manual"}, userTeams(t, store, carol))
+		is.ElementsMatch([]string{"manual"}, userTeams(t, store, admin))
+
+		user, err := store.User().User(carol.ID)
+		is.NoError(err)
+		is.True(user.Disabled)
+
+		status := synchronizer.Status()
+		is.NotZero(status.LastSync)
+		is.Empty(status.Error)
+		is.Equal(report, status.Report)
+	})
+
+	t.Run("sync removes the users from the groups they left", func(t *testing.T) {
+		server.Add(groupOfNames("developers", aliceDN))
+		server.Remove(aliceDN)
+
+		report, err := synchronizer.Sync(false)
+		is.NoError(err)
+		is.Empty(report.CreatedTeams)
+		is.Empty(report.AddedMemberships)
+		is.Equal([]portainer.LDAPSyncMembership{
+			{Username: "alice", TeamName: "Developers"},
+			{Username: "alice", TeamName: "ops"},
+			{Username: "bob", TeamName: "Developers"},
+		}, report.RemovedMemberships)
+		is.Equal([]string{"alice"}, report.DisabledUsers)
+
+		is.ElementsMatch([]string{"qa"}, userTeams(t, store, bob))
+		is.Empty(userTeams(t, store, alice))
+	})
+
+	t.Run("sync enables the users matching the search settings again", func(t *testing.T) {
+		server.Add(person(aliceDN, "alice"))
+
+		report, err := synchronizer.Sync(false)
+		is.NoError(err)
+		is.Equal([]string{"alice"}, report.EnabledUsers)
+
+		user, err := store.User().User(alice.ID)
+		is.NoError(err)
+		is.False(user.Disabled)
+		is.ElementsMatch([]string{"Developers", "ops"}, userTeams(t, store, alice))
+	})
+
+	t.Run("sync leaves the users disabled by an administrator disabled", func(t *testing.T) {
+		user, err := store.User().User(bob.ID)
+		is.NoError(err)
+		user.Disabled = true
+		is.NoError(store.User().UpdateUser(user.ID, user))
+
+		report, err := synchronizer.Sync(false)
+		is.NoError(err)
+		is.Empty(report.EnabledUsers)
+
+		user, err = store.User().User(bob.ID)
+		is.NoError(err)
+		is.True(user.Disabled)
+
+		user.Disabled = false
+		is.NoError(store.User().UpdateUser(user.ID, user))
+	})
+
+	t.Run("sync is aborted when no user matches the search settings", func(t *testing.T) {
+		server.Remove(aliceDN)
+		server.Remove(bobDN)
+
+		_, err := synchronizer.Sync(false)
+		is.ErrorIs(err, errNoUserMatched)
+		is.Equal(errNoUserMatched.Error(), synchronizer.Status().Error)
+
+		user, err := store.User().User(bob.ID)
+		is.NoError(err)
+		is.False(user.Disabled)
+	})
+
+	t.Run("sync fails when LDAP authentication is not enabled", func(t *testing.T) {
+		settings, err := store.Settings().Settings()
+		is.NoError(err)
+		settings.AuthenticationMethod = portainer.AuthenticationInternal
+		is.NoError(store.Settings().UpdateSettings(settings))
+
+		_, err = synchronizer.Sync(true)
+		is.ErrorIs(err, ErrLDAPAuthenticationDisabled)
+	})
+}
@@ -0,0 +1,42 @@
+package notification
+
+import (
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// EventBus dispatches the published events to its subscribers.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []func(event portainer.NotificationEvent)
+}
+
+// NewEventBus creates a new instance of an event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a function called with every event published to the bus.
+func (bus *EventBus) Subscribe(subscriber func(event portainer.NotificationEvent)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.subscribers = append(bus.subscribers, subscriber)
+}
+
+// Publish dispatches an event to the subscribers of the bus. The subscribers are called
+// in their own goroutine so that publishing never blocks the publisher.
+func (bus *EventBus) Publish(event portainer.NotificationEvent) {
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UTC().Unix()
+	}
+
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+
+	for _, subscriber := range bus.subscribers {
+		go subscriber(event)
+	}
+}
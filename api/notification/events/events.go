@@ -0,0 +1,56 @@
+// Package events creates the events published to the notification channels.
+package events
+
+import (
+	"fmt"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// StackDeployFailed creates the event published when a stack fails to deploy.
+func StackDeployFailed(stack *portainer.Stack, err error) portainer.NotificationEvent {
+	return portainer.NotificationEvent{
+		Type:         portainer.NotificationEventStackDeployFailed,
+		EndpointID:   stack.EndpointID,
+		ResourceType: "stack",
+		ResourceID:   int(stack.ID),
+		ResourceName: stack.Name,
+		Message:      fmt.Sprintf("failed to deploy the stack %s: %s", stack.Name, err),
+	}
+}
+
+// EndpointDown creates the event published when an environment(endpoint) becomes unreachable.
+func EndpointDown(endpoint *portainer.Endpoint, err error) portainer.NotificationEvent {
+	return portainer.NotificationEvent{
+		Type:         portainer.NotificationEventEndpointDown,
+		EndpointID:   endpoint.ID,
+		ResourceType: "endpoint",
+		ResourceID:   int(endpoint.ID),
+		ResourceName: endpoint.Name,
+		Message:      fmt.Sprintf("the environment %s is down: %s", endpoint.Name, err),
+	}
+}
+
+// EdgeStackError creates the event published when an edge stack reports an error on an environment(endpoint).
+func EdgeStackError(edgeStack *portainer.EdgeStack, endpointID portainer.EndpointID, message string) portainer.NotificationEvent {
+	return portainer.NotificationEvent{
+		Type:         portainer.NotificationEventEdgeStackError,
+		EndpointID:   endpointID,
+		ResourceType: "edge_stack",
+		ResourceID:   int(edgeStack.ID),
+		ResourceName: edgeStack.Name,
+		Message:      fmt.Sprintf("the edge stack %s failed on the environment %d: %s", edgeStack.Name, endpointID, message),
+	}
+}
+
+// APIKeyExpiring creates the event published when an API key is about to expire.
+func APIKeyExpiring(apiKey *portainer.APIKey) portainer.NotificationEvent {
+	return portainer.NotificationEvent{
+		Type:         portainer.NotificationEventAPIKeyExpiring,
+		ResourceType: "api_key",
+		ResourceID:   int(apiKey.ID),
+		ResourceName: apiKey.Description,
+		Message:      fmt.Sprintf("the API key %s of the user %d expires on %s", apiKey.Description, apiKey.UserID, time.Unix(apiKey.ExpiresAt, 0).UTC().Format(time.RFC3339)),
+	}
+}
@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+const httpTimeout = 10 * time.Second
+
+// sender delivers an event to a notification channel.
+type sender interface {
+	Send(channel *portainer.NotificationChannel, event portainer.NotificationEvent) error
+}
+
+type webhookSender struct {
+	client *http.Client
+}
+
+type slackSender struct {
+	client *http.Client
+}
+
+type emailSender struct {
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// Send posts the event as JSON to the URL of the channel.
+func (s *webhookSender) Send(channel *portainer.NotificationChannel, event portainer.NotificationEvent) error {
+	return postJSON(s.client, channel.URL, event)
+}
+
+// Send posts the event to the Slack compatible incoming webhook of the channel.
+func (s *slackSender) Send(channel *portainer.NotificationChannel, event portainer.NotificationEvent) error {
+	return postJSON(s.client, channel.URL, slackPayload{Text: formatEvent(event)})
+}
+
+// Send emails the event to the recipients of the channel.
+func (s *emailSender) Send(channel *portainer.NotificationChannel, event portainer.NotificationEvent) error {
+	settings := channel.SMTPSettings
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(settings.Port))
+
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	subject := fmt.Sprintf("[Portainer] %s", event.Type)
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		settings.From, strings.Join(settings.To, ", "), subject, formatEvent(event))
+
+	return s.sendMail(addr, auth, settings.From, settings.To, []byte(message))
+}
+
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// formatEvent creates a human readable description of an event.
+func formatEvent(event portainer.NotificationEvent) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s", event.Type, event.Message)
+	if event.ResourceName != "" {
+		fmt.Fprintf(&b, "\n%s: %s (id: %d)", event.ResourceType, event.ResourceName, event.ResourceID)
+	}
+	if event.EndpointID != 0 {
+		fmt.Fprintf(&b, "\nenvironment id: %d", event.EndpointID)
+	}
+	fmt.Fprintf(&b, "\ntime: %s", time.Unix(event.Timestamp, 0).UTC().Format(time.RFC3339))
+
+	return b.String()
+}
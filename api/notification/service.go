@@ -0,0 +1,188 @@
+package notification
+
+import (
+	"net/http"
+	"net/smtp"
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// deliveryRetention is the duration for which the delivery log entries are kept
+	deliveryRetention    = 30 * 24 * time.Hour
+	retentionJobInterval = time.Hour
+)
+
+var errChannelUnavailable = errors.New("the notification channel was deleted or disabled before the delivery completed")
+
+// defaultRetryDelays are the delays waited before each new delivery attempt,
+// a delivery is attempted len(defaultRetryDelays)+1 times.
+var defaultRetryDelays = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute}
+
+// Service delivers the events published on the event bus to the subscribed notification channels
+// and records every delivery in the delivery log.
+type Service struct {
+	dataStore   dataservices.DataStore
+	senders     map[portainer.NotificationChannelType]sender
+	retryDelays []time.Duration
+}
+
+// NewService creates a new instance of the notification service.
+func NewService(dataStore dataservices.DataStore) *Service {
+	client := &http.Client{Timeout: httpTimeout}
+
+	return &Service{
+		dataStore: dataStore,
+		senders: map[portainer.NotificationChannelType]sender{
+			portainer.NotificationChannelWebhook: &webhookSender{client: client},
+			portainer.NotificationChannelSlack:   &slackSender{client: client},
+			portainer.NotificationChannelEmail:   &emailSender{sendMail: smtp.SendMail},
+		},
+		retryDelays: defaultRetryDelays,
+	}
+}
+
+// Start resumes the deliveries left pending by a previous run and subscribes the service to the events published on the bus.
+func (service *Service) Start(bus *EventBus) {
+	service.resumePendingDeliveries()
+
+	bus.Subscribe(service.dispatch)
+}
+
+// resumePendingDeliveries delivers again the deliveries which were still pending when Portainer stopped.
+func (service *Service) resumePendingDeliveries() {
+	deliveries, err := service.dataStore.NotificationDelivery().NotificationDeliveries()
+	if err != nil {
+		logrus.WithError(err).Error("unable to retrieve the notification deliveries")
+		return
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if delivery.Status == portainer.NotificationDeliveryPending {
+			go service.deliver(delivery)
+		}
+	}
+}
+
+// dispatch creates a delivery for every enabled channel subscribed to the event and delivers them.
+func (service *Service) dispatch(event portainer.NotificationEvent) {
+	channels, err := service.dataStore.NotificationChannel().NotificationChannels()
+	if err != nil {
+		logrus.WithError(err).Error("unable to retrieve the notification channels")
+		return
+	}
+
+	for i := range channels {
+		channel := channels[i]
+		if !channel.Enabled || !IsSubscribed(&channel, event.Type) {
+			continue
+		}
+
+		now := time.Now().UTC().Unix()
+		delivery := &portainer.NotificationDelivery{
+			ChannelID: channel.ID,
+			Event:     event,
+			Status:    portainer.NotificationDeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		err := service.dataStore.NotificationDelivery().Create(delivery)
+		if err != nil {
+			logrus.WithError(err).WithField("channel", channel.Name).Error("unable to persist the notification delivery")
+			continue
+		}
+
+		go service.deliver(delivery)
+	}
+}
+
+// deliver sends the event of the delivery to its channel, retrying on failure. The channel is loaded on every
+// attempt so that the changes made between the attempts are used. The delivery fails when the channel is deleted or disabled.
+func (service *Service) deliver(delivery *portainer.NotificationDelivery) {
+	for {
+		err := service.attempt(delivery)
+
+		delivery.Attempts++
+		delivery.UpdatedAt = time.Now().UTC().Unix()
+		delivery.Error = ""
+		delivery.Status = portainer.NotificationDeliveryDelivered
+		if err != nil {
+			delivery.Error = err.Error()
+			delivery.Status = portainer.NotificationDeliveryPending
+			if delivery.Attempts > len(service.retryDelays) || errors.Is(err, errChannelUnavailable) {
+				delivery.Status = portainer.NotificationDeliveryFailed
+			}
+		}
+
+		updateErr := service.dataStore.NotificationDelivery().UpdateNotificationDelivery(delivery.ID, delivery)
+		if updateErr != nil {
+			logrus.WithError(updateErr).WithField("channel", delivery.ChannelID).Error("unable to update the notification delivery")
+		}
+
+		if delivery.Status != portainer.NotificationDeliveryPending {
+			if delivery.Status == portainer.NotificationDeliveryFailed {
+				logrus.WithError(err).WithField("channel", delivery.ChannelID).Warn("unable to deliver the notification")
+			}
+			return
+		}
+
+		time.Sleep(service.retryDelays[delivery.Attempts-1])
+	}
+}
+
+// attempt loads the channel of the delivery and sends the event to it
+func (service *Service) attempt(delivery *portainer.NotificationDelivery) error {
+	channel, err := service.dataStore.NotificationChannel().NotificationChannel(delivery.ChannelID)
+	if service.dataStore.IsErrObjectNotFound(err) || (err == nil && !channel.Enabled) {
+		return errChannelUnavailable
+	} else if err != nil {
+		return errors.Wrap(err, "unable to retrieve the notification channel")
+	}
+
+	return service.send(channel, delivery.Event)
+}
+
+func (service *Service) send(channel *portainer.NotificationChannel, event portainer.NotificationEvent) error {
+	sender, ok := service.senders[channel.Type]
+	if !ok {
+		return errors.Errorf("unsupported notification channel type: %d", channel.Type)
+	}
+
+	return sender.Send(channel, event)
+}
+
+// PurgeExpiredDeliveries removes the delivery log entries older than the delivery retention.
+func (service *Service) PurgeExpiredDeliveries() error {
+	return service.dataStore.NotificationDelivery().DeleteNotificationDeliveriesBefore(time.Now().UTC().Add(-deliveryRetention).Unix())
+}
+
+// StartRetentionJob schedules the periodic purge of the expired delivery log entries.
+func (service *Service) StartRetentionJob(scheduler *scheduler.Scheduler) {
+	scheduler.StartJobEvery(retentionJobInterval, func() error {
+		err := service.PurgeExpiredDeliveries()
+		if err != nil {
+			logrus.WithError(err).Error("unable to purge expired notification deliveries")
+		}
+
+		// never stop the job, the next run may succeed
+		return nil
+	})
+}
+
+// IsSubscribed returns true if the channel is subscribed to the given event type.
+func IsSubscribed(channel *portainer.NotificationChannel, eventType portainer.NotificationEventType) bool {
+	for _, subscribed := range channel.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,227 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/notification/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForDeliveries(t *testing.T, store *datastore.Store, status portainer.NotificationDeliveryStatus, count int) []portainer.NotificationDelivery {
+	var deliveries []portainer.NotificationDelivery
+
+	assert.Eventually(t, func() bool {
+		all, err := store.NotificationDelivery().NotificationDeliveries()
+		if err != nil {
+			return false
+		}
+
+		deliveries = nil
+		for _, delivery := range all {
+			if delivery.Status == status {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return len(deliveries) == count
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return deliveries
+}
+
+func Test_WebhookChannel_shouldRetryFailedDeliveries(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	var calls int32
+	var received portainer.NotificationEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	is.NoError(store.NotificationChannel().Create(&portainer.NotificationChannel{
+		Name:    "webhook",
+		Type:    portainer.NotificationChannelWebhook,
+		Enabled: true,
+		Events:  []portainer.NotificationEventType{portainer.NotificationEventStackDeployFailed},
+		URL:     server.URL,
+	}))
+
+	service := NewService(store)
+	service.retryDelays = []time.Duration{0, 0}
+
+	bus := NewEventBus()
+	service.Start(bus)
+
+	bus.Publish(events.StackDeployFailed(&portainer.Stack{ID: 1, Name: "web", EndpointID: 2}, assert.AnError))
+
+	deliveries := waitForDeliveries(t, store, portainer.NotificationDeliveryDelivered, 1)
+	is.Equal(2, deliveries[0].Attempts)
+	is.Empty(deliveries[0].Error)
+	is.Equal(portainer.NotificationEventStackDeployFailed, received.Type)
+	is.Equal(portainer.EndpointID(2), received.EndpointID)
+	is.Equal("web", received.ResourceName)
+	is.NotZero(received.Timestamp)
+}
+
+func Test_Channel_shouldFailAfterTheLastAttempt(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	is.NoError(store.NotificationChannel().Create(&portainer.NotificationChannel{
+		Name:    "slack",
+		Type:    portainer.NotificationChannelSlack,
+		Enabled: true,
+		Events:  []portainer.NotificationEventType{portainer.NotificationEventEndpointDown},
+		URL:     server.URL,
+	}))
+
+	service := NewService(store)
+	service.retryDelays = []time.Duration{0, 0}
+
+	service.dispatch(events.EndpointDown(&portainer.Endpoint{ID: 1, Name: "local"}, assert.AnError))
+
+	deliveries := waitForDeliveries(t, store, portainer.NotificationDeliveryFailed, 1)
+	is.Equal(3, deliveries[0].Attempts)
+	is.Equal("unexpected status code 502", deliveries[0].Error)
+}
+
+func Test_dispatch_shouldOnlyDeliverToEnabledSubscribedChannels(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	var sentMessages int32
+	var recipients []string
+
+	channels := []portainer.NotificationChannel{
+		{Name: "subscribed", Type: portainer.NotificationChannelEmail, Enabled: true, Events: []portainer.NotificationEventType{portainer.NotificationEventEdgeStackError},
+			SMTPSettings: portainer.NotificationSMTPSettings{Host: "smtp.local", Port: 25, From: "portainer@local", To: []string{"ops@local"}}},
+		{Name: "disabled", Type: portainer.NotificationChannelEmail, Enabled: false, Events: []portainer.NotificationEventType{portainer.NotificationEventEdgeStackError}},
+		{Name: "unsubscribed", Type: portainer.NotificationChannelEmail, Enabled: true, Events: []portainer.NotificationEventType{portainer.NotificationEventEndpointDown}},
+	}
+	for i := range channels {
+		is.NoError(store.NotificationChannel().Create(&channels[i]))
+	}
+
+	service := NewService(store)
+	service.senders[portainer.NotificationChannelEmail] = &emailSender{
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			is.Equal("smtp.local:25", addr)
+			is.Nil(a, "no authentication is expected without username")
+			recipients = to
+			atomic.AddInt32(&sentMessages, 1)
+			return nil
+		},
+	}
+
+	service.dispatch(events.EdgeStackError(&portainer.EdgeStack{ID: 1, Name: "edge"}, 3, "image not found"))
+
+	deliveries := waitForDeliveries(t, store, portainer.NotificationDeliveryDelivered, 1)
+	is.Equal(channels[0].ID, deliveries[0].ChannelID)
+	is.Equal(int32(1), atomic.LoadInt32(&sentMessages))
+	is.Equal([]string{"ops@local"}, recipients)
+}
+
+func Test_Start_shouldResumePendingDeliveries(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	channel := &portainer.NotificationChannel{
+		Name:    "webhook",
+		Type:    portainer.NotificationChannelWebhook,
+		Enabled: true,
+		Events:  []portainer.NotificationEventType{portainer.NotificationEventStackDeployFailed},
+		URL:     server.URL,
+	}
+	is.NoError(store.NotificationChannel().Create(channel))
+
+	event := events.StackDeployFailed(&portainer.Stack{ID: 1, Name: "web", EndpointID: 2}, assert.AnError)
+	is.NoError(store.NotificationDelivery().Create(&portainer.NotificationDelivery{ChannelID: channel.ID, Event: event, Status: portainer.NotificationDeliveryPending, Attempts: 1}))
+	is.NoError(store.NotificationDelivery().Create(&portainer.NotificationDelivery{ChannelID: channel.ID + 1, Event: event, Status: portainer.NotificationDeliveryPending}))
+	is.NoError(store.NotificationDelivery().Create(&portainer.NotificationDelivery{ChannelID: channel.ID, Event: event, Status: portainer.NotificationDeliveryFailed, Attempts: 4}))
+
+	service := NewService(store)
+	service.retryDelays = []time.Duration{0, 0}
+	service.Start(NewEventBus())
+
+	delivered := waitForDeliveries(t, store, portainer.NotificationDeliveryDelivered, 1)
+	is.Equal(2, delivered[0].Attempts)
+	is.Equal(int32(1), atomic.LoadInt32(&calls), "only the pending delivery of the existing channel should be sent")
+
+	failed := waitForDeliveries(t, store, portainer.NotificationDeliveryFailed, 2)
+	is.ElementsMatch([]portainer.NotificationChannelID{channel.ID, channel.ID + 1}, []portainer.NotificationChannelID{failed[0].ChannelID, failed[1].ChannelID})
+}
+
+func Test_deliver_shouldReloadTheChannelOnEveryAttempt(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	var received int32
+	fixed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+	}))
+	defer fixed.Close()
+
+	channel := &portainer.NotificationChannel{
+		Name:    "webhook",
+		Type:    portainer.NotificationChannelWebhook,
+		Enabled: true,
+		Events:  []portainer.NotificationEventType{portainer.NotificationEventStackDeployFailed},
+	}
+
+	// the first URL fails and is fixed by an administrator before the next attempt
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		channel.URL = fixed.URL
+		store.NotificationChannel().UpdateNotificationChannel(channel.ID, channel)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer broken.Close()
+
+	channel.URL = broken.URL
+	is.NoError(store.NotificationChannel().Create(channel))
+
+	service := NewService(store)
+	service.retryDelays = []time.Duration{0, 0}
+
+	bus := NewEventBus()
+	service.Start(bus)
+
+	bus.Publish(events.StackDeployFailed(&portainer.Stack{ID: 1, Name: "web", EndpointID: 2}, assert.AnError))
+
+	deliveries := waitForDeliveries(t, store, portainer.NotificationDeliveryDelivered, 1)
+	is.Equal(2, deliveries[0].Attempts)
+	is.Equal(int32(1), atomic.LoadInt32(&received))
+}
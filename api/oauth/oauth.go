@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"golang.org/x/oauth2"
 
@@ -18,7 +19,13 @@ import (
 )
 
 // Service represents a service used to authenticate users against an authorization server
-type Service struct{}
+type Service struct {
+	mu sync.Mutex
+	// keys of the OpenID Connect providers indexed by JWKS URI and key identifier
+	keys map[string]map[string]interface{}
+	// discovery documents of the OpenID Connect providers indexed by issuer URL
+	discoveries map[string]cachedDiscovery
+}
 
 // NewService returns a pointer to a new instance of this service
 func NewService() *Service {
@@ -26,24 +33,38 @@ func NewService() *Service {
 }
 
 // Authenticate takes an access code and exchanges it for an access token from portainer OAuthSettings token environment(endpoint).
-// On success, it will then return the username and token expiry time associated to authenticated user by fetching this information
-// from the resource server and matching it with the user identifier setting.
-func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings) (string, error) {
+// On success, it will then return the username and the groups associated to authenticated user by fetching this information
+// from the resource server and matching it with the user identifier and group claim settings.
+// When an OpenID Connect issuer is configured, the id_token of the response is required and verified,
+// its nonce must match the nonce sent in the authorization request.
+func (s *Service) Authenticate(code, nonce string, configuration *portainer.OAuthSettings) (*portainer.OAuthInfo, error) {
 	token, err := getOAuthToken(code, configuration)
 	if err != nil {
 		log.Debugf("[internal,oauth] [message: failed retrieving oauth token: %v]", err)
-		return "", err
+		return nil, err
 	}
 
-	idToken, err := getIdToken(token)
-	if err != nil {
-		log.Debugf("[internal,oauth] [message: failed parsing id_token: %v]", err)
+	var idToken map[string]interface{}
+	if configuration.OIDCIssuerURL != "" {
+		idToken, err = s.getVerifiedIdToken(token, nonce, configuration)
+		if err != nil {
+			log.Debugf("[internal,oauth] [message: failed verifying id_token: %v]", err)
+			return nil, err
+		}
+	} else {
+		idToken, err = getIdToken(token)
+		if err != nil {
+			log.Debugf("[internal,oauth] [message: failed parsing id_token: %v]", err)
+		}
 	}
 
-	resource, err := getResource(token.AccessToken, configuration)
-	if err != nil {
-		log.Debugf("[internal,oauth] [message: failed retrieving resource: %v]", err)
-		return "", err
+	resource := make(map[string]interface{})
+	if configuration.OIDCIssuerURL == "" || configuration.ResourceURI != "" {
+		resource, err = getResource(token.AccessToken, configuration)
+		if err != nil {
+			log.Debugf("[internal,oauth] [message: failed retrieving resource: %v]", err)
+			return nil, err
+		}
 	}
 
 	resource = mergeSecondIntoFirst(idToken, resource)
@@ -51,9 +72,13 @@ func (*Service) Authenticate(code string, configuration *portainer.OAuthSettings
 	username, err := getUsername(resource, configuration)
 	if err != nil {
 		log.Debugf("[internal,oauth] [message: failed retrieving username: %v]", err)
-		return "", err
+		return nil, err
 	}
-	return username, nil
+
+	return &portainer.OAuthInfo{
+		Username: username,
+		Groups:   getGroups(resource, configuration.TeamMemberships.OAuthClaimName),
+	}, nil
 }
 
 // mergeSecondIntoFirst merges the overlap map into the base overwriting any existing values.
@@ -22,3 +22,27 @@ func getUsername(datamap map[string]interface{}, configuration *portainer.OAuthS
 
 	return "", errors.New("failed to extract username from oauth resource")
 }
+
+// getGroups returns the values of the group claim, which is either a single value or a list of values
+func getGroups(datamap map[string]interface{}, claimName string) []string {
+	groups := []string{}
+	if claimName == "" {
+		return groups
+	}
+
+	switch claim := datamap[claimName].(type) {
+	case string:
+		if claim != "" {
+			groups = append(groups, claim)
+		}
+	case []interface{}:
+		for _, value := range claim {
+			group, ok := value.(string)
+			if ok && group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups
+}
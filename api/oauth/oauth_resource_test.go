@@ -1,6 +1,7 @@
 package oauth
 
 import (
+	"reflect"
 	"testing"
 
 	portaineree "github.com/portainer/portainer/api"
@@ -78,3 +79,29 @@ func Test_getUsername(t *testing.T) {
 		}
 	})
 }
+
+func Test_getGroups(t *testing.T) {
+	datamap := map[string]interface{}{
+		"groups": []interface{}{"dev", "", "ops", 12},
+		"role":   "admins",
+		"count":  3,
+	}
+
+	tests := []struct {
+		claimName string
+		want      []string
+	}{
+		{claimName: "groups", want: []string{"dev", "ops"}},
+		{claimName: "role", want: []string{"admins"}},
+		{claimName: "count", want: []string{}},
+		{claimName: "missing", want: []string{}},
+		{claimName: "", want: []string{}},
+	}
+
+	for _, tc := range tests {
+		got := getGroups(datamap, tc.claimName)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("getGroups(%q) = %v, want %v", tc.claimName, got, tc.want)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package oauth
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/oauth/oauthtest"
 	"github.com/stretchr/testify/assert"
@@ -120,7 +124,7 @@ func Test_Authenticate(t *testing.T) {
 		srv, config := oauthtest.RunOAuthServer(code, &portainer.OAuthSettings{})
 		defer srv.Close()
 
-		_, err := authService.Authenticate(code, config)
+		_, err := authService.Authenticate(code, "", config)
 		if err == nil {
 			t.Error("Authenticate should fail to extract username from resource if incorrect UserIdentifier provided")
 		}
@@ -131,15 +135,134 @@ func Test_Authenticate(t *testing.T) {
 		srv, config := oauthtest.RunOAuthServer(code, config)
 		defer srv.Close()
 
-		username, err := authService.Authenticate(code, config)
+		info, err := authService.Authenticate(code, "", config)
 		if err != nil {
-			t.Errorf("Authenticate should succeed to extract username from resource if correct UserIdentifier provided; UserIdentifier=%s", config.UserIdentifier)
+			t.Fatalf("Authenticate should succeed to extract username from resource if correct UserIdentifier provided; UserIdentifier=%s", config.UserIdentifier)
 		}
 
 		want := "test-oauth-user"
-		if username != want {
-			t.Errorf("Authenticate should return correct username; got=%s, want=%s", username, want)
+		if info.Username != want {
+			t.Errorf("Authenticate should return correct username; got=%s, want=%s", info.Username, want)
 		}
 	})
 
 }
+
+func Test_AuthenticateOIDC(t *testing.T) {
+	is := assert.New(t)
+
+	code := "valid-code"
+	claims := jwt.MapClaims{
+		"preferred_username": "jane",
+		"groups":             []string{"dev", "portainer-admins"},
+		"nonce":              "valid-nonce",
+	}
+
+	srv, config := oauthtest.RunOIDCServer(code, claims, &portainer.OAuthSettings{
+		ClientID:        "portainer",
+		UserIdentifier:  "preferred_username",
+		TeamMemberships: portainer.OAuthTeamMemberships{OAuthClaimName: "groups"},
+	})
+	defer srv.Close()
+
+	err := Discover(config)
+	is.NoError(err)
+	is.Equal(config.OIDCIssuerURL+"/authorize", config.AuthorizationURI)
+	is.Equal(config.OIDCIssuerURL+"/access_token", config.AccessTokenURI)
+	is.Equal(config.OIDCIssuerURL+"/logout", config.LogoutURI)
+	is.Empty(config.ResourceURI)
+
+	authService := NewService()
+
+	t.Run("should return the username and the groups of a verified id_token", func(t *testing.T) {
+		info, err := authService.Authenticate(code, "valid-nonce", config)
+		is.NoError(err)
+		is.Equal("jane", info.Username)
+		is.Equal([]string{"dev", "portainer-admins"}, info.Groups)
+	})
+
+	t.Run("should fail if the id_token is issued for another client", func(t *testing.T) {
+		wrongClient := *config
+		wrongClient.ClientID = "another-client"
+
+		_, err := authService.Authenticate(code, "valid-nonce", &wrongClient)
+		is.Error(err)
+	})
+
+	t.Run("should fail if the nonce of the id_token doesn't match the authorization request", func(t *testing.T) {
+		_, err := authService.Authenticate(code, "another-nonce", config)
+		is.Error(err)
+
+		_, err = authService.Authenticate(code, "", config)
+		is.Error(err)
+	})
+
+	t.Run("should fail if the discovery document is unavailable", func(t *testing.T) {
+		err := Discover(&portainer.OAuthSettings{OIDCIssuerURL: config.OIDCIssuerURL + "/unknown"})
+		is.Error(err)
+	})
+
+	t.Run("should reject an id_token not signed by the issuer", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		is.NoError(err)
+
+		forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   config.OIDCIssuerURL,
+			"aud":   config.ClientID,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "valid-nonce",
+		})
+		forged.Header["kid"] = oauthtest.SigningKeyID
+		rawToken, err := forged.SignedString(key)
+		is.NoError(err)
+
+		token := (&oauth2.Token{}).WithExtra(map[string]interface{}{"id_token": rawToken})
+		_, err = authService.getVerifiedIdToken(token, "valid-nonce", config)
+		is.Error(err)
+
+		valid := oauthtest.IDToken(jwt.MapClaims{
+			"iss":   config.OIDCIssuerURL,
+			"aud":   config.ClientID,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "valid-nonce",
+		})
+		token = (&oauth2.Token{}).WithExtra(map[string]interface{}{"id_token": valid})
+		_, err = authService.getVerifiedIdToken(token, "valid-nonce", config)
+		is.NoError(err)
+	})
+
+	t.Run("should reject an expired id_token", func(t *testing.T) {
+		expired := oauthtest.IDToken(jwt.MapClaims{
+			"iss":   config.OIDCIssuerURL,
+			"aud":   config.ClientID,
+			"exp":   time.Now().Add(-time.Hour).Unix(),
+			"nonce": "valid-nonce",
+		})
+
+		token := (&oauth2.Token{}).WithExtra(map[string]interface{}{"id_token": expired})
+		_, err := authService.getVerifiedIdToken(token, "valid-nonce", config)
+		is.Error(err)
+	})
+}
+
+func Test_discoveryIsCached(t *testing.T) {
+	is := assert.New(t)
+
+	srv, config := oauthtest.RunOIDCServer("valid-code", jwt.MapClaims{}, &portainer.OAuthSettings{ClientID: "portainer"})
+
+	authService := NewService()
+
+	discovery, err := authService.discovery(config.OIDCIssuerURL)
+	is.NoError(err)
+
+	srv.Close()
+
+	cached, err := authService.discovery(config.OIDCIssuerURL)
+	is.NoError(err, "the cached discovery document should be used")
+	is.Same(discovery, cached)
+
+	authService.discoveries[config.OIDCIssuerURL] = cachedDiscovery{configuration: discovery, expiresAt: time.Now().Add(-time.Second)}
+
+	_, err = authService.discovery(config.OIDCIssuerURL)
+	is.Error(err, "an expired discovery document should be retrieved again")
+}
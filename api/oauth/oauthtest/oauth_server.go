@@ -1,12 +1,18 @@
 package oauthtest
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
 	portainer "github.com/portainer/portainer/api"
 )
@@ -94,3 +100,96 @@ func RunOAuthServer(code string, config *portainer.OAuthSettings) (*httptest.Ser
 
 	return srv, config
 }
+
+// SigningKeyID is the identifier of the key signing the ID tokens of the OpenID Connect test server
+const SigningKeyID = "test-key"
+
+var signingKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+// IDToken returns an ID token holding the claims, signed with the key published by the OpenID Connect test server
+func IDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = SigningKeyID
+
+	signed, _ := token.SignedString(signingKey)
+	return signed
+}
+
+// RunOIDCServer is a barebones OpenID Connect provider which can be used to test the discovery and the verification of the ID tokens.
+// The ID token returned for the code holds the given claims, completed with the issuer, the audience and the expiry when they are missing.
+func RunOIDCServer(code string, claims jwt.MapClaims, config *portainer.OAuthSettings) (*httptest.Server, *portainer.OAuthSettings) {
+	srv := httptest.NewUnstartedServer(http.DefaultServeMux)
+
+	issuer := fmt.Sprintf("http://%s", srv.Listener.Addr())
+
+	config.OIDCIssuerURL = issuer
+	config.RedirectURI = issuer + "/"
+
+	router := mux.NewRouter()
+
+	router.HandleFunc(
+		"/.well-known/openid-configuration",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"issuer":                 issuer,
+				"authorization_endpoint": issuer + "/authorize",
+				"token_endpoint":         issuer + "/access_token",
+				"jwks_uri":               issuer + "/jwks",
+				"end_session_endpoint":   issuer + "/logout",
+			})
+		},
+	).Methods(http.MethodGet)
+
+	router.HandleFunc(
+		"/jwks",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"keys": []map[string]interface{}{
+					{
+						"kid": SigningKeyID,
+						"kty": "RSA",
+						"use": "sig",
+						"alg": "RS256",
+						"n":   base64.RawURLEncoding.EncodeToString(signingKey.PublicKey.N.Bytes()),
+						"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signingKey.PublicKey.E)).Bytes()),
+					},
+				},
+			})
+		},
+	).Methods(http.MethodGet)
+
+	router.HandleFunc(
+		"/access_token",
+		func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			if err := req.ParseForm(); err != nil || req.FormValue("code") != code {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			idTokenClaims := jwt.MapClaims{
+				"iss": issuer,
+				"aud": config.ClientID,
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}
+			for k, v := range claims {
+				idTokenClaims[k] = v
+			}
+
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token_type":   "Bearer",
+				"expires_in":   86400,
+				"access_token": AccessToken,
+				"id_token":     IDToken(idTokenClaims),
+			})
+		},
+	).Methods(http.MethodPost)
+
+	srv.Config.Handler = router
+	srv.Start()
+
+	return srv, config
+}
@@ -0,0 +1,293 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"golang.org/x/oauth2"
+)
+
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// oidcDiscoveryCacheDuration is the time a discovery document is used before being retrieved again
+const oidcDiscoveryCacheDuration = time.Hour
+
+// signingMethods are the asymmetric algorithms accepted for the ID tokens, the symmetric
+// ones would let anyone knowing the client secret forge a token
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcConfiguration represents the fields used by Portainer in the OpenID Connect discovery document
+type oidcConfiguration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+type cachedDiscovery struct {
+	configuration *oidcConfiguration
+	expiresAt     time.Time
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Discover retrieves the OpenID Connect configuration of the issuer and fills the authorization,
+// token and user info endpoints of the settings with it. The logout URI is only filled when empty.
+func Discover(configuration *portainer.OAuthSettings) error {
+	discovery, err := discover(configuration.OIDCIssuerURL)
+	if err != nil {
+		return err
+	}
+
+	configuration.AuthorizationURI = discovery.AuthorizationEndpoint
+	configuration.AccessTokenURI = discovery.TokenEndpoint
+	configuration.ResourceURI = discovery.UserinfoEndpoint
+	if configuration.LogoutURI == "" {
+		configuration.LogoutURI = discovery.EndSessionEndpoint
+	}
+
+	return nil
+}
+
+func discover(issuerURL string) (*oidcConfiguration, error) {
+	var discovery oidcConfiguration
+	err := getJSON(strings.TrimSuffix(issuerURL, "/")+oidcDiscoveryPath, &discovery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the OpenID Connect configuration")
+	}
+
+	// the issuer must be identical to the URL used to retrieve the configuration, see OpenID Connect Discovery 1.0 section 4.3
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return nil, errors.Errorf("issuer mismatch in the OpenID Connect configuration: %s", discovery.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("incomplete OpenID Connect configuration")
+	}
+
+	return &discovery, nil
+}
+
+// discovery returns the OpenID Connect configuration of the issuer, cached for oidcDiscoveryCacheDuration
+// so that the logins don't retrieve it every time
+func (s *Service) discovery(issuerURL string) (*oidcConfiguration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, ok := s.discoveries[issuerURL]
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.configuration, nil
+	}
+
+	configuration, err := discover(issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.discoveries == nil {
+		s.discoveries = map[string]cachedDiscovery{}
+	}
+	s.discoveries[issuerURL] = cachedDiscovery{configuration: configuration, expiresAt: time.Now().Add(oidcDiscoveryCacheDuration)}
+
+	return configuration, nil
+}
+
+func getJSON(url string, v interface{}) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// getVerifiedIdToken returns the claims of the id_token of the OAuth token response after verifying its signature
+// with the keys of the issuer, its issuer, its audience, its expiry and its nonce
+func (s *Service) getVerifiedIdToken(token *oauth2.Token, nonce string, configuration *portainer.OAuthSettings) (map[string]interface{}, error) {
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIdToken == "" {
+		return nil, errors.New("missing id_token in the token response")
+	}
+
+	discovery, err := s.discovery(configuration.OIDCIssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.Parser{ValidMethods: signingMethods}
+	t, err := parser.Parse(rawIdToken, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.publicKey(discovery.JWKSURI, kid)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify id_token")
+	}
+
+	claims, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid id_token claims")
+	}
+
+	if !claims.VerifyIssuer(discovery.Issuer, true) {
+		return nil, errors.New("invalid id_token issuer")
+	}
+
+	if !claims.VerifyAudience(configuration.ClientID, true) {
+		return nil, errors.New("invalid id_token audience")
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("expired id_token")
+	}
+
+	// the nonce binds the id_token to the authorization request of the browser, a replayed id_token is rejected
+	tokenNonce, _ := claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid id_token nonce")
+	}
+
+	return claims, nil
+}
+
+// publicKey returns the key of the JWKS identified by kid. The keys are cached and retrieved again when
+// the key is unknown, to follow the key rotations of the issuer.
+func (s *Service) publicKey(jwksURI, kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := findKey(s.keys[jwksURI], kid)
+	if ok {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err := getJSON(jwksURI, &jwks)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the JWKS")
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseJSONWebKey(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if s.keys == nil {
+		s.keys = map[string]map[string]interface{}{}
+	}
+	s.keys[jwksURI] = keys
+
+	key, ok = findKey(keys, kid)
+	if !ok {
+		return nil, errors.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// findKey returns the key identified by kid, or the only key of the set when the token does not identify its key
+func findKey(keys map[string]interface{}, kid string) (interface{}, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	key, ok := keys[kid]
+	return key, ok && kid != ""
+}
+
+func parseJSONWebKey(jwk jsonWebKey) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %s", jwk.Crv)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, errors.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,183 @@
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+)
+
+// maxMetadataSize is the maximum size of the identity provider metadata retrieved from its URL
+const maxMetadataSize = 1 << 20
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// entityDescriptor represents the fields used by Portainer in the metadata of the identity provider
+type entityDescriptor struct {
+	XMLName          xml.Name           `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID         string             `xml:"entityID,attr"`
+	IDPSSODescriptor []idpSSODescriptor `xml:"urn:oasis:names:tc:SAML:2.0:metadata IDPSSODescriptor"`
+}
+
+type idpSSODescriptor struct {
+	KeyDescriptors      []keyDescriptor `xml:"urn:oasis:names:tc:SAML:2.0:metadata KeyDescriptor"`
+	SingleSignOnService []endpoint      `xml:"urn:oasis:names:tc:SAML:2.0:metadata SingleSignOnService"`
+}
+
+type keyDescriptor struct {
+	Use          string   `xml:"use,attr"`
+	Certificates []string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+type endpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+// identityProvider represents an identity provider described by its metadata
+type identityProvider struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// FetchMetadata retrieves the metadata of the identity provider from its URL
+func FetchMetadata(url string) (string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve the identity provider metadata")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the identity provider metadata")
+	}
+
+	return string(data), nil
+}
+
+// ValidateIdentityProviderMetadata returns an error when the metadata does not describe an identity provider
+// supporting the HTTP-Redirect binding with at least one valid signing certificate
+func ValidateIdentityProviderMetadata(metadata string) error {
+	_, err := parseIdentityProviderMetadata(metadata)
+	return err
+}
+
+func parseIdentityProviderMetadata(metadata string) (*identityProvider, error) {
+	var descriptor entityDescriptor
+	err := xml.Unmarshal([]byte(metadata), &descriptor)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid identity provider metadata")
+	}
+
+	if descriptor.EntityID == "" || len(descriptor.IDPSSODescriptor) == 0 {
+		return nil, errors.New("the metadata does not describe an identity provider")
+	}
+
+	idp := &identityProvider{EntityID: descriptor.EntityID}
+	for _, sso := range descriptor.IDPSSODescriptor {
+		for _, service := range sso.SingleSignOnService {
+			if service.Binding == redirectBinding && idp.SSOURL == "" {
+				idp.SSOURL = service.Location
+			}
+		}
+
+		for _, key := range sso.KeyDescriptors {
+			if key.Use != "" && key.Use != "signing" {
+				continue
+			}
+
+			for _, data := range key.Certificates {
+				der, err := base64.StdEncoding.DecodeString(whitespace.ReplaceAllString(data, ""))
+				if err != nil {
+					return nil, errors.Wrap(err, "invalid identity provider certificate")
+				}
+
+				certificate, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, errors.Wrap(err, "invalid identity provider certificate")
+				}
+
+				idp.Certificates = append(idp.Certificates, certificate)
+			}
+		}
+	}
+
+	if idp.SSOURL == "" {
+		return nil, errors.New("the identity provider does not support the HTTP-Redirect binding")
+	}
+
+	if len(idp.Certificates) == 0 {
+		return nil, errors.New("the identity provider metadata does not contain any signing certificate")
+	}
+
+	return idp, nil
+}
+
+// GenerateKeyPair returns a PEM encoded self-signed certificate and its private key, used by Portainer
+// to sign the authentication requests
+func GenerateKeyPair(commonName string) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return string(certificate), string(privateKey), nil
+}
+
+// loadKeyPair returns the private key and the DER encoded certificate of the service provider
+func loadKeyPair(settings *portainer.SAMLSettings) (*rsa.PrivateKey, []byte, error) {
+	pair, err := tls.X509KeyPair([]byte(settings.SPCertificate), []byte(settings.SPPrivateKey))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid service provider key pair")
+	}
+
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("the service provider private key must be a RSA key")
+	}
+
+	return key, pair.Certificate[0], nil
+}
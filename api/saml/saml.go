@@ -0,0 +1,556 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	// AssertionConsumerServicePath is the path of the endpoint receiving the responses of the identity provider
+	AssertionConsumerServicePath = "/api/auth/saml/acs"
+	// LoginPath is the path of the endpoint redirecting the users to the identity provider
+	LoginPath = "/api/auth/saml/login"
+	// MetadataPath is the path of the endpoint serving the service provider metadata
+	MetadataPath = "/api/auth/saml/metadata"
+
+	protocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	assertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	metadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	redirectBinding    = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	postBinding        = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	bearerMethod       = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	unspecifiedFormat  = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	rsaSHA256          = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+
+	timeFormat = "2006-01-02T15:04:05Z"
+	// requestTimeout is the time a user has to authenticate against the identity provider
+	requestTimeout = 5 * time.Minute
+	// clockSkew is the difference tolerated between the clocks of Portainer and of the identity provider
+	clockSkew = 3 * time.Minute
+)
+
+// Service represents a SAML 2.0 service provider. The identifiers of the authentication requests are kept
+// in memory until the identity provider responds to them, the unsolicited responses are rejected.
+type Service struct {
+	mu       sync.Mutex
+	requests map[string]time.Time
+}
+
+// NewService returns a pointer to a new instance of this service
+func NewService() *Service {
+	return &Service{
+		requests: map[string]time.Time{},
+	}
+}
+
+// AssertionConsumerServiceURL returns the URL receiving the responses of the identity provider
+func AssertionConsumerServiceURL(settings *portainer.SAMLSettings) string {
+	return strings.TrimSuffix(settings.RootURL, "/") + AssertionConsumerServicePath
+}
+
+// Metadata returns the metadata of Portainer as a service provider, to register it in the identity provider
+func (*Service) Metadata(settings *portainer.SAMLSettings) ([]byte, error) {
+	_, certificate, err := loadKeyPair(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+
+	descriptor := doc.CreateElement("md:EntityDescriptor")
+	descriptor.CreateAttr("xmlns:md", metadataNamespace)
+	descriptor.CreateAttr("xmlns:ds", dsig.Namespace)
+	descriptor.CreateAttr("entityID", settings.EntityID)
+
+	sp := descriptor.CreateElement("md:SPSSODescriptor")
+	sp.CreateAttr("AuthnRequestsSigned", "true")
+	sp.CreateAttr("WantAssertionsSigned", "true")
+	sp.CreateAttr("protocolSupportEnumeration", protocolNamespace)
+
+	key := sp.CreateElement("md:KeyDescriptor")
+	key.CreateAttr("use", "signing")
+	key.CreateElement("ds:KeyInfo").CreateElement("ds:X509Data").CreateElement("ds:X509Certificate").SetText(base64.StdEncoding.EncodeToString(certificate))
+
+	sp.CreateElement("md:NameIDFormat").SetText(unspecifiedFormat)
+
+	acs := sp.CreateElement("md:AssertionConsumerService")
+	acs.CreateAttr("Binding", postBinding)
+	acs.CreateAttr("Location", AssertionConsumerServiceURL(settings))
+	acs.CreateAttr("index", "0")
+	acs.CreateAttr("isDefault", "true")
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+// AuthenticationRequestURL returns the URL of the identity provider the users are redirected to in order to
+// authenticate. The authentication request is sent with the HTTP-Redirect binding and signed with the key of
+// the service provider.
+func (service *Service) AuthenticationRequestURL(settings *portainer.SAMLSettings) (string, error) {
+	idp, err := parseIdentityProviderMetadata(settings.IdPMetadata)
+	if err != nil {
+		return "", err
+	}
+
+	key, _, err := loadKeyPair(settings)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
+	doc := etree.NewDocument()
+	request := doc.CreateElement("samlp:AuthnRequest")
+	request.CreateAttr("xmlns:samlp", protocolNamespace)
+	request.CreateAttr("xmlns:saml", assertionNamespace)
+	request.CreateAttr("ID", id)
+	request.CreateAttr("Version", "2.0")
+	request.CreateAttr("IssueInstant", time.Now().UTC().Format(timeFormat))
+	request.CreateAttr("Destination", idp.SSOURL)
+	request.CreateAttr("AssertionConsumerServiceURL", AssertionConsumerServiceURL(settings))
+	request.CreateAttr("ProtocolBinding", postBinding)
+	request.CreateElement("saml:Issuer").SetText(settings.EntityID)
+	request.CreateElement("samlp:NameIDPolicy").CreateAttr("AllowCreate", "true")
+
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	_, err = writer.Write(data)
+	if err != nil {
+		return "", err
+	}
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	// the signature covers the query string in the order defined by the section 3.4.4.1 of the SAML bindings
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(buf.Bytes())) +
+		"&SigAlg=" + url.QueryEscape(rsaSHA256)
+
+	digest := sha256.Sum256([]byte(query))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign the authentication request")
+	}
+	query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+
+	service.addRequest(id)
+
+	separator := "?"
+	if strings.Contains(idp.SSOURL, "?") {
+		separator = "&"
+	}
+
+	return idp.SSOURL + separator + query, nil
+}
+
+// Authenticate validates the base64 encoded response of the identity provider and returns the username and
+// the groups of the user. The response must answer a pending authentication request and either the response
+// or its assertion must be signed by one of the certificates of the identity provider metadata. Only the
+// signed content is used to identify the user.
+func (service *Service) Authenticate(samlResponse string, settings *portainer.SAMLSettings) (*portainer.SAMLInfo, error) {
+	idp, err := parseIdentityProviderMetadata(settings.IdPMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SAML response encoding")
+	}
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SAML response")
+	}
+
+	response := doc.Root()
+	if response == nil || response.Tag != "Response" || response.NamespaceURI() != protocolNamespace {
+		return nil, errors.New("invalid SAML response")
+	}
+
+	validator := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: idp.Certificates})
+
+	signature, err := findChild(response, dsig.Namespace, dsig.SignatureTag)
+	if err != nil {
+		return nil, err
+	}
+
+	if signature != nil {
+		response, err = validator.Validate(response)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid SAML response signature")
+		}
+	}
+
+	assertion, err := findAssertion(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if signature == nil {
+		assertion, err = validateAssertionSignature(validator, assertion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = validateResponse(response, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	requestID := response.SelectAttrValue("InResponseTo", "")
+	if !service.consumeRequest(requestID) {
+		return nil, errors.New("the SAML response does not answer a pending authentication request")
+	}
+
+	err = validateAssertion(assertion, idp, requestID, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return assertionInfo(assertion, settings)
+}
+
+func (service *Service) addRequest(id string) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	now := time.Now()
+	for requestID, expiresAt := range service.requests {
+		if now.After(expiresAt) {
+			delete(service.requests, requestID)
+		}
+	}
+
+	service.requests[id] = now.Add(requestTimeout)
+}
+
+// consumeRequest returns true when the request is pending, a request can only be answered once
+func (service *Service) consumeRequest(id string) bool {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	expiresAt, ok := service.requests[id]
+	if !ok {
+		return false
+	}
+	delete(service.requests, id)
+
+	return time.Now().Before(expiresAt)
+}
+
+// findAssertion returns the only assertion of the response
+func findAssertion(response *etree.Element) (*etree.Element, error) {
+	encrypted, err := findChild(response, assertionNamespace, "EncryptedAssertion")
+	if err != nil {
+		return nil, err
+	}
+	if encrypted != nil {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+
+	assertions, err := findChildren(response, assertionNamespace, "Assertion")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(assertions) != 1 {
+		return nil, errors.New("the SAML response must contain exactly one assertion")
+	}
+
+	return assertions[0], nil
+}
+
+// validateAssertionSignature verifies the signature of an assertion of an unsigned response and returns the
+// signed assertion. The assertion is detached from the response with the namespaces declared by its ancestors.
+func validateAssertionSignature(validator *dsig.ValidationContext, assertion *etree.Element) (*etree.Element, error) {
+	ctx, err := etreeutils.NSBuildParentContext(assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	detached, err := etreeutils.NSDetatch(ctx, assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	validated, err := validator.Validate(detached)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid SAML assertion signature")
+	}
+
+	return validated, nil
+}
+
+func validateResponse(response *etree.Element, settings *portainer.SAMLSettings) error {
+	status, err := findChild(response, protocolNamespace, "Status")
+	if err != nil || status == nil {
+		return errors.New("missing SAML response status")
+	}
+
+	statusCode, err := findChild(status, protocolNamespace, "StatusCode")
+	if err != nil || statusCode == nil {
+		return errors.New("missing SAML response status")
+	}
+
+	if value := statusCode.SelectAttrValue("Value", ""); value != statusSuccess {
+		return errors.Errorf("the identity provider rejected the authentication: %s", value)
+	}
+
+	destination := response.SelectAttrValue("Destination", "")
+	if destination != "" && destination != AssertionConsumerServiceURL(settings) {
+		return errors.Errorf("invalid SAML response destination: %s", destination)
+	}
+
+	return nil
+}
+
+func validateAssertion(assertion *etree.Element, idp *identityProvider, requestID string, settings *portainer.SAMLSettings) error {
+	now := time.Now()
+
+	issuer, err := findChild(assertion, assertionNamespace, "Issuer")
+	if err != nil || issuer == nil || strings.TrimSpace(issuer.Text()) != idp.EntityID {
+		return errors.New("invalid SAML assertion issuer")
+	}
+
+	conditions, err := findChild(assertion, assertionNamespace, "Conditions")
+	if err != nil || conditions == nil {
+		return errors.New("missing SAML assertion conditions")
+	}
+
+	err = validateTimeRange(conditions, now)
+	if err != nil {
+		return err
+	}
+
+	err = validateAudience(conditions, settings.EntityID)
+	if err != nil {
+		return err
+	}
+
+	subject, err := findChild(assertion, assertionNamespace, "Subject")
+	if err != nil || subject == nil {
+		return errors.New("missing SAML assertion subject")
+	}
+
+	confirmations, err := findChildren(subject, assertionNamespace, "SubjectConfirmation")
+	if err != nil {
+		return err
+	}
+
+	// at least one bearer confirmation must be addressed to Portainer, in response to the request, and still valid
+	for _, confirmation := range confirmations {
+		if confirmation.SelectAttrValue("Method", "") != bearerMethod {
+			continue
+		}
+
+		data, err := findChild(confirmation, assertionNamespace, "SubjectConfirmationData")
+		if err != nil || data == nil {
+			continue
+		}
+
+		notOnOrAfter, err := time.Parse(time.RFC3339, data.SelectAttrValue("NotOnOrAfter", ""))
+		if err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			continue
+		}
+
+		if data.SelectAttrValue("Recipient", "") == AssertionConsumerServiceURL(settings) && data.SelectAttrValue("InResponseTo", "") == requestID {
+			return nil
+		}
+	}
+
+	return errors.New("invalid SAML assertion subject confirmation")
+}
+
+func validateTimeRange(conditions *etree.Element, now time.Time) error {
+	if value := conditions.SelectAttrValue("NotBefore", ""); value != "" {
+		notBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.Wrap(err, "invalid SAML assertion conditions")
+		}
+
+		if now.Add(clockSkew).Before(notBefore) {
+			return errors.New("the SAML assertion is not yet valid")
+		}
+	}
+
+	if value := conditions.SelectAttrValue("NotOnOrAfter", ""); value != "" {
+		notOnOrAfter, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.Wrap(err, "invalid SAML assertion conditions")
+		}
+
+		if !now.Before(notOnOrAfter.Add(clockSkew)) {
+			return errors.New("the SAML assertion is expired")
+		}
+	}
+
+	return nil
+}
+
+// validateAudience returns an error unless each audience restriction of the conditions contains the entity ID
+func validateAudience(conditions *etree.Element, entityID string) error {
+	restrictions, err := findChildren(conditions, assertionNamespace, "AudienceRestriction")
+	if err != nil {
+		return err
+	}
+
+	if len(restrictions) == 0 {
+		return errors.New("missing SAML assertion audience restriction")
+	}
+
+	for _, restriction := range restrictions {
+		audiences, err := findChildren(restriction, assertionNamespace, "Audience")
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, audience := range audiences {
+			if strings.TrimSpace(audience.Text()) == entityID {
+				found = true
+			}
+		}
+
+		if !found {
+			return errors.New("Portainer is not an audience of the SAML assertion")
+		}
+	}
+
+	return nil
+}
+
+// assertionInfo returns the username and the groups of the user from the attributes of the assertion. The
+// attributes are identified by their name or their friendly name, the NameID is used when no username
+// attribute is configured.
+func assertionInfo(assertion *etree.Element, settings *portainer.SAMLSettings) (*portainer.SAMLInfo, error) {
+	statements, err := findChildren(assertion, assertionNamespace, "AttributeStatement")
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string][]string{}
+	for _, statement := range statements {
+		elements, err := findChildren(statement, assertionNamespace, "Attribute")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, attribute := range elements {
+			values, err := findChildren(attribute, assertionNamespace, "AttributeValue")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, name := range []string{attribute.SelectAttrValue("Name", ""), attribute.SelectAttrValue("FriendlyName", "")} {
+				if name == "" {
+					continue
+				}
+
+				for _, value := range values {
+					attributes[name] = append(attributes[name], strings.TrimSpace(value.Text()))
+				}
+			}
+		}
+	}
+
+	info := &portainer.SAMLInfo{Groups: attributes[settings.GroupsAttribute]}
+
+	if settings.UserNameAttribute != "" {
+		if values := attributes[settings.UserNameAttribute]; len(values) > 0 {
+			info.Username = values[0]
+		}
+	} else {
+		subject, err := findChild(assertion, assertionNamespace, "Subject")
+		if err != nil {
+			return nil, err
+		}
+
+		if subject != nil {
+			nameID, err := findChild(subject, assertionNamespace, "NameID")
+			if err != nil {
+				return nil, err
+			}
+
+			if nameID != nil {
+				info.Username = strings.TrimSpace(nameID.Text())
+			}
+		}
+	}
+
+	if info.Username == "" {
+		return nil, errors.New("unable to find the username in the SAML assertion")
+	}
+
+	return info, nil
+}
+
+// findChild returns the first child of the element matching the namespace and the tag, the prefixes are
+// resolved with the namespaces declared by the element and its ancestors
+func findChild(el *etree.Element, namespace, tag string) (*etree.Element, error) {
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+
+	return etreeutils.NSFindOneChildCtx(ctx, el, namespace, tag)
+}
+
+// findChildren returns the children of the element matching the namespace and the tag
+func findChildren(el *etree.Element, namespace, tag string) ([]*etree.Element, error) {
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*etree.Element{}
+	err = etreeutils.NSFindChildrenIterateCtx(ctx, el, namespace, tag, func(ctx etreeutils.NSContext, child *etree.Element) error {
+		children = append(children, child)
+		return nil
+	})
+
+	return children, err
+}
+
+// randomID returns an identifier for a request, which must not start with a digit to be a valid xsd:ID
+func randomID() (string, error) {
+	data := make([]byte, 20)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+
+	return "id-" + hex.EncodeToString(data), nil
+}
@@ -0,0 +1,191 @@
+package saml
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/saml/samltest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rootURL  = "https://portainer.example.org"
+	entityID = rootURL + MetadataPath
+	acsURL   = rootURL + AssertionConsumerServicePath
+)
+
+func setupSAML(t *testing.T) (*Service, *samltest.IdentityProvider, *portainer.SAMLSettings) {
+	idp, err := samltest.NewIdentityProvider("https://idp.example.org", "https://idp.example.org/sso")
+	assert.NoError(t, err)
+
+	certificate, key, err := GenerateKeyPair("portainer")
+	assert.NoError(t, err)
+
+	settings := &portainer.SAMLSettings{
+		IdPMetadata:       idp.Metadata(),
+		RootURL:           rootURL,
+		EntityID:          entityID,
+		UserNameAttribute: "upn",
+		GroupsAttribute:   "groups",
+		SPCertificate:     certificate,
+		SPPrivateKey:      key,
+	}
+
+	return NewService(), idp, settings
+}
+
+func newRequest(t *testing.T, service *Service, idp *samltest.IdentityProvider, settings *portainer.SAMLSettings) string {
+	requestURL, err := service.AuthenticationRequestURL(settings)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(requestURL, idp.SSOURL+"?SAMLRequest="))
+
+	id, err := idp.RequestID(requestURL, settings.SPCertificate)
+	assert.NoError(t, err)
+
+	return id
+}
+
+func validResponse(requestID string) samltest.Response {
+	return samltest.Response{
+		InResponseTo: requestID,
+		Destination:  acsURL,
+		Audience:     entityID,
+		NameID:       "alice-id",
+		Attributes: map[string][]string{
+			"upn":    {"alice@example.org"},
+			"groups": {"developers", "ops"},
+		},
+		SignAssertion: true,
+	}
+}
+
+func Test_Metadata(t *testing.T) {
+	is := assert.New(t)
+
+	service, _, settings := setupSAML(t)
+
+	metadata, err := service.Metadata(settings)
+	is.NoError(err)
+	is.Contains(string(metadata), `entityID="`+entityID+`"`)
+	is.Contains(string(metadata), `AuthnRequestsSigned="true"`)
+	is.Contains(string(metadata), `Location="`+acsURL+`"`)
+}
+
+func Test_ValidateIdentityProviderMetadata(t *testing.T) {
+	is := assert.New(t)
+
+	_, idp, _ := setupSAML(t)
+
+	is.NoError(ValidateIdentityProviderMetadata(idp.Metadata()))
+	is.Error(ValidateIdentityProviderMetadata("<html></html>"))
+	is.Error(ValidateIdentityProviderMetadata(strings.Replace(idp.Metadata(), "HTTP-Redirect", "HTTP-POST", 1)))
+}
+
+func Test_Authenticate(t *testing.T) {
+	is := assert.New(t)
+
+	service, idp, settings := setupSAML(t)
+
+	t.Run("signed assertion", func(t *testing.T) {
+		response, err := idp.Encode(validResponse(newRequest(t, service, idp, settings)))
+		is.NoError(err)
+
+		info, err := service.Authenticate(response, settings)
+		is.NoError(err)
+		is.Equal(&portainer.SAMLInfo{Username: "alice@example.org", Groups: []string{"developers", "ops"}}, info)
+	})
+
+	t.Run("signed response uses the NameID when no username attribute is set", func(t *testing.T) {
+		r := validResponse(newRequest(t, service, idp, settings))
+		r.SignAssertion = false
+		r.SignResponse = true
+
+		response, err := idp.Encode(r)
+		is.NoError(err)
+
+		nameIDSettings := *settings
+		nameIDSettings.UserNameAttribute = ""
+
+		info, err := service.Authenticate(response, &nameIDSettings)
+		is.NoError(err)
+		is.Equal("alice-id", info.Username)
+	})
+
+	t.Run("a response can only be used once", func(t *testing.T) {
+		response, err := idp.Encode(validResponse(newRequest(t, service, idp, settings)))
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.Error(err)
+	})
+
+	t.Run("unsolicited responses are rejected", func(t *testing.T) {
+		response, err := idp.Encode(validResponse("id-unknown"))
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.Error(err)
+	})
+
+	t.Run("unsigned responses are rejected", func(t *testing.T) {
+		r := validResponse(newRequest(t, service, idp, settings))
+		r.SignAssertion = false
+
+		response, err := idp.Encode(r)
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.Error(err)
+	})
+
+	t.Run("tampered assertions are rejected", func(t *testing.T) {
+		response, err := idp.Encode(validResponse(newRequest(t, service, idp, settings)))
+		is.NoError(err)
+
+		data, err := base64.StdEncoding.DecodeString(response)
+		is.NoError(err)
+		tampered := strings.Replace(string(data), "alice@example.org", "admin", 1)
+
+		_, err = service.Authenticate(base64.StdEncoding.EncodeToString([]byte(tampered)), settings)
+		is.Error(err)
+	})
+
+	t.Run("assertions signed by another identity provider are rejected", func(t *testing.T) {
+		other, err := samltest.NewIdentityProvider(idp.EntityID, idp.SSOURL)
+		is.NoError(err)
+
+		response, err := other.Encode(validResponse(newRequest(t, service, idp, settings)))
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.Error(err)
+	})
+
+	t.Run("assertions for another audience are rejected", func(t *testing.T) {
+		r := validResponse(newRequest(t, service, idp, settings))
+		r.Audience = "https://other.example.org"
+
+		response, err := idp.Encode(r)
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.Error(err)
+	})
+
+	t.Run("expired assertions are rejected", func(t *testing.T) {
+		r := validResponse(newRequest(t, service, idp, settings))
+		r.NotOnOrAfter = time.Now().Add(-time.Hour)
+
+		response, err := idp.Encode(r)
+		is.NoError(err)
+
+		_, err = service.Authenticate(response, settings)
+		is.Error(err)
+	})
+}
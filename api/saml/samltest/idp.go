@@ -0,0 +1,231 @@
+package samltest
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const timeFormat = "2006-01-02T15:04:05Z"
+
+// IdentityProvider is a SAML identity provider signing its responses with a self-signed certificate
+type IdentityProvider struct {
+	EntityID    string
+	SSOURL      string
+	key         *rsa.PrivateKey
+	certificate []byte
+}
+
+// Response describes the response sent by the identity provider to the service provider
+type Response struct {
+	InResponseTo string
+	Destination  string
+	Audience     string
+	NameID       string
+	Attributes   map[string][]string
+	// NotOnOrAfter is the end of the validity of the assertion, defaults to 5 minutes from now
+	NotOnOrAfter  time.Time
+	SignResponse  bool
+	SignAssertion bool
+}
+
+// NewIdentityProvider creates an identity provider with a new signing key
+func NewIdentityProvider(entityID, ssoURL string) (*IdentityProvider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: entityID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IdentityProvider{EntityID: entityID, SSOURL: ssoURL, key: key, certificate: certificate}, nil
+}
+
+// Metadata returns the metadata of the identity provider
+func (idp *IdentityProvider) Metadata() string {
+	doc := etree.NewDocument()
+
+	descriptor := doc.CreateElement("md:EntityDescriptor")
+	descriptor.CreateAttr("xmlns:md", "urn:oasis:names:tc:SAML:2.0:metadata")
+	descriptor.CreateAttr("xmlns:ds", dsig.Namespace)
+	descriptor.CreateAttr("entityID", idp.EntityID)
+
+	sso := descriptor.CreateElement("md:IDPSSODescriptor")
+	sso.CreateAttr("protocolSupportEnumeration", "urn:oasis:names:tc:SAML:2.0:protocol")
+
+	key := sso.CreateElement("md:KeyDescriptor")
+	key.CreateAttr("use", "signing")
+	key.CreateElement("ds:KeyInfo").CreateElement("ds:X509Data").CreateElement("ds:X509Certificate").SetText(base64.StdEncoding.EncodeToString(idp.certificate))
+
+	service := sso.CreateElement("md:SingleSignOnService")
+	service.CreateAttr("Binding", "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect")
+	service.CreateAttr("Location", idp.SSOURL)
+
+	metadata, _ := doc.WriteToString()
+	return metadata
+}
+
+// RequestID verifies the signature of an authentication request sent with the HTTP-Redirect binding against
+// the PEM encoded certificate of the service provider and returns the identifier of the request
+func (idp *IdentityProvider) RequestID(requestURL, certificate string) (string, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return "", errors.New("invalid service provider certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return "", errors.New("the service provider certificate must hold a RSA key")
+	}
+
+	signedQuery := u.RawQuery[:strings.Index(u.RawQuery, "&Signature=")]
+	signature, err := base64.StdEncoding.DecodeString(u.Query().Get("Signature"))
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256([]byte(signedQuery))
+	err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature)
+	if err != nil {
+		return "", err
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return "", err
+	}
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromBytes(data)
+	if err != nil {
+		return "", err
+	}
+
+	return doc.Root().SelectAttrValue("ID", ""), nil
+}
+
+// Encode returns the base64 encoded response, as posted by the browser to the assertion consumer service
+func (idp *IdentityProvider) Encode(r Response) (string, error) {
+	now := time.Now().UTC()
+	notOnOrAfter := r.NotOnOrAfter
+	if notOnOrAfter.IsZero() {
+		notOnOrAfter = now.Add(5 * time.Minute)
+	}
+
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	assertion.CreateAttr("ID", "assertion-"+r.InResponseTo)
+	assertion.CreateAttr("Version", "2.0")
+	assertion.CreateAttr("IssueInstant", now.Format(timeFormat))
+	assertion.CreateElement("saml:Issuer").SetText(idp.EntityID)
+
+	subject := assertion.CreateElement("saml:Subject")
+	subject.CreateElement("saml:NameID").SetText(r.NameID)
+	confirmation := subject.CreateElement("saml:SubjectConfirmation")
+	confirmation.CreateAttr("Method", "urn:oasis:names:tc:SAML:2.0:cm:bearer")
+	confirmationData := confirmation.CreateElement("saml:SubjectConfirmationData")
+	confirmationData.CreateAttr("InResponseTo", r.InResponseTo)
+	confirmationData.CreateAttr("NotOnOrAfter", notOnOrAfter.Format(timeFormat))
+	confirmationData.CreateAttr("Recipient", r.Destination)
+
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", now.Add(-time.Minute).Format(timeFormat))
+	conditions.CreateAttr("NotOnOrAfter", notOnOrAfter.Format(timeFormat))
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(r.Audience)
+
+	statement := assertion.CreateElement("saml:AttributeStatement")
+	for name, values := range r.Attributes {
+		attribute := statement.CreateElement("saml:Attribute")
+		attribute.CreateAttr("Name", name)
+		for _, value := range values {
+			attribute.CreateElement("saml:AttributeValue").SetText(value)
+		}
+	}
+
+	var err error
+	if r.SignAssertion {
+		assertion, err = idp.sign(assertion)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	response := etree.NewElement("samlp:Response")
+	response.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	response.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	response.CreateAttr("ID", "response-"+r.InResponseTo)
+	response.CreateAttr("Version", "2.0")
+	response.CreateAttr("IssueInstant", now.Format(timeFormat))
+	response.CreateAttr("Destination", r.Destination)
+	response.CreateAttr("InResponseTo", r.InResponseTo)
+	response.CreateElement("saml:Issuer").SetText(idp.EntityID)
+	response.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Success")
+	response.AddChild(assertion)
+
+	if r.SignResponse {
+		response, err = idp.sign(response)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	doc := etree.NewDocument()
+	doc.SetRoot(response)
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func (idp *IdentityProvider) sign(el *etree.Element) (*etree.Element, error) {
+	ctx, err := dsig.NewSigningContext(idp.key, [][]byte{idp.certificate})
+	if err != nil {
+		return nil, err
+	}
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+
+	return ctx.SignEnveloped(el)
+}
@@ -83,6 +83,22 @@ func (s *Scheduler) StopJob(jobID string) error {
 // Returns job id that could be used to stop the given job.
 // When job run returns an error, that job won't be run again.
 func (s *Scheduler) StartJobEvery(duration time.Duration, job func() error) string {
+	return s.startJob(cron.Every(duration), job)
+}
+
+// StartJobWithCronRule schedules a new job using a standard cron expression, e.g. "0 2 * * *".
+// Returns job id that could be used to stop the given job.
+// When job run returns an error, that job won't be run again.
+func (s *Scheduler) StartJobWithCronRule(cronRule string, job func() error) (string, error) {
+	schedule, err := cron.ParseStandard(cronRule)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse cron rule %q", cronRule)
+	}
+
+	return s.startJob(schedule, job), nil
+}
+
+func (s *Scheduler) startJob(schedule cron.Schedule, job func() error) string {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	j := cron.FuncJob(func() {
@@ -92,7 +108,7 @@ func (s *Scheduler) StartJobEvery(duration time.Duration, job func() error) stri
 		}
 	})
 
-	entryID := s.crontab.Schedule(cron.Every(duration), j)
+	entryID := s.crontab.Schedule(schedule, j)
 
 	s.mu.Lock()
 	s.activeJobs[entryID] = cancel
@@ -49,6 +49,21 @@ func Test_JobCanBeStopped(t *testing.T) {
 	assert.False(t, workDone, "job shouldn't had a chance to run")
 }
 
+func Test_StartJobWithCronRule(t *testing.T) {
+	s := NewScheduler(context.Background())
+	defer s.Shutdown()
+
+	_, err := s.StartJobWithCronRule("not a cron rule", func() error { return nil })
+	assert.Error(t, err, "an invalid cron rule should be rejected")
+
+	jobID, err := s.StartJobWithCronRule("0 2 * * *", func() error { return nil })
+	assert.NoError(t, err)
+	assert.Len(t, s.crontab.Entries(), 1)
+
+	s.StopJob(jobID)
+	assert.Eventually(t, func() bool { return len(s.crontab.Entries()) == 0 }, jobInterval, 10*time.Millisecond, "job should be removed from the crontab")
+}
+
 func Test_JobShouldStop_UponError(t *testing.T) {
 	s := NewScheduler(context.Background())
 	defer s.Shutdown()
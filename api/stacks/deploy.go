@@ -8,6 +8,8 @@ import (
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/git"
+	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/http/security"
 	log "github.com/sirupsen/logrus"
 )
@@ -47,12 +49,26 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 		return &StackAuthorMissingErr{int(stack.ID), author}
 	}
 
-	username, password := "", ""
-	if stack.GitConfig.Authentication != nil {
-		username, password = stack.GitConfig.Authentication.Username, stack.GitConfig.Authentication.Password
+	// resolve the credentials on every run so that a rotated Git credential is picked up
+	auth, err := git.ResolveAuthentication(datastore, stack.GitConfig.Authentication)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to retrieve the git credentials of the stack %v", stack.ID)
+	}
+
+	referenceName := stack.GitConfig.ReferenceName
+	if stack.GitConfig.TagConstraint != "" {
+		refs, err := gitService.ListRefs(stack.GitConfig.URL, auth)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to list the tags of the stack %v", stack.ID)
+		}
+
+		referenceName, err = git.ResolveTagConstraint(refs, stack.GitConfig.TagConstraint)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to resolve the tag constraint %s of the stack %v", stack.GitConfig.TagConstraint, stack.ID)
+		}
 	}
 
-	newHash, err := gitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, username, password)
+	newHash, err := gitService.LatestCommitID(stack.GitConfig.URL, referenceName, auth)
 	if err != nil {
 		return errors.WithMessagef(err, "failed to fetch latest commit id of the stack %v", stack.ID)
 	}
@@ -62,15 +78,11 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 	}
 
 	cloneParams := &cloneRepositoryParameters{
-		url:   stack.GitConfig.URL,
-		ref:   stack.GitConfig.ReferenceName,
-		toDir: stack.ProjectPath,
-	}
-	if stack.GitConfig.Authentication != nil {
-		cloneParams.auth = &gitAuth{
-			username: username,
-			password: password,
-		}
+		url:     stack.GitConfig.URL,
+		ref:     referenceName,
+		toDir:   stack.ProjectPath,
+		auth:    auth,
+		options: stack.GitConfig.CloneOptions,
 	}
 
 	if err := cloneGitRepository(gitService, cloneParams); err != nil {
@@ -110,10 +122,15 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 
 	stack.UpdateDate = time.Now().Unix()
 	stack.GitConfig.ConfigHash = newHash
+	stack.GitConfig.ReferenceName = referenceName
 	if err := datastore.Stack().UpdateStack(stack.ID, stack); err != nil {
 		return errors.WithMessagef(err, "failed to update the stack %v", stack.ID)
 	}
 
+	if _, err := RecordStackVersion(datastore, stack, author); err != nil {
+		logger.WithError(err).Warn("unable to record the stack version")
+	}
+
 	return nil
 }
 
@@ -143,20 +160,13 @@ func getUserRegistries(datastore dataservices.DataStore, user *portainer.User, e
 }
 
 type cloneRepositoryParameters struct {
-	url   string
-	ref   string
-	toDir string
-	auth  *gitAuth
-}
-
-type gitAuth struct {
-	username string
-	password string
+	url     string
+	ref     string
+	toDir   string
+	auth    *gittypes.GitAuthentication
+	options *gittypes.CloneOptions
 }
 
 func cloneGitRepository(gitService portainer.GitService, cloneParams *cloneRepositoryParameters) error {
-	if cloneParams.auth != nil {
-		return gitService.CloneRepository(cloneParams.toDir, cloneParams.url, cloneParams.ref, cloneParams.auth.username, cloneParams.auth.password)
-	}
-	return gitService.CloneRepository(cloneParams.toDir, cloneParams.url, cloneParams.ref, "", "")
+	return gitService.CloneRepository(cloneParams.toDir, cloneParams.url, cloneParams.ref, cloneParams.auth, cloneParams.options)
 }
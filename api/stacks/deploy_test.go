@@ -18,14 +18,18 @@ type gitService struct {
 	id       string
 }
 
-func (g *gitService) CloneRepository(destination, repositoryURL, referenceName, username, password string) error {
+func (g *gitService) CloneRepository(destination, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOptions *gittypes.CloneOptions) error {
 	return g.cloneErr
 }
 
-func (g *gitService) LatestCommitID(repositoryURL, referenceName, username, password string) (string, error) {
+func (g *gitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication) (string, error) {
 	return g.id, nil
 }
 
+func (g *gitService) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication) ([]string, error) {
+	return nil, nil
+}
+
 type noopDeployer struct{}
 
 func (s *noopDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, prune bool) error {
@@ -164,6 +168,101 @@ func Test_redeployWhenChanged(t *testing.T) {
 	})
 }
 
+type recordingGitService struct {
+	id         string
+	refs       []string
+	auths      []*gittypes.GitAuthentication
+	clonedRefs []string
+}
+
+func (g *recordingGitService) CloneRepository(destination, repositoryURL, referenceName string, auth *gittypes.GitAuthentication, cloneOptions *gittypes.CloneOptions) error {
+	g.auths = append(g.auths, auth)
+	g.clonedRefs = append(g.clonedRefs, referenceName)
+	return nil
+}
+
+func (g *recordingGitService) LatestCommitID(repositoryURL, referenceName string, auth *gittypes.GitAuthentication) (string, error) {
+	g.auths = append(g.auths, auth)
+	return g.id, nil
+}
+
+func (g *recordingGitService) ListRefs(repositoryURL string, auth *gittypes.GitAuthentication) ([]string, error) {
+	g.auths = append(g.auths, auth)
+	return g.refs, nil
+}
+
+func Test_redeployWhenChanged_UsesTheCurrentValueOfTheGitCredential(t *testing.T) {
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	tmpDir, _ := ioutil.TempDir("", "stack")
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1})
+	assert.NoError(t, err, "error creating environment")
+
+	err = store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	assert.NoError(t, err, "error creating a user")
+
+	credential := &portainer.GitCredential{Name: "deploy-key", Username: "git", SSHPrivateKey: "old-key"}
+	err = store.GitCredential().Create(credential)
+	assert.NoError(t, err, "error creating a git credential")
+
+	err = store.Stack().Create(&portainer.Stack{
+		ID:          1,
+		EndpointID:  1,
+		Type:        portainer.DockerComposeStack,
+		ProjectPath: tmpDir,
+		CreatedBy:   "admin",
+		GitConfig: &gittypes.RepoConfig{
+			URL:            "git@gitea.local:org/repo.git",
+			ReferenceName:  "ref",
+			ConfigHash:     "oldHash",
+			Authentication: &gittypes.GitAuthentication{GitCredentialID: int(credential.ID)},
+		}})
+	assert.NoError(t, err, "failed to create a test stack")
+
+	// rotate the key without touching the stack
+	credential.SSHPrivateKey = "new-key"
+	err = store.GitCredential().UpdateGitCredential(credential.ID, credential)
+	assert.NoError(t, err, "error updating the git credential")
+
+	gitService := &recordingGitService{id: "newHash"}
+	err = RedeployWhenChanged(1, &noopDeployer{}, store, gitService)
+	assert.NoError(t, err)
+
+	assert.Len(t, gitService.auths, 2, "expected a fetch and a clone")
+	for _, auth := range gitService.auths {
+		assert.Equal(t, "git", auth.Username)
+		assert.Equal(t, "new-key", auth.SSHPrivateKey)
+	}
+
+	stack, err := store.Stack().Stack(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &gittypes.GitAuthentication{GitCredentialID: int(credential.ID)}, stack.GitConfig.Authentication, "the resolved credentials should not be persisted in the stack")
+}
+
+func Test_redeployWhenChanged_FailsWhenTheGitCredentialIsMissing(t *testing.T) {
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	err := store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	assert.NoError(t, err, "error creating a user")
+
+	err = store.Stack().Create(&portainer.Stack{
+		ID:        1,
+		CreatedBy: "admin",
+		GitConfig: &gittypes.RepoConfig{
+			URL:            "url",
+			ReferenceName:  "ref",
+			ConfigHash:     "oldHash",
+			Authentication: &gittypes.GitAuthentication{GitCredentialID: 42},
+		}})
+	assert.NoError(t, err, "failed to create a test stack")
+
+	err = RedeployWhenChanged(1, &noopDeployer{}, store, &recordingGitService{id: "newHash"})
+	assert.Error(t, err)
+}
+
 func Test_getUserRegistries(t *testing.T) {
 	_, store, teardown := datastore.MustNewTestStore(true, true)
 	defer teardown()
@@ -241,3 +340,44 @@ func Test_getUserRegistries(t *testing.T) {
 		assert.ElementsMatch(t, []portainer.Registry{registryReachableByUser, registryReachableByTeam}, registries)
 	})
 }
+
+func Test_redeployWhenChanged_DeploysTheHighestTagMatchingTheConstraint(t *testing.T) {
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	tmpDir, _ := ioutil.TempDir("", "stack")
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1})
+	assert.NoError(t, err, "error creating environment")
+
+	err = store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole})
+	assert.NoError(t, err, "error creating a user")
+
+	err = store.Stack().Create(&portainer.Stack{
+		ID:          1,
+		EndpointID:  1,
+		Type:        portainer.DockerComposeStack,
+		ProjectPath: tmpDir,
+		CreatedBy:   "admin",
+		GitConfig: &gittypes.RepoConfig{
+			URL:           "url",
+			ReferenceName: "refs/tags/v1.4.1",
+			TagConstraint: "~1.4",
+			ConfigHash:    "oldHash",
+		}})
+	assert.NoError(t, err, "failed to create a test stack")
+
+	gitService := &recordingGitService{
+		id:   "newHash",
+		refs: []string{"refs/heads/main", "refs/tags/v1.4.1", "refs/tags/v1.4.2", "refs/tags/v1.5.0"},
+	}
+	err = RedeployWhenChanged(1, &noopDeployer{}, store, gitService)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"refs/tags/v1.4.2"}, gitService.clonedRefs)
+
+	stack, err := store.Stack().Stack(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/tags/v1.4.2", stack.GitConfig.ReferenceName, "the resolved tag should be recorded on the stack")
+	assert.Equal(t, "newHash", stack.GitConfig.ConfigHash)
+}
@@ -10,6 +10,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/stackutils"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/notification/events"
 )
 
 type StackDeployer interface {
@@ -23,15 +24,18 @@ type stackDeployer struct {
 	swarmStackManager   portainer.SwarmStackManager
 	composeStackManager portainer.ComposeStackManager
 	kubernetesDeployer  portainer.KubernetesDeployer
+	eventPublisher      portainer.NotificationEventPublisher
 }
 
-// NewStackDeployer inits a stackDeployer struct with a SwarmStackManager, a ComposeStackManager and a KubernetesDeployer
-func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStackManager portainer.ComposeStackManager, kubernetesDeployer portainer.KubernetesDeployer) *stackDeployer {
+// NewStackDeployer inits a stackDeployer struct with a SwarmStackManager, a ComposeStackManager and a KubernetesDeployer.
+// Deployment failures are published to the eventPublisher when it is not nil.
+func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStackManager portainer.ComposeStackManager, kubernetesDeployer portainer.KubernetesDeployer, eventPublisher portainer.NotificationEventPublisher) *stackDeployer {
 	return &stackDeployer{
 		lock:                &sync.Mutex{},
 		swarmStackManager:   swarmStackManager,
 		composeStackManager: composeStackManager,
 		kubernetesDeployer:  kubernetesDeployer,
+		eventPublisher:      eventPublisher,
 	}
 }
 
@@ -42,7 +46,10 @@ func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *porta
 	d.swarmStackManager.Login(registries, endpoint)
 	defer d.swarmStackManager.Logout(endpoint)
 
-	return d.swarmStackManager.Deploy(stack, prune, endpoint)
+	err := d.swarmStackManager.Deploy(stack, prune, endpoint)
+	d.notifyFailure(stack, err)
+
+	return err
 }
 
 func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forceRereate bool) error {
@@ -56,6 +63,8 @@ func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *por
 	if err != nil {
 		d.composeStackManager.Down(context.TODO(), stack, endpoint)
 	}
+	d.notifyFailure(stack, err)
+
 	return err
 }
 
@@ -63,6 +72,22 @@ func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
+	manifestFilePaths, tempDir, err := stackutils.CreateTempK8SDeploymentFiles(stack, d.kubernetesDeployer, kubeAppLabels(stack, user))
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp kub deployment files")
+	}
+	defer os.RemoveAll(tempDir)
+
+	_, err = d.kubernetesDeployer.Deploy(user.ID, endpoint, manifestFilePaths, stack.Namespace)
+	if err != nil {
+		d.notifyFailure(stack, err)
+		return errors.Wrap(err, "failed to deploy kubernetes application")
+	}
+
+	return nil
+}
+
+func kubeAppLabels(stack *portainer.Stack, user *portainer.User) k.KubeAppLabels {
 	appLabels := k.KubeAppLabels{
 		StackID:   int(stack.ID),
 		StackName: stack.Name,
@@ -75,16 +100,11 @@ func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *
 		appLabels.Kind = "git"
 	}
 
-	manifestFilePaths, tempDir, err := stackutils.CreateTempK8SDeploymentFiles(stack, d.kubernetesDeployer, appLabels)
-	if err != nil {
-		return errors.Wrap(err, "failed to create temp kub deployment files")
-	}
-	defer os.RemoveAll(tempDir)
+	return appLabels
+}
 
-	_, err = d.kubernetesDeployer.Deploy(user.ID, endpoint, manifestFilePaths, stack.Namespace)
-	if err != nil {
-		return errors.Wrap(err, "failed to deploy kubernetes application")
+func (d *stackDeployer) notifyFailure(stack *portainer.Stack, err error) {
+	if err != nil && d.eventPublisher != nil {
+		d.eventPublisher.Publish(events.StackDeployFailed(stack, err))
 	}
-
-	return nil
 }
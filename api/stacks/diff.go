@@ -0,0 +1,260 @@
+package stacks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/docker/cli/cli/compose/loader"
+	"github.com/docker/cli/cli/compose/types"
+	"github.com/pkg/errors"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/stackutils"
+	"gopkg.in/yaml.v3"
+)
+
+// ResourceChangeType represents the change applied to a service or a resource by a deployment
+type ResourceChangeType string
+
+const (
+	// ResourceCreated is a service or a resource that is only part of the proposed stack
+	ResourceCreated ResourceChangeType = "created"
+	// ResourceUpdated is a service or a resource whose definition is changed by the proposed stack
+	ResourceUpdated ResourceChangeType = "updated"
+	// ResourceRemoved is a service or a resource that is no longer part of the proposed stack
+	ResourceRemoved ResourceChangeType = "removed"
+)
+
+// ResourceChange represents a service of a Compose stack or a resource of a Kubernetes stack
+// that is created, updated or removed by a deployment
+type ResourceChange struct {
+	// Name of the Compose service, or kind, namespace and name of the Kubernetes resource
+	Name string `example:"Deployment/default/nginx"`
+	// Change applied to the service or the resource
+	Change ResourceChangeType `example:"updated" enums:"created,updated,removed"`
+}
+
+// StackDiff represents the changes between two versions of the files of a stack
+type StackDiff struct {
+	// Unified diff of each file
+	Files []FileDiff
+	// Environment variables added, removed or updated
+	Env []EnvChange
+	// Services or resources created, updated or removed
+	Resources []ResourceChange
+}
+
+// DiffStack compares two versions of the files of a stack. The labels are used to name both sides of the file diffs.
+// The Kustomize and Helm chart stacks are rendered with the Kubernetes deployer to compare their resources.
+func DiffStack(stack *portainer.Stack, from, to *portainer.StackVersion, fromLabel, toLabel string, kubeDeployer portainer.KubernetesDeployer) (*StackDiff, error) {
+	files, err := DiffFiles(from.Files, to.Files, fromLabel, toLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []ResourceChange
+	if stackutils.IsRenderedKubernetesStack(stack) {
+		resources, err = diffRenderedResources(stack, from, to, kubeDeployer)
+	} else if stack.Type == portainer.KubernetesStack && !stack.IsComposeFormat {
+		resources, err = DiffKubernetesResources(versionFiles(from), versionFiles(to))
+	} else {
+		resources, err = DiffComposeServices(versionFiles(from), from.Env, versionFiles(to), to.Env)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &StackDiff{
+		Files:     files,
+		Env:       DiffEnv(from.Env, to.Env),
+		Resources: resources,
+	}, nil
+}
+
+// DiffComposeServices returns the services created, updated or removed between two sets of Compose files.
+// The environment variables are interpolated so that a variable change is reported on the services using it.
+func DiffComposeServices(fromFiles []StackFile, fromEnv []portainer.Pair, toFiles []StackFile, toEnv []portainer.Pair) ([]ResourceChange, error) {
+	from, err := composeServices(fromFiles, fromEnv)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load the current Compose files")
+	}
+
+	to, err := composeServices(toFiles, toEnv)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load the proposed Compose files")
+	}
+
+	return diffResources(from, to), nil
+}
+
+func composeServices(files []StackFile, env []portainer.Pair) (map[string]interface{}, error) {
+	environment := make(map[string]string, len(env))
+	for _, pair := range env {
+		environment[pair.Name] = pair.Value
+	}
+
+	configFiles := make([]types.ConfigFile, 0, len(files))
+	for _, file := range files {
+		config, err := loader.ParseYAML(file.Content)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "unable to parse %s", file.Name)
+		}
+		configFiles = append(configFiles, types.ConfigFile{Filename: file.Name, Config: config})
+	}
+
+	composeConfig, err := loader.Load(types.ConfigDetails{ConfigFiles: configFiles, Environment: environment}, func(options *loader.Options) {
+		options.SkipValidation = true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]interface{}, len(composeConfig.Services))
+	for _, service := range composeConfig.Services {
+		services[service.Name] = service
+	}
+
+	return services, nil
+}
+
+// DiffKubernetesResources returns the resources created, updated or removed between two sets of Kubernetes manifests.
+func DiffKubernetesResources(fromFiles, toFiles []StackFile) ([]ResourceChange, error) {
+	from, err := kubernetesResources(fromFiles)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the current manifests")
+	}
+
+	to, err := kubernetesResources(toFiles)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the proposed manifests")
+	}
+
+	return diffResources(from, to), nil
+}
+
+func diffRenderedResources(stack *portainer.Stack, from, to *portainer.StackVersion, kubeDeployer portainer.KubernetesDeployer) ([]ResourceChange, error) {
+	fromFiles, err := renderVersion(stack, from, kubeDeployer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to render the current stack")
+	}
+
+	toFiles, err := renderVersion(stack, to, kubeDeployer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to render the proposed stack")
+	}
+
+	return DiffKubernetesResources(fromFiles, toFiles)
+}
+
+// renderVersion writes the files of a version of a Kustomize or Helm chart stack in a temporary folder
+// and returns the rendered manifest
+func renderVersion(stack *portainer.Stack, version *portainer.StackVersion, kubeDeployer portainer.KubernetesDeployer) ([]StackFile, error) {
+	tempDir, err := ioutil.TempDir("", "stack_render")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	rendered := *stack
+	rendered.ProjectPath = tempDir
+	err = RestoreStackVersion(&rendered, version)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := stackutils.RenderKubernetesManifest(&rendered, kubeDeployer)
+	if err != nil {
+		return nil, err
+	}
+
+	return []StackFile{{Name: filesystem.ManifestFileDefaultName, Content: manifest}}, nil
+}
+
+func kubernetesResources(files []StackFile) (map[string]interface{}, error) {
+	resources := make(map[string]interface{})
+
+	var addResource func(resource map[string]interface{})
+	addResource = func(resource map[string]interface{}) {
+		kind, _ := resource["kind"].(string)
+		if kind == "" {
+			return
+		}
+
+		if items, ok := resource["items"].([]interface{}); ok && kind == "List" {
+			for _, item := range items {
+				if itemResource, ok := item.(map[string]interface{}); ok {
+					addResource(itemResource)
+				}
+			}
+			return
+		}
+
+		metadata, _ := resource["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+
+		key := fmt.Sprintf("%s/%s", kind, name)
+		if namespace != "" {
+			key = fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+		}
+		resources[key] = resource
+	}
+
+	for _, file := range files {
+		decoder := yaml.NewDecoder(bytes.NewReader(file.Content))
+		for {
+			resource := make(map[string]interface{})
+			err := decoder.Decode(&resource)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errors.WithMessagef(err, "unable to parse %s", file.Name)
+			}
+
+			addResource(resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// diffResources compares two sets of services or resources indexed by their name, the changes are sorted by name
+func diffResources(from, to map[string]interface{}) []ResourceChange {
+	changes := make([]ResourceChange, 0)
+
+	for name, fromResource := range from {
+		toResource, ok := to[name]
+		if !ok {
+			changes = append(changes, ResourceChange{Name: name, Change: ResourceRemoved})
+		} else if !reflect.DeepEqual(fromResource, toResource) {
+			changes = append(changes, ResourceChange{Name: name, Change: ResourceUpdated})
+		}
+	}
+
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			changes = append(changes, ResourceChange{Name: name, Change: ResourceCreated})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
+
+// versionFiles returns the entry point and the additional files of a version, in the order used for the deployment
+func versionFiles(version *portainer.StackVersion) []StackFile {
+	files := make([]StackFile, 0, len(version.Files))
+	for _, fileName := range append([]string{version.EntryPoint}, version.AdditionalFiles...) {
+		files = append(files, StackFile{Name: fileName, Content: []byte(version.Files[fileName])})
+	}
+	return files
+}
@@ -0,0 +1,114 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffComposeServices(t *testing.T) {
+	is := assert.New(t)
+
+	from := []StackFile{{Name: "docker-compose.yml", Content: []byte("services:\n  web:\n    image: nginx:${TAG}\n  db:\n    image: postgres\n  cache:\n    image: redis\n")}}
+	to := []StackFile{{Name: "docker-compose.yml", Content: []byte("services:\n  web:\n    image: nginx:${TAG}\n  db:\n    image: postgres\n  queue:\n    image: rabbitmq\n")}}
+
+	changes, err := DiffComposeServices(from, []portainer.Pair{{Name: "TAG", Value: "1"}}, to, []portainer.Pair{{Name: "TAG", Value: "2"}})
+	is.NoError(err)
+	is.Equal([]ResourceChange{
+		{Name: "cache", Change: ResourceRemoved},
+		{Name: "queue", Change: ResourceCreated},
+		{Name: "web", Change: ResourceUpdated},
+	}, changes, "a changed variable should update the services using it")
+
+	_, err = DiffComposeServices(from, nil, []StackFile{{Name: "docker-compose.yml", Content: []byte("services: [")}}, nil)
+	is.Error(err)
+}
+
+func Test_DiffKubernetesResources(t *testing.T) {
+	is := assert.New(t)
+
+	from := []StackFile{{Name: "app.yml", Content: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx
+`)}}
+	to := []StackFile{{Name: "app.yml", Content: []byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: nginx
+    namespace: default
+  spec:
+    replicas: 2
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: nginx
+`)}}
+
+	changes, err := DiffKubernetesResources(from, to)
+	is.NoError(err)
+	is.Equal([]ResourceChange{
+		{Name: "ConfigMap/nginx", Change: ResourceCreated},
+		{Name: "Deployment/default/nginx", Change: ResourceUpdated},
+		{Name: "Service/nginx", Change: ResourceRemoved},
+	}, changes)
+}
+
+// kustomizeDeployer renders a Kustomize directory as the content of its manifest.yml file
+type kustomizeDeployer struct {
+	portainer.KubernetesDeployer
+}
+
+func (deployer *kustomizeDeployer) RenderKustomize(directory string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(directory, "manifest.yml"))
+}
+
+func Test_DiffStack_renderedStack(t *testing.T) {
+	is := assert.New(t)
+
+	stack := &portainer.Stack{
+		ID:               1,
+		Type:             portainer.KubernetesStack,
+		KubernetesFormat: portainer.KubernetesStackKustomize,
+		EntryPoint:       "overlays/production",
+	}
+
+	from := &portainer.StackVersion{
+		StackID:    1,
+		EntryPoint: "overlays/production",
+		Files: map[string]string{
+			"overlays/production/kustomization.yaml": "resources: []",
+			"overlays/production/manifest.yml":       "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n",
+		},
+	}
+	to := &portainer.StackVersion{
+		StackID:    1,
+		EntryPoint: "overlays/production",
+		Files: map[string]string{
+			"overlays/production/kustomization.yaml": "resources: []",
+			"overlays/production/manifest.yml":       "apiVersion: v1\nkind: Secret\nmetadata:\n  name: config\n",
+		},
+	}
+
+	diff, err := DiffStack(stack, from, to, "deployed", "proposed", &kustomizeDeployer{})
+	is.NoError(err)
+	is.Equal([]ResourceChange{
+		{Name: "ConfigMap/config", Change: ResourceRemoved},
+		{Name: "Secret/config", Change: ResourceCreated},
+	}, diff.Resources)
+	is.Len(diff.Files, 2)
+}
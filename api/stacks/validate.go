@@ -0,0 +1,292 @@
+package stacks
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/cli/cli/compose/loader"
+	"github.com/docker/cli/cli/compose/schema"
+	"github.com/docker/cli/cli/compose/template"
+	"github.com/docker/cli/cli/compose/types"
+	"github.com/docker/distribution/reference"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/stackutils"
+)
+
+// DiagnosticSeverity represents the severity of an issue found while validating a stack
+type DiagnosticSeverity string
+
+const (
+	// DiagnosticError is an issue that prevents the deployment of the stack
+	DiagnosticError DiagnosticSeverity = "error"
+	// DiagnosticWarning is an issue that doesn't prevent the deployment of the stack
+	DiagnosticWarning DiagnosticSeverity = "warning"
+	// DiagnosticInfo describes the change applied to a resource by the deployment
+	DiagnosticInfo DiagnosticSeverity = "info"
+)
+
+// Diagnostic represents an issue found while validating a stack before its deployment
+type Diagnostic struct {
+	// Severity of the issue
+	Severity DiagnosticSeverity `example:"error" enums:"error,warning,info"`
+	// Name of the service or Kubernetes resource concerned by the issue, empty when the issue concerns the whole stack
+	Service string `example:"web"`
+	// Description of the issue
+	Message string `example:"privileged mode disabled for non administrator users"`
+}
+
+// StackFile represents a file of a stack
+type StackFile struct {
+	// Path of the file inside the project folder of the stack
+	Name string
+	// Content of the file
+	Content []byte
+}
+
+// HasErrors returns true when at least one of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == DiagnosticError {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadStackFiles returns the entry point and the additional files of a stack.
+func ReadStackFiles(stack *portainer.Stack) ([]StackFile, error) {
+	version, err := SnapshotStack(stack)
+	if err != nil {
+		return nil, err
+	}
+
+	return versionFiles(version), nil
+}
+
+// ValidateComposeStack checks the files of a Compose or Swarm stack without deploying it.
+// It parses the files against the maximum supported version of the Compose syntax, resolves the
+// interpolation of the environment variables and checks that the images are hosted on the Docker Hub
+// or on one of the registries. The services are checked against the security settings
+// when they are not nil, they should be nil for the administrators.
+func ValidateComposeStack(files []StackFile, env []portainer.Pair, maxVersion string, registries []portainer.Registry, securitySettings *portainer.EndpointSecuritySettings) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+
+	environment := make(map[string]string, len(env))
+	for _, pair := range env {
+		environment[pair.Name] = pair.Value
+	}
+
+	configFiles := make([]types.ConfigFile, 0, len(files))
+	for _, file := range files {
+		config, err := loader.ParseYAML(file.Content)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Severity: DiagnosticError, Message: fmt.Sprintf("unable to parse %s: %s", file.Name, err)})
+			continue
+		}
+
+		diagnostics = append(diagnostics, validateComposeVersion(file.Name, config, maxVersion)...)
+
+		for _, name := range undefinedVariables(config, environment) {
+			diagnostics = append(diagnostics, Diagnostic{Severity: DiagnosticWarning, Message: fmt.Sprintf("the variable %s used in %s is not set, it defaults to a blank string", name, file.Name)})
+		}
+
+		configFiles = append(configFiles, types.ConfigFile{Filename: file.Name, Config: config})
+	}
+
+	if HasErrors(diagnostics) {
+		return diagnostics
+	}
+
+	composeConfig, err := loader.Load(types.ConfigDetails{ConfigFiles: configFiles, Environment: environment}, func(options *loader.Options) {
+		options.SkipValidation = true
+	})
+	if err != nil {
+		return append(diagnostics, Diagnostic{Severity: DiagnosticError, Message: err.Error()})
+	}
+
+	for _, service := range composeConfig.Services {
+		diagnostics = append(diagnostics, validateServiceImage(service, registries)...)
+
+		if securitySettings != nil {
+			for _, violation := range ServiceSecurityViolations(service, securitySettings) {
+				diagnostics = append(diagnostics, Diagnostic{Severity: DiagnosticError, Service: service.Name, Message: violation})
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+func validateComposeVersion(fileName string, config map[string]interface{}, maxVersion string) []Diagnostic {
+	if _, ok := config["version"]; !ok {
+		// files following the Compose specification don't declare a version
+		return nil
+	}
+
+	version := schema.Version(config)
+	if compareVersions(version, maxVersion) > 0 {
+		return []Diagnostic{{Severity: DiagnosticError, Message: fmt.Sprintf("the version %s of %s is not supported, the maximum supported version is %s", version, fileName, maxVersion)}}
+	}
+
+	err := schema.Validate(config, version)
+	if err != nil {
+		return []Diagnostic{{Severity: DiagnosticError, Message: fmt.Sprintf("%s is invalid: %s", fileName, err)}}
+	}
+
+	return nil
+}
+
+// compareVersions compares two dotted version numbers, it returns a positive number when a is greater than b
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aPart != bPart {
+			return aPart - bPart
+		}
+	}
+
+	return 0
+}
+
+// undefinedVariables returns the sorted names of the variables interpolated in a Compose file
+// that are neither set in the environment nor given a default value
+func undefinedVariables(config map[string]interface{}, environment map[string]string) []string {
+	names := make([]string, 0)
+	for name, defaultValue := range template.ExtractVariables(config, nil) {
+		if _, ok := environment[name]; ok || defaultValue != "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func validateServiceImage(service types.ServiceConfig, registries []portainer.Registry) []Diagnostic {
+	if service.Image == "" {
+		if service.Build.Context == "" {
+			return []Diagnostic{{Severity: DiagnosticError, Service: service.Name, Message: "the service doesn't define an image"}}
+		}
+		return nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(service.Image)
+	if err != nil {
+		return []Diagnostic{{Severity: DiagnosticError, Service: service.Name, Message: fmt.Sprintf("invalid image %s: %s", service.Image, err)}}
+	}
+
+	domain := reference.Domain(named)
+	if domain == "docker.io" {
+		return nil
+	}
+
+	for _, registry := range registries {
+		if registryHost(registry.URL) == domain {
+			return nil
+		}
+	}
+
+	return []Diagnostic{{Severity: DiagnosticWarning, Service: service.Name, Message: fmt.Sprintf("the registry %s of the image %s is not configured, the image will be pulled anonymously", domain, service.Image)}}
+}
+
+// registryHost returns the host of a registry URL, without scheme nor path
+func registryHost(registryURL string) string {
+	host := registryURL
+	if index := strings.Index(host, "://"); index >= 0 {
+		host = host[index+3:]
+	}
+	if index := strings.Index(host, "/"); index >= 0 {
+		host = host[:index]
+	}
+	return strings.ToLower(host)
+}
+
+// ServiceSecurityViolations returns the features used by a Compose service that are disabled
+// by the security settings of an environment.
+func ServiceSecurityViolations(service types.ServiceConfig, securitySettings *portainer.EndpointSecuritySettings) []string {
+	violations := make([]string, 0)
+
+	if !securitySettings.AllowBindMountsForRegularUsers {
+		for _, volume := range service.Volumes {
+			if volume.Type == "bind" {
+				violations = append(violations, "bind-mount disabled for non administrator users")
+				break
+			}
+		}
+	}
+
+	if !securitySettings.AllowPrivilegedModeForRegularUsers && service.Privileged {
+		violations = append(violations, "privileged mode disabled for non administrator users")
+	}
+
+	if !securitySettings.AllowHostNamespaceForRegularUsers && service.Pid == "host" {
+		violations = append(violations, "pid host disabled for non administrator users")
+	}
+
+	if !securitySettings.AllowDeviceMappingForRegularUsers && len(service.Devices) > 0 {
+		violations = append(violations, "device mapping disabled for non administrator users")
+	}
+
+	if !securitySettings.AllowSysctlSettingForRegularUsers && len(service.Sysctls) > 0 {
+		violations = append(violations, "sysctl setting disabled for non administrator users")
+	}
+
+	if !securitySettings.AllowContainerCapabilitiesForRegularUsers && (len(service.CapAdd) > 0 || len(service.CapDrop) > 0) {
+		violations = append(violations, "container capabilities disabled for non administrator users")
+	}
+
+	return violations
+}
+
+// DryRunKubernetesStack submits the manifests of a Kubernetes stack to the cluster without persisting them.
+// It returns the change applied to each resource, or the error reported by the cluster.
+func DryRunKubernetesStack(deployer portainer.KubernetesDeployer, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) []Diagnostic {
+	manifestFilePaths, tempDir, err := stackutils.CreateTempK8SDeploymentFiles(stack, deployer, kubeAppLabels(stack, user))
+	if err != nil {
+		return []Diagnostic{{Severity: DiagnosticError, Message: err.Error()}}
+	}
+	defer os.RemoveAll(tempDir)
+
+	output, err := deployer.DryRun(user.ID, endpoint, manifestFilePaths, stack.Namespace)
+	if err != nil {
+		return []Diagnostic{{Severity: DiagnosticError, Message: err.Error()}}
+	}
+
+	return parseKubernetesDryRunOutput(output)
+}
+
+// parseKubernetesDryRunOutput parses the lines of a server side dry run apply,
+// e.g. "deployment.apps/nginx created (server dry run)"
+func parseKubernetesDryRunOutput(output string) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "(server dry run)"))
+		if line == "" {
+			continue
+		}
+
+		resource, change := line, ""
+		if index := strings.LastIndex(line, " "); index >= 0 {
+			resource, change = line[:index], line[index+1:]
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{Severity: DiagnosticInfo, Service: resource, Message: change})
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,77 @@
+package stacks
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateComposeStack(t *testing.T) {
+	tests := []struct {
+		name             string
+		content          string
+		env              []portainer.Pair
+		securitySettings *portainer.EndpointSecuritySettings
+		expected         []Diagnostic
+	}{
+		{
+			name:     "valid stack",
+			content:  "version: '3.7'\nservices:\n  web:\n    image: nginx:${TAG}\n",
+			env:      []portainer.Pair{{Name: "TAG", Value: "latest"}},
+			expected: []Diagnostic{},
+		},
+		{
+			name:     "unsupported version",
+			content:  "version: '3.10'\nservices:\n  web:\n    image: nginx\n",
+			expected: []Diagnostic{{Severity: DiagnosticError, Message: "the version 3.10 of docker-compose.yml is not supported, the maximum supported version is 3.9"}},
+		},
+		{
+			name:     "invalid yaml",
+			content:  "services: [\n",
+			expected: []Diagnostic{{Severity: DiagnosticError, Message: "unable to parse docker-compose.yml: yaml: line 1: did not find expected node content"}},
+		},
+		{
+			name:     "undefined variable",
+			content:  "version: '3.7'\nservices:\n  web:\n    image: nginx\n    environment:\n      - TAG=${TAG}\n      - MODE=${MODE:-prod}\n",
+			expected: []Diagnostic{{Severity: DiagnosticWarning, Message: "the variable TAG used in docker-compose.yml is not set, it defaults to a blank string"}},
+		},
+		{
+			name:     "unknown registry",
+			content:  "services:\n  web:\n    image: quay.io/org/web:1\n  db:\n    image: registry.example.com/postgres\n",
+			expected: []Diagnostic{{Severity: DiagnosticWarning, Service: "web", Message: "the registry quay.io of the image quay.io/org/web:1 is not configured, the image will be pulled anonymously"}},
+		},
+		{
+			name:             "security settings",
+			content:          "services:\n  web:\n    image: nginx\n    privileged: true\n    volumes:\n      - /etc:/etc\n",
+			securitySettings: &portainer.EndpointSecuritySettings{AllowBindMountsForRegularUsers: true},
+			expected:         []Diagnostic{{Severity: DiagnosticError, Service: "web", Message: "privileged mode disabled for non administrator users"}},
+		},
+		{
+			name:     "service without image",
+			content:  "services:\n  web:\n    ports:\n      - 80:80\n",
+			expected: []Diagnostic{{Severity: DiagnosticError, Service: "web", Message: "the service doesn't define an image"}},
+		},
+	}
+
+	registries := []portainer.Registry{{URL: "https://registry.example.com/v2"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []StackFile{{Name: "docker-compose.yml", Content: []byte(tt.content)}}
+
+			diagnostics := ValidateComposeStack(files, tt.env, "3.9", registries, tt.securitySettings)
+
+			assert.Equal(t, tt.expected, diagnostics)
+		})
+	}
+}
+
+func Test_parseKubernetesDryRunOutput(t *testing.T) {
+	output := "deployment.apps/nginx created (server dry run)\nservice/nginx unchanged (server dry run)\n\n"
+
+	assert.Equal(t, []Diagnostic{
+		{Severity: DiagnosticInfo, Service: "deployment.apps/nginx", Message: "created"},
+		{Severity: DiagnosticInfo, Service: "service/nginx", Message: "unchanged"},
+	}, parseKubernetesDryRunOutput(output))
+}
@@ -0,0 +1,313 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/filesystem"
+)
+
+// MaxStackVersions is the number of versions kept for each stack, the oldest versions are removed first.
+const MaxStackVersions = 20
+
+// FileDiff represents the changes of a single file between two versions of a stack
+type FileDiff struct {
+	// Path of the file
+	FileName string `example:"docker-compose.yml"`
+	// Unified diff of the file, empty when the file didn't change
+	Diff string `example:"--- docker-compose.yml (version 1)\n+++ docker-compose.yml (version 2)\n"`
+}
+
+// EnvChange represents an environment variable that differs between two versions of a stack
+type EnvChange struct {
+	// Name of the variable
+	Name string `example:"MYSQL_VERSION"`
+	// Previous value of the variable, empty when the variable is added
+	From string `example:"5.7"`
+	// New value of the variable, empty when the variable is removed
+	To string `example:"8.0"`
+}
+
+// StackVersions returns the versions of a stack sorted from the oldest to the most recent one.
+func StackVersions(datastore dataservices.DataStore, stackID portainer.StackID) ([]portainer.StackVersion, error) {
+	versions, err := datastore.StackVersion().StackVersionsByStackID(stackID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to retrieve the versions of the stack %v", stackID)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// RecordStackVersion stores the files, environment variables and Git commit of a successfully deployed stack
+// as a new version of the stack. Only the MaxStackVersions most recent versions are kept.
+func RecordStackVersion(datastore dataservices.DataStore, stack *portainer.Stack, author string) (*portainer.StackVersion, error) {
+	versions, err := StackVersions(datastore, stack.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := SnapshotStack(stack)
+	if err != nil {
+		return nil, err
+	}
+
+	version.Version = 1
+	if len(versions) > 0 {
+		version.Version = versions[len(versions)-1].Version + 1
+	}
+	version.CreatedBy = author
+	version.CreationDate = time.Now().Unix()
+
+	err = datastore.StackVersion().Create(version)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to store the version of the stack %v", stack.ID)
+	}
+
+	for len(versions) >= MaxStackVersions {
+		err = datastore.StackVersion().DeleteStackVersion(versions[0].ID)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to remove an old version of the stack %v", stack.ID)
+		}
+		versions = versions[1:]
+	}
+
+	return version, nil
+}
+
+// SnapshotStack returns an unsaved version holding the current files, environment variables
+// and Git commit of a stack.
+func SnapshotStack(stack *portainer.Stack) (*portainer.StackVersion, error) {
+	files := make(map[string]string)
+	for _, fileName := range append([]string{stack.EntryPoint}, stack.AdditionalFiles...) {
+		info, err := os.Stat(filesystem.JoinPaths(stack.ProjectPath, fileName))
+		if err == nil && info.IsDir() {
+			// the Kustomize and Helm chart directories are stored file by file
+			err = readDirectoryFiles(stack.ProjectPath, fileName, files)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to read the directory %s of the stack %v", fileName, stack.ID)
+			}
+			continue
+		}
+
+		content, err := os.ReadFile(filesystem.JoinPaths(stack.ProjectPath, fileName))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to read the file %s of the stack %v", fileName, stack.ID)
+		}
+		files[fileName] = string(content)
+	}
+
+	version := &portainer.StackVersion{
+		StackID:         stack.ID,
+		EntryPoint:      stack.EntryPoint,
+		AdditionalFiles: stack.AdditionalFiles,
+		Files:           files,
+		Env:             stack.Env,
+	}
+
+	if stack.GitConfig != nil {
+		version.ConfigHash = stack.GitConfig.ConfigHash
+	}
+
+	return version, nil
+}
+
+// readDirectoryFiles adds the text files of a directory of the project folder to files, indexed by their path
+// relative to the project folder. The Git metadata and the binary files, such as packaged chart dependencies, are skipped.
+func readDirectoryFiles(projectPath, directory string, files map[string]string) error {
+	return filepath.Walk(filesystem.JoinPaths(projectPath, directory), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(content) {
+			return nil
+		}
+
+		fileName, err := filepath.Rel(projectPath, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(fileName)] = string(content)
+
+		return nil
+	})
+}
+
+// DeleteStackVersions removes all the versions of a stack.
+func DeleteStackVersions(datastore dataservices.DataStore, stackID portainer.StackID) error {
+	versions, err := datastore.StackVersion().StackVersionsByStackID(stackID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to retrieve the versions of the stack %v", stackID)
+	}
+
+	for _, version := range versions {
+		err = datastore.StackVersion().DeleteStackVersion(version.ID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to remove the version %d of the stack %v", version.Version, stackID)
+		}
+	}
+
+	return nil
+}
+
+// RestoreStackVersion writes the files of a version inside the project folder of the stack
+// and restores the entry point, the additional files and the environment variables of the version.
+// The files of the current version which are not part of the restored version are removed.
+func RestoreStackVersion(stack *portainer.Stack, version *portainer.StackVersion) error {
+	if version.StackID != stack.ID {
+		return errors.Errorf("the version %d doesn't belong to the stack %v", version.Version, stack.ID)
+	}
+
+	err := removeFilesMissingFromVersion(stack, version)
+	if err != nil {
+		return err
+	}
+
+	for fileName, content := range version.Files {
+		path := filesystem.JoinPaths(stack.ProjectPath, fileName)
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to create the folder of the file %s", fileName)
+		}
+
+		err = filesystem.WriteToFile(path, []byte(content))
+		if err != nil {
+			return errors.WithMessagef(err, "failed to restore the file %s", fileName)
+		}
+	}
+
+	stack.EntryPoint = version.EntryPoint
+	stack.AdditionalFiles = version.AdditionalFiles
+	stack.Env = version.Env
+	if stack.GitConfig != nil {
+		stack.GitConfig.ConfigHash = version.ConfigHash
+	}
+
+	return nil
+}
+
+// removeFilesMissingFromVersion removes the files versioned for the current state of the stack which are not part
+// of the version, such as a compose override or a values file added after the version. The other files of the project
+// folder, e.g. the rest of a Git repository, are left untouched.
+func removeFilesMissingFromVersion(stack *portainer.Stack, version *portainer.StackVersion) error {
+	files := make(map[string]string)
+	for _, fileName := range append([]string{stack.EntryPoint}, stack.AdditionalFiles...) {
+		info, err := os.Stat(filesystem.JoinPaths(stack.ProjectPath, fileName))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return errors.WithMessagef(err, "failed to read the file %s of the stack %v", fileName, stack.ID)
+		}
+
+		if !info.IsDir() {
+			files[fileName] = ""
+			continue
+		}
+
+		err = readDirectoryFiles(stack.ProjectPath, fileName, files)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to read the directory %s of the stack %v", fileName, stack.ID)
+		}
+	}
+
+	for fileName := range files {
+		if _, ok := version.Files[fileName]; ok {
+			continue
+		}
+
+		err := os.Remove(filesystem.JoinPaths(stack.ProjectPath, fileName))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.WithMessagef(err, "failed to remove the file %s", fileName)
+		}
+	}
+
+	return nil
+}
+
+// DiffFiles returns the unified diff of each file between two sets of files indexed by their path.
+// The labels are used to name both sides of the diff.
+func DiffFiles(from, to map[string]string, fromLabel, toLabel string) ([]FileDiff, error) {
+	fileNames := make([]string, 0, len(from)+len(to))
+	for fileName := range from {
+		fileNames = append(fileNames, fileName)
+	}
+	for fileName := range to {
+		if _, ok := from[fileName]; !ok {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	sort.Strings(fileNames)
+
+	diffs := make([]FileDiff, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(from[fileName]),
+			B:        difflib.SplitLines(to[fileName]),
+			FromFile: fileName + " (" + fromLabel + ")",
+			ToFile:   fileName + " (" + toLabel + ")",
+			Context:  3,
+		})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to compute the diff of the file %s", fileName)
+		}
+
+		diffs = append(diffs, FileDiff{FileName: fileName, Diff: diff})
+	}
+
+	return diffs, nil
+}
+
+// DiffEnv returns the environment variables that are added, removed or updated between two sets of variables.
+func DiffEnv(from, to []portainer.Pair) []EnvChange {
+	fromValues := make(map[string]string, len(from))
+	for _, pair := range from {
+		fromValues[pair.Name] = pair.Value
+	}
+
+	toValues := make(map[string]string, len(to))
+	for _, pair := range to {
+		toValues[pair.Name] = pair.Value
+	}
+
+	changes := make([]EnvChange, 0)
+	for name, value := range fromValues {
+		newValue, ok := toValues[name]
+		if !ok || newValue != value {
+			changes = append(changes, EnvChange{Name: name, From: value, To: newValue})
+		}
+	}
+	for name, value := range toValues {
+		if _, ok := fromValues[name]; !ok {
+			changes = append(changes, EnvChange{Name: name, To: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
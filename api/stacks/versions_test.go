@@ -0,0 +1,215 @@
+package stacks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RecordStackVersion(t *testing.T) {
+	is := assert.New(t)
+
+	_, store, teardown := datastore.MustNewTestStore(true, true)
+	defer teardown()
+
+	projectPath := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(projectPath, "docker-compose.yml"), []byte("version: 1"), 0644))
+
+	stack := &portainer.Stack{
+		ID:          1,
+		EntryPoint:  "docker-compose.yml",
+		ProjectPath: projectPath,
+		Env:         []portainer.Pair{{Name: "TAG", Value: "1"}},
+		GitConfig:   &gittypes.RepoConfig{ConfigHash: "first"},
+	}
+
+	version, err := RecordStackVersion(store, stack, "admin")
+	is.NoError(err)
+	is.Equal(1, version.Version)
+	is.Equal("version: 1", version.Files["docker-compose.yml"])
+	is.Equal("first", version.ConfigHash)
+	is.Equal("admin", version.CreatedBy)
+
+	for i := 0; i < MaxStackVersions; i++ {
+		_, err = RecordStackVersion(store, stack, "admin")
+		is.NoError(err)
+	}
+
+	versions, err := StackVersions(store, stack.ID)
+	is.NoError(err)
+	is.Len(versions, MaxStackVersions, "the oldest versions should be removed")
+	is.Equal(2, versions[0].Version)
+	is.Equal(MaxStackVersions+1, versions[len(versions)-1].Version)
+
+	is.NoError(DeleteStackVersions(store, stack.ID))
+	versions, err = StackVersions(store, stack.ID)
+	is.NoError(err)
+	is.Empty(versions)
+}
+
+func Test_RestoreStackVersion(t *testing.T) {
+	is := assert.New(t)
+
+	projectPath := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(projectPath, "docker-compose.yml"), []byte("version: 2"), 0644))
+
+	stack := &portainer.Stack{
+		ID:          1,
+		EntryPoint:  "docker-compose.yml",
+		ProjectPath: projectPath,
+		Env:         []portainer.Pair{{Name: "TAG", Value: "2"}},
+		GitConfig:   &gittypes.RepoConfig{ConfigHash: "second"},
+	}
+
+	version := &portainer.StackVersion{
+		StackID:         1,
+		Version:         1,
+		EntryPoint:      "docker-compose.yml",
+		AdditionalFiles: []string{"config/override.yml"},
+		Files: map[string]string{
+			"docker-compose.yml":  "version: 1",
+			"config/override.yml": "services: {}",
+		},
+		Env:        []portainer.Pair{{Name: "TAG", Value: "1"}},
+		ConfigHash: "first",
+	}
+
+	is.Error(RestoreStackVersion(&portainer.Stack{ID: 2}, version), "a version of another stack should be rejected")
+
+	is.NoError(RestoreStackVersion(stack, version))
+
+	content, err := os.ReadFile(filepath.Join(projectPath, "docker-compose.yml"))
+	is.NoError(err)
+	is.Equal("version: 1", string(content))
+
+	content, err = os.ReadFile(filepath.Join(projectPath, "config", "override.yml"))
+	is.NoError(err)
+	is.Equal("services: {}", string(content))
+
+	is.Equal([]string{"config/override.yml"}, stack.AdditionalFiles)
+	is.Equal("1", stack.Env[0].Value)
+	is.Equal("first", stack.GitConfig.ConfigHash)
+}
+
+func Test_RestoreStackVersion_removesTheFilesAddedAfterTheVersion(t *testing.T) {
+	is := assert.New(t)
+
+	projectPath := t.TempDir()
+	is.NoError(os.MkdirAll(filepath.Join(projectPath, "overlays", "prod"), 0755))
+	for fileName, content := range map[string]string{
+		"docker-compose.yml":               "version: 2",
+		"docker-compose.override.yml":      "services: {}",
+		"overlays/prod/kustomization.yaml": "resources: []",
+		"overlays/prod/values.yaml":        "replicas: 3",
+		"README.md":                        "not part of the stack",
+	} {
+		is.NoError(os.WriteFile(filepath.Join(projectPath, fileName), []byte(content), 0644))
+	}
+
+	stack := &portainer.Stack{
+		ID:              1,
+		EntryPoint:      "docker-compose.yml",
+		AdditionalFiles: []string{"docker-compose.override.yml", "overlays/prod"},
+		ProjectPath:     projectPath,
+	}
+
+	version := &portainer.StackVersion{
+		StackID:         1,
+		Version:         1,
+		EntryPoint:      "docker-compose.yml",
+		AdditionalFiles: []string{"overlays/prod"},
+		Files: map[string]string{
+			"docker-compose.yml":               "version: 1",
+			"overlays/prod/kustomization.yaml": "resources: []",
+		},
+	}
+
+	is.NoError(RestoreStackVersion(stack, version))
+
+	for _, fileName := range []string{"docker-compose.override.yml", "overlays/prod/values.yaml"} {
+		_, err := os.Stat(filepath.Join(projectPath, fileName))
+		is.True(os.IsNotExist(err), "the file %s added after the version should be removed", fileName)
+	}
+
+	for _, fileName := range []string{"docker-compose.yml", "overlays/prod/kustomization.yaml", "README.md"} {
+		_, err := os.Stat(filepath.Join(projectPath, fileName))
+		is.NoError(err, "the file %s should be kept", fileName)
+	}
+}
+
+func Test_DiffFiles(t *testing.T) {
+	is := assert.New(t)
+
+	diffs, err := DiffFiles(
+		map[string]string{"docker-compose.yml": "image: nginx:1\nports: []\n", "removed.yml": "a\n"},
+		map[string]string{"docker-compose.yml": "image: nginx:2\nports: []\n", "added.yml": "b\n"},
+		"version 1", "version 2",
+	)
+	is.NoError(err)
+	is.Len(diffs, 3)
+
+	is.Equal("added.yml", diffs[0].FileName)
+	is.Contains(diffs[0].Diff, "+b")
+
+	is.Equal("docker-compose.yml", diffs[1].FileName)
+	is.Contains(diffs[1].Diff, "--- docker-compose.yml (version 1)")
+	is.Contains(diffs[1].Diff, "-image: nginx:1")
+	is.Contains(diffs[1].Diff, "+image: nginx:2")
+
+	is.Equal("removed.yml", diffs[2].FileName)
+	is.Contains(diffs[2].Diff, "-a")
+
+	diffs, err = DiffFiles(map[string]string{"a.yml": "a\n"}, map[string]string{"a.yml": "a\n"}, "version 1", "version 2")
+	is.NoError(err)
+	is.Empty(diffs[0].Diff, "an unchanged file should have an empty diff")
+}
+
+func Test_DiffEnv(t *testing.T) {
+	changes := DiffEnv(
+		[]portainer.Pair{{Name: "KEPT", Value: "1"}, {Name: "UPDATED", Value: "1"}, {Name: "REMOVED", Value: "1"}},
+		[]portainer.Pair{{Name: "KEPT", Value: "1"}, {Name: "UPDATED", Value: "2"}, {Name: "ADDED", Value: "1"}},
+	)
+
+	assert.Equal(t, []EnvChange{
+		{Name: "ADDED", To: "1"},
+		{Name: "REMOVED", From: "1"},
+		{Name: "UPDATED", From: "1", To: "2"},
+	}, changes)
+}
+
+func Test_SnapshotStack_directoryEntryPoint(t *testing.T) {
+	is := assert.New(t)
+
+	projectPath := t.TempDir()
+	for fileName, content := range map[string]string{
+		".git/HEAD":                         "ref: refs/heads/main",
+		"chart/Chart.yaml":                  "name: app",
+		"chart/templates/deployment.yaml":   "kind: Deployment",
+		"chart/charts/dependency-1.0.0.tgz": "\x1f\x8b\x08\x00\xff\xfe",
+		"values/production.yaml":            "replicas: 3",
+		"docs/README.md":                    "not part of the stack",
+	} {
+		is.NoError(os.MkdirAll(filepath.Dir(filepath.Join(projectPath, fileName)), 0755))
+		is.NoError(os.WriteFile(filepath.Join(projectPath, fileName), []byte(content), 0644))
+	}
+
+	version, err := SnapshotStack(&portainer.Stack{
+		ID:               1,
+		Type:             portainer.KubernetesStack,
+		KubernetesFormat: portainer.KubernetesStackHelmChart,
+		EntryPoint:       "chart",
+		AdditionalFiles:  []string{"values/production.yaml"},
+		ProjectPath:      projectPath,
+	})
+	is.NoError(err)
+	is.Equal(map[string]string{
+		"chart/Chart.yaml":                "name: app",
+		"chart/templates/deployment.yaml": "kind: Deployment",
+		"values/production.yaml":          "replicas: 3",
+	}, version.Files, "the binary files should be skipped")
+}
@@ -0,0 +1,208 @@
+// Package totp implements the time-based one-time passwords (RFC 6238) used as a second authentication factor
+// by the internal users.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	issuer = "Portainer"
+	// period is the duration of a time step, in seconds
+	period = 30
+	digits = 6
+	// skew is the number of time steps accepted before and after the current one, to absorb clock drifts
+	skew              = 1
+	secretSize        = 20
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrInvalidCode is returned when a one-time password or a recovery code does not match
+	ErrInvalidCode = errors.New("invalid one-time password")
+	// ErrNoPendingEnrollment is returned when activating the two-factor authentication without enrolling first
+	ErrNoPendingEnrollment = errors.New("no pending two-factor authentication enrollment")
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new base32 encoded secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI returns the otpauth URI used to register the secret in an authenticator application, usually as a QR code
+func ProvisioningURI(username, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + username,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// GenerateCode returns the one-time password of the secret at the specified time
+func GenerateCode(secret string, at time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return code(key, timeStep(at)), nil
+}
+
+// Enroll generates a new pending secret, the two-factor authentication is enabled once Activate verifies
+// a first one-time password generated with it
+func Enroll(settings *portainer.UserTOTP) (string, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	settings.PendingSecret = secret
+
+	return secret, nil
+}
+
+// Activate enables the two-factor authentication when the one-time password matches the pending secret
+// and returns the recovery codes, only their digests are kept in the settings
+func Activate(settings *portainer.UserTOTP, otp string, now time.Time) ([]string, error) {
+	if settings.PendingSecret == "" {
+		return nil, ErrNoPendingEnrollment
+	}
+
+	step, ok := validate(settings.PendingSecret, otp, now)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	recoveryCodes := make([]string, recoveryCodeCount)
+	digests := make([]string, recoveryCodeCount)
+	for i := range recoveryCodes {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		recoveryCodes[i] = recoveryCode
+		digests[i] = digest(recoveryCode)
+	}
+
+	*settings = portainer.UserTOTP{
+		Enabled:       true,
+		Secret:        settings.PendingSecret,
+		RecoveryCodes: digests,
+		LastTimeStep:  step,
+	}
+
+	return recoveryCodes, nil
+}
+
+// Verify returns true when the code is a valid one-time password or an unused recovery code.
+// A one-time password can only be used once and a recovery code is removed from the settings once used.
+func Verify(settings *portainer.UserTOTP, otp string, now time.Time) bool {
+	if !settings.Enabled {
+		return false
+	}
+
+	step, ok := validate(settings.Secret, otp, now)
+	if ok && step > settings.LastTimeStep {
+		settings.LastTimeStep = step
+		return true
+	}
+
+	otpDigest := digest(otp)
+	for i, recoveryCode := range settings.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(otpDigest)) == 1 {
+			settings.RecoveryCodes = append(settings.RecoveryCodes[:i], settings.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate returns the time step matching the one-time password
+func validate(secret, otp string, now time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(otp) != digits {
+		return 0, false
+	}
+
+	current := timeStep(now)
+	for step := current - skew; step <= current+skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(code(key, step)), []byte(otp)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func timeStep(at time.Time) int64 {
+	return at.Unix() / period
+}
+
+// code computes the HOTP value (RFC 4226) of the key for the counter
+func code(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
+
+func generateRecoveryCode() (string, error) {
+	data := make([]byte, 5)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", err
+	}
+
+	recoveryCode := hex.EncodeToString(data)
+
+	return recoveryCode[:5] + "-" + recoveryCode[5:], nil
+}
+
+// digest normalizes the recovery code before hashing it, the dashes and the case are ignored
+func digest(recoveryCode string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(recoveryCode), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
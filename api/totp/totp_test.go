@@ -0,0 +1,99 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the secret of the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func Test_GenerateCode(t *testing.T) {
+	is := assert.New(t)
+
+	// 6 digits truncations of the RFC 6238 SHA1 test vectors
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for timestamp, expected := range vectors {
+		code, err := GenerateCode(rfcSecret, time.Unix(timestamp, 0))
+		is.NoError(err)
+		is.Equal(expected, code, "timestamp %d", timestamp)
+	}
+}
+
+func Test_ProvisioningURI(t *testing.T) {
+	is := assert.New(t)
+
+	u, err := url.Parse(ProvisioningURI("alice", "SECRET"))
+	is.NoError(err)
+	is.Equal("otpauth", u.Scheme)
+	is.Equal("totp", u.Host)
+	is.Equal("/Portainer:alice", u.Path)
+	is.Equal("SECRET", u.Query().Get("secret"))
+	is.Equal("Portainer", u.Query().Get("issuer"))
+}
+
+func Test_EnrollmentAndVerification(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Now()
+	settings := &portainer.UserTOTP{}
+
+	_, err := Activate(settings, "123456", now)
+	is.ErrorIs(err, ErrNoPendingEnrollment)
+
+	secret, err := Enroll(settings)
+	is.NoError(err)
+	is.False(settings.Enabled)
+
+	_, err = Activate(settings, "000000", now.Add(-time.Hour))
+	is.ErrorIs(err, ErrInvalidCode)
+
+	code, err := GenerateCode(secret, now)
+	is.NoError(err)
+
+	recoveryCodes, err := Activate(settings, code, now)
+	is.NoError(err)
+	is.True(settings.Enabled)
+	is.Equal(secret, settings.Secret)
+	is.Empty(settings.PendingSecret)
+	is.Len(recoveryCodes, recoveryCodeCount)
+	is.NotContains(settings.RecoveryCodes, recoveryCodes[0])
+
+	t.Run("a one-time password cannot be reused", func(t *testing.T) {
+		is.False(Verify(settings, code, now))
+	})
+
+	t.Run("the next one-time password is accepted once", func(t *testing.T) {
+		next, err := GenerateCode(secret, now.Add(period*time.Second))
+		is.NoError(err)
+
+		is.True(Verify(settings, next, now.Add(period*time.Second)))
+		is.False(Verify(settings, next, now.Add(period*time.Second)))
+	})
+
+	t.Run("one-time passwords outside the skew are rejected", func(t *testing.T) {
+		old, err := GenerateCode(secret, now.Add(-10*period*time.Second))
+		is.NoError(err)
+
+		is.False(Verify(settings, old, now.Add(2*period*time.Second)))
+	})
+
+	t.Run("a recovery code can only be used once", func(t *testing.T) {
+		is.True(Verify(settings, strings.ToUpper(recoveryCodes[1]), now))
+		is.Len(settings.RecoveryCodes, recoveryCodeCount-1)
+		is.False(Verify(settings, recoveryCodes[1], now))
+	})
+}
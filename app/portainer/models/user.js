@@ -3,6 +3,7 @@ export function UserViewModel(data) {
   this.Username = data.Username;
   this.Role = data.Role;
   this.UserTheme = data.UserTheme;
+  this.Disabled = data.Disabled;
   if (data.Role === 1) {
     this.RoleName = 'administrator';
   } else {
@@ -52,8 +52,8 @@ export function UserService($q, Users, TeamService, TeamMembershipService) {
     return Users.remove({ id: id }).$promise;
   };
 
-  service.updateUser = function (id, { password, role, username }) {
-    return Users.update({ id }, { password, role, username }).$promise;
+  service.updateUser = function (id, { password, role, username, disabled }) {
+    return Users.update({ id }, { password, role, username, disabled }).$promise;
   };
 
   service.updateUserPassword = function (id, currentPassword, newPassword) {
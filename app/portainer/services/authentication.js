@@ -53,6 +53,7 @@ angular.module('portainer.app').factory('Authentication', [
       EndpointProvider.setCurrentEndpoint(null);
       LocalStorage.cleanAuthData();
       LocalStorage.storeLoginStateUUID('');
+      LocalStorage.storeLoginNonce('');
       tryAutoLoginExtension();
     }
 
@@ -65,7 +66,7 @@ angular.module('portainer.app').factory('Authentication', [
     }
 
     async function OAuthLoginAsync(code) {
-      const response = await OAuth.validate({ code: code }).$promise;
+      const response = await OAuth.validate({ code: code, nonce: LocalStorage.getLoginNonce() }).$promise;
       const jwt = setJWTFromResponse(response);
       await setUser(jwt);
     }
@@ -118,6 +119,7 @@ angular.module('portainer.app').factory('Authentication', [
       user.ID = tokenPayload.id;
       user.role = tokenPayload.role;
       user.forceChangePassword = tokenPayload.forceChangePassword;
+      user.passwordChangeRequired = tokenPayload.passwordChangeRequired;
       await setUserTheme();
     }
 
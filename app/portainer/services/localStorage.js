@@ -20,6 +20,12 @@ angular.module('portainer.app').factory('LocalStorage', [
       getLoginStateUUID: function () {
         return localStorageService.get('LOGIN_STATE_UUID');
       },
+      storeLoginNonce: function (nonce) {
+        localStorageService.set('LOGIN_NONCE', nonce);
+      },
+      getLoginNonce: function () {
+        return localStorageService.get('LOGIN_NONCE');
+      },
       storeOfflineMode: function (isOffline) {
         localStorageService.set('ENDPOINT_OFFLINE_MODE', isOffline);
       },
@@ -43,8 +43,9 @@ angular.module('portainer.app').controller('AccountController', [
       }
     };
 
+    // an expired password can't be skipped, the API only allows the password change until it is updated
     $scope.userCanSkip = function () {
-      return $scope.timesPasswordChangeSkipped < 2;
+      return !$scope.passwordChangeRequired && $scope.timesPasswordChangeSkipped < 2;
     };
 
     this.uiCanExit = (newTransition) => {
@@ -118,15 +119,18 @@ angular.module('portainer.app').controller('AccountController', [
       $scope.userID = userDetails.ID;
       $scope.userRole = Authentication.getUserDetails().role;
       $scope.forceChangePassword = userDetails.forceChangePassword;
+      $scope.passwordChangeRequired = userDetails.passwordChangeRequired;
       $scope.isInitialAdmin = userDetails.ID === 1;
 
       if (state.application.demoEnvironment.enabled) {
         $scope.isDemoUser = state.application.demoEnvironment.users.includes($scope.userID);
       }
 
-      const data = await UserService.user($scope.userID);
-
-      $scope.formValues.userTheme = data.UserTheme;
+      // the API only allows the password change until the expired password is updated
+      if (!$scope.passwordChangeRequired) {
+        const data = await UserService.user($scope.userID);
+        $scope.formValues.userTheme = data.UserTheme;
+      }
 
       SettingsService.publicSettings()
         .then(function success(data) {
@@ -148,6 +152,10 @@ angular.module('portainer.app').controller('AccountController', [
           Notifications.error('Failure', err, 'Unable to retrieve application settings');
         });
 
+      if ($scope.passwordChangeRequired) {
+        return;
+      }
+
       UserService.getAccessTokens($scope.userID)
         .then(function success(data) {
           $scope.tokens = data;
@@ -110,8 +110,15 @@ class AuthenticationController {
     return '&state=' + uuid;
   }
 
+  // the OpenID Connect providers return the nonce in the id_token, the API checks it matches this login
+  generateNonce() {
+    const nonce = uuidv4();
+    this.LocalStorage.storeLoginNonce(nonce);
+    return '&nonce=' + nonce;
+  }
+
   generateOAuthLoginURI() {
-    this.OAuthLoginURI = this.state.OAuthLoginURI + this.generateState();
+    this.OAuthLoginURI = this.state.OAuthLoginURI + this.generateState() + this.generateNonce();
   }
 
   hasValidState(state) {
@@ -129,13 +136,13 @@ class AuthenticationController {
 
   async checkForEndpointsAsync() {
     try {
-      const isAdmin = this.Authentication.isAdmin();
-      const endpoints = await getEnvironments({ limit: 1 });
-
       if (this.Authentication.getUserDetails().forceChangePassword) {
         return this.$state.go('portainer.account');
       }
 
+      const isAdmin = this.Authentication.isAdmin();
+      const endpoints = await getEnvironments({ limit: 1 });
+
       if (endpoints.value.length === 0 && isAdmin) {
         return this.$state.go('portainer.wizard');
       } else {
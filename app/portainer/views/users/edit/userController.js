@@ -90,6 +90,17 @@ angular.module('portainer.app').controller('UserController', [
         });
     };
 
+    $scope.enableUser = function () {
+      UserService.updateUser($scope.user.Id, { disabled: false })
+        .then(function success() {
+          Notifications.success('Success', 'User successfully enabled');
+          $state.reload();
+        })
+        .catch(function error(err) {
+          Notifications.error('Failure', err, 'Unable to enable user');
+        });
+    };
+
     function deleteUser() {
       UserService.deleteUser($scope.user.Id)
         .then(function success() {